@@ -14,6 +14,9 @@ type AuditEntry struct {
 	URL       string
 	User      string
 	Time      time.Time
+
+	// Action describes what the user did, for requests which are audited whether or not audit logging is enabled.
+	Action string
 }
 
 var ignoredURLs = [5]string{
@@ -71,6 +74,26 @@ func (alh *AuditLogHandler) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// auditAction records that the account which made r did action. Admin actions which affect drivers, such as kicking
+// them, are always recorded.
+func auditAction(store Store, r *http.Request, action string) {
+	entry := &AuditEntry{
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Time:   time.Now(),
+		Action: action,
+	}
+
+	if account := AccountFromRequest(r); account != nil {
+		entry.UserGroup = account.Group()
+		entry.User = account.Name
+	}
+
+	if err := store.AddAuditEntry(entry); err != nil {
+		logrus.WithError(err).Errorf("Couldn't add audit entry for action: %s", action)
+	}
+}
+
 type auditLogTemplateVars struct {
 	BaseTemplateVars
 
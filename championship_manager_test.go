@@ -1,6 +1,7 @@
 package servermanager
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"os"
@@ -67,10 +68,26 @@ func (dummyServerProcess) Start(event RaceEvent, udpPluginAddress string, udpPlu
 	return nil
 }
 
+func (dummyServerProcess) StartContext(ctx context.Context, event RaceEvent, udpPluginAddress string, udpPluginLocalPort int, forwardingAddress string, forwardListenPort int) error {
+	return nil
+}
+
 func (dummyServerProcess) Logs() string {
 	return ""
 }
 
+func (dummyServerProcess) Subscribe() (<-chan ProcessEvent, func()) {
+	return make(chan ProcessEvent), func() {}
+}
+
+func (dummyServerProcess) PreviousLogs() string {
+	return ""
+}
+
+func (dummyServerProcess) FilteredLogs(filter LogFilter) (string, error) {
+	return "", nil
+}
+
 func (d dummyServerProcess) Stop() error {
 	if d.doneCh != nil {
 		d.doneCh <- struct{}{}
@@ -78,6 +95,18 @@ func (d dummyServerProcess) Stop() error {
 	return nil
 }
 
+func (d dummyServerProcess) ForceStop() error {
+	return d.Stop()
+}
+
+func (dummyServerProcess) Adopt() (bool, error) {
+	return false, nil
+}
+
+func (dummyServerProcess) ValidateStart(ServerConfig) []ValidationProblem {
+	return nil
+}
+
 func (dummyServerProcess) Restart() error {
 	return nil
 }
@@ -97,10 +126,166 @@ func (dummyServerProcess) SendUDPMessage(message udp.Message) error {
 	return nil
 }
 
+func (dummyServerProcess) SendUDPMessageImmediate(message udp.Message) error {
+	return nil
+}
+
+func (dummyServerProcess) ServerLogFiles() ([]ServerLogFile, error) {
+	return nil, ErrServerLogFilesDisabled
+}
+
+func (dummyServerProcess) OpenServerLogFile(name string) (*os.File, error) {
+	return nil, ErrServerLogFilesDisabled
+}
+
+func (dummyServerProcess) UDPRecordings() ([]UDPRecording, error) {
+	return nil, ErrUDPRecordingDisabled
+}
+
+func (dummyServerProcess) ReplayUDPRecording(name string, multiplier int) error {
+	return ErrUDPRecordingDisabled
+}
+
+func (dummyServerProcess) Health() ProcessHealth {
+	return ProcessHealth{Healthy: true}
+}
+
+func (dummyServerProcess) PluginHealth() []PluginHealthStatus {
+	return nil
+}
+
+func (dummyServerProcess) CrashReports() []*CrashReport {
+	return nil
+}
+
+func (d dummyServerProcess) Done() <-chan struct{} {
+	return closedDoneCh
+}
+
 func (d dummyServerProcess) NotifyDone(chan struct{}) {
 
 }
 
+func (d dummyServerProcess) NotifyStart(chan RaceEvent) {
+
+}
+
+func (dummyServerProcess) LogsJSON() []LogLine {
+	return nil
+}
+
+func (dummyServerProcess) Status() ProcessStatus {
+	return ProcessStatus{Running: true}
+}
+
+func (dummyServerProcess) DiagnosticsBundle() (*DiagnosticsBundle, error) {
+	return &DiagnosticsBundle{}, nil
+}
+
+func (dummyServerProcess) Forwarding() ForwardingStatus {
+	return ForwardingStatus{}
+}
+
+func (dummyServerProcess) SetForwardingEnabled(bool) {
+
+}
+
+func (dummyServerProcess) SetForwardingTargets([]udp.ForwardTarget) error {
+	return nil
+}
+
+func (dummyServerProcess) NotifyCrash(chan *CrashReport) {
+
+}
+
+func (dummyServerProcess) SimulateCrash() error {
+	return nil
+}
+
+func (dummyServerProcess) CSPClients() []udp.CarID {
+	return nil
+}
+
+func (dummyServerProcess) LiveWeather() (LiveWeather, bool) {
+	return LiveWeather{}, false
+}
+
+func (dummyServerProcess) SetLiveWeather(LiveWeather) (int, error) {
+	return 0, nil
+}
+
+func (dummyServerProcess) KickCar(udp.CarID) error {
+	return nil
+}
+
+func (dummyServerProcess) BanCar(udp.CarID) error {
+	return nil
+}
+
+func (dummyServerProcess) NextSession() error {
+	return nil
+}
+
+func (dummyServerProcess) RestartSession() error {
+	return nil
+}
+
+func (dummyServerProcess) Availability(from, to time.Time) (*Availability, error) {
+	return &Availability{From: from, To: to}, nil
+}
+
+func (dummyServerProcess) Tail() (<-chan string, func()) {
+	ch := make(chan string)
+
+	return ch, func() {}
+}
+
+func (dummyServerProcess) TailFrom(offset int64) (<-chan TailLine, func()) {
+	ch := make(chan TailLine)
+
+	return ch, func() {}
+}
+
+func (dummyServerProcess) SessionState() SessionState {
+	return SessionState{}
+}
+
+func (dummyServerProcess) LogLines(offset int64, max int) (int64, []TailLine) {
+	return offset, nil
+}
+
+func (dummyServerProcess) AddObserver(chan<- udp.Message) {}
+
+func (dummyServerProcess) RemoveObserver(chan<- udp.Message) {}
+
+func (dummyServerProcess) IsFeatureEnabled(Feature) bool {
+	return true
+}
+
+func (dummyServerProcess) SetFeature(Feature, bool) error {
+	return nil
+}
+
+func (dummyServerProcess) PluginLogs(string) string {
+	return ""
+}
+
+func (dummyServerProcess) RealtimePosInterval() int {
+	return udp.RealtimePosIntervalMs
+}
+
+func (dummyServerProcess) StartedAt() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (dummyServerProcess) Uptime() time.Duration {
+	return 0
+}
+
+func (dummyServerProcess) LastExit() (ExitInfo, bool) {
+	return ExitInfo{}, false
+}
+
 func (dummyServerProcess) GetServerConfig() ServerConfig {
 	return ConfigIniDefault()
 }
@@ -180,6 +365,7 @@ func init() {
 			NewTrackManager(),
 			&dummyNotificationManager{},
 			NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore)),
+			nil,
 		),
 		&ACSRClient{Enabled: false},
 	)
@@ -750,6 +750,42 @@ func (c *Championship) NumCompletedEvents() int {
 	return i
 }
 
+// ServerBuildChange is an event in a Championship which was run by a different build of acServer to the event
+// before it.
+type ServerBuildChange struct {
+	Event, PreviousEvent *ChampionshipEvent
+	Build, PreviousBuild ServerBuild
+}
+
+// ServerBuildChanges lists the events which were run by a different build of acServer to the event before them,
+// e.g. because a Steam update changed acServer between rounds.
+func (c *Championship) ServerBuildChanges() []ServerBuildChange {
+	var changes []ServerBuildChange
+	var previous *ChampionshipEvent
+	var previousBuild ServerBuild
+
+	for _, event := range ExtractRaceWeekendSessionsIntoIndividualEvents(c.Events) {
+		build, ok := event.ServerBuild()
+
+		if !ok {
+			continue
+		}
+
+		if previous != nil && build.Changed(previousBuild) {
+			changes = append(changes, ServerBuildChange{
+				Event:         event,
+				PreviousEvent: previous,
+				Build:         build,
+				PreviousBuild: previousBuild,
+			})
+		}
+
+		previous, previousBuild = event, build
+	}
+
+	return changes
+}
+
 // AddClass to the championship
 func (c *Championship) AddClass(class *ChampionshipClass) {
 	c.Classes = append(c.Classes, class)
@@ -1480,6 +1516,20 @@ type ChampionshipEvent struct {
 	championship *Championship
 }
 
+// ServerBuild returns the build of acServer which ran the event, from the results of its sessions. The bool is false
+// if none of its results recorded one.
+func (cr *ChampionshipEvent) ServerBuild() (ServerBuild, bool) {
+	for _, sessionType := range AvailableSessions {
+		session, ok := cr.Sessions[sessionType]
+
+		if ok && session.Results != nil && session.Results.ServerBuild != nil {
+			return *session.Results.ServerBuild, true
+		}
+	}
+
+	return ServerBuild{}, false
+}
+
 func (cr *ChampionshipEvent) IsRaceWeekend() bool {
 	return cr.RaceWeekendID != uuid.Nil
 }
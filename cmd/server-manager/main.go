@@ -36,6 +36,10 @@ func init() {
 }
 
 func main() {
+	servermanager.RunService(run)
+}
+
+func run() {
 	config, err := servermanager.ReadConfig("config.yml")
 
 	if err != nil {
@@ -147,6 +151,8 @@ func main() {
 
 	logrus.Infof("starting assetto server manager on: %s", config.HTTP.Hostname)
 
+	servermanager.NotifyServiceReady(store)
+
 	if !config.Server.DisableWindowsBrowserOpen && runtime.GOOS == "windows" {
 		_ = browser.OpenURL("http://" + strings.Replace(config.HTTP.Hostname, "0.0.0.0", "127.0.0.1", 1))
 	}
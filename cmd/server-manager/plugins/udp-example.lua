@@ -0,0 +1,87 @@
+json = require "json"
+
+-- this is an example UDP script. UDP scripts react to what happens on the server as it happens. to use this one, copy
+-- it into the plugins/udp folder (or the udp_scripts folder set in your config.yml) and restart server manager.
+-- for help please view lua_readme.md!
+--
+-- each hook below is called with the message from the server encoded as json. hooks which a script doesn't define
+-- aren't called. unlike the other lua plugins, a UDP script keeps running between hooks, so variables set outside of
+-- the hooks (like collisions below) are remembered.
+--
+-- UDP scripts can use these functions to talk to the server, each returns true if it worked:
+--   server.sendChat(carID, message)
+--   server.broadcastChat(message)
+--   server.kick(carID)
+--   server.setBallast(carID, kg)
+
+-- the number of collisions with other cars, by car ID
+collisions = {}
+
+-- called when a driver joins the server
+function onNewConnection(encodedCar)
+    local car = json.decode(encodedCar)
+
+    collisions[car.CarID] = 0
+end
+
+-- called when a driver leaves the server
+function onConnectionClosed(encodedCar)
+    local car = json.decode(encodedCar)
+
+    collisions[car.CarID] = nil
+end
+
+-- called when a driver has loaded in to the session
+function onClientLoaded(encodedCarID)
+    local carID = json.decode(encodedCarID)
+
+    server.sendChat(carID, "This server is running an example UDP script. Keep it clean!")
+end
+
+-- called when two cars hit each other
+function onCollisionWithCar(encodedCollision)
+    local collision = json.decode(encodedCollision)
+
+    -- ignore tiny bumps
+    if collision.ImpactSpeed < 10 then
+        return
+    end
+
+    collisions[collision.CarID] = (collisions[collision.CarID] or 0) + 1
+
+    if collisions[collision.CarID] == 5 then
+        server.sendChat(collision.CarID, "You have had 5 collisions, one more and you will be kicked!")
+    elseif collisions[collision.CarID] > 5 then
+        server.kick(collision.CarID)
+    end
+end
+
+-- called when a car hits a wall, barrier etc.
+function onCollisionWithEnvironment(encodedCollision)
+end
+
+-- called when a car completes a lap
+function onLapCompleted(encodedLap)
+    local lap = json.decode(encodedLap)
+
+    -- a lap with cuts doesn't count, so add 10kg of ballast as a reminder to stay on track
+    --if lap.Cuts > 0 then
+    --    server.setBallast(lap.CarID, 10)
+    --end
+end
+
+-- called when a chat message is sent in game
+function onChat(encodedChat)
+    local chat = json.decode(encodedChat)
+
+    if chat.Message == "!collisions" then
+        server.sendChat(chat.CarID, "Collisions: " .. (collisions[chat.CarID] or 0))
+    end
+end
+
+-- called when a session starts, and when a session ends. the end session message is the path to the results file.
+function onNewSession(encodedSessionInfo)
+end
+
+function onEndSession(encodedResultsFile)
+end
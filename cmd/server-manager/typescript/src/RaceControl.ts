@@ -32,9 +32,15 @@ const EventCollisionWithCar = 10,
     EventError = 60,
     EventLapCompleted = 73,
     EventClientEvent = 130,
-    EventRaceControl = 200
+    EventRaceControl = 200,
+    EventRaceControlSplit = 242
 ;
 
+interface RaceControlSplit {
+    DriverGUID: string
+    Split: string
+}
+
 interface SimpleCollision {
     WorldPos: CarUpdateVec
 }
@@ -715,6 +721,17 @@ class LiveTimings implements WebsocketHandler {
             const connectedDriver = new SessionCarInfo(message.Message);
 
             this.addDriverToAdminSelects(connectedDriver);
+        } else if (message.EventType === EventRaceControlSplit) {
+            const split = message.Message as RaceControlSplit;
+
+            if (this.raceControl.status && this.raceControl.status.ConnectedDrivers) {
+                const driver = this.raceControl.status.ConnectedDrivers.Drivers[split.DriverGUID];
+
+                if (driver) {
+                    driver.Split = split.Split;
+                    this.$connectedDriversTable.find("tr[data-guid='" + split.DriverGUID + "'] .gap").text(split.Split);
+                }
+            }
         }
     }
 
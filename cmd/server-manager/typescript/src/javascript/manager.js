@@ -2,6 +2,7 @@
 
 import {CarSearch} from "../CarSearch";
 import {Form} from "../Form";
+import ReconnectingWebSocket from "reconnecting-websocket";
 
 let $document;
 
@@ -319,6 +320,53 @@ class RaceSetup {
         this.initPickupModeWatcher();
         this.initTimeAttackWatcher();
         this.initDriverSwapToggle();
+        this.initValidation();
+    }
+
+    initValidation() {
+        let $validateButton = this.$parent.find("#validate-race-button");
+        let $report = this.$parent.find("#race-validation-report");
+        let $form = this.$parent;
+
+        if (!$validateButton.length) {
+            return;
+        }
+
+        $validateButton.click(function (e) {
+            e.preventDefault();
+
+            $validateButton.prop("disabled", true);
+
+            $.ajax({
+                type: "POST",
+                url: $validateButton.data("validate-url"),
+                data: $form.serialize(),
+                dataType: "json",
+            }).done(function (report) {
+                let $list = $("<ul class='mb-0'>");
+
+                for (let problem of report.Problems || []) {
+                    $list.append($("<li>").text((problem.Warning ? "Warning: " : "") + problem.Message));
+                }
+
+                $report
+                    .empty()
+                    .removeClass("alert-success alert-warning alert-danger")
+                    .addClass("alert")
+                    .addClass(!report.OK ? "alert-danger" : (report.Problems && report.Problems.length ? "alert-warning" : "alert-success"))
+                    .append($("<strong>").text(report.OK ? "This race can be started." : "This race can't be started."));
+
+                if (report.Problems && report.Problems.length) {
+                    $report.append($list);
+                }
+
+                $report.show();
+            }).fail(function () {
+                $report.empty().removeClass("alert-success alert-warning").addClass("alert alert-danger").text("The race setup could not be checked.").show();
+            }).always(function () {
+                $validateButton.prop("disabled", false);
+            });
+        });
     }
 
     initPickupModeWatcher() {
@@ -1301,6 +1349,9 @@ function getSunAngle(hours, mins) {
     return Math.round(((diff / 60000) / 60) * 16);
 }
 
+// maxServerLogLines is how many lines of the server log are kept in the console view.
+const maxServerLogLines = 5000;
+
 let serverLogs = {
     init: function () {
         let $serverLog = $document.find("#server-logs");
@@ -1341,15 +1392,54 @@ let serverLogs = {
         }
 
         if ($serverLog.length && $managerLog.length && $pluginLog.length) {
+            // the server log is streamed line by line. lines which arrive while the log is being read or selected are
+            // held back until it is scrolled to the bottom again.
+            let serverLogLines = [];
+            let pendingServerLogLines = [];
+            let nextServerLogOffset = 0;
+
+            function showServerLogLines() {
+                if (!pendingServerLogLines.length || window.getSelection().toString() || !isAtBottom($serverLog) || disableServerLogRefresh) {
+                    return;
+                }
+
+                serverLogLines = serverLogLines.concat(pendingServerLogLines).slice(-maxServerLogLines);
+                pendingServerLogLines = [];
+
+                $serverLog.text(serverLogLines.join("\n"));
+                $serverLog.scrollTop(1E10);
+            }
+
+            let ws = new ReconnectingWebSocket(function () {
+                // resume from the next line after a reconnect, rather than being sent the whole log again.
+                return ((window.location.protocol === "https:") ? "wss://" : "ws://") + window.location.host + "/api/logs/stream?offset=" + nextServerLogOffset;
+            }, [], {
+                minReconnectionDelay: 0,
+            });
+
+            ws.onmessage = function (ev) {
+                let line = JSON.parse(ev.data);
+
+                if (!line) {
+                    return;
+                }
+
+                pendingServerLogLines.push(line.Line);
+                nextServerLogOffset = line.Offset + 1;
+
+                showServerLogLines();
+            };
+
+            $(window).on('beforeunload', function () {
+                ws.close();
+            });
+
             setInterval(function () {
+                showServerLogLines();
+
                 $.get("/api/logs", function (data) {
                     if (!window.getSelection().toString()) {
 
-                        if (isAtBottom($serverLog) && !disableServerLogRefresh) {
-                            $serverLog.text(data.ServerLog);
-                            $serverLog.scrollTop(1E10);
-                        }
-
                         if (isAtBottom($managerLog) && !disableManagerLogRefresh) {
                             $managerLog.text(data.ManagerLog);
                             $managerLog.scrollTop(1E10);
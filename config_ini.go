@@ -74,6 +74,11 @@ type ServerConfig struct {
 }
 
 func (sc ServerConfig) Write() error {
+	return sc.WriteTo(ServerInstallPath)
+}
+
+// WriteTo writes the server config into the cfg directory of the acServer installed in installPath.
+func (sc ServerConfig) WriteTo(installPath string) error {
 	// overwrite server config
 	sc.GlobalServerConfig.WelcomeMessage = MOTDFilename
 
@@ -140,7 +145,7 @@ func (sc ServerConfig) Write() error {
 		}
 	}
 
-	return f.SaveTo(filepath.Join(ServerInstallPath, ServerConfigPath, serverConfigIniPath))
+	return f.SaveTo(filepath.Join(installPath, ServerConfigPath, serverConfigIniPath))
 }
 
 func (sc ServerConfig) ReadString() (string, error) {
@@ -163,6 +168,7 @@ type GlobalServerConfig struct {
 	HTTPPort                  int                  `ini:"HTTP_PORT" show:"open" min:"0" max:"65535" help:"Lobby port number: open these ports (both UDP and TCP) on your server's firewall"`
 	UDPPluginLocalPort        int                  `ini:"UDP_PLUGIN_LOCAL_PORT" show:"open" min:"0" max:"65535" help:"The port on which to listen for UDP messages from a plugin. Please note that Server Manager proxies UDP ports so that it can use them as well, for things such as Championships, Live Timings and the Map. This means that the UDP ports you see in the server_cfg.ini will be different to the ones you specify here. This is not an issue, and messages will be correctly sent/received on the UDP ports you specify here as well."`
 	UDPPluginAddress          string               `ini:"UDP_PLUGIN_ADDRESS" show:"open" help:"The address of the plugin to which UDP messages are sent.  Please note that Server Manager proxies UDP ports so that it can use them as well, for things such as Championships, Live Timings and the Map. This means that the UDP ports you see in the server_cfg.ini will be different to the ones you specify here. This is not an issue, and messages will be correctly sent/received on the UDP ports you specify here as well."`
+	AutoUDPPluginPorts        formulate.BoolNumber `ini:"-" show:"open" name:"Automatic UDP Plugin Ports" help:"When on, Server Manager picks a free UDP Plugin Local Port and UDP Plugin Address each time an event is started, instead of using the ones above, and tells sTracker, KissMyRank, Real Penalty and plugins (in the AC_PLUGIN_ADDRESS and AC_PLUGIN_LOCAL_PORT environment variables) which ports were picked. This stops events failing to start because another program is using the ports."`
 	AuthPluginAddress         string               `ini:"AUTH_PLUGIN_ADDRESS" show:"open" help:"The address of the auth plugin"`
 	RegisterToLobby           formulate.BoolNumber `ini:"REGISTER_TO_LOBBY" show:"open" help:"Register the AC Server to the main lobby"`
 	ClientSendIntervalInHertz int                  `ini:"CLIENT_SEND_INTERVAL_HZ" show:"open" help:"Refresh rate of packet sending by the server. 10Hz = ~100ms. Higher number = higher MP quality = higher bandwidth resources needed. Really high values can create connection issues"`
@@ -211,6 +217,8 @@ type GlobalServerConfig struct {
 	LogACServerOutputToFile           bool                 `ini:"-" show:"open" help:"When on, Server Manager will output each Assetto Corsa session into a log file in the logs folder."`
 	NumberOfACServerLogsToKeep        int                  `ini:"-" show:"open" help:"The number of AC Server logs to keep in the logs folder. (Oldest files will be deleted first. 0 = keep all files)"`
 	ShowEventDetailsPopup             bool                 `ini:"-" help:"Allows all users to view a popup that describes in detail the setup of Custom Races, Championship Events and Race Weekend Sessions."`
+	CPUQuotaPercent                   int                  `ini:"-" show:"open" min:"0" name:"CPU Quota Percent" help:"Linux and Windows only. Limits the CPU time the acServer process can use, as a percentage of one CPU (e.g. 50 is half of one CPU, 200 is two CPUs). This protects other servers running on the same machine. On Linux this requires cgroups v2, and Server Manager must be allowed to create cgroups. 0 = no limit."`
+	AutoRestartExitCodes              string               `ini:"-" show:"open" help:"Only used when 'restart_on_crash' is enabled in config.yml. A comma separated list of acServer exit codes which restart the event after a crash, e.g. '1, 2'. Prefix a code with '!' to never restart the event for it, e.g. '!78' for an exit code that means the configuration is bad. Leave empty to restart after any crash."`
 
 	// Discord Integration
 	DiscordIntegration FormHeading `ini:"-" json:"-"`
@@ -335,6 +343,9 @@ type CurrentRaceConfig struct {
 	DriverSwapPenaltyTime           int `ini:"-" help:"Driver should be given a penalty of this many seconds if they set off this many seconds or more before the minimum time during a Driver Swap"`
 	DriverSwapMinimumNumberOfSwaps  int `ini:"-" help:"Minimum number of swaps required."`
 	DriverSwapNotEnoughSwapsPenalty int `ini:"-" help:"Penalty to be applied if the minimum number of swaps is not met. Applied once per each swap not taken. (Seconds)"`
+	DriverSwapMinimumStintTime      int `ini:"-" help:"Minimum time (minutes) a driver must drive for before swapping. The last stint of the race can be shorter."`
+	DriverSwapMaximumStintTime      int `ini:"-" help:"Maximum time (minutes) a driver can drive for before swapping."`
+	DriverSwapStintPenalty          int `ini:"-" help:"Penalty to be applied for each stint which is shorter than the minimum or longer than the maximum. (Seconds)"`
 
 	MaxClients   int       `ini:"MAX_CLIENTS" help:"max number of clients (must be <= track's number of pits)"`
 	RaceOverTime int       `ini:"RACE_OVER_TIME" help:"time remaining in seconds to finish the race from the moment the first one passes on the finish line"`
@@ -350,6 +361,11 @@ type CurrentRaceConfig struct {
 
 	DynamicTrack DynamicTrackConfig `ini:"-"`
 
+	ChatBroadcasts        []ChatBroadcast `ini:"-"`
+	ChatBroadcastInterval int             `ini:"-" help:"Minutes between each of the broadcasts which are sent in rotation"`
+
+	WeatherTimeline []WeatherTimelineStep `ini:"-"`
+
 	Sessions Sessions                  `ini:"-"`
 	Weather  map[string]*WeatherConfig `ini:"-"`
 }
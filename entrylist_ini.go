@@ -27,7 +27,12 @@ type EntryList map[string]*Entrant
 
 // Write the EntryList to the server location
 func (e EntryList) Write() error {
-	setupDirectory := filepath.Join(ServerInstallPath, "setups")
+	return e.WriteTo(ServerInstallPath)
+}
+
+// WriteTo writes the EntryList into the cfg directory of the acServer installed in installPath.
+func (e EntryList) WriteTo(installPath string) error {
+	setupDirectory := filepath.Join(installPath, "setups")
 
 	// belt and braces check to make sure setup file exists
 	for _, entrant := range e.AsSlice() {
@@ -67,7 +72,7 @@ func (e EntryList) Write() error {
 		}
 	}
 
-	return f.SaveTo(filepath.Join(ServerInstallPath, ServerConfigPath, entryListFilename))
+	return f.SaveTo(filepath.Join(installPath, ServerConfigPath, entryListFilename))
 }
 
 func (e EntryList) ReadString() (string, error) {
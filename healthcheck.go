@@ -59,10 +59,13 @@ type HealthCheckResponse struct {
 	EventIsPractice     bool
 	NumConnectedDrivers int
 	MaxClientsOverride  int
+
+	ServerHealth ProcessHealth
 }
 
 func (h *HealthCheck) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	event := h.process.Event()
+	sessionInfo := h.raceControl.CurrentSessionInfo()
 	opts, err := h.store.LoadServerOptions()
 
 	var serverName string
@@ -86,13 +89,14 @@ func (h *HealthCheck) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ServerName:          serverName,
 		ServerID:            serverID,
 		EventInProgress:     h.raceControl.process.IsRunning(),
-		EventIsCritical:     !event.IsPractice() && (event.IsChampionship() || event.IsRaceWeekend() || h.raceControl.SessionInfo.Type == udp.SessionTypeRace || h.raceControl.SessionInfo.Type == udp.SessionTypeQualifying),
+		EventIsCritical:     !event.IsPractice() && (event.IsChampionship() || event.IsRaceWeekend() || sessionInfo.Type == udp.SessionTypeRace || sessionInfo.Type == udp.SessionTypeQualifying),
 		EventIsChampionship: event.IsChampionship(),
 		EventIsRaceWeekend:  event.IsRaceWeekend(),
 		EventIsPractice:     event.IsPractice(),
 		NumConnectedDrivers: h.raceControl.ConnectedDrivers.Len(),
 		AssettoIsInstalled:  IsAssettoInstalled(),
 		StrackerIsInstalled: IsStrackerInstalled(),
+		ServerHealth:        h.process.Health(),
 
 		ConfigDirectoryIsWritable:  IsDirWriteable(filepath.Join(ServerInstallPath, "cfg")) == nil,
 		CarDirectoryIsWritable:     IsDirWriteable(filepath.Join(ServerInstallPath, "content", "cars")) == nil,
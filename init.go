@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
@@ -75,47 +76,56 @@ func InitWithResolver(resolver *Resolver) error {
 	notificationManager := resolver.resolveNotificationManager()
 	raceControl := resolver.ResolveRaceControl()
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	adopted, err := process.Adopt()
 
-	go func() {
-		for range c {
-			// ^C, handle it
-			if process.IsRunning() {
-				event := process.Event()
+	if err != nil {
+		logrus.WithError(err).Error("Could not adopt the acServer left running by the last Server Manager")
+	}
 
-				opts, err := store.LoadServerOptions()
+	shutdownFunc = func() {
+		if process.IsRunning() {
+			event := process.Event()
 
-				if err == nil && opts.RestartEventOnServerManagerLaunch == 1 {
-					// save the event so it can be started again next time server-manager starts
-					err := store.UpsertLastRaceEvent(event)
+			opts, err := store.LoadServerOptions()
 
-					if err != nil {
-						logrus.WithError(err).Error("Could not save last server event")
-					}
-				}
+			if err == nil && opts.RestartEventOnServerManagerLaunch == 1 {
+				// save the event so it can be started again next time server-manager starts
+				err := store.UpsertLastRaceEvent(event)
 
-				if event.IsChampionship() && !event.IsPractice() {
-					if err := championshipManager.StopActiveEvent(); err != nil {
-						logrus.WithError(err).Errorf("Error stopping Championship event")
-					}
-				} else if event.IsRaceWeekend() && !event.IsPractice() {
-					if err := raceWeekendManager.StopActiveSession(); err != nil {
-						logrus.WithError(err).Errorf("Error stopping Race Weekend session")
-					}
-				} else {
-					if err := process.Stop(); err != nil {
-						logrus.WithError(err).Errorf("Could not stop server")
-					}
+				if err != nil {
+					logrus.WithError(err).Error("Could not save last server event")
 				}
 			}
 
-			if err := notificationManager.Stop(); err != nil {
-				logrus.WithError(err).Errorf("Could not stop notification manager")
+			if event.IsChampionship() && !event.IsPractice() {
+				if err := championshipManager.StopActiveEvent(); err != nil {
+					logrus.WithError(err).Errorf("Error stopping Championship event")
+				}
+			} else if event.IsRaceWeekend() && !event.IsPractice() {
+				if err := raceWeekendManager.StopActiveSession(); err != nil {
+					logrus.WithError(err).Errorf("Error stopping Race Weekend session")
+				}
+			} else {
+				if err := process.Stop(); err != nil {
+					logrus.WithError(err).Errorf("Could not stop server")
+				}
 			}
+		}
 
-			raceControl.persistTimingData()
+		if err := notificationManager.Stop(); err != nil {
+			logrus.WithError(err).Errorf("Could not stop notification manager")
+		}
+
+		raceControl.persistTimingData()
+	}
 
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		for range c {
+			// ^C, or the service manager stopping Server Manager
+			Shutdown()
 			os.Exit(0)
 		}
 	}()
@@ -151,7 +161,7 @@ func InitWithResolver(resolver *Resolver) error {
 		}
 	}()
 
-	if opts.RestartEventOnServerManagerLaunch == 1 {
+	if opts.RestartEventOnServerManagerLaunch == 1 && !adopted {
 		if lastEvent, err := store.LoadLastRaceEvent(); err == nil && lastEvent != nil {
 			var err error
 
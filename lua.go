@@ -18,6 +18,10 @@ func InitLua(raceControl *RaceControl) {
 	luaFunctions["broadcastChat"] = raceControl.LuaBroadcastChat
 	luaFunctions["sendChat"] = raceControl.LuaSendChat
 
+	if err := loadLuaUDPScripts(config.Lua.UDPScripts); err != nil {
+		logrus.WithError(err).Error("could not load UDP scripts")
+	}
+
 	go func() {
 		err := managerStartPlugin()
 
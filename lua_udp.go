@@ -0,0 +1,317 @@
+package servermanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/sirupsen/logrus"
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// luaUDPScriptsDefaultDirectory is where UDP scripts are loaded from if no directory is configured.
+	luaUDPScriptsDefaultDirectory = "./plugins/udp"
+
+	// luaUDPScriptQueueSize is how many messages a UDP script can fall behind by before messages are dropped for it.
+	luaUDPScriptQueueSize = 256
+)
+
+// luaUDPScriptTimeout is how long a UDP script's hook can run for before it is stopped.
+var luaUDPScriptTimeout = time.Second
+
+// luaUDPScriptHooks are the functions a UDP script can define, by the event which calls them. Each is called with the
+// message from acServer, encoded as JSON.
+var luaUDPScriptHooks = map[udp.Event]string{
+	udp.EventLapCompleted:     "onLapCompleted",
+	udp.EventCollisionWithCar: "onCollisionWithCar",
+	udp.EventCollisionWithEnv: "onCollisionWithEnvironment",
+	udp.EventNewConnection:    "onNewConnection",
+	udp.EventConnectionClosed: "onConnectionClosed",
+	udp.EventClientLoaded:     "onClientLoaded",
+	udp.EventChat:             "onChat",
+	udp.EventNewSession:       "onNewSession",
+	udp.EventEndSession:       "onEndSession",
+}
+
+type luaUDPMessage struct {
+	process ServerProcess
+	message udp.Message
+}
+
+// luaUDPScript is a Lua script which reacts to messages from acServer. Unlike the other Lua plugins, a UDP script
+// keeps its state for as long as Server Manager runs, so it can keep track of e.g. each driver's collisions. Its hooks
+// are called one at a time, in order, by run.
+type luaUDPScript struct {
+	name     string
+	state    *lua.LState
+	messages chan luaUDPMessage
+
+	// process is the server process which sent the message being handled, for the server functions to reply to.
+	process ServerProcess
+}
+
+// loadLuaUDPScripts loads each script in directory and registers a UDP handler for each hook the scripts define.
+func loadLuaUDPScripts(directory string) error {
+	if directory == "" {
+		directory = luaUDPScriptsDefaultDirectory
+	}
+
+	files, err := ioutil.ReadDir(directory)
+
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".lua" {
+			continue
+		}
+
+		script, err := newLuaUDPScript(filepath.Join(directory, file.Name()))
+
+		if err != nil {
+			logrus.WithError(err).Errorf("Could not load UDP script %s, it will not run", file.Name())
+			continue
+		}
+
+		go script.run()
+
+		for event, hook := range luaUDPScriptHooks {
+			if script.state.GetGlobal(hook).Type() == lua.LTFunction {
+				RegisterUDPHandler(event, script.handle)
+			}
+		}
+
+		logrus.Infof("Loaded UDP script %s", file.Name())
+	}
+
+	return nil
+}
+
+func newLuaUDPScript(path string) (*luaUDPScript, error) {
+	script := &luaUDPScript{
+		name:     filepath.Base(path),
+		state:    newSandboxedLuaState(),
+		messages: make(chan luaUDPMessage, luaUDPScriptQueueSize),
+	}
+
+	server := script.state.NewTable()
+
+	script.state.SetFuncs(server, map[string]lua.LGFunction{
+		"sendChat":      script.luaSendChat,
+		"broadcastChat": script.luaBroadcastChat,
+		"kick":          script.luaKick,
+		"setBallast":    script.luaSetBallast,
+	})
+
+	script.state.SetGlobal("server", server)
+
+	if err := script.callWithTimeout(func() error { return script.state.DoFile(path) }); err != nil {
+		script.state.Close()
+
+		return nil, err
+	}
+
+	return script, nil
+}
+
+// newSandboxedLuaState opens only the Lua libraries which can't touch the rest of the system, so that a UDP script
+// can't run commands or read and write files. Modules can still be required from LUA_PATH.
+func newSandboxedLuaState() *lua.LState {
+	state := lua.NewState(lua.Options{SkipOpenLibs: true})
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.LoadLibName, lua.OpenPackage},
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		state.Push(state.NewFunction(lib.open))
+		state.Push(lua.LString(lib.name))
+		state.Call(1, 0)
+	}
+
+	for _, name := range []string{"dofile", "loadfile"} {
+		state.SetGlobal(name, lua.LNil)
+	}
+
+	return state
+}
+
+// handle is a UDPHandlerFunc. Messages are dropped if the script can't keep up, rather than holding up acServer.
+func (s *luaUDPScript) handle(process ServerProcess, message udp.Message) {
+	select {
+	case s.messages <- luaUDPMessage{process: process, message: message}:
+	default:
+		logrus.Warnf("UDP script %s can't keep up, dropped a %T message", s.name, message)
+	}
+}
+
+func (s *luaUDPScript) run() {
+	for m := range s.messages {
+		if err := s.call(m); err != nil {
+			logrus.WithError(err).Errorf("UDP script %s failed in %s", s.name, luaUDPScriptHooks[m.message.Event()])
+		}
+	}
+}
+
+func (s *luaUDPScript) call(m luaUDPMessage) error {
+	hook := s.state.GetGlobal(luaUDPScriptHooks[m.message.Event()])
+
+	if hook.Type() != lua.LTFunction {
+		return nil
+	}
+
+	encoded, err := json.Marshal(m.message)
+
+	if err != nil {
+		return err
+	}
+
+	s.process = m.process
+	defer func() {
+		s.process = nil
+	}()
+
+	return s.callWithTimeout(func() error {
+		return s.state.CallByParam(lua.P{
+			Fn:      hook,
+			NRet:    0,
+			Protect: true,
+		}, lua.LString(encoded))
+	})
+}
+
+func (s *luaUDPScript) callWithTimeout(fn func() error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), luaUDPScriptTimeout)
+	defer cancel()
+
+	s.state.SetContext(ctx)
+	defer s.state.RemoveContext()
+
+	return fn()
+}
+
+// luaResult pushes whether a server function succeeded, logging err if it didn't.
+func (s *luaUDPScript) luaResult(l *lua.LState, function string, err error) int {
+	if err != nil {
+		logrus.WithError(err).Errorf("UDP script %s: server.%s failed", s.name, function)
+	}
+
+	l.Push(lua.LBool(err == nil))
+
+	return 1
+}
+
+// checkCarID reads a car ID argument, which acServer takes as a single byte.
+func checkCarID(l *lua.LState, n int) (udp.CarID, error) {
+	carID := l.CheckInt(n)
+
+	if carID < 0 || carID > 255 {
+		return 0, fmt.Errorf("servermanager: invalid car ID %d", carID)
+	}
+
+	return udp.CarID(carID), nil
+}
+
+// sendUDPMessage sends message to the server process which sent the message being handled.
+func (s *luaUDPScript) sendUDPMessage(message udp.Message) error {
+	if s.process == nil {
+		return ErrNoOpenUDPConnection
+	}
+
+	return s.process.SendUDPMessage(message)
+}
+
+// sendChat sends text to carID, or to everyone if broadcast is set. Long messages are split over multiple lines, as
+// acServer can only send 255 characters at once.
+func (s *luaUDPScript) sendChat(carID udp.CarID, text string, broadcast bool) error {
+	for _, line := range strings.Split(wordwrap.WrapString(text, 60), "\n") {
+		var message udp.Message
+		var err error
+
+		if broadcast {
+			message, err = udp.NewBroadcastChat(line)
+		} else {
+			message, err = udp.NewSendChat(carID, line)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := s.sendUDPMessage(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// luaSendChat is server.sendChat(carID, message) in a UDP script.
+func (s *luaUDPScript) luaSendChat(l *lua.LState) int {
+	carID, err := checkCarID(l, 1)
+
+	if err == nil {
+		err = s.sendChat(carID, l.CheckString(2), false)
+	}
+
+	return s.luaResult(l, "sendChat", err)
+}
+
+// luaBroadcastChat is server.broadcastChat(message) in a UDP script.
+func (s *luaUDPScript) luaBroadcastChat(l *lua.LState) int {
+	return s.luaResult(l, "broadcastChat", s.sendChat(0, l.CheckString(1), true))
+}
+
+// luaKick is server.kick(carID) in a UDP script.
+func (s *luaUDPScript) luaKick(l *lua.LState) int {
+	carID, err := checkCarID(l, 1)
+
+	if err == nil {
+		err = s.sendUDPMessage(udp.NewKickUser(uint8(carID)))
+	}
+
+	return s.luaResult(l, "kick", err)
+}
+
+// luaSetBallast is server.setBallast(carID, kg) in a UDP script.
+func (s *luaUDPScript) luaSetBallast(l *lua.LState) int {
+	carID, err := checkCarID(l, 1)
+	ballast := l.CheckInt(2)
+
+	if err == nil && ballast < 0 {
+		err = fmt.Errorf("servermanager: invalid ballast %dkg", ballast)
+	}
+
+	if err == nil {
+		var command *udp.AdminCommand
+
+		command, err = udp.NewAdminCommand(fmt.Sprintf("/ballast %d %d", carID, ballast))
+
+		if err == nil {
+			err = s.sendUDPMessage(command)
+		}
+	}
+
+	return s.luaResult(l, "setBallast", err)
+}
@@ -0,0 +1,141 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+type sentUDPMessagesProcess struct {
+	dummyServerProcess
+
+	sent chan udp.Message
+}
+
+func (p sentUDPMessagesProcess) SendUDPMessage(message udp.Message) error {
+	p.sent <- message
+
+	return nil
+}
+
+func newTestLuaUDPScript(t *testing.T, source string) (*luaUDPScript, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "asm-lua-udp")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "script.lua")
+
+	if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script, err := newLuaUDPScript(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return script, func() {
+		script.state.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestLuaUDPScript(t *testing.T) {
+	script, cleanup := newTestLuaUDPScript(t, `
+		collisions = 0
+
+		function onCollisionWithCar(encodedCollision)
+			collisions = collisions + 1
+
+			if collisions == 2 then
+				server.kick(3)
+				server.setBallast(3, 25)
+				server.broadcastChat("car 3 was kicked")
+			end
+		end
+	`)
+	defer cleanup()
+
+	process := sentUDPMessagesProcess{sent: make(chan udp.Message, 10)}
+
+	for i := 0; i < 2; i++ {
+		if err := script.call(luaUDPMessage{process: process, message: udp.CollisionWithCar{CarID: 3}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(process.sent) != 3 {
+		t.Fatalf("expected the script to remember its collisions and send 3 messages, sent %d", len(process.sent))
+	}
+
+	if kick, ok := (<-process.sent).(*udp.KickUser); !ok || kick.CarID != 3 {
+		t.Errorf("expected car 3 to be kicked, got %+v", kick)
+	}
+
+	if ballast, ok := (<-process.sent).(*udp.AdminCommand); !ok || ballast.Len != uint8(len("/ballast 3 25")) {
+		t.Errorf("expected car 3's ballast to be set, got %+v", ballast)
+	}
+
+	if _, ok := (<-process.sent).(*udp.BroadcastChat); !ok {
+		t.Error("expected a chat message to be broadcast")
+	}
+
+	if err := script.call(luaUDPMessage{process: process, message: udp.Chat{Message: "no hook"}}); err != nil {
+		t.Errorf("expected messages without a hook to be ignored, got %s", err)
+	}
+}
+
+func TestLuaUDPScript_Sandbox(t *testing.T) {
+	script, cleanup := newTestLuaUDPScript(t, `
+		function onChat(encodedChat)
+			os.execute("echo hello")
+		end
+
+		function onLapCompleted(encodedLap)
+			server.kick(1000)
+		end
+	`)
+	defer cleanup()
+
+	process := sentUDPMessagesProcess{sent: make(chan udp.Message, 10)}
+
+	if err := script.call(luaUDPMessage{process: process, message: udp.Chat{}}); err == nil {
+		t.Error("expected a UDP script not to be able to use os")
+	}
+
+	if err := script.call(luaUDPMessage{process: process, message: udp.LapCompleted{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(process.sent) != 0 {
+		t.Error("expected an invalid car ID not to be sent")
+	}
+}
+
+func TestLuaUDPScript_Timeout(t *testing.T) {
+	oldLuaUDPScriptTimeout := luaUDPScriptTimeout
+	luaUDPScriptTimeout = time.Millisecond * 50
+	defer func() {
+		luaUDPScriptTimeout = oldLuaUDPScriptTimeout
+	}()
+
+	script, cleanup := newTestLuaUDPScript(t, `
+		function onChat(encodedChat)
+			while true do end
+		end
+	`)
+	defer cleanup()
+
+	if err := script.call(luaUDPMessage{message: udp.Chat{}}); err == nil {
+		t.Error("expected a hook which never finishes to be stopped")
+	}
+}
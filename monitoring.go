@@ -59,7 +59,7 @@ func InitMonitoring() {
 	http.DefaultClient.Transport = http.DefaultTransport
 
 	logrus.Infof("initialising Prometheus Monitoring")
-	prometheus.MustRegister(HTTPInFlightGauge, HTTPCounter, HTTPDuration, HTTPResponseSize, httpInFlightRequests, httpRequestCounter, dnsLatencyVec, tlsLatencyVec, histVec)
+	prometheus.MustRegister(HTTPInFlightGauge, HTTPCounter, HTTPDuration, HTTPResponseSize, httpInFlightRequests, httpRequestCounter, dnsLatencyVec, tlsLatencyVec, histVec, hostLoadGauge, hostMemoryAvailableGauge, hostDiskBytesGauge, serverProcessStartsCounter, serverProcessStopsCounter, serverProcessUDPMessagesCounter)
 	prometheusMonitoringHandler = promhttp.Handler
 	prometheusMonitoringWrapper = func(next http.Handler) http.Handler {
 		return promhttp.InstrumentHandlerInFlight(HTTPInFlightGauge,
@@ -0,0 +1,305 @@
+package udp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Custom Shaders Patch (CSP) clients extend the plugin protocol without changing acServer: extended messages travel
+// as chat messages which start with cspChatPrefix, followed by the message type and its payload in base64. CSP
+// clients hide these chat messages, and clients without CSP ignore them.
+const cspChatPrefix = "\t\t\t\t$CSP0:"
+
+// maxChatLength is the longest chat message acServer takes, its length is sent as a single byte.
+const maxChatLength = 255
+
+// EventCSPClientMessage isn't sent by acServer. It is the Event of a CSPClientMessage, which acServer sends as a chat.
+// Server Manager uses 240 for its own events.
+const EventCSPClientMessage Event = 241
+
+var (
+	ErrCSPMessageTooLong      = errors.New("udp: CSP message is too long to send as a chat message")
+	ErrUnknownCSPMessageType  = errors.New("udp: unknown CSP message type")
+	errCSPMessageTooShort     = errors.New("udp: CSP message is too short")
+	maxCSPPayloadLength       = base64.RawURLEncoding.DecodedLen(maxChatLength-len(cspChatPrefix)) - 2
+	cspMessageTypeDescription = map[CSPMessageType]string{
+		CSPMessageHandshake:    "handshake",
+		CSPMessageExtendedChat: "extended chat",
+		CSPMessageWeather:      "weather",
+		CSPMessageTeleport:     "teleport",
+	}
+)
+
+// CSPMessageType identifies the payload of an extended message.
+type CSPMessageType uint16
+
+const (
+	CSPMessageHandshake    CSPMessageType = 0
+	CSPMessageExtendedChat CSPMessageType = 1
+	CSPMessageWeather      CSPMessageType = 2
+	CSPMessageTeleport     CSPMessageType = 3
+)
+
+func (t CSPMessageType) String() string {
+	if description, ok := cspMessageTypeDescription[t]; ok {
+		return description
+	}
+
+	return fmt.Sprintf("unknown (%d)", uint16(t))
+}
+
+// CSPMessage is the payload of an extended message.
+type CSPMessage interface {
+	CSPType() CSPMessageType
+}
+
+// CSPClientMessage is an extended message sent by a CSP client. Message is one of the CSPMessage types in this
+// package, or a CSPRawMessage if the type isn't known.
+type CSPClientMessage struct {
+	CarID   CarID          `json:"CarID"`
+	Type    CSPMessageType `json:"Type"`
+	Message CSPMessage     `json:"Message"`
+}
+
+func (CSPClientMessage) Event() Event {
+	return EventCSPClientMessage
+}
+
+// CSPRawMessage is an extended message of a type this package doesn't know how to decode.
+type CSPRawMessage struct {
+	Type CSPMessageType
+	Data []byte
+}
+
+func (m CSPRawMessage) CSPType() CSPMessageType {
+	return m.Type
+}
+
+// CSPHandshake is sent by CSP clients once they have loaded, to tell the server which version of CSP they have.
+// Clients which haven't sent one don't support extended messages.
+type CSPHandshake struct {
+	Version uint32
+}
+
+func (CSPHandshake) CSPType() CSPMessageType {
+	return CSPMessageHandshake
+}
+
+// CSPExtendedChat is a chat message which isn't limited to ASCII, shown in Color (0xRRGGBB).
+type CSPExtendedChat struct {
+	Color   uint32
+	Message string
+}
+
+func (CSPExtendedChat) CSPType() CSPMessageType {
+	return CSPMessageExtendedChat
+}
+
+// CSPWeatherType is one of the types of weather which CSP's weather effects can show.
+type CSPWeatherType uint8
+
+const (
+	CSPWeatherLightThunderstorm CSPWeatherType = iota
+	CSPWeatherThunderstorm
+	CSPWeatherHeavyThunderstorm
+	CSPWeatherLightDrizzle
+	CSPWeatherDrizzle
+	CSPWeatherHeavyDrizzle
+	CSPWeatherLightRain
+	CSPWeatherRain
+	CSPWeatherHeavyRain
+	CSPWeatherLightSnow
+	CSPWeatherSnow
+	CSPWeatherHeavySnow
+	CSPWeatherLightSleet
+	CSPWeatherSleet
+	CSPWeatherHeavySleet
+	CSPWeatherClear
+	CSPWeatherFewClouds
+	CSPWeatherScatteredClouds
+	CSPWeatherBrokenClouds
+	CSPWeatherOvercastClouds
+	CSPWeatherFog
+	CSPWeatherMist
+	CSPWeatherSmoke
+	CSPWeatherHaze
+	CSPWeatherSand
+	CSPWeatherDust
+	CSPWeatherSqualls
+	CSPWeatherTornado
+	CSPWeatherHurricane
+	CSPWeatherCold
+	CSPWeatherHot
+	CSPWeatherWindy
+	CSPWeatherHail
+)
+
+var cspWeatherTypeNames = []string{
+	"Light Thunderstorm", "Thunderstorm", "Heavy Thunderstorm",
+	"Light Drizzle", "Drizzle", "Heavy Drizzle",
+	"Light Rain", "Rain", "Heavy Rain",
+	"Light Snow", "Snow", "Heavy Snow",
+	"Light Sleet", "Sleet", "Heavy Sleet",
+	"Clear", "Few Clouds", "Scattered Clouds", "Broken Clouds", "Overcast Clouds",
+	"Fog", "Mist", "Smoke", "Haze", "Sand", "Dust",
+	"Squalls", "Tornado", "Hurricane", "Cold", "Hot", "Windy", "Hail",
+}
+
+// CSPWeatherTypes lists every CSPWeatherType, in order.
+func CSPWeatherTypes() []CSPWeatherType {
+	types := make([]CSPWeatherType, len(cspWeatherTypeNames))
+
+	for i := range types {
+		types[i] = CSPWeatherType(i)
+	}
+
+	return types
+}
+
+// Valid reports whether t is a weather type which CSP knows.
+func (t CSPWeatherType) Valid() bool {
+	return int(t) < len(cspWeatherTypeNames)
+}
+
+func (t CSPWeatherType) String() string {
+	if t.Valid() {
+		return cspWeatherTypeNames[t]
+	}
+
+	return fmt.Sprintf("unknown (%d)", uint8(t))
+}
+
+// CSPWeather sets the weather CSP clients show, moving to it over TransitionSeconds.
+type CSPWeather struct {
+	WeatherType        CSPWeatherType
+	TemperatureAmbient float32
+	TemperatureRoad    float32
+	WindSpeedKMH       float32
+	WindDirection      uint16
+	TransitionSeconds  uint16
+}
+
+func (CSPWeather) CSPType() CSPMessageType {
+	return CSPMessageWeather
+}
+
+// CSPTeleport moves a car to Position, facing Direction.
+type CSPTeleport struct {
+	Position  Vec
+	Direction Vec
+}
+
+func (CSPTeleport) CSPType() CSPMessageType {
+	return CSPMessageTeleport
+}
+
+// encodeCSPMessage encodes message as the text of a chat message.
+func encodeCSPMessage(message CSPMessage) (string, error) {
+	buf := new(bytes.Buffer)
+
+	if err := binary.Write(buf, binary.LittleEndian, message.CSPType()); err != nil {
+		return "", err
+	}
+
+	var err error
+
+	switch m := message.(type) {
+	case CSPRawMessage:
+		_, err = buf.Write(m.Data)
+	case CSPExtendedChat:
+		if err = binary.Write(buf, binary.LittleEndian, m.Color); err == nil {
+			_, err = buf.WriteString(m.Message)
+		}
+	case CSPHandshake, CSPWeather, CSPTeleport:
+		err = binary.Write(buf, binary.LittleEndian, m)
+	default:
+		return "", ErrUnknownCSPMessageType
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if buf.Len()-2 > maxCSPPayloadLength {
+		return "", ErrCSPMessageTooLong
+	}
+
+	return cspChatPrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// isCSPChat reports whether a chat message is an extended message.
+func isCSPChat(message string) bool {
+	return strings.HasPrefix(message, cspChatPrefix)
+}
+
+// decodeCSPMessage decodes the text of a chat message which isCSPChat.
+func decodeCSPMessage(message string) (CSPMessage, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimPrefix(message, cspChatPrefix), "="))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 2 {
+		return nil, errCSPMessageTooShort
+	}
+
+	messageType := CSPMessageType(binary.LittleEndian.Uint16(data))
+	r := bytes.NewReader(data[2:])
+
+	switch messageType {
+	case CSPMessageHandshake:
+		var m CSPHandshake
+		err = binary.Read(r, binary.LittleEndian, &m)
+
+		return m, err
+	case CSPMessageExtendedChat:
+		var m CSPExtendedChat
+
+		if err := binary.Read(r, binary.LittleEndian, &m.Color); err != nil {
+			return nil, err
+		}
+
+		m.Message = string(data[6:])
+
+		return m, nil
+	case CSPMessageWeather:
+		var m CSPWeather
+		err = binary.Read(r, binary.LittleEndian, &m)
+
+		return m, err
+	case CSPMessageTeleport:
+		var m CSPTeleport
+		err = binary.Read(r, binary.LittleEndian, &m)
+
+		return m, err
+	default:
+		return CSPRawMessage{Type: messageType, Data: data[2:]}, nil
+	}
+}
+
+// NewCSPSendChat sends an extended message to the CSP client in car carID.
+func NewCSPSendChat(carID CarID, message CSPMessage) (*SendChat, error) {
+	text, err := encodeCSPMessage(message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSendChat(carID, text)
+}
+
+// NewCSPBroadcastChat sends an extended message to every CSP client.
+func NewCSPBroadcastChat(message CSPMessage) (*BroadcastChat, error) {
+	text, err := encodeCSPMessage(message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBroadcastChat(text)
+}
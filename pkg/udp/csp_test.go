@@ -0,0 +1,108 @@
+package udp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSPMessage_RoundTrip(t *testing.T) {
+	messages := []CSPMessage{
+		CSPHandshake{Version: 1937},
+		CSPExtendedChat{Color: 0xff8800, Message: "Grüße aus Spa 🏁"},
+		CSPWeather{WeatherType: 3, TemperatureAmbient: 18.5, TemperatureRoad: 24, WindSpeedKMH: 12, WindDirection: 270, TransitionSeconds: 600},
+		CSPTeleport{Position: Vec{X: -120.5, Y: 4.25, Z: 873}, Direction: Vec{X: 0, Y: 0, Z: 1}},
+		CSPRawMessage{Type: 1000, Data: []byte{1, 2, 3}},
+	}
+
+	for _, message := range messages {
+		text, err := encodeCSPMessage(message)
+
+		if err != nil {
+			t.Errorf("could not encode %T: %s", message, err)
+			continue
+		}
+
+		if !isCSPChat(text) || len(text) > maxChatLength {
+			t.Errorf("expected %T to encode to a CSP chat message, got %q", message, text)
+			continue
+		}
+
+		if _, err := NewSendChat(0, text); err != nil {
+			t.Errorf("could not send %T as a chat message: %s", message, err)
+		}
+
+		decoded, err := decodeCSPMessage(text)
+
+		if err != nil {
+			t.Errorf("could not decode %T: %s", message, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(decoded, message) {
+			t.Errorf("expected %+v, got %+v", message, decoded)
+		}
+	}
+}
+
+func TestCSPMessage_TooLong(t *testing.T) {
+	if _, err := NewCSPBroadcastChat(CSPExtendedChat{Message: strings.Repeat("x", maxCSPPayloadLength-4)}); err != nil {
+		t.Errorf("expected the longest extended chat to be sent, got %s", err)
+	}
+
+	if _, err := NewCSPSendChat(1, CSPExtendedChat{Message: strings.Repeat("x", maxCSPPayloadLength-3)}); err != ErrCSPMessageTooLong {
+		t.Errorf("expected ErrCSPMessageTooLong, got %v", err)
+	}
+}
+
+// chatPacket is a chat message as acServer sends it to plugins.
+func chatPacket(carID CarID, message string) []byte {
+	packet := []byte{byte(EventChat), byte(carID), byte(len(message))}
+
+	for _, c := range []byte(message) {
+		packet = append(packet, c, 0, 0, 0)
+	}
+
+	return packet
+}
+
+func TestParseMessage_CSPClientMessage(t *testing.T) {
+	text, err := encodeCSPMessage(CSPHandshake{Version: 1937})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := ParseMessage(chatPacket(4, text))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := CSPClientMessage{CarID: 4, Type: CSPMessageHandshake, Message: CSPHandshake{Version: 1937}}
+
+	if !reflect.DeepEqual(message, expected) {
+		t.Errorf("expected %+v, got %+v", expected, message)
+	}
+
+	// a chat message which only looks like an extended message is still a chat message.
+	message, err = ParseMessage(chatPacket(4, cspChatPrefix+"!"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chat, ok := message.(Chat); !ok || chat.Message != cspChatPrefix+"!" {
+		t.Errorf("expected a chat message, got %+v", message)
+	}
+
+	message, err = ParseMessage(chatPacket(4, "hello"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if chat, ok := message.(Chat); !ok || chat.Message != "hello" {
+		t.Errorf("expected a chat message, got %+v", message)
+	}
+}
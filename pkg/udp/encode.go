@@ -0,0 +1,138 @@
+package udp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/text/encoding/unicode/utf32"
+)
+
+// EncodeMessage encodes a message from acServer exactly as acServer sends it to plugins, so that it can be parsed by
+// ParseMessage. It is the opposite of ParseMessage, for standing in for acServer, e.g. in tests.
+func EncodeMessage(message Message) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := &messageWriter{buf: buf}
+
+	w.write(uint8(message.Event()))
+
+	switch m := message.(type) {
+	case SessionCarInfo:
+		w.writeStringW(m.DriverName)
+		w.writeStringW(string(m.DriverGUID))
+		w.write(m.CarID)
+		w.writeString(m.CarModel)
+		w.writeString(m.CarSkin)
+	case CarUpdate:
+		w.write(m)
+	case CarInfo:
+		w.write(m.CarID)
+		w.write(m.IsConnected)
+		w.writeStringW(m.CarModel)
+		w.writeStringW(m.CarSkin)
+		w.writeStringW(m.DriverName)
+		w.writeStringW(m.DriverTeam)
+		w.writeStringW(string(m.DriverGUID))
+	case EndSession:
+		w.writeStringW(string(m))
+	case Version:
+		w.write(uint8(m))
+	case Chat:
+		w.write(m.CarID)
+		w.writeStringW(m.Message)
+	case ClientLoaded:
+		w.write(CarID(m))
+	case SessionInfo:
+		w.write(m.Version)
+		w.write(m.SessionIndex)
+		w.write(m.CurrentSessionIndex)
+		w.write(m.SessionCount)
+		w.writeStringW(m.ServerName)
+		w.writeString(m.Track)
+		w.writeString(m.TrackConfig)
+		w.writeString(m.Name)
+		w.write(m.Type)
+		w.write(m.Time)
+		w.write(m.Laps)
+		w.write(m.WaitTime)
+		w.write(m.AmbientTemp)
+		w.write(m.RoadTemp)
+		w.writeString(m.WeatherGraphics)
+		w.write(m.ElapsedMilliseconds)
+	case ServerError:
+		w.writeStringW(m.Error())
+	case LapCompleted:
+		w.write(lapCompletedInternal{CarID: m.CarID, LapTime: m.LapTime, Cuts: m.Cuts, CarsCount: uint8(len(m.Cars))})
+
+		for _, car := range m.Cars {
+			w.write(car)
+		}
+	case CollisionWithCar:
+		buf.Reset()
+		w.write(uint8(EventClientEvent))
+		w.write(uint8(EventCollisionWithCar))
+		w.write(m)
+	case CollisionWithEnvironment:
+		buf.Reset()
+		w.write(uint8(EventClientEvent))
+		w.write(uint8(EventCollisionWithEnv))
+		w.write(m)
+	default:
+		return nil, fmt.Errorf("udp: can't encode message of type %T", message)
+	}
+
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// messageWriter writes the fields of a message, keeping the first error.
+type messageWriter struct {
+	buf *bytes.Buffer
+	err error
+}
+
+func (w *messageWriter) write(data interface{}) {
+	if w.err != nil {
+		return
+	}
+
+	w.err = binary.Write(w.buf, binary.LittleEndian, data)
+}
+
+// writeString writes s as readString(r, 1) reads it.
+func (w *messageWriter) writeString(s string) {
+	if len(s) > 255 {
+		s = s[:255]
+	}
+
+	w.write(uint8(len(s)))
+
+	if w.err == nil {
+		w.buf.WriteString(s)
+	}
+}
+
+// writeStringW writes s as readStringW reads it.
+func (w *messageWriter) writeStringW(s string) {
+	runes := []rune(s)
+
+	if len(runes) > 255 {
+		runes = runes[:255]
+	}
+
+	encoded, err := utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM).NewEncoder().Bytes([]byte(string(runes)))
+
+	if err != nil {
+		w.err = err
+		return
+	}
+
+	w.write(uint8(len(runes)))
+
+	if w.err == nil {
+		w.buf.Write(encoded)
+	}
+}
@@ -0,0 +1,66 @@
+package udp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeMessage(t *testing.T) {
+	messages := []Message{
+		Version(4),
+		SessionInfo{
+			Version:         4,
+			SessionIndex:    2,
+			SessionCount:    3,
+			ServerName:      "Ünïcode Server",
+			Track:           "ks_vallelunga",
+			TrackConfig:     "extended_circuit",
+			Name:            "Race",
+			Type:            SessionTypeRace,
+			Laps:            12,
+			WaitTime:        60,
+			AmbientTemp:     22,
+			RoadTemp:        28,
+			WeatherGraphics: "3_clear",
+			EventType:       EventNewSession,
+		},
+		SessionCarInfo{CarID: 3, DriverName: "Driver", DriverGUID: "76561198000000000", CarModel: "ks_mazda_mx5_cup", CarSkin: "00_red", EventType: EventNewConnection},
+		ClientLoaded(3),
+		CarInfo{CarID: 3, IsConnected: true, CarModel: "ks_mazda_mx5_cup", CarSkin: "00_red", DriverName: "Driver", DriverTeam: "Team", DriverGUID: "76561198000000000"},
+		CarUpdate{CarID: 3, Pos: Vec{X: 1, Y: 2, Z: 3}, Velocity: Vec{X: 4}, Gear: 3, EngineRPM: 6500, NormalisedSplinePos: 0.5},
+		LapCompleted{CarID: 3, LapTime: 92345, Cuts: 1, CarsCount: 2, Cars: []*LapCompletedCar{
+			{CarID: 3, LapTime: 92345, Laps: 4},
+			{CarID: 1, LapTime: 93001, Laps: 3, Completed: 1},
+		}},
+		CollisionWithCar{CarID: 3, OtherCarID: 1, ImpactSpeed: 23.5, WorldPos: Vec{X: 1}, RelPos: Vec{Z: 1}},
+		CollisionWithEnvironment{CarID: 3, ImpactSpeed: 40, WorldPos: Vec{Y: 1}},
+		Chat{CarID: 3, Message: "hello"},
+		ServerError{errors.New("something broke")},
+		EndSession("results/2020_5_1_19_30_RACE.json"),
+	}
+
+	for _, message := range messages {
+		data, err := EncodeMessage(message)
+
+		if err != nil {
+			t.Errorf("Could not encode %T: %s", message, err)
+			continue
+		}
+
+		parsed, err := ParseMessage(data)
+
+		if err != nil {
+			t.Errorf("Could not parse encoded %T: %s", message, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(parsed, message) {
+			t.Errorf("Expected %#v, got %#v", message, parsed)
+		}
+	}
+
+	if _, err := EncodeMessage(GetSessionInfo{}); err == nil {
+		t.Error("Expected an error encoding a message which acServer doesn't send")
+	}
+}
@@ -0,0 +1,297 @@
+package udp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrConnectionClosed = errors.New("udp: connection is closed")
+
+// ForwardTarget is an additional address which messages from the server are forwarded to, alongside the forwarding
+// address the client was created with. Messages sent back by the target are passed on to the server.
+type ForwardTarget struct {
+	// Name labels the target in its status, e.g. "stracker".
+	Name string `json:",omitempty" yaml:"name"`
+	// Address is the host:port that messages are forwarded to.
+	Address string `yaml:"address"`
+	// ListenPort is the local port that messages are sent from and replies are read on. If it is 0, any free port
+	// is used.
+	ListenPort int `yaml:"listen_port"`
+	// Disabled targets keep their socket and counters, but nothing is forwarded to them and their replies are
+	// dropped, so a plugin can be switched off without losing its port.
+	Disabled bool `json:",omitempty" yaml:"disabled"`
+}
+
+func (ft ForwardTarget) String() string {
+	var name string
+
+	if ft.Name != "" {
+		name = ft.Name + ": "
+	}
+
+	if ft.Disabled {
+		return fmt.Sprintf("%s%s (listen port: %d, disabled)", name, ft.Address, ft.ListenPort)
+	}
+
+	return fmt.Sprintf("%s%s (listen port: %d)", name, ft.Address, ft.ListenPort)
+}
+
+// Same reports whether ft and other forward to the same address from the same port, whatever their names and
+// whether or not they are disabled.
+func (ft ForwardTarget) Same(other ForwardTarget) bool {
+	return ft.Address == other.Address && ft.ListenPort == other.ListenPort
+}
+
+type forwardTargetKey struct {
+	address    string
+	listenPort int
+}
+
+func (ft ForwardTarget) key() forwardTargetKey {
+	return forwardTargetKey{address: ft.Address, listenPort: ft.ListenPort}
+}
+
+// ForwardTargetStatus describes the messages which have been forwarded to a ForwardTarget.
+type ForwardTargetStatus struct {
+	ForwardTarget
+
+	Active bool
+
+	// Sent counts the messages forwarded to the target, and Received the replies passed on from it to the server.
+	Sent          uint64
+	Received      uint64
+	Errors        uint64
+	LastSent      time.Time `json:",omitempty"`
+	LastError     string    `json:",omitempty"`
+	LastErrorTime time.Time `json:",omitempty"`
+}
+
+type forwardTarget struct {
+	conn *net.UDPConn
+
+	// closed is accessed atomically, it is non-zero once the target has been removed.
+	closed int32
+
+	mutex         sync.Mutex
+	target        ForwardTarget
+	sent          uint64
+	received      uint64
+	errors        uint64
+	lastSent      time.Time
+	lastError     error
+	lastErrorTime time.Time
+}
+
+func (t *forwardTarget) write(buf []byte) {
+	if t.disabled() {
+		return
+	}
+
+	_, err := t.conn.Write(buf)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if err != nil {
+		t.errors++
+		t.lastError = err
+		t.lastErrorTime = time.Now()
+		return
+	}
+
+	t.sent++
+	t.lastSent = time.Now()
+}
+
+func (t *forwardTarget) disabled() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.target.Disabled
+}
+
+// update replaces the name and disabled flag of the target, which keeps its socket and counters.
+func (t *forwardTarget) update(target ForwardTarget) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.target = target
+}
+
+func (t *forwardTarget) replied() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.received++
+}
+
+func (t *forwardTarget) close() error {
+	atomic.StoreInt32(&t.closed, 1)
+
+	return t.conn.Close()
+}
+
+func (t *forwardTarget) status() ForwardTargetStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	status := ForwardTargetStatus{
+		ForwardTarget: t.target,
+		Active:        atomic.LoadInt32(&t.closed) == 0,
+		Sent:          t.sent,
+		Received:      t.received,
+		Errors:        t.errors,
+		LastSent:      t.lastSent,
+		LastErrorTime: t.lastErrorTime,
+	}
+
+	if t.lastError != nil {
+		status.LastError = t.lastError.Error()
+	}
+
+	return status
+}
+
+// SetForwardingTargets replaces the additional forwarding targets. Sockets are opened for new targets and closed for
+// targets which are no longer present, targets with the same address and listen port as before keep their socket and
+// counters, even if they have been renamed, disabled or enabled. Messages which
+// are being forwarded while the targets change are delivered to the old set of targets. If some targets can't be
+// opened, the rest are still applied and an error listing the failures is returned.
+func (asu *AssettoServerUDP) SetForwardingTargets(targets []ForwardTarget) error {
+	asu.targetsMutex.Lock()
+	defer asu.targetsMutex.Unlock()
+
+	if asu.ctx.Err() != nil {
+		return ErrConnectionClosed
+	}
+
+	existing := make(map[forwardTargetKey]*forwardTarget)
+
+	for _, t := range asu.targets {
+		existing[t.status().key()] = t
+	}
+
+	var updated []*forwardTarget
+	var failed []string
+
+	seen := make(map[forwardTargetKey]bool)
+
+	for _, target := range targets {
+		if seen[target.key()] {
+			continue
+		}
+
+		seen[target.key()] = true
+
+		if t, ok := existing[target.key()]; ok {
+			t.update(target)
+			updated = append(updated, t)
+			delete(existing, target.key())
+			continue
+		}
+
+		t, err := asu.openForwardTarget(target)
+
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", target, err))
+			continue
+		}
+
+		updated = append(updated, t)
+
+		go asu.forwardTargetServe(t)
+	}
+
+	for _, t := range existing {
+		_ = t.close()
+	}
+
+	asu.targets = updated
+
+	if len(failed) > 0 {
+		return fmt.Errorf("udp: could not open forwarding targets: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// ForwardingTargets returns the status of each additional forwarding target.
+func (asu *AssettoServerUDP) ForwardingTargets() []ForwardTargetStatus {
+	asu.targetsMutex.RLock()
+	defer asu.targetsMutex.RUnlock()
+
+	var statuses []ForwardTargetStatus
+
+	for _, t := range asu.targets {
+		statuses = append(statuses, t.status())
+	}
+
+	return statuses
+}
+
+func (asu *AssettoServerUDP) openForwardTarget(target ForwardTarget) (*forwardTarget, error) {
+	addr, err := net.ResolveUDPAddr("udp", target.Address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialUDP("udp", &net.UDPAddr{IP: net.ParseIP(asu.addr), Port: target.ListenPort}, addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &forwardTarget{target: target, conn: conn}, nil
+}
+
+// forwardTargetServe passes messages sent back by a target on to the server, until the target is closed.
+func (asu *AssettoServerUDP) forwardTargetServe(t *forwardTarget) {
+	buf := make([]byte, 1024)
+
+	for {
+		n, _, err := t.conn.ReadFromUDP(buf)
+
+		if err != nil {
+			if atomic.LoadInt32(&t.closed) != 0 || asu.ctx.Err() != nil {
+				return
+			}
+
+			continue
+		}
+
+		if t.disabled() {
+			continue
+		}
+
+		if _, err := asu.listener.Write(buf[:n]); err == nil {
+			t.replied()
+		}
+	}
+}
+
+// forwardToTargets writes a message from the server to each additional forwarding target.
+func (asu *AssettoServerUDP) forwardToTargets(buf []byte) {
+	asu.targetsMutex.RLock()
+	defer asu.targetsMutex.RUnlock()
+
+	for _, t := range asu.targets {
+		t.write(buf)
+	}
+}
+
+func (asu *AssettoServerUDP) closeForwardingTargets() {
+	asu.targetsMutex.Lock()
+	defer asu.targetsMutex.Unlock()
+
+	for _, t := range asu.targets {
+		_ = t.close()
+	}
+
+	asu.targets = nil
+}
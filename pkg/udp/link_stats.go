@@ -0,0 +1,167 @@
+package udp
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// carUpdateOutOfOrderMinSpeed is how fast (in m/s) a car must be going for a spline position behind its last one
+	// to count as an out of order update, rather than the car going backwards.
+	carUpdateOutOfOrderMinSpeed = 10
+
+	// carUpdateMaxGap is the longest gap between two updates for a car which is counted as lost updates. Longer gaps
+	// are from the car not being on track, e.g. while it loads.
+	carUpdateMaxGap = time.Second * 5
+
+	// latencyAverageWeight is how much each message counts towards the average callback latency and duration.
+	latencyAverageWeight = 0.05
+)
+
+// LinkStats describe how well the messages from acServer are being received and handled, for diagnosing laggy live
+// timing. They are counted from when the connection was opened.
+type LinkStats struct {
+	Since time.Time
+
+	PacketsReceived uint64
+	BytesReceived   uint64
+	ReadErrors      uint64
+	DecodeErrors    uint64
+
+	// QueueLength is the number of messages which have been received but not yet handled.
+	QueueLength int
+
+	// ProtocolVersion is the version of the UDP plugin protocol which acServer has said it speaks.
+	ProtocolVersion ProtocolVersion
+
+	// RealtimePosInterval is the interval between car updates currently requested from acServer, in milliseconds.
+	RealtimePosInterval int
+
+	// acServer doesn't number its car updates, so CarUpdatesLost and CarUpdatesOutOfOrder are estimates. An update is
+	// counted as lost when a car's updates are further apart than RealtimePosInterval, and as out of order when it puts
+	// a car which is going forwards behind where its last update put it.
+	CarUpdates           uint64
+	CarUpdatesLost       uint64
+	CarUpdatesOutOfOrder uint64
+
+	// CallbackLatency is the time from a message being received to the callback having handled it, including the time
+	// spent waiting in the queue. CallbackDuration is the time spent in the callback alone. Averages are weighted
+	// towards recent messages.
+	CallbackLatencyAverage  time.Duration
+	CallbackLatencyMax      time.Duration
+	CallbackDurationAverage time.Duration
+	CallbackDurationMax     time.Duration
+}
+
+type lastCarUpdate struct {
+	received  time.Time
+	splinePos float32
+}
+
+// linkStats counts LinkStats as messages are received and handled.
+type linkStats struct {
+	mutex sync.Mutex
+	stats LinkStats
+	cars  map[CarID]lastCarUpdate
+}
+
+func newLinkStats(now time.Time) *linkStats {
+	return &linkStats{
+		stats: LinkStats{Since: now},
+		cars:  make(map[CarID]lastCarUpdate),
+	}
+}
+
+func (l *linkStats) received(size int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.stats.PacketsReceived++
+	l.stats.BytesReceived += uint64(size)
+}
+
+func (l *linkStats) readError() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.stats.ReadErrors++
+}
+
+func (l *linkStats) decodeError() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.stats.DecodeErrors++
+}
+
+// handled records that message, which was received at received, was handled by the callback in duration.
+// posIntervalMs is the real time pos interval which was requested from acServer at the time.
+func (l *linkStats) handled(message Message, received time.Time, duration time.Duration, now time.Time, posIntervalMs int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	latency := now.Sub(received)
+
+	if l.stats.CallbackLatencyAverage == 0 {
+		l.stats.CallbackLatencyAverage, l.stats.CallbackDurationAverage = latency, duration
+	} else {
+		l.stats.CallbackLatencyAverage = weightedAverage(l.stats.CallbackLatencyAverage, latency)
+		l.stats.CallbackDurationAverage = weightedAverage(l.stats.CallbackDurationAverage, duration)
+	}
+
+	if latency > l.stats.CallbackLatencyMax {
+		l.stats.CallbackLatencyMax = latency
+	}
+
+	if duration > l.stats.CallbackDurationMax {
+		l.stats.CallbackDurationMax = duration
+	}
+
+	switch m := message.(type) {
+	case CarUpdate:
+		l.carUpdate(m, received, posIntervalMs)
+	case SessionCarInfo:
+		// the car's next update is from a new driver, so it can't be compared with the last one.
+		delete(l.cars, m.CarID)
+	}
+}
+
+// carUpdate must be called with l.mutex held.
+func (l *linkStats) carUpdate(update CarUpdate, received time.Time, posIntervalMs int) {
+	l.stats.CarUpdates++
+
+	last, ok := l.cars[update.CarID]
+
+	l.cars[update.CarID] = lastCarUpdate{received: received, splinePos: update.NormalisedSplinePos}
+
+	if !ok {
+		return
+	}
+
+	if gap := received.Sub(last.received); posIntervalMs > 0 && gap < carUpdateMaxGap {
+		interval := time.Duration(posIntervalMs) * time.Millisecond
+
+		if gap > interval*3/2 {
+			l.stats.CarUpdatesLost += uint64(math.Round(float64(gap)/float64(interval))) - 1
+		}
+	}
+
+	speed := math.Sqrt(float64(update.Velocity.X*update.Velocity.X + update.Velocity.Y*update.Velocity.Y + update.Velocity.Z*update.Velocity.Z))
+
+	// spline positions wrap from 1 to 0 at the line, a drop of more than half a lap is a car crossing it.
+	if back := last.splinePos - update.NormalisedSplinePos; back > 0 && back < 0.5 && speed > carUpdateOutOfOrderMinSpeed {
+		l.stats.CarUpdatesOutOfOrder++
+	}
+}
+
+func (l *linkStats) snapshot() LinkStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.stats
+}
+
+func weightedAverage(average, value time.Duration) time.Duration {
+	return time.Duration(float64(average)*(1-latencyAverageWeight) + float64(value)*latencyAverageWeight)
+}
@@ -0,0 +1,49 @@
+package udp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinkStats(t *testing.T) {
+	start := time.Date(2020, 5, 1, 19, 0, 0, 0, time.UTC)
+	stats := newLinkStats(start)
+
+	// a car going forwards at 50m/s, with an update every 100ms.
+	update := func(offset time.Duration, splinePos float32) {
+		stats.received(60)
+		stats.handled(CarUpdate{CarID: 1, Velocity: Vec{X: 50}, NormalisedSplinePos: splinePos}, start.Add(offset), time.Millisecond, start.Add(offset+time.Millisecond*5), 100)
+	}
+
+	update(0, 0.90)
+	update(time.Millisecond*100, 0.91)
+	// two updates are lost.
+	update(time.Millisecond*400, 0.94)
+	// the update from before the last one arrives late.
+	update(time.Millisecond*410, 0.93)
+	update(time.Millisecond*500, 0.95)
+	// crossing the line isn't out of order.
+	update(time.Millisecond*600, 0.01)
+	// the car is off track for a while, e.g. in the pits, which isn't loss.
+	update(time.Second*30, 0.02)
+
+	stats.decodeError()
+
+	snapshot := stats.snapshot()
+
+	if snapshot.PacketsReceived != 7 || snapshot.BytesReceived != 420 || snapshot.CarUpdates != 7 || snapshot.DecodeErrors != 1 {
+		t.Errorf("Unexpected counts: %#v", snapshot)
+	}
+
+	if snapshot.CarUpdatesLost != 2 {
+		t.Errorf("Expected 2 lost car updates, got %d", snapshot.CarUpdatesLost)
+	}
+
+	if snapshot.CarUpdatesOutOfOrder != 1 {
+		t.Errorf("Expected 1 out of order car update, got %d", snapshot.CarUpdatesOutOfOrder)
+	}
+
+	if snapshot.CallbackLatencyAverage != time.Millisecond*5 || snapshot.CallbackLatencyMax != time.Millisecond*5 || snapshot.CallbackDurationMax != time.Millisecond {
+		t.Errorf("Unexpected callback latency: %#v", snapshot)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"io"
 	"net"
 	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -18,11 +21,26 @@ import (
 // RealtimePosIntervalMs is the interval to request real time positional information.
 // Set this to greater than 0 to enable.
 var RealtimePosIntervalMs = -1
-var CurrentRealtimePosIntervalMs = -1
 var PosIntervalModifierEnabled = false
 
+// maxConsecutiveReadErrors is how many reads in a row can fail before the connection is considered to have failed.
+// Single errors are expected, e.g. if the server isn't listening yet.
+const maxConsecutiveReadErrors = 50
+
 func NewServerClient(addr string, receivePort, sendPort int, forward bool, forwardAddrStr string, forwardListenPort int, callback CallbackFunc) (*AssettoServerUDP, error) {
-	listener, err := net.DialUDP("udp", &net.UDPAddr{IP: net.ParseIP(addr), Port: receivePort}, &net.UDPAddr{IP: net.ParseIP(addr), Port: sendPort})
+	return NewRemoteServerClient(addr, receivePort, addr, sendPort, forward, forwardAddrStr, forwardListenPort, callback)
+}
+
+// NewRemoteServerClient is NewServerClient for an acServer on another host. Messages from acServer are received on
+// addr, and messages to it are sent to remoteHost.
+func NewRemoteServerClient(addr string, receivePort int, remoteHost string, sendPort int, forward bool, forwardAddrStr string, forwardListenPort int, callback CallbackFunc) (*AssettoServerUDP, error) {
+	remoteAddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(remoteHost, strconv.Itoa(sendPort)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.DialUDP("udp", &net.UDPAddr{IP: net.ParseIP(addr), Port: receivePort}, remoteAddr)
 
 	if err != nil {
 		return nil, err
@@ -37,11 +55,15 @@ func NewServerClient(addr string, receivePort, sendPort int, forward bool, forwa
 	ctx, cfn := context.WithCancel(context.Background())
 
 	u := &AssettoServerUDP{
+		addr:     addr,
 		ctx:      ctx,
 		cfn:      cfn,
 		callback: callback,
 		forward:  forward,
 		listener: listener,
+		failed:   make(chan struct{}),
+		messages: make(chan receivedMessage, 1000),
+		stats:    newLinkStats(time.Now()),
 	}
 
 	if forward && forwardAddrStr != "" && forwardListenPort != 0 {
@@ -67,19 +89,114 @@ func NewServerClient(addr string, receivePort, sendPort int, forward bool, forwa
 
 type CallbackFunc func(response Message)
 
+type receivedMessage struct {
+	received time.Time
+	data     []byte
+}
+
+// RawMessageFunc is given each message exactly as it was received from acServer, before it is parsed.
+type RawMessageFunc func(received time.Time, data []byte)
+
 type AssettoServerUDP struct {
 	listener  *net.UDPConn
 	forwarder *net.UDPConn
 
 	forward bool
 
+	// targets are the additional forwarding targets, see SetForwardingTargets.
+	addr         string
+	targets      []*forwardTarget
+	targetsMutex sync.RWMutex
+
+	// forwardingPaused is accessed atomically. when non-zero, messages from the server are not duplicated to the forwarder.
+	forwardingPaused int32
+
+	// protocolVersion is accessed atomically. It is the ProtocolVersion which the server has said it speaks.
+	protocolVersion int32
+
+	// realtimePosIntervalMs is accessed atomically. it is the real time pos interval currently requested from the
+	// server, which is raised while messages from the server can't be kept up with.
+	realtimePosIntervalMs int32
+
 	cfn      func()
 	ctx      context.Context
 	callback CallbackFunc
 
+	recorder      RawMessageFunc
+	recorderMutex sync.Mutex
+
+	// messages are the messages which have been read from the server but not yet handled.
+	messages chan receivedMessage
+	stats    *linkStats
+
+	// failed is closed if reading from the server stops working, after which failErr holds the last read error.
+	failed   chan struct{}
+	failErr  error
+	failOnce sync.Once
+
 	closed bool
 }
 
+// Failed is closed if the connection stops working while it is open. It is not closed by Close.
+func (asu *AssettoServerUDP) Failed() <-chan struct{} {
+	return asu.failed
+}
+
+// Fail marks the connection as failed with err, and stops it reading from the server. It is for connections which
+// look healthy but which the server has stopped answering.
+func (asu *AssettoServerUDP) Fail(err error) {
+	asu.failOnce.Do(func() {
+		asu.failErr = err
+		close(asu.failed)
+		asu.cfn()
+	})
+}
+
+// Closed is closed once Close has been called.
+func (asu *AssettoServerUDP) Closed() <-chan struct{} {
+	return asu.ctx.Done()
+}
+
+// Err returns why the connection failed. It must only be called once Failed is closed.
+func (asu *AssettoServerUDP) Err() error {
+	return asu.failErr
+}
+
+// Stats describes how well messages from the server are being received and handled since the connection was opened.
+func (asu *AssettoServerUDP) Stats() LinkStats {
+	stats := asu.stats.snapshot()
+	stats.QueueLength = len(asu.messages)
+	stats.RealtimePosInterval = asu.RealtimePosInterval()
+	stats.ProtocolVersion = asu.ProtocolVersion()
+
+	return stats
+}
+
+// ProtocolVersion is the version of the UDP plugin protocol which the server has said it speaks. Messages are decoded
+// for this version. It is ProtocolVersionUnknown until the server has sent a Version or SessionInfo message.
+func (asu *AssettoServerUDP) ProtocolVersion() ProtocolVersion {
+	return ProtocolVersion(atomic.LoadInt32(&asu.protocolVersion))
+}
+
+func (asu *AssettoServerUDP) setProtocolVersion(version ProtocolVersion) {
+	previous := ProtocolVersion(atomic.SwapInt32(&asu.protocolVersion, int32(version)))
+
+	if previous == version {
+		return
+	}
+
+	if version == DefaultProtocolVersion {
+		logrus.Debugf("acServer speaks UDP plugin protocol version %d", version)
+	} else {
+		logrus.Infof("acServer speaks UDP plugin protocol version %d, messages will be decoded with the decoders registered for it (or for version %d where there are none)", version, DefaultProtocolVersion)
+	}
+}
+
+// RealtimePosInterval is the real time pos interval currently requested from the server, in milliseconds.
+func (asu *AssettoServerUDP) RealtimePosInterval() int {
+	return int(atomic.LoadInt32(&asu.realtimePosIntervalMs))
+}
+
 func (asu *AssettoServerUDP) Close() error {
 	if asu.closed {
 		return nil
@@ -90,6 +207,8 @@ func (asu *AssettoServerUDP) Close() error {
 	}()
 
 	asu.cfn()
+	asu.closeForwardingTargets()
+
 	err := asu.listener.Close()
 
 	if err != nil {
@@ -109,6 +228,40 @@ func (asu *AssettoServerUDP) Close() error {
 	return nil
 }
 
+// SetForwardingEnabled pauses or resumes duplicating server messages to the forwarding address and targets.
+// Messages are still read from the server and passed to the callback while forwarding is paused.
+func (asu *AssettoServerUDP) SetForwardingEnabled(enabled bool) {
+	var paused int32
+
+	if !enabled {
+		paused = 1
+	}
+
+	atomic.StoreInt32(&asu.forwardingPaused, paused)
+}
+
+func (asu *AssettoServerUDP) ForwardingEnabled() bool {
+	return atomic.LoadInt32(&asu.forwardingPaused) == 0
+}
+
+// SetRecorder passes every message received from acServer from now on to record, e.g. so that a session can be
+// replayed later. A nil record stops recording.
+func (asu *AssettoServerUDP) SetRecorder(record RawMessageFunc) {
+	asu.recorderMutex.Lock()
+	defer asu.recorderMutex.Unlock()
+
+	asu.recorder = record
+}
+
+func (asu *AssettoServerUDP) record(data []byte) {
+	asu.recorderMutex.Lock()
+	defer asu.recorderMutex.Unlock()
+
+	if asu.recorder != nil {
+		asu.recorder(time.Now(), data)
+	}
+}
+
 func (asu *AssettoServerUDP) forwardServe() {
 	if !asu.forward || asu.forwarder == nil {
 		return
@@ -138,10 +291,10 @@ func (asu *AssettoServerUDP) forwardServe() {
 }
 
 func (asu *AssettoServerUDP) serve() {
-	messageChan := make(chan []byte, 1000)
+	messageChan := asu.messages
 	defer close(messageChan)
 
-	CurrentRealtimePosIntervalMs = RealtimePosIntervalMs
+	atomic.StoreInt32(&asu.realtimePosIntervalMs, int32(RealtimePosIntervalMs))
 	lastQueueSize := 0
 
 	go func() {
@@ -149,19 +302,33 @@ func (asu *AssettoServerUDP) serve() {
 
 		for {
 			select {
-			case buf := <-messageChan:
+			case received := <-messageChan:
+				buf := received.data
+				asu.record(buf)
+
 				msg, err := asu.handleMessage(bytes.NewReader(buf))
 
 				if err != nil {
+					// one bad message mustn't stop the rest being handled, or the connection would stop reading once
+					// messageChan filled up.
 					logrus.WithError(err).Error("could not handle UDP message")
-					return
+					asu.stats.decodeError()
+					continue
 				}
 
+				callbackStarted := time.Now()
 				asu.callback(msg)
+				callbackFinished := time.Now()
+
+				asu.stats.handled(msg, received.received, callbackFinished.Sub(callbackStarted), callbackFinished, asu.RealtimePosInterval())
+
+				if asu.forward && asu.ForwardingEnabled() {
+					if asu.forwarder != nil {
+						// write the message to the forwarding address
+						_, _ = asu.forwarder.Write(buf)
+					}
 
-				if asu.forward && asu.forwarder != nil {
-					// write the message to the forwarding address
-					_, _ = asu.forwarder.Write(buf)
+					asu.forwardToTargets(buf)
 				}
 			case <-ticker.C:
 				if RealtimePosIntervalMs < 0 || !PosIntervalModifierEnabled {
@@ -176,23 +343,23 @@ func (asu *AssettoServerUDP) serve() {
 
 					// update as infrequently as we can, within sensible limits
 					if currentQueueSize > 5 { // at this point we are half a second behind
-						CurrentRealtimePosIntervalMs += (currentQueueSize * 2) + 1
+						interval := int(atomic.AddInt32(&asu.realtimePosIntervalMs, int32(currentQueueSize*2)+1))
 
-						logrus.Debugf("Adjusting real time pos interval: %d", CurrentRealtimePosIntervalMs)
-						err := asu.SendMessage(NewEnableRealtimePosInterval(CurrentRealtimePosIntervalMs))
+						logrus.Debugf("Adjusting real time pos interval: %d", interval)
+						err := asu.SendMessage(NewEnableRealtimePosInterval(interval))
 
 						if err != nil {
 							logrus.WithError(err).Error("Could not send realtime pos interval adjustment")
 						}
 					}
-				} else if currentQueueSize <= lastQueueSize && currentQueueSize < 5 && CurrentRealtimePosIntervalMs > RealtimePosIntervalMs {
+				} else if currentQueueSize <= lastQueueSize && currentQueueSize < 5 && asu.RealtimePosInterval() > RealtimePosIntervalMs {
 					logrus.Debugf("Catching up, queue size: %d vs %d: changed by %d", currentQueueSize, lastQueueSize, currentQueueSize-lastQueueSize)
 
-					if CurrentRealtimePosIntervalMs-1 >= RealtimePosIntervalMs {
-						CurrentRealtimePosIntervalMs--
+					if asu.RealtimePosInterval()-1 >= RealtimePosIntervalMs {
+						interval := int(atomic.AddInt32(&asu.realtimePosIntervalMs, -1))
 
-						logrus.Debugf("Adjusting real time pos interval, is now: %d", CurrentRealtimePosIntervalMs)
-						err := asu.SendMessage(NewEnableRealtimePosInterval(CurrentRealtimePosIntervalMs))
+						logrus.Debugf("Adjusting real time pos interval, is now: %d", interval)
+						err := asu.SendMessage(NewEnableRealtimePosInterval(interval))
 
 						if err != nil {
 							logrus.WithError(err).Error("Could not send realtime pos interval adjustment")
@@ -208,6 +375,8 @@ func (asu *AssettoServerUDP) serve() {
 		}
 	}()
 
+	readErrors := 0
+
 	for {
 		select {
 		case <-asu.ctx.Done():
@@ -220,11 +389,30 @@ func (asu *AssettoServerUDP) serve() {
 			n, _, err := asu.listener.ReadFromUDP(buf)
 
 			if err != nil {
+				if asu.ctx.Err() != nil {
+					// the connection was closed
+					continue
+				}
+
 				logrus.WithError(err).Debug("could not read from UDP")
+
+				asu.stats.readError()
+				readErrors++
+
+				if readErrors >= maxConsecutiveReadErrors {
+					logrus.WithError(err).Errorf("UDP connection failed after %d read errors", readErrors)
+
+					asu.Fail(err)
+					return
+				}
+
 				continue
 			}
 
-			messageChan <- buf[:n]
+			readErrors = 0
+
+			asu.stats.received(n)
+			messageChan <- receivedMessage{received: time.Now(), data: buf[:n]}
 		}
 	}
 }
@@ -370,6 +558,33 @@ func (asu *AssettoServerUDP) SendMessage(message Message) error {
 }
 
 func (asu *AssettoServerUDP) handleMessage(r io.Reader) (Message, error) {
+	msg, err := parseMessage(asu.ProtocolVersion(), r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if version, ok := DetectProtocolVersion(msg); ok {
+		asu.setProtocolVersion(version)
+	}
+
+	if RealtimePosIntervalMs > 0 && msg.Event() == EventNewSession {
+		err = asu.SendMessage(NewEnableRealtimePosInterval(RealtimePosIntervalMs))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+// ParseMessage parses a message exactly as it was sent by acServer, assuming that it speaks DefaultProtocolVersion.
+func ParseMessage(data []byte) (Message, error) {
+	return ParseMessageVersion(ProtocolVersionUnknown, data)
+}
+
+func parseMessage(version ProtocolVersion, r io.Reader) (Message, error) {
 	var messageType uint8
 
 	err := binary.Read(r, binary.LittleEndian, &messageType)
@@ -380,6 +595,10 @@ func (asu *AssettoServerUDP) handleMessage(r io.Reader) (Message, error) {
 
 	eventType := Event(messageType)
 
+	if decode, ok := findDecoder(version, eventType); ok {
+		return decode(eventType, r)
+	}
+
 	var response Message
 
 	switch eventType {
@@ -468,6 +687,13 @@ func (asu *AssettoServerUDP) handleMessage(r io.Reader) (Message, error) {
 
 		message := readStringW(r)
 
+		if isCSPChat(message) {
+			if cspMessage, err := decodeCSPMessage(message); err == nil {
+				response = CSPClientMessage{CarID: carID, Type: cspMessage.CSPType(), Message: cspMessage}
+				break
+			}
+		}
+
 		response = Chat{
 			CarID:   carID,
 			Message: message,
@@ -561,14 +787,6 @@ func (asu *AssettoServerUDP) handleMessage(r io.Reader) (Message, error) {
 		sessionInfo.EventType = eventType
 
 		response = sessionInfo
-
-		if RealtimePosIntervalMs > 0 && eventType == EventNewSession {
-			err = asu.SendMessage(NewEnableRealtimePosInterval(RealtimePosIntervalMs))
-
-			if err != nil {
-				return nil, err
-			}
-		}
 	case EventError:
 		message := readStringW(r)
 
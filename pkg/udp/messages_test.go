@@ -0,0 +1,418 @@
+package udp
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func freeUDPPort(t *testing.T) int {
+	l, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	return l.LocalAddr().(*net.UDPAddr).Port
+}
+
+type testUDPConnection struct {
+	client     *AssettoServerUDP
+	server     *net.UDPConn
+	plugin     *net.UDPConn
+	clientAddr *net.UDPAddr
+
+	messages chan Message
+}
+
+func newTestUDPConnection(t *testing.T) *testUDPConnection {
+	receivePort, sendPort, pluginPort, forwardListenPort := freeUDPPort(t), freeUDPPort(t), freeUDPPort(t), freeUDPPort(t)
+
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: sendPort})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plugin, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: pluginPort})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make(chan Message, 10)
+
+	client, err := NewServerClient("127.0.0.1", receivePort, sendPort, true, plugin.LocalAddr().String(), forwardListenPort, func(message Message) {
+		messages <- message
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testUDPConnection{
+		client:     client,
+		server:     server,
+		plugin:     plugin,
+		clientAddr: &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: receivePort},
+		messages:   messages,
+	}
+}
+
+func (c *testUDPConnection) Close() {
+	_ = c.client.Close()
+	_ = c.server.Close()
+	_ = c.plugin.Close()
+}
+
+// sendVersion sends a Version message from the fake acServer and waits for the client to process it.
+func (c *testUDPConnection) sendVersion(t *testing.T) {
+	if _, err := c.server.WriteToUDP([]byte{byte(EventVersion), 4}, c.clientAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-c.messages:
+	case <-time.After(time.Second):
+		t.Fatal("message was not passed to callback")
+	}
+}
+
+// pluginReceived reports whether the forwarding address received a message within the timeout.
+func (c *testUDPConnection) pluginReceived(timeout time.Duration) bool {
+	buf := make([]byte, 1024)
+
+	_ = c.plugin.SetReadDeadline(time.Now().Add(timeout))
+
+	_, _, err := c.plugin.ReadFromUDP(buf)
+
+	return err == nil
+}
+
+func TestAssettoServerUDP_SetForwardingEnabled(t *testing.T) {
+	conn := newTestUDPConnection(t)
+	defer conn.Close()
+
+	conn.sendVersion(t)
+
+	if !conn.pluginReceived(time.Second) {
+		t.Fatal("expected message to be forwarded")
+	}
+
+	conn.client.SetForwardingEnabled(false)
+	conn.sendVersion(t)
+
+	if conn.pluginReceived(time.Millisecond * 200) {
+		t.Fatal("expected message not to be forwarded while forwarding is disabled")
+	}
+
+	conn.client.SetForwardingEnabled(true)
+	conn.sendVersion(t)
+
+	if !conn.pluginReceived(time.Second) {
+		t.Fatal("expected message to be forwarded after forwarding is re-enabled")
+	}
+}
+
+func newTestForwardTarget(t *testing.T) *net.UDPConn {
+	target, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return target
+}
+
+// received reports whether conn received a message within the timeout, and where it came from.
+func received(conn *net.UDPConn, timeout time.Duration) (*net.UDPAddr, bool) {
+	buf := make([]byte, 1024)
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+
+	_, addr, err := conn.ReadFromUDP(buf)
+
+	return addr, err == nil
+}
+
+func TestAssettoServerUDP_SetForwardingTargets(t *testing.T) {
+	conn := newTestUDPConnection(t)
+	defer conn.Close()
+
+	targetA, targetB := newTestForwardTarget(t), newTestForwardTarget(t)
+	defer targetA.Close()
+	defer targetB.Close()
+
+	a := ForwardTarget{Address: targetA.LocalAddr().String()}
+	b := ForwardTarget{Address: targetB.LocalAddr().String()}
+
+	// keep messages flowing from the server while the targets are changed.
+	stop := make(chan struct{})
+	flowing := make(chan struct{})
+
+	go func() {
+		defer close(flowing)
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(time.Millisecond * 5):
+				_, _ = conn.server.WriteToUDP([]byte{byte(EventVersion), 4}, conn.clientAddr)
+			}
+		}
+	}()
+
+	go func() {
+		for range conn.messages {
+		}
+	}()
+
+	defer func() {
+		close(stop)
+		<-flowing
+	}()
+
+	if err := conn.client.SetForwardingTargets([]ForwardTarget{a}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := received(targetA, time.Second); !ok {
+		t.Fatal("expected message to be forwarded to target A")
+	}
+
+	if _, ok := received(targetB, time.Millisecond*200); ok {
+		t.Fatal("expected message not to be forwarded to target B before it is added")
+	}
+
+	if err := conn.client.SetForwardingTargets([]ForwardTarget{a, b}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := received(targetA, time.Second); !ok {
+		t.Fatal("expected message to be forwarded to target A after target B is added")
+	}
+
+	replyAddr, ok := received(targetB, time.Second)
+
+	if !ok {
+		t.Fatal("expected message to be forwarded to target B after it is added")
+	}
+
+	if !conn.pluginReceived(time.Second) {
+		t.Fatal("expected message to still be forwarded to the forwarding address")
+	}
+
+	// replies from a target are passed on to the server
+	if _, err := targetB.WriteToUDP([]byte{byte(EventGetSessionInfo)}, replyAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	_ = conn.server.SetReadDeadline(time.Now().Add(time.Second))
+
+	if n, _, err := conn.server.ReadFromUDP(buf); err != nil || n != 1 || buf[0] != byte(EventGetSessionInfo) {
+		t.Fatal("expected reply from target B to be passed on to the server")
+	}
+
+	if err := conn.client.SetForwardingTargets([]ForwardTarget{b}); err != nil {
+		t.Fatal(err)
+	}
+
+	// drain anything which was in flight when target A was removed
+	for {
+		if _, ok := received(targetA, time.Millisecond*200); !ok {
+			break
+		}
+	}
+
+	if _, ok := received(targetB, time.Second); !ok {
+		t.Fatal("expected message to be forwarded to target B after target A is removed")
+	}
+
+	statuses := conn.client.ForwardingTargets()
+
+	if len(statuses) != 1 || statuses[0].ForwardTarget != b || !statuses[0].Active || statuses[0].Sent == 0 {
+		t.Errorf("expected status for target B only, got: %+v", statuses)
+	}
+}
+
+func TestAssettoServerUDP_DisabledForwardingTarget(t *testing.T) {
+	conn := newTestUDPConnection(t)
+	defer conn.Close()
+
+	go func() {
+		for range conn.messages {
+		}
+	}()
+
+	target := newTestForwardTarget(t)
+	defer target.Close()
+
+	enabled := ForwardTarget{Name: "plugin", Address: target.LocalAddr().String()}
+	disabled := enabled
+	disabled.Disabled = true
+
+	if err := conn.client.SetForwardingTargets([]ForwardTarget{enabled}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = conn.server.WriteToUDP([]byte{byte(EventVersion), 4}, conn.clientAddr)
+
+	replyAddr, ok := received(target, time.Second)
+
+	if !ok {
+		t.Fatal("expected message to be forwarded to the target")
+	}
+
+	if _, err := target.WriteToUDP([]byte{byte(EventGetSessionInfo)}, replyAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	_ = conn.server.SetReadDeadline(time.Now().Add(time.Second))
+
+	if _, _, err := conn.server.ReadFromUDP(buf); err != nil {
+		t.Fatal("expected reply from the target to be passed on to the server")
+	}
+
+	if err := conn.client.SetForwardingTargets([]ForwardTarget{disabled}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = conn.server.WriteToUDP([]byte{byte(EventVersion), 4}, conn.clientAddr)
+
+	if _, ok := received(target, time.Millisecond*200); ok {
+		t.Fatal("expected message not to be forwarded to a disabled target")
+	}
+
+	if _, err := target.WriteToUDP([]byte{byte(EventGetSessionInfo)}, replyAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.server.SetReadDeadline(time.Now().Add(time.Millisecond * 200))
+
+	if _, _, err := conn.server.ReadFromUDP(buf); err == nil {
+		t.Fatal("expected reply from a disabled target not to be passed on to the server")
+	}
+
+	// disabling the target keeps its socket and counters.
+	statuses := conn.client.ForwardingTargets()
+
+	if len(statuses) != 1 || statuses[0].ForwardTarget != disabled || !statuses[0].Active || statuses[0].Sent != 1 || statuses[0].Received != 1 {
+		t.Errorf("expected the disabled target to keep its counters, got: %+v", statuses)
+	}
+
+	if err := conn.client.SetForwardingTargets([]ForwardTarget{enabled}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = conn.server.WriteToUDP([]byte{byte(EventVersion), 4}, conn.clientAddr)
+
+	if addr, ok := received(target, time.Second); !ok || addr.String() != replyAddr.String() {
+		t.Fatal("expected message to be forwarded from the same port once the target is enabled again")
+	}
+}
+
+func TestAssettoServerUDP_Failed(t *testing.T) {
+	t.Run("Read errors", func(t *testing.T) {
+		conn := newTestUDPConnection(t)
+		defer conn.Close()
+
+		conn.sendVersion(t)
+
+		// the socket breaking underneath the connection makes every read fail
+		_ = conn.client.listener.Close()
+
+		select {
+		case <-conn.client.Failed():
+		case <-time.After(time.Second * 5):
+			t.Fatal("expected connection to fail")
+		}
+
+		if conn.client.Err() == nil {
+			t.Error("expected the read error to be recorded")
+		}
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		conn := newTestUDPConnection(t)
+		defer conn.Close()
+
+		conn.client.Fail(errors.New("no answer"))
+		conn.client.Fail(errors.New("no answer again"))
+
+		select {
+		case <-conn.client.Failed():
+		case <-time.After(time.Second):
+			t.Fatal("expected connection to fail")
+		}
+
+		if err := conn.client.Err(); err == nil || err.Error() != "no answer" {
+			t.Errorf("expected the first error to be kept, got %v", err)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		conn := newTestUDPConnection(t)
+
+		conn.sendVersion(t)
+		conn.Close()
+
+		select {
+		case <-conn.client.Closed():
+		case <-time.After(time.Second):
+			t.Fatal("expected connection to be closed")
+		}
+
+		select {
+		case <-conn.client.Failed():
+			t.Error("expected closing the connection not to fail it")
+		case <-time.After(time.Millisecond * 200):
+		}
+	})
+}
+
+func TestAssettoServerUDP_SetRecorder(t *testing.T) {
+	conn := newTestUDPConnection(t)
+	defer conn.Close()
+
+	recorded := make(chan []byte, 10)
+
+	conn.client.SetRecorder(func(received time.Time, data []byte) {
+		recorded <- append([]byte(nil), data...)
+	})
+
+	conn.sendVersion(t)
+
+	select {
+	case data := <-recorded:
+		if len(data) != 2 || data[0] != byte(EventVersion) || data[1] != 4 {
+			t.Errorf("expected the raw message to be recorded, got %v", data)
+		}
+	default:
+		t.Fatal("expected the message to be recorded before it was passed to the callback")
+	}
+
+	conn.client.SetRecorder(nil)
+	conn.sendVersion(t)
+
+	if len(recorded) != 0 {
+		t.Error("expected no messages to be recorded once recording has stopped")
+	}
+}
+
+func TestAssettoServerUDP_BadMessage(t *testing.T) {
+	conn := newTestUDPConnection(t)
+	defer conn.Close()
+
+	// a car info message with no car ID can't be parsed.
+	if _, err := conn.server.WriteToUDP([]byte{byte(EventCarInfo)}, conn.clientAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.sendVersion(t)
+}
@@ -0,0 +1,96 @@
+package udp
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+// ProtocolVersion is the version of the UDP plugin protocol which acServer speaks. acServer sends it in its Version
+// message when it starts, and at the start of each SessionInfo.
+type ProtocolVersion uint8
+
+const (
+	// ProtocolVersionUnknown is used until acServer has said which version it speaks. Messages are decoded as
+	// DefaultProtocolVersion in the meantime.
+	ProtocolVersionUnknown ProtocolVersion = 0
+
+	// DefaultProtocolVersion is the version spoken by the official acServer, which the built in decoders understand.
+	DefaultProtocolVersion ProtocolVersion = 4
+)
+
+// DecodeFunc decodes the body of a message from acServer, i.e. everything after its event type.
+type DecodeFunc func(event Event, r io.Reader) (Message, error)
+
+var (
+	decoders      = make(map[ProtocolVersion]map[Event]DecodeFunc)
+	decodersMutex sync.RWMutex
+)
+
+// RegisterDecoder replaces the decoder for event in messages from acServer builds which speak version, or any later
+// version which doesn't have a decoder of its own. It is for patched acServer builds which change the layout of a
+// message. Like the other registration functions, it is meant to be called from an init function.
+func RegisterDecoder(version ProtocolVersion, event Event, decode DecodeFunc) {
+	if decode == nil {
+		panic("udp: nil decoder")
+	}
+
+	decodersMutex.Lock()
+	defer decodersMutex.Unlock()
+
+	if decoders[version] == nil {
+		decoders[version] = make(map[Event]DecodeFunc)
+	}
+
+	decoders[version][event] = decode
+}
+
+// findDecoder returns the registered decoder for event with the highest version which isn't after version. It is
+// false if event should be decoded by the built in decoder.
+func findDecoder(version ProtocolVersion, event Event) (DecodeFunc, bool) {
+	decodersMutex.RLock()
+	defer decodersMutex.RUnlock()
+
+	if version == ProtocolVersionUnknown {
+		version = DefaultProtocolVersion
+	}
+
+	var versions []ProtocolVersion
+
+	for registered := range decoders {
+		if registered <= version {
+			versions = append(versions, registered)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] > versions[j]
+	})
+
+	for _, registered := range versions {
+		if decode, ok := decoders[registered][event]; ok {
+			return decode, true
+		}
+	}
+
+	return nil, false
+}
+
+// ParseMessageVersion parses a message exactly as it was sent by an acServer which speaks version.
+func ParseMessageVersion(version ProtocolVersion, data []byte) (Message, error) {
+	return parseMessage(version, bytes.NewReader(data))
+}
+
+// DetectProtocolVersion returns the protocol version which message says acServer speaks. It is false if message
+// doesn't say.
+func DetectProtocolVersion(message Message) (ProtocolVersion, bool) {
+	switch m := message.(type) {
+	case Version:
+		return ProtocolVersion(m), true
+	case SessionInfo:
+		return ProtocolVersion(m.Version), true
+	default:
+		return ProtocolVersionUnknown, false
+	}
+}
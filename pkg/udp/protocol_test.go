@@ -0,0 +1,92 @@
+package udp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// carUpdateWithBoost is a CarUpdate from a made up acServer build, which adds the car's boost to the end of it.
+type carUpdateWithBoost struct {
+	CarUpdate
+	Boost float32
+}
+
+func carUpdatePacket(t *testing.T, update interface{}) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(EventCarUpdate))
+
+	if err := binary.Write(buf, binary.LittleEndian, update); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestParseMessageVersion(t *testing.T) {
+	RegisterDecoder(200, EventCarUpdate, func(event Event, r io.Reader) (Message, error) {
+		var update carUpdateWithBoost
+
+		if err := binary.Read(r, binary.LittleEndian, &update); err != nil {
+			return nil, err
+		}
+
+		update.CarUpdate.EngineRPM += uint16(update.Boost * 1000)
+
+		return update.CarUpdate, nil
+	})
+
+	defer func() {
+		decodersMutex.Lock()
+		delete(decoders, 200)
+		decodersMutex.Unlock()
+	}()
+
+	standard := carUpdatePacket(t, CarUpdate{CarID: 1, Gear: 3, EngineRPM: 5000})
+	patched := carUpdatePacket(t, carUpdateWithBoost{CarUpdate: CarUpdate{CarID: 1, Gear: 3, EngineRPM: 5000}, Boost: 1.5})
+
+	for _, test := range []struct {
+		version ProtocolVersion
+		packet  []byte
+		rpm     uint16
+	}{
+		{ProtocolVersionUnknown, standard, 5000},
+		{DefaultProtocolVersion, standard, 5000},
+		{200, patched, 6500},
+		// later versions use the decoder for the version before them.
+		{201, patched, 6500},
+	} {
+		message, err := ParseMessageVersion(test.version, test.packet)
+
+		if err != nil {
+			t.Fatalf("version %d: %s", test.version, err)
+		}
+
+		if update, ok := message.(CarUpdate); !ok || update.EngineRPM != test.rpm || update.Gear != 3 {
+			t.Errorf("version %d: expected a car update with %d RPM, got %#v", test.version, test.rpm, message)
+		}
+	}
+}
+
+func TestAssettoServerUDP_ProtocolVersion(t *testing.T) {
+	asu := &AssettoServerUDP{}
+
+	if _, err := asu.handleMessage(bytes.NewReader([]byte{byte(EventVersion), 5})); err != nil {
+		t.Fatal(err)
+	}
+
+	if asu.ProtocolVersion() != 5 {
+		t.Errorf("Expected protocol version 5 from the version message, got %d", asu.ProtocolVersion())
+	}
+
+	if _, ok := DetectProtocolVersion(SessionInfo{Version: 4}); !ok {
+		t.Error("Expected the protocol version to be detected from session info")
+	}
+
+	if _, ok := DetectProtocolVersion(CarUpdate{}); ok {
+		t.Error("Expected car updates not to have a protocol version")
+	}
+}
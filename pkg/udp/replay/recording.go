@@ -0,0 +1,168 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecordedMessage is a message from acServer as it was received, before it was parsed. Recordings keep the raw
+// payload rather than the parsed message, so that a recording can reproduce bugs in parsing as well as in handling.
+type RecordedMessage struct {
+	Received  time.Time
+	EventType udp.Event
+	Payload   []byte
+}
+
+// Recorder writes each message it is given to a recording, one JSON encoded RecordedMessage per line.
+type Recorder struct {
+	mutex   sync.Mutex
+	w       io.WriteCloser
+	encoder *json.Encoder
+	failed  bool
+}
+
+func NewRecorder(w io.WriteCloser) *Recorder {
+	return &Recorder{
+		w:       w,
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// CreateRecording creates a recording at path, replacing any file which is already there.
+func CreateRecording(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRecorder(f), nil
+}
+
+// Record adds a message to the recording. It is a udp.RawMessageFunc. Recording stops at the first error, which is
+// logged, so that a problem with the recording never holds up the messages themselves.
+func (r *Recorder) Record(received time.Time, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.failed {
+		return
+	}
+
+	err := r.encoder.Encode(RecordedMessage{
+		Received:  received,
+		EventType: udp.Event(data[0]),
+		Payload:   data,
+	})
+
+	if err != nil {
+		logrus.WithError(err).Error("Could not write to UDP recording, no longer recording")
+		r.failed = true
+	}
+}
+
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.failed = true
+
+	return r.w.Close()
+}
+
+// ReadRecording reads every message in a recording, in the order they were received.
+func ReadRecording(r io.Reader) ([]RecordedMessage, error) {
+	var messages []RecordedMessage
+
+	decoder := json.NewDecoder(bufio.NewReader(r))
+
+	for {
+		var message RecordedMessage
+
+		err := decoder.Decode(&message)
+
+		if err == io.EOF {
+			return messages, nil
+		} else if err != nil {
+			// a recording which was cut off part way through a message is still worth replaying.
+			if err == io.ErrUnexpectedEOF && len(messages) > 0 {
+				return messages, nil
+			}
+
+			return nil, err
+		}
+
+		messages = append(messages, message)
+	}
+}
+
+// ReplayRecording parses each message and passes it to callbackFunc in turn, waiting between messages for as long as
+// acServer did divided by multiplier, but never for longer than maxWait. Messages which can't be parsed are logged
+// and skipped. Unlike UDPMessages, the callback is called for one message at a time, in the order they were received.
+func ReplayRecording(messages []RecordedMessage, multiplier int, callbackFunc udp.CallbackFunc, maxWait time.Duration) {
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+
+	version := udp.ProtocolVersionUnknown
+
+	for i, message := range messages {
+		if i > 0 {
+			wait := message.Received.Sub(messages[i-1].Received) / time.Duration(multiplier)
+
+			if wait > maxWait {
+				wait = maxWait
+			}
+
+			if wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		msg, err := udp.ParseMessageVersion(version, message.Payload)
+
+		if err != nil {
+			logrus.WithError(err).Warnf("Could not parse recorded UDP message %d (event type: %d), skipping it", i, message.EventType)
+			continue
+		}
+
+		if detected, ok := udp.DetectProtocolVersion(msg); ok {
+			version = detected
+		}
+
+		callbackFunc(msg)
+	}
+}
+
+// ReplayRecordingFile replays the recording at path, see ReplayRecording.
+func ReplayRecordingFile(path string, multiplier int, callbackFunc udp.CallbackFunc, maxWait time.Duration) error {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	messages, err := ReadRecording(f)
+
+	if err != nil {
+		return err
+	}
+
+	ReplayRecording(messages, multiplier, callbackFunc, maxWait)
+
+	return nil
+}
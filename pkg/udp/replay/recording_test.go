@@ -0,0 +1,69 @@
+package replay
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestRecording(t *testing.T) {
+	buf := nopCloser{new(bytes.Buffer)}
+	recorder := NewRecorder(buf)
+
+	start := time.Now()
+
+	recorder.Record(start, []byte{byte(udp.EventVersion), 4})
+	recorder.Record(start.Add(time.Second), []byte{0xff, 1, 2})
+	recorder.Record(start.Add(time.Second*2), []byte{byte(udp.EventClientLoaded), 3})
+
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is recorded once the recorder is closed.
+	recorder.Record(start.Add(time.Second*3), []byte{byte(udp.EventVersion), 4})
+
+	// a recording which was cut off part way through a message is still read.
+	data := buf.Bytes()
+	data = append(data, []byte(`{"Received":`)...)
+
+	messages, err := ReadRecording(bytes.NewReader(data))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 3 || messages[2].EventType != udp.EventClientLoaded || !messages[1].Received.Equal(start.Add(time.Second)) {
+		t.Fatalf("expected the recorded messages to be read back, got %+v", messages)
+	}
+
+	var replayed []udp.Message
+
+	ReplayRecording(messages, 1000, func(message udp.Message) {
+		replayed = append(replayed, message)
+	}, time.Second)
+
+	if len(replayed) != 2 || replayed[0] != udp.Version(4) || replayed[1] != udp.ClientLoaded(3) {
+		t.Errorf("expected the messages which could be parsed to be replayed in order, got %+v", replayed)
+	}
+}
+
+func TestReadRecording_Invalid(t *testing.T) {
+	if _, err := ReadRecording(bytes.NewReader([]byte("not a recording"))); err == nil {
+		t.Error("expected an error reading something which isn't a recording")
+	}
+
+	if _, err := ReadRecording(bytes.NewReader(nil)); err != nil {
+		t.Errorf("expected an empty recording to be read, got %s", err)
+	}
+}
@@ -0,0 +1,333 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// Step is a message which is sent At a time after the start of a script.
+type Step struct {
+	At      time.Duration
+	Message udp.Message
+}
+
+// Driver is a driver who joins at the start of an Event and stays for all of its sessions.
+type Driver struct {
+	CarID    udp.CarID
+	Name     string
+	GUID     udp.DriverGUID
+	CarModel string
+	CarSkin  string
+}
+
+// Collision is a collision At a time after the start of its session's driving. Cars collide with the environment if
+// Environment is set, otherwise with OtherCarID.
+type Collision struct {
+	At          time.Duration
+	CarID       udp.CarID
+	OtherCarID  udp.CarID
+	Environment bool
+	ImpactSpeed float32
+}
+
+// Session is one session of an Event.
+type Session struct {
+	Name string
+	Type udp.SessionType
+
+	// Laps and Time (in minutes) are sent in the session info, they don't change how long the session runs.
+	Laps uint16
+	Time uint16
+
+	// LapTimes are the times of each car's laps, in order. Cars drive until they have finished their laps.
+	LapTimes   map[udp.CarID][]time.Duration
+	Collisions []Collision
+}
+
+// Event is a sequence of sessions at a track, which Script turns into the messages acServer sends.
+type Event struct {
+	ServerName  string
+	Track       string
+	TrackConfig string
+
+	// Start is the time of the first session, used to name results files.
+	Start time.Time
+
+	Drivers  []Driver
+	Sessions []Session
+
+	// CarUpdateInterval is how often each car sends a car update while it is driving. Car updates aren't sent if
+	// it is 0.
+	CarUpdateInterval time.Duration
+}
+
+const (
+	trackLength = 4000 // metres
+
+	sessionLoadTime = time.Second * 5
+	sessionEndTime  = time.Second * 10
+)
+
+// Script returns the messages acServer would send during the Event, in the order it would send them: the protocol
+// version, each session's start, the drivers joining, car updates, laps and collisions, and each session's end.
+func (e Event) Script() []Step {
+	steps := []Step{{Message: udp.Version(udp.DefaultProtocolVersion)}}
+
+	var sessionStart time.Duration
+
+	for i, session := range e.Sessions {
+		steps = append(steps, Step{At: sessionStart, Message: udp.SessionInfo{
+			Version:             uint8(udp.DefaultProtocolVersion),
+			SessionIndex:        uint8(i),
+			CurrentSessionIndex: uint8(i),
+			SessionCount:        uint8(len(e.Sessions)),
+			ServerName:          e.ServerName,
+			Track:               e.Track,
+			TrackConfig:         e.TrackConfig,
+			Name:                session.Name,
+			Type:                session.Type,
+			Time:                session.Time,
+			Laps:                session.Laps,
+			AmbientTemp:         20,
+			RoadTemp:            26,
+			WeatherGraphics:     "3_clear",
+			EventType:           udp.EventNewSession,
+		}})
+
+		if i == 0 {
+			for j, driver := range e.Drivers {
+				connected := sessionStart + time.Millisecond*100*time.Duration(j+1)
+
+				steps = append(steps,
+					Step{At: connected, Message: udp.SessionCarInfo{
+						CarID:      driver.CarID,
+						DriverName: driver.Name,
+						DriverGUID: driver.GUID,
+						CarModel:   driver.CarModel,
+						CarSkin:    driver.CarSkin,
+						EventType:  udp.EventNewConnection,
+					}},
+					Step{At: connected + time.Second, Message: udp.ClientLoaded(driver.CarID)},
+				)
+			}
+		}
+
+		drivingStart := sessionStart + sessionLoadTime
+		sessionSteps, drivingTime := e.sessionScript(session)
+
+		for _, step := range sessionSteps {
+			step.At += drivingStart
+			steps = append(steps, step)
+		}
+
+		sessionEnd := drivingStart + drivingTime + sessionEndTime
+
+		steps = append(steps, Step{At: sessionEnd, Message: udp.EndSession(e.resultsFile(session, sessionEnd))})
+
+		sessionStart = sessionEnd + time.Second
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].At < steps[j].At
+	})
+
+	return steps
+}
+
+type lapCrossing struct {
+	at      time.Duration
+	carID   udp.CarID
+	lapTime time.Duration
+}
+
+type carStanding struct {
+	carID       udp.CarID
+	laps        uint16
+	bestLap     time.Duration
+	lastCrossed time.Duration
+	finished    bool
+}
+
+// sessionScript returns a session's car updates, laps and collisions, timed from when the cars start driving, and
+// how long they drive for.
+func (e Event) sessionScript(session Session) ([]Step, time.Duration) {
+	var (
+		steps       []Step
+		crossings   []lapCrossing
+		drivingTime time.Duration
+	)
+
+	carIDs := make([]udp.CarID, 0, len(session.LapTimes))
+
+	for carID := range session.LapTimes {
+		carIDs = append(carIDs, carID)
+	}
+
+	sort.Slice(carIDs, func(i, j int) bool {
+		return carIDs[i] < carIDs[j]
+	})
+
+	for _, carID := range carIDs {
+		lapTimes := session.LapTimes[carID]
+
+		var at time.Duration
+
+		for lap, lapTime := range lapTimes {
+			if e.CarUpdateInterval > 0 {
+				for t := time.Duration(0); t < lapTime; t += e.CarUpdateInterval {
+					steps = append(steps, Step{At: at + t, Message: carUpdate(carID, lap, t, lapTime)})
+				}
+			}
+
+			at += lapTime
+			crossings = append(crossings, lapCrossing{at: at, carID: carID, lapTime: lapTime})
+		}
+
+		if at > drivingTime {
+			drivingTime = at
+		}
+	}
+
+	sort.Slice(crossings, func(i, j int) bool {
+		if crossings[i].at == crossings[j].at {
+			return crossings[i].carID < crossings[j].carID
+		}
+
+		return crossings[i].at < crossings[j].at
+	})
+
+	standings := make(map[udp.CarID]*carStanding)
+
+	for _, carID := range carIDs {
+		standings[carID] = &carStanding{carID: carID}
+	}
+
+	for _, crossing := range crossings {
+		standing := standings[crossing.carID]
+		standing.laps++
+		standing.lastCrossed = crossing.at
+		standing.finished = int(standing.laps) == len(session.LapTimes[crossing.carID])
+
+		if standing.bestLap == 0 || crossing.lapTime < standing.bestLap {
+			standing.bestLap = crossing.lapTime
+		}
+
+		steps = append(steps, Step{At: crossing.at, Message: udp.LapCompleted{
+			CarID:     crossing.carID,
+			LapTime:   uint32(crossing.lapTime / time.Millisecond),
+			CarsCount: uint8(len(standings)),
+			Cars:      leaderboard(session.Type, standings),
+		}})
+	}
+
+	for _, collision := range session.Collisions {
+		var message udp.Message
+
+		if collision.Environment {
+			message = udp.CollisionWithEnvironment{CarID: collision.CarID, ImpactSpeed: collision.ImpactSpeed}
+		} else {
+			message = udp.CollisionWithCar{CarID: collision.CarID, OtherCarID: collision.OtherCarID, ImpactSpeed: collision.ImpactSpeed}
+		}
+
+		steps = append(steps, Step{At: collision.At, Message: message})
+	}
+
+	return steps, drivingTime
+}
+
+// leaderboard orders cars the way acServer does in lap completed messages: by laps and then who got there first in
+// a race, or by best lap otherwise.
+func leaderboard(sessionType udp.SessionType, standings map[udp.CarID]*carStanding) []*udp.LapCompletedCar {
+	ordered := make([]*carStanding, 0, len(standings))
+
+	for _, standing := range standings {
+		ordered = append(ordered, standing)
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+
+		if sessionType == udp.SessionTypeRace {
+			if a.laps != b.laps {
+				return a.laps > b.laps
+			}
+
+			if a.laps > 0 && a.lastCrossed != b.lastCrossed {
+				return a.lastCrossed < b.lastCrossed
+			}
+		} else if a.bestLap != b.bestLap {
+			if a.bestLap == 0 || b.bestLap == 0 {
+				return b.bestLap == 0
+			}
+
+			return a.bestLap < b.bestLap
+		}
+
+		return a.carID < b.carID
+	})
+
+	cars := make([]*udp.LapCompletedCar, 0, len(ordered))
+
+	for _, standing := range ordered {
+		car := &udp.LapCompletedCar{
+			CarID:   standing.carID,
+			LapTime: uint32(standing.bestLap / time.Millisecond),
+			Laps:    standing.laps,
+		}
+
+		if standing.finished && sessionType == udp.SessionTypeRace {
+			car.Completed = 1
+		}
+
+		cars = append(cars, car)
+	}
+
+	return cars
+}
+
+// carUpdate places a car elapsed into a lap of a round track.
+func carUpdate(carID udp.CarID, lap int, elapsed, lapTime time.Duration) udp.CarUpdate {
+	spline := float64(elapsed) / float64(lapTime)
+	angle := spline * 2 * math.Pi
+	radius := trackLength / (2 * math.Pi)
+	speed := trackLength / lapTime.Seconds()
+
+	return udp.CarUpdate{
+		CarID: carID,
+		Pos: udp.Vec{
+			X: float32(radius * math.Cos(angle)),
+			Z: float32(radius * math.Sin(angle)),
+		},
+		Velocity: udp.Vec{
+			X: float32(-speed * math.Sin(angle)),
+			Z: float32(speed * math.Cos(angle)),
+		},
+		Gear:                4,
+		EngineRPM:           uint16(6000 + 100*(lap%10)),
+		NormalisedSplinePos: float32(spline),
+	}
+}
+
+// resultsFile names a session's results file the way acServer does.
+func (e Event) resultsFile(session Session, end time.Duration) string {
+	var sessionType string
+
+	switch session.Type {
+	case udp.SessionTypeBooking:
+		sessionType = "BOOK"
+	case udp.SessionTypePractice:
+		sessionType = "PRACTICE"
+	case udp.SessionTypeQualifying:
+		sessionType = "QUALIFY"
+	default:
+		sessionType = "RACE"
+	}
+
+	t := e.Start.Add(end)
+
+	return fmt.Sprintf("results/%d_%d_%d_%d_%d_%s.json", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), sessionType)
+}
@@ -0,0 +1,277 @@
+// Package simulator stands in for acServer's UDP plugin interface, so that Server Manager (or any other UDP plugin)
+// can be tested without the game's server binary.
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/text/encoding/unicode/utf32"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// PluginMessage is a message which the plugin sent to the Server.
+type PluginMessage struct {
+	Event udp.Event
+	CarID udp.CarID
+
+	// Text is the chat message or admin command, if there is one.
+	Text string
+}
+
+// Server listens for a UDP plugin's messages on the acServer UDP_PLUGIN_LOCAL_PORT, and sends messages to the plugin
+// at UDP_PLUGIN_ADDRESS. It answers requests for session and car info with what it has sent so far, the way
+// acServer does.
+type Server struct {
+	conn   *net.UDPConn
+	plugin *net.UDPAddr
+
+	mutex       sync.Mutex
+	sessionInfo udp.SessionInfo
+	cars        map[udp.CarID]udp.CarInfo
+
+	received chan PluginMessage
+	closed   chan struct{}
+}
+
+// NewServer listens on localPort and sends to pluginAddress. A localPort of 0 picks a free port, see LocalPort.
+func NewServer(localPort int, pluginAddress string) (*Server, error) {
+	plugin, err := net.ResolveUDPAddr("udp", pluginAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: localPort})
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		conn:     conn,
+		plugin:   plugin,
+		cars:     make(map[udp.CarID]udp.CarInfo),
+		received: make(chan PluginMessage, 1000),
+		closed:   make(chan struct{}),
+	}
+
+	go s.serve()
+
+	return s, nil
+}
+
+// LocalPort is the port the Server listens for the plugin's messages on.
+func (s *Server) LocalPort() int {
+	return s.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+// Received is the messages the plugin has sent to the Server, once the Server has answered them.
+func (s *Server) Received() <-chan PluginMessage {
+	return s.received
+}
+
+// Send sends messages to the plugin, in order.
+func (s *Server) Send(messages ...udp.Message) error {
+	for _, message := range messages {
+		s.track(message)
+
+		data, err := udp.EncodeMessage(message)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.conn.WriteToUDP(data, s.plugin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Play sends each of the steps to the plugin when it is due. speed speeds up (or slows down) the script, e.g. a
+// speed of 60 plays a minute of the script each second. Play stops early if ctx is done.
+func (s *Server) Play(ctx context.Context, steps []Step, speed float64) error {
+	if speed <= 0 {
+		speed = 1
+	}
+
+	start := time.Now()
+
+	for _, step := range steps {
+		if wait := time.Until(start.Add(time.Duration(float64(step.At) / speed))); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		if err := s.Send(step.Message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stops the Server.
+func (s *Server) Close() error {
+	select {
+	case <-s.closed:
+		return nil
+	default:
+		close(s.closed)
+	}
+
+	return s.conn.Close()
+}
+
+// track keeps the session and car info up to date with messages sent to the plugin.
+func (s *Server) track(message udp.Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch m := message.(type) {
+	case udp.SessionInfo:
+		s.sessionInfo = m
+	case udp.SessionCarInfo:
+		s.cars[m.CarID] = udp.CarInfo{
+			CarID:       m.CarID,
+			IsConnected: m.Event() == udp.EventNewConnection,
+			CarModel:    m.CarModel,
+			CarSkin:     m.CarSkin,
+			DriverName:  m.DriverName,
+			DriverGUID:  m.DriverGUID,
+		}
+	}
+}
+
+func (s *Server) serve() {
+	buf := make([]byte, 1024)
+
+	for {
+		n, _, err := s.conn.ReadFromUDP(buf)
+
+		if err != nil {
+			select {
+			case <-s.closed:
+				return
+			default:
+				continue
+			}
+		}
+
+		message, err := parsePluginMessage(buf[:n])
+
+		if err != nil {
+			continue
+		}
+
+		if err := s.answer(message); err != nil {
+			continue
+		}
+
+		select {
+		case s.received <- message:
+		default:
+			// nobody is reading what the plugin sends, drop it rather than block.
+		}
+	}
+}
+
+// answer replies to the plugin's requests for information.
+func (s *Server) answer(message PluginMessage) error {
+	s.mutex.Lock()
+	sessionInfo := s.sessionInfo
+	car, carOK := s.cars[message.CarID]
+	s.mutex.Unlock()
+
+	switch message.Event {
+	case udp.EventGetSessionInfo:
+		sessionInfo.EventType = udp.EventSessionInfo
+
+		return s.Send(sessionInfo)
+	case udp.EventGetCarInfo:
+		if !carOK {
+			car = udp.CarInfo{CarID: message.CarID}
+		}
+
+		return s.Send(car)
+	}
+
+	return nil
+}
+
+var errShortMessage = errors.New("simulator: plugin message is too short")
+
+// parsePluginMessage reads a message which a plugin sends to acServer.
+func parsePluginMessage(data []byte) (PluginMessage, error) {
+	if len(data) == 0 {
+		return PluginMessage{}, errShortMessage
+	}
+
+	message := PluginMessage{Event: udp.Event(data[0])}
+	data = data[1:]
+
+	switch message.Event {
+	case udp.EventGetCarInfo, udp.EventKickUser:
+		if len(data) < 1 {
+			return message, errShortMessage
+		}
+
+		message.CarID = udp.CarID(data[0])
+	case udp.EventSendChat:
+		if len(data) < 1 {
+			return message, errShortMessage
+		}
+
+		message.CarID = udp.CarID(data[0])
+
+		text, err := readStringW(data[1:])
+
+		if err != nil {
+			return message, err
+		}
+
+		message.Text = text
+	case udp.EventBroadcastChat, udp.EventAdminCommand:
+		text, err := readStringW(data)
+
+		if err != nil {
+			return message, err
+		}
+
+		message.Text = text
+	}
+
+	return message, nil
+}
+
+// readStringW reads a length byte, followed by that many UTF-32 characters.
+func readStringW(data []byte) (string, error) {
+	if len(data) < 1 {
+		return "", errShortMessage
+	}
+
+	length := int(data[0]) * 4
+	data = data[1:]
+
+	if len(data) < length {
+		return "", errShortMessage
+	}
+
+	decoded, err := utf32.UTF32(utf32.LittleEndian, utf32.IgnoreBOM).NewDecoder().Bytes(data[:length])
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes.TrimRight(decoded, "\x00")), nil
+}
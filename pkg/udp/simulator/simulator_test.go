@@ -0,0 +1,173 @@
+package simulator
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+var testEvent = Event{
+	ServerName: "Simulated Server",
+	Track:      "ks_vallelunga",
+	Start:      time.Date(2020, 5, 1, 19, 0, 0, 0, time.UTC),
+	Drivers: []Driver{
+		{CarID: 0, Name: "Driver One", GUID: "1", CarModel: "ks_mazda_mx5_cup", CarSkin: "00_red"},
+		{CarID: 1, Name: "Driver Two", GUID: "2", CarModel: "ks_mazda_mx5_cup", CarSkin: "01_blue"},
+	},
+	Sessions: []Session{
+		{
+			Name: "Qualify",
+			Type: udp.SessionTypeQualifying,
+			Time: 10,
+			LapTimes: map[udp.CarID][]time.Duration{
+				0: {time.Second * 95, time.Second * 92},
+				1: {time.Second * 91},
+			},
+		},
+		{
+			Name: "Race",
+			Type: udp.SessionTypeRace,
+			Laps: 2,
+			LapTimes: map[udp.CarID][]time.Duration{
+				0: {time.Second * 90, time.Second * 90},
+				1: {time.Second * 91, time.Second * 88},
+			},
+			Collisions: []Collision{
+				{At: time.Second * 30, CarID: 0, OtherCarID: 1, ImpactSpeed: 20},
+				{At: time.Second * 100, CarID: 1, Environment: true, ImpactSpeed: 45},
+			},
+		},
+	},
+	CarUpdateInterval: time.Second * 10,
+}
+
+func TestEvent_Script(t *testing.T) {
+	steps := testEvent.Script()
+
+	if _, ok := steps[0].Message.(udp.Version); !ok {
+		t.Fatalf("Expected the script to start with the protocol version, got %T", steps[0].Message)
+	}
+
+	var (
+		lastLap    udp.LapCompleted
+		laps       int
+		collisions int
+		ends       []udp.EndSession
+	)
+
+	for i, step := range steps {
+		if i > 0 && step.At < steps[i-1].At {
+			t.Fatalf("Step %d is at %s, before the step before it", i, step.At)
+		}
+
+		switch m := step.Message.(type) {
+		case udp.LapCompleted:
+			lastLap = m
+			laps++
+		case udp.CollisionWithCar, udp.CollisionWithEnvironment:
+			collisions++
+		case udp.EndSession:
+			ends = append(ends, m)
+		}
+	}
+
+	if laps != 7 || collisions != 2 {
+		t.Errorf("Expected 7 laps and 2 collisions, got %d and %d", laps, collisions)
+	}
+
+	if !reflect.DeepEqual(ends, []udp.EndSession{"results/2020_5_1_19_3_QUALIFY.json", "results/2020_5_1_19_6_RACE.json"}) {
+		t.Errorf("Unexpected results files: %v", ends)
+	}
+
+	// car 1 crosses the line last, but won the race by a second.
+	expected := []*udp.LapCompletedCar{
+		{CarID: 1, LapTime: 88000, Laps: 2, Completed: 1},
+		{CarID: 0, LapTime: 90000, Laps: 2, Completed: 1},
+	}
+
+	if lastLap.CarID != 0 || !reflect.DeepEqual(lastLap.Cars, expected) {
+		t.Errorf("Unexpected final leaderboard from car %d: %v", lastLap.CarID, lastLap.Cars)
+	}
+}
+
+func freeUDPPort(t *testing.T) int {
+	l, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	return l.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestServer_Play(t *testing.T) {
+	pluginPort := freeUDPPort(t)
+
+	server, err := NewServer(0, net.JoinHostPort("127.0.0.1", strconv.Itoa(pluginPort)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer server.Close()
+
+	messages := make(chan udp.Message, 1000)
+
+	client, err := udp.NewServerClient("127.0.0.1", pluginPort, server.LocalPort(), false, "", 0, func(message udp.Message) {
+		messages <- message
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Close()
+
+	steps := testEvent.Script()
+
+	if err := server.Play(context.Background(), steps, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, step := range steps {
+		select {
+		case message := <-messages:
+			if message.Event() != step.Message.Event() {
+				t.Fatalf("Step %d: expected a %T, got a %T", i, step.Message, message)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Step %d: %T wasn't received", i, step.Message)
+		}
+	}
+
+	if err := client.SendMessage(udp.GetSessionInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case message := <-messages:
+		sessionInfo, ok := message.(udp.SessionInfo)
+
+		if !ok || sessionInfo.EventType != udp.EventSessionInfo || sessionInfo.Name != "Race" {
+			t.Errorf("Expected the race's session info, got %#v", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Session info wasn't sent")
+	}
+
+	select {
+	case message := <-server.Received():
+		if message.Event != udp.EventGetSessionInfo {
+			t.Errorf("Expected to receive a request for session info, got %d", message.Event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Request for session info wasn't received")
+	}
+}
@@ -19,32 +19,50 @@ const (
 )
 
 func KissMyRankExecutablePath() string {
+	return kissMyRankExecutablePath(ServerInstallPath)
+}
+
+// kissMyRankExecutablePath is the kissmyrank executable in the kissmyrank directory of the acServer in installPath.
+func kissMyRankExecutablePath(installPath string) string {
 	if runtime.GOOS == "windows" {
-		return filepath.Join(KissMyRankFolderPath(), "ac_kissmyrank-win.exe")
+		return filepath.Join(kissMyRankFolderPath(installPath), "ac_kissmyrank-win.exe")
 	}
 
-	return filepath.Join(KissMyRankFolderPath(), "ac_kissmyrank-linux")
+	return filepath.Join(kissMyRankFolderPath(installPath), "ac_kissmyrank-linux")
 }
 
-func fixKissMyRankExecutablePermissions() error {
+func fixKissMyRankExecutablePermissions(installPath string) error {
 	if runtime.GOOS == "linux" {
-		return os.Chmod(KissMyRankExecutablePath(), 0755)
+		return os.Chmod(kissMyRankExecutablePath(installPath), 0755)
 	}
 
 	return nil
 }
 
 func KissMyRankFolderPath() string {
-	return filepath.Join(ServerInstallPath, kissMyRankBaseFolderName)
+	return kissMyRankFolderPath(ServerInstallPath)
+}
+
+func kissMyRankFolderPath(installPath string) string {
+	return filepath.Join(installPath, kissMyRankBaseFolderName)
 }
 
 func KissMyRankConfigPath() string {
-	return filepath.Join(KissMyRankFolderPath(), kissMyRankConfigJSONFileName)
+	return kissMyRankConfigPath(ServerInstallPath)
+}
+
+func kissMyRankConfigPath(installPath string) string {
+	return filepath.Join(kissMyRankFolderPath(installPath), kissMyRankConfigJSONFileName)
 }
 
 // IsKissMyRankInstalled looks in the ServerInstallPath for a "kissmyrank" directory with the correct kissmyrank executable for the given platform
 func IsKissMyRankInstalled() bool {
-	if _, err := os.Stat(KissMyRankExecutablePath()); os.IsNotExist(err) {
+	return isKissMyRankInstalled(ServerInstallPath)
+}
+
+// isKissMyRankInstalled looks for kissmyrank in the acServer install at installPath.
+func isKissMyRankInstalled(installPath string) bool {
+	if _, err := os.Stat(kissMyRankExecutablePath(installPath)); os.IsNotExist(err) {
 		return false
 	} else if err != nil {
 		logrus.WithError(err).Error("Could not determine if kissmyrank is enabled")
@@ -483,8 +483,9 @@ type KissMyRankConfig struct {
 	TrackRotationVoteMinVotes   int `json:"track_rotation_vote_min_votes" show:"-" help:"The minimum total amount of votes (for or against) required to initiate the track change. 4 = minimum 4 votes are required to change the track. Use this if you wish to prevent lonely players from changing the track."`
 }
 
-func (kmr *KissMyRankConfig) Write() error {
-	f, err := os.Create(KissMyRankConfigPath())
+// Write saves the config for the kissmyrank installed with the acServer in installPath.
+func (kmr *KissMyRankConfig) Write(installPath string) error {
+	f, err := os.Create(kissMyRankConfigPath(installPath))
 
 	if err != nil {
 		return err
@@ -22,11 +22,13 @@ type RaceControl struct {
 	store            Store
 	penaltiesManager *PenaltiesManager
 
+	// SessionInfo is written from the UDP callback, anything else must read it with CurrentSessionInfo.
 	SessionInfo                udp.SessionInfo `json:"SessionInfo"`
-	TrackMapData               TrackMapData    `json:"TrackMapData"`
-	TrackInfo                  TrackInfo       `json:"TrackInfo"`
-	SessionStartTime           time.Time       `json:"SessionStartTime"`
-	CurrentRealtimePosInterval int             `json:"CurrentRealtimePosInterval"`
+	sessionInfoMutex           sync.RWMutex
+	TrackMapData               TrackMapData `json:"TrackMapData"`
+	TrackInfo                  TrackInfo    `json:"TrackInfo"`
+	SessionStartTime           time.Time    `json:"SessionStartTime"`
+	CurrentRealtimePosInterval int          `json:"CurrentRealtimePosInterval"`
 
 	ChatMessages      []udp.Chat
 	ChatMessagesMutex sync.Mutex
@@ -37,9 +39,21 @@ type RaceControl struct {
 	CarIDToGUID      map[udp.CarID]udp.DriverGUID `json:"CarIDToGUID"`
 	carIDToGUIDMutex sync.RWMutex
 
+	// DuplicateGUIDs are the connected cars which share a GUID with another car, guarded by carIDToGUIDMutex.
+	DuplicateGUIDs map[udp.CarID]*DuplicateGUID `json:"DuplicateGUIDs"`
+
 	carUpdaters          map[udp.CarID]chan udp.CarUpdate
 	serverProcessStopped chan struct{}
 
+	liveGaps    liveGaps
+	idleDrivers idleDrivers
+	stints      carStints
+
+	chatBroadcastRotation sessionTask
+
+	incidents      *SessionIncidents
+	incidentsMutex sync.Mutex
+
 	broadcaster      Broadcaster
 	trackDataGateway TrackDataGateway
 
@@ -159,6 +173,10 @@ func (rc *RaceControl) UDPCallback(message udp.Message) {
 		m.Time = time.Now()
 
 		err = rc.OnChatMessage(m)
+
+		if err == nil {
+			err = rc.OnChatCommand(m)
+		}
 	default:
 		return
 	}
@@ -170,7 +188,7 @@ func (rc *RaceControl) UDPCallback(message udp.Message) {
 
 	if sendUpdatedRaceControlStatus {
 		// update the current refresh rate
-		rc.CurrentRealtimePosInterval = udp.CurrentRealtimePosIntervalMs
+		rc.CurrentRealtimePosInterval = rc.process.RealtimePosInterval()
 
 		lastUpdateMessage, err := rc.broadcaster.Send(rc)
 
@@ -246,11 +264,15 @@ func (rc *RaceControl) OnVersion(version udp.Version) error {
 // OnCarUpdate occurs every udp.RealTimePosInterval and returns car position, speed, etc.
 // drivers top speeds are recorded per lap, as well as their last seen updated.
 func (rc *RaceControl) OnCarUpdate(update udp.CarUpdate) error {
-	if ch, ok := rc.carUpdaters[update.CarID]; !ok || ch == nil {
-		rc.carUpdaters[update.CarID] = make(chan udp.CarUpdate, 1000)
+	ch, ok := rc.carUpdaters[update.CarID]
+
+	if !ok || ch == nil {
+		// the goroutine ranges over its own channel, carUpdaters is only used from the UDP callback.
+		ch = make(chan udp.CarUpdate, 1000)
+		rc.carUpdaters[update.CarID] = ch
 
 		go panicCapture(func() {
-			for update := range rc.carUpdaters[update.CarID] {
+			for update := range ch {
 				err := rc.handleCarUpdate(update)
 
 				if err != nil {
@@ -260,7 +282,7 @@ func (rc *RaceControl) OnCarUpdate(update udp.CarUpdate) error {
 		})
 	}
 
-	rc.carUpdaters[update.CarID] <- update
+	ch <- update
 
 	return nil
 }
@@ -283,12 +305,26 @@ func (rc *RaceControl) handleCarUpdate(update udp.CarUpdate) error {
 		driver.CurrentCar().TopSpeedThisLap = speed
 	}
 
-	driver.LastSeen = time.Now()
+	now := time.Now()
+
+	driver.LastSeen = now
 	driver.LastPos = update.Pos
 
-	_, err = rc.broadcaster.Send(update)
+	rc.liveGaps.update(update.CarID, update.NormalisedSplinePos, now)
 
-	return err
+	if err := rc.checkIdle(driver, update, now); err != nil {
+		logrus.WithError(err).Errorf("Could not handle idle car %d", update.CarID)
+	}
+
+	if _, err := rc.broadcaster.Send(update); err != nil {
+		return err
+	}
+
+	if rc.liveGaps.shouldPublish(update.CarID, now) {
+		return rc.publishLiveSplit(driver)
+	}
+
+	return nil
 }
 
 var emptyCarInfoMutex = sync.Mutex{}
@@ -297,8 +333,19 @@ var emptyCarInfoMutex = sync.Mutex{}
 // then all driver information is cleared.
 func (rc *RaceControl) OnNewSession(sessionInfo udp.SessionInfo) error {
 	oldSessionInfo := rc.SessionInfo
+	rc.sessionInfoMutex.Lock()
 	rc.SessionInfo = sessionInfo
+	rc.sessionInfoMutex.Unlock()
 	rc.SessionStartTime = time.Now()
+	rc.liveGaps.reset(sessionInfo.Type)
+	rc.idleDrivers.reset()
+	rc.resetStints()
+	rc.startChatBroadcasts()
+
+	if err := rc.applyBallastToConnectedDrivers(); err != nil {
+		logrus.WithError(err).Error("Could not apply ballast for the new session")
+	}
+	rc.newIncidentSession(sessionInfo, rc.SessionStartTime)
 
 	emptyCarInfo := true
 
@@ -393,6 +440,7 @@ func (rc *RaceControl) clearAllDrivers() {
 	rc.DisconnectedDrivers = NewDriverMap(DisconnectedDrivers, rc.SortDrivers)
 	rc.carIDToGUIDMutex.Lock()
 	rc.CarIDToGUID = make(map[udp.CarID]udp.DriverGUID)
+	rc.DuplicateGUIDs = make(map[udp.CarID]*DuplicateGUID)
 	rc.carIDToGUIDMutex.Unlock()
 }
 
@@ -418,6 +466,7 @@ func (rc *RaceControl) requestSessionInfo() {
 		case <-rc.serverProcessStopped:
 			logrus.Debugf("Assetto Process completed. Disconnecting all connected drivers. Session done.")
 			sessionInfoTicker.Stop()
+			rc.chatBroadcastRotation.stop()
 
 			var drivers []*RaceControlDriver
 
@@ -460,16 +509,27 @@ func (rc *RaceControl) disconnectDriver(driver *RaceControlDriver) error {
 	return rc.OnClientDisconnect(carInfo)
 }
 
+// CurrentSessionInfo returns a copy of the information about the current session, which is safe to call outside of
+// the UDP callback.
+func (rc *RaceControl) CurrentSessionInfo() udp.SessionInfo {
+	rc.sessionInfoMutex.RLock()
+	defer rc.sessionInfoMutex.RUnlock()
+
+	return rc.SessionInfo
+}
+
 // OnSessionUpdate is called every sessionRequestInterval.
 func (rc *RaceControl) OnSessionUpdate(sessionInfo udp.SessionInfo) (bool, error) {
 	oldSessionInfo := rc.SessionInfo
 
 	// we can't just copy over the session information, we must copy individual
 	// parts of it, as the session type is incorrect.
+	rc.sessionInfoMutex.Lock()
 	rc.SessionInfo.AmbientTemp = sessionInfo.AmbientTemp
 	rc.SessionInfo.RoadTemp = sessionInfo.RoadTemp
 	rc.SessionInfo.WeatherGraphics = sessionInfo.WeatherGraphics
 	rc.SessionInfo.ElapsedMilliseconds = sessionInfo.ElapsedMilliseconds
+	rc.sessionInfoMutex.Unlock()
 
 	sessionHasChanged := oldSessionInfo.AmbientTemp != rc.SessionInfo.AmbientTemp || oldSessionInfo.RoadTemp != rc.SessionInfo.RoadTemp || oldSessionInfo.WeatherGraphics != rc.SessionInfo.WeatherGraphics
 
@@ -481,6 +541,16 @@ func (rc *RaceControl) OnEndSession(sessionFile udp.EndSession) error {
 	filename := filepath.Base(string(sessionFile))
 	logrus.Infof("End Session, file outputted at: %s", filename)
 
+	rc.endChatBroadcasts()
+
+	if err := rc.endIncidentSession(filename); err != nil {
+		logrus.WithError(err).Error("Could not store the session's incidents")
+	}
+
+	if err := rc.updateSuccessBallast(filename); err != nil {
+		logrus.WithError(err).Error("Could not work out success ballast from the session's results")
+	}
+
 	config := rc.process.Event().GetRaceConfig()
 
 	if config.DriverSwapEnabled == 1 {
@@ -607,16 +677,32 @@ func (rc *RaceControl) addFileToTimeAttackEvent(file string) error {
 }
 
 // OnClientConnect stores CarID -> DriverGUID mappings. if a driver is known to have previously been in this event,
-// they will be moved from DisconnectedDrivers to ConnectedDrivers.
+// they will be moved from DisconnectedDrivers to ConnectedDrivers. if the driver's GUID is already connected in another
+// car, the duplicate GUID policy is applied.
 func (rc *RaceControl) OnClientConnect(client udp.SessionCarInfo) error {
+	client, ok := rc.handleDuplicateGUID(client)
+
+	if !ok {
+		rc.carIDToGUIDMutex.Lock()
+		delete(rc.CarIDToGUID, client.CarID)
+		rc.carIDToGUIDMutex.Unlock()
+
+		return nil
+	}
+
 	rc.carIDToGUIDMutex.Lock()
 	rc.CarIDToGUID[client.CarID] = client.DriverGUID
 	rc.carIDToGUIDMutex.Unlock()
 
+	rc.liveGaps.remove(client.CarID)
+	rc.idleDrivers.remove(client.CarID)
+
 	client.DriverInitials = driverInitials(client.DriverName)
 	client.DriverName = driverName(client.DriverName)
 	client.CarName = prettifyName(client.CarModel, true)
 
+	rc.stintDriverConnected(client)
+
 	var driver *RaceControlDriver
 
 	if disconnectedDriver, ok := rc.DisconnectedDrivers.Get(client.DriverGUID); ok {
@@ -658,6 +744,15 @@ func (rc *RaceControl) OnClientDisconnect(client udp.SessionCarInfo) error {
 		delete(rc.carUpdaters, client.CarID)
 	}
 
+	client, ok := rc.resolveDuplicateGUID(client)
+
+	if !ok {
+		// the client was rejected when it connected, so it was never added to the roster
+		return nil
+	}
+
+	rc.stints.driverDisconnected(client.CarID, client.DriverGUID, time.Now())
+
 	driver, ok := rc.ConnectedDrivers.Get(client.DriverGUID)
 
 	if !ok {
@@ -848,18 +943,7 @@ func (rc *RaceControl) handleDriverSwap(ticker *time.Ticker, config CurrentRaceC
 						currentDriver.LastPos = udp.Vec{X: 0, Y: 0, Z: 0}
 					} else if countdown >= (time.Second * time.Duration(config.DriverSwapPenaltyTime)) {
 
-						rc.driverSwapPenaltiesMutex.Lock()
-						{
-							if _, ok := rc.driverSwapPenalties[currentDriver.CarInfo.DriverGUID]; ok {
-								rc.driverSwapPenalties[currentDriver.CarInfo.DriverGUID].penalty += countdown + (time.Second * 5)
-							} else {
-								rc.driverSwapPenalties[currentDriver.CarInfo.DriverGUID] = &driverSwapPenalty{
-									penalty:  countdown + (time.Second * 5),
-									carModel: currentDriver.CarInfo.CarModel,
-								}
-							}
-						}
-						rc.driverSwapPenaltiesMutex.Unlock()
+						rc.addDriverSwapPenalty(currentDriver.CarInfo.DriverGUID, currentDriver.CarInfo.CarModel, countdown+(time.Second*5))
 
 						sendChat, err := udp.NewSendChat(
 							currentDriver.CarInfo.CarID,
@@ -950,6 +1034,10 @@ func (rc *RaceControl) OnClientLoaded(loadedCar udp.ClientLoaded) error {
 		return err
 	}
 
+	if err := rc.applyBallast(map[udp.CarID]udp.DriverGUID{driver.CarInfo.CarID: driver.CarInfo.DriverGUID}); err != nil {
+		logrus.WithError(err).Errorf("Could not apply ballast to car %d", driver.CarInfo.CarID)
+	}
+
 	serverConfig, err := rc.store.LoadServerOptions()
 
 	if err != nil {
@@ -997,6 +1085,10 @@ func (rc *RaceControl) OnClientLoaded(loadedCar udp.ClientLoaded) error {
 		logrus.WithError(err).Errorf("Couldn't send championship welcome message to driver: %s", driver.CarInfo.DriverName)
 	}
 
+	if err := rc.sendDriverJoinChatBroadcasts(driver.CarInfo.CarID); err != nil {
+		logrus.WithError(err).Errorf("Couldn't send chat broadcasts to driver: %s", driver.CarInfo.DriverName)
+	}
+
 	logrus.Debugf("Driver: %s (%s) loaded", driver.CarInfo.DriverName, driver.CarInfo.DriverGUID)
 
 	driver.LoadedTime = time.Now()
@@ -1085,6 +1177,8 @@ func (rc *RaceControl) OnLapCompleted(lap udp.LapCompleted) error {
 	logrus.Debugf("Lap completed by driver: %s (%s), %s", driver.CarInfo.DriverName, driver.CarInfo.DriverGUID, lapDuration)
 
 	driver.TotalNumLaps++
+	rc.stintLapCompleted(lap.CarID, driver.CarInfo)
+
 	currentCar := driver.CurrentCar()
 
 	currentCar.TotalLapTime += lapDuration
@@ -1099,6 +1193,13 @@ func (rc *RaceControl) OnLapCompleted(lap udp.LapCompleted) error {
 
 	currentCar.TopSpeedThisLap = 0
 
+	for _, leaderboardCar := range lap.Cars {
+		if leaderboardCar != nil && leaderboardCar.CarID == lap.CarID {
+			rc.liveGaps.lapCompleted(lap.CarID, int(leaderboardCar.Laps))
+			break
+		}
+	}
+
 	rc.ConnectedDrivers.sort()
 
 	if rc.SessionInfo.Type == udp.SessionTypeRace {
@@ -1154,12 +1255,12 @@ func (rc *RaceControl) OnLapCompleted(lap udp.LapCompleted) error {
 }
 
 const (
-	chatMessageLimit  = 50
-	chatCommandPrefix = "/"
+	chatMessageLimit   = 50
+	adminCommandPrefix = "/"
 )
 
 func (rc *RaceControl) OnChatMessage(chat udp.Chat) error {
-	if strings.HasPrefix(chat.Message, chatCommandPrefix) {
+	if strings.HasPrefix(chat.Message, adminCommandPrefix) {
 		return nil
 	}
 
@@ -1272,6 +1373,15 @@ func (rc *RaceControl) OnCollisionWithCar(collision udp.CollisionWithCar) error
 
 	driver.Collisions = append(driver.Collisions, c)
 
+	cars := []IncidentCar{
+		{CarID: collision.CarID, DriverGUID: driver.CarInfo.DriverGUID, DriverName: driver.CarInfo.DriverName},
+		rc.incidentCar(collision.OtherCarID),
+	}
+
+	if err := rc.recordIncident(CollisionWithCar, cars, c.Speed, collision.WorldPos); err != nil {
+		logrus.WithError(err).Error("Could not store collision incident")
+	}
+
 	_, err = rc.broadcaster.Send(collision)
 
 	return err
@@ -1288,12 +1398,22 @@ func (rc *RaceControl) OnCollisionWithEnvironment(collision udp.CollisionWithEnv
 	driver.mutex.Lock()
 	defer driver.mutex.Unlock()
 
-	driver.Collisions = append(driver.Collisions, Collision{
+	c := Collision{
 		ID:    uuid.New().String(),
 		Type:  CollisionWithEnvironment,
 		Time:  time.Now(),
 		Speed: metersPerSecondToKilometersPerHour(float64(collision.ImpactSpeed)),
-	})
+	}
+
+	driver.Collisions = append(driver.Collisions, c)
+
+	cars := []IncidentCar{
+		{CarID: collision.CarID, DriverGUID: driver.CarInfo.DriverGUID, DriverName: driver.CarInfo.DriverName},
+	}
+
+	if err := rc.recordIncident(CollisionWithEnvironment, cars, c.Speed, collision.WorldPos); err != nil {
+		logrus.WithError(err).Error("Could not store collision incident")
+	}
 
 	_, err = rc.broadcaster.Send(collision)
 
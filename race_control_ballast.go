@@ -0,0 +1,239 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// maxRestrictor is the most restrictor acServer allows.
+const maxRestrictor = 100
+
+var ErrDriverBallastNotFound = errors.New("servermanager: driver ballast not found")
+
+// SuccessBallastConfig adds ballast and restrictor to the drivers who finish at the front of each race. Ballast[0]
+// (in kg) and Restrictor[0] (in %) go to the winner, Ballast[1] and Restrictor[1] to second place and so on. The
+// ballast is sent to acServer at the start of the next session, and whenever the driver joins.
+type SuccessBallastConfig struct {
+	Ballast    []int `yaml:"ballast"`
+	Restrictor []int `yaml:"restrictor"`
+
+	// Accumulate adds each race's ballast to what drivers already have. Otherwise, each race's ballast replaces the
+	// last race's.
+	Accumulate bool `yaml:"accumulate"`
+
+	MaxBallast    int `yaml:"max_ballast"`
+	MaxRestrictor int `yaml:"max_restrictor"`
+}
+
+func successBallastConfig() SuccessBallastConfig {
+	if config == nil {
+		return SuccessBallastConfig{}
+	}
+
+	return config.Server.SuccessBallast
+}
+
+func (c SuccessBallastConfig) enabled() bool {
+	return len(c.Ballast) > 0 || len(c.Restrictor) > 0
+}
+
+// DriverBallast is the ballast and restrictor which a driver is given at the start of each session.
+type DriverBallast struct {
+	DriverGUID string `json:"DriverGUID"`
+	DriverName string `json:"DriverName"`
+	BallastKG  int    `json:"BallastKG"`
+	Restrictor int    `json:"Restrictor"`
+
+	// Override is set for ballast set by an admin, which isn't changed by results.
+	Override bool `json:"Override"`
+
+	Updated time.Time `json:"Updated"`
+}
+
+func positionValue(values []int, position int) int {
+	if position < len(values) {
+		return values[position]
+	}
+
+	return 0
+}
+
+func capValue(value, max int) int {
+	if value < 0 {
+		return 0
+	}
+
+	if max > 0 && value > max {
+		return max
+	}
+
+	return value
+}
+
+// computeSuccessBallast returns the ballast of each driver whose ballast is changed by a race's results, starting
+// from the ballast they already have in current. Disqualified drivers don't take a position.
+func computeSuccessBallast(conf SuccessBallastConfig, results *SessionResults, current map[string]*DriverBallast, now time.Time) []*DriverBallast {
+	var changed []*DriverBallast
+
+	position := 0
+
+	for _, result := range results.Result {
+		if result.DriverGUID == "" || result.Disqualified {
+			continue
+		}
+
+		ballastKG, restrictor := positionValue(conf.Ballast, position), positionValue(conf.Restrictor, position)
+		position++
+
+		ballast, ok := current[result.DriverGUID]
+
+		if ok && ballast.Override {
+			continue
+		}
+
+		if !ok {
+			if ballastKG == 0 && restrictor == 0 {
+				// drivers who have never had ballast don't need a record of none.
+				continue
+			}
+
+			ballast = &DriverBallast{DriverGUID: result.DriverGUID}
+		}
+
+		if conf.Accumulate {
+			ballastKG += ballast.BallastKG
+			restrictor += ballast.Restrictor
+		}
+
+		maxRestrictorValue := conf.MaxRestrictor
+
+		if maxRestrictorValue <= 0 || maxRestrictorValue > maxRestrictor {
+			maxRestrictorValue = maxRestrictor
+		}
+
+		ballast.DriverName = result.DriverName
+		ballast.BallastKG = capValue(ballastKG, conf.MaxBallast)
+		ballast.Restrictor = capValue(restrictor, maxRestrictorValue)
+		ballast.Updated = now
+
+		changed = append(changed, ballast)
+	}
+
+	return changed
+}
+
+func loadDriverBallasts(store Store) (map[string]*DriverBallast, error) {
+	ballasts, err := store.ListDriverBallasts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	byGUID := make(map[string]*DriverBallast, len(ballasts))
+
+	for _, ballast := range ballasts {
+		byGUID[ballast.DriverGUID] = ballast
+	}
+
+	return byGUID, nil
+}
+
+// updateSuccessBallast works out the ballast from the results of a race which has just ended.
+func (rc *RaceControl) updateSuccessBallast(resultsFile string) error {
+	conf := successBallastConfig()
+
+	if !conf.enabled() || rc.SessionInfo.Type != udp.SessionTypeRace {
+		return nil
+	}
+
+	results, err := LoadResult(filepath.Base(resultsFile), LoadResultWithoutPluginFire)
+
+	if err != nil {
+		return err
+	}
+
+	current, err := loadDriverBallasts(rc.store)
+
+	if err != nil {
+		return err
+	}
+
+	for _, ballast := range computeSuccessBallast(conf, results, current, time.Now()) {
+		logrus.Infof("Success ballast for %s (%s) is now %dkg, %d%% restrictor", ballast.DriverName, ballast.DriverGUID, ballast.BallastKG, ballast.Restrictor)
+
+		if err := rc.store.UpsertDriverBallast(ballast); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendBallast has acServer give carID the ballast and restrictor in ballast.
+func sendBallast(process ServerProcess, carID udp.CarID, ballast *DriverBallast) error {
+	for _, command := range []string{
+		fmt.Sprintf("/ballast %d %d", carID, ballast.BallastKG),
+		fmt.Sprintf("/restrictor %d %d", carID, ballast.Restrictor),
+	} {
+		adminCommand, err := udp.NewAdminCommand(command)
+
+		if err != nil {
+			return err
+		}
+
+		if err := process.SendUDPMessage(adminCommand); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyBallast sends the stored ballast of each of cars' drivers to acServer. cars maps car IDs to their drivers.
+func (rc *RaceControl) applyBallast(cars map[udp.CarID]udp.DriverGUID) error {
+	if len(cars) == 0 {
+		return nil
+	}
+
+	ballasts, err := loadDriverBallasts(rc.store)
+
+	if err != nil || len(ballasts) == 0 {
+		return err
+	}
+
+	for carID, driverGUID := range cars {
+		ballast, ok := ballasts[string(driverGUID)]
+
+		if !ok {
+			continue
+		}
+
+		if err := sendBallast(rc.process, carID, ballast); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyBallastToConnectedDrivers sends the stored ballast of every connected driver to acServer, at the start of a
+// session.
+func (rc *RaceControl) applyBallastToConnectedDrivers() error {
+	cars := make(map[udp.CarID]udp.DriverGUID)
+
+	_ = rc.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
+		driver.mutex.Lock()
+		cars[driver.CarInfo.CarID] = driver.CarInfo.DriverGUID
+		driver.mutex.Unlock()
+
+		return nil
+	})
+
+	return rc.applyBallast(cars)
+}
@@ -0,0 +1,120 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestComputeSuccessBallast(t *testing.T) {
+	results := &SessionResults{
+		Result: []*SessionResult{
+			{DriverGUID: "1", DriverName: "Winner"},
+			{DriverGUID: "2", DriverName: "Disqualified", Disqualified: true},
+			{DriverGUID: "3", DriverName: "Second"},
+			{DriverGUID: "4", DriverName: "Third"},
+			{DriverGUID: "5", DriverName: "Overridden"},
+		},
+	}
+
+	now := time.Now()
+
+	ballasts := func(conf SuccessBallastConfig, current ...*DriverBallast) map[string][2]int {
+		byGUID := make(map[string]*DriverBallast)
+
+		for _, ballast := range current {
+			byGUID[ballast.DriverGUID] = ballast
+		}
+
+		out := make(map[string][2]int)
+
+		for _, ballast := range computeSuccessBallast(conf, results, byGUID, now) {
+			out[ballast.DriverGUID] = [2]int{ballast.BallastKG, ballast.Restrictor}
+		}
+
+		return out
+	}
+
+	t.Run("Replace", func(t *testing.T) {
+		changed := ballasts(
+			SuccessBallastConfig{Ballast: []int{30, 20}, Restrictor: []int{10}},
+			&DriverBallast{DriverGUID: "4", BallastKG: 30},
+			&DriverBallast{DriverGUID: "5", BallastKG: 50, Override: true},
+		)
+
+		expected := map[string][2]int{"1": {30, 10}, "3": {20, 0}, "4": {0, 0}}
+
+		if !reflect.DeepEqual(changed, expected) {
+			t.Errorf("Expected %v, got %v", expected, changed)
+		}
+	})
+
+	t.Run("Accumulate", func(t *testing.T) {
+		changed := ballasts(
+			SuccessBallastConfig{Ballast: []int{30, 20}, Restrictor: []int{60}, Accumulate: true, MaxBallast: 50},
+			&DriverBallast{DriverGUID: "1", BallastKG: 40, Restrictor: 60},
+			&DriverBallast{DriverGUID: "4", BallastKG: 30},
+		)
+
+		expected := map[string][2]int{"1": {50, 100}, "3": {20, 0}, "4": {30, 0}}
+
+		if !reflect.DeepEqual(changed, expected) {
+			t.Errorf("Expected %v, got %v", expected, changed)
+		}
+	})
+}
+
+func TestRaceControl_ApplyBallast(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-ballast")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(dir, dir)
+	process := sentUDPMessagesProcess{sent: make(chan udp.Message, 10)}
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, process, store, NewPenaltiesManager(store))
+
+	if err := store.UpsertDriverBallast(&DriverBallast{DriverGUID: string(drivers[0].DriverGUID), BallastKG: 25, Restrictor: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := raceControl.applyBallast(map[udp.CarID]udp.DriverGUID{drivers[0].CarID: drivers[0].DriverGUID, drivers[1].CarID: drivers[1].DriverGUID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, command := range []string{"/ballast 1 25", "/restrictor 1 5"} {
+		expected, err := udp.NewAdminCommand(command)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case message := <-process.sent:
+			if !reflect.DeepEqual(message, expected) {
+				t.Errorf("Expected admin command %s, got %#v", command, message)
+			}
+		default:
+			t.Fatalf("Expected admin command %s, nothing was sent", command)
+		}
+	}
+
+	if len(process.sent) != 0 {
+		t.Error("Expected no ballast to be sent for a driver without any")
+	}
+
+	if err := store.DeleteDriverBallast(string(drivers[0].DriverGUID)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteDriverBallast(string(drivers[0].DriverGUID)); err != ErrDriverBallastNotFound {
+		t.Errorf("Expected ErrDriverBallastNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package servermanager
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// ChatBroadcastTrigger is when a ChatBroadcast is sent.
+type ChatBroadcastTrigger string
+
+const (
+	// ChatBroadcastRotate broadcasts are sent one at a time, in turn, every ChatBroadcastInterval minutes.
+	ChatBroadcastRotate ChatBroadcastTrigger = "rotate"
+
+	ChatBroadcastSessionStart ChatBroadcastTrigger = "session-start"
+	ChatBroadcastSessionEnd   ChatBroadcastTrigger = "session-end"
+
+	// ChatBroadcastDriverJoin broadcasts are sent to each driver when they have loaded in, rather than to everyone.
+	ChatBroadcastDriverJoin ChatBroadcastTrigger = "driver-join"
+)
+
+// ChatBroadcast is a chat message which is sent to drivers during an event, e.g. a reminder of the rules or a link
+// to a Discord server.
+type ChatBroadcast struct {
+	Message string
+	Trigger ChatBroadcastTrigger
+
+	// SessionType limits the broadcast to one type of session. It is sent in every session if SessionType is empty.
+	SessionType SessionType
+}
+
+func (b ChatBroadcast) sentIn(sessionType udp.SessionType) bool {
+	return b.SessionType == "" || b.SessionType.String() == sessionType.String()
+}
+
+// ChatBroadcastRows are the rows of the race setup form's broadcasts: one for each broadcast, and an empty one for
+// adding another.
+func (c CurrentRaceConfig) ChatBroadcastRows() []ChatBroadcast {
+	return append(append([]ChatBroadcast(nil), c.ChatBroadcasts...), ChatBroadcast{Trigger: ChatBroadcastRotate})
+}
+
+// chatBroadcastsFromForm reads the broadcasts from the race setup form, which has one row of fields per broadcast.
+// Rows without a message are left out, so clearing a message removes its broadcast.
+func chatBroadcastsFromForm(form url.Values) []ChatBroadcast {
+	var broadcasts []ChatBroadcast
+
+	triggers, sessionTypes := form["ChatBroadcast.Trigger"], form["ChatBroadcast.SessionType"]
+
+	for i, message := range form["ChatBroadcast.Message"] {
+		message = strings.TrimSpace(message)
+
+		if message == "" || i >= len(triggers) || i >= len(sessionTypes) {
+			continue
+		}
+
+		broadcasts = append(broadcasts, ChatBroadcast{
+			Message:     message,
+			Trigger:     ChatBroadcastTrigger(triggers[i]),
+			SessionType: SessionType(sessionTypes[i]),
+		})
+	}
+
+	return broadcasts
+}
+
+// chatBroadcastsFor returns the broadcasts in raceConfig with trigger which are sent in sessionType.
+func chatBroadcastsFor(raceConfig CurrentRaceConfig, trigger ChatBroadcastTrigger, sessionType udp.SessionType) []ChatBroadcast {
+	var broadcasts []ChatBroadcast
+
+	for _, broadcast := range raceConfig.ChatBroadcasts {
+		if broadcast.Trigger == trigger && broadcast.sentIn(sessionType) && strings.TrimSpace(broadcast.Message) != "" {
+			broadcasts = append(broadcasts, broadcast)
+		}
+	}
+
+	return broadcasts
+}
+
+func chatLines(message string) []string {
+	return strings.Split(wordwrap.WrapString(message, 60), "\n")
+}
+
+// broadcastChat sends message to everyone on the server, split into lines which fit in the chat window.
+func broadcastChat(process ServerProcess, message string) error {
+	for _, line := range chatLines(message) {
+		broadcast, err := udp.NewBroadcastChat(line)
+
+		if err != nil {
+			return err
+		}
+
+		if err := process.SendUDPMessage(broadcast); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chatBroadcastIntervalUnit is the unit of CurrentRaceConfig.ChatBroadcastInterval.
+var chatBroadcastIntervalUnit = time.Minute
+
+// rotateChatBroadcasts sends broadcasts in turn, one every interval, until ctx is done.
+func rotateChatBroadcasts(ctx context.Context, process ServerProcess, broadcasts []ChatBroadcast, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for next := 0; ; next = (next + 1) % len(broadcasts) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := broadcastChat(process, broadcasts[next].Message)
+
+		if err == ErrNoOpenUDPConnection {
+			// the server has stopped, the next session starts a new rotation.
+			return
+		} else if err != nil {
+			logrus.WithError(err).Error("Could not send chat broadcast")
+		}
+	}
+}
+
+// sendChatBroadcasts broadcasts each of the current event's broadcasts with trigger.
+func (rc *RaceControl) sendChatBroadcasts(trigger ChatBroadcastTrigger) {
+	for _, broadcast := range chatBroadcastsFor(rc.process.Event().GetRaceConfig(), trigger, rc.SessionInfo.Type) {
+		if err := broadcastChat(rc.process, broadcast.Message); err != nil {
+			logrus.WithError(err).Error("Could not send chat broadcast")
+			return
+		}
+	}
+}
+
+// startChatBroadcasts sends the broadcasts for the start of a session, and starts its rotation.
+func (rc *RaceControl) startChatBroadcasts() {
+	raceConfig := rc.process.Event().GetRaceConfig()
+
+	rc.sendChatBroadcasts(ChatBroadcastSessionStart)
+
+	broadcasts := chatBroadcastsFor(raceConfig, ChatBroadcastRotate, rc.SessionInfo.Type)
+	interval := time.Duration(raceConfig.ChatBroadcastInterval) * chatBroadcastIntervalUnit
+
+	if len(broadcasts) == 0 || interval <= 0 {
+		rc.chatBroadcastRotation.stop()
+		return
+	}
+
+	process := rc.process
+
+	rc.chatBroadcastRotation.start(func(ctx context.Context) {
+		rotateChatBroadcasts(ctx, process, broadcasts, interval)
+	})
+}
+
+// endChatBroadcasts stops the session's rotation and sends the broadcasts for the end of the session.
+func (rc *RaceControl) endChatBroadcasts() {
+	rc.chatBroadcastRotation.stop()
+	rc.sendChatBroadcasts(ChatBroadcastSessionEnd)
+}
+
+// sendDriverJoinChatBroadcasts sends the broadcasts for drivers joining to carID.
+func (rc *RaceControl) sendDriverJoinChatBroadcasts(carID udp.CarID) error {
+	for _, broadcast := range chatBroadcastsFor(rc.process.Event().GetRaceConfig(), ChatBroadcastDriverJoin, rc.SessionInfo.Type) {
+		for _, line := range chatLines(broadcast.Message) {
+			chat, err := udp.NewSendChat(carID, line)
+
+			if err != nil {
+				return err
+			}
+
+			if err := rc.process.SendUDPMessage(chat); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package servermanager
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestChatBroadcastsFromForm(t *testing.T) {
+	broadcasts := chatBroadcastsFromForm(url.Values{
+		"ChatBroadcast.Message":     {"Join our Discord", "  ", "No divebombs"},
+		"ChatBroadcast.Trigger":     {"rotate", "rotate", "session-start"},
+		"ChatBroadcast.SessionType": {"", "", "RACE"},
+	})
+
+	expected := []ChatBroadcast{
+		{Message: "Join our Discord", Trigger: ChatBroadcastRotate},
+		{Message: "No divebombs", Trigger: ChatBroadcastSessionStart, SessionType: SessionTypeRace},
+	}
+
+	if !reflect.DeepEqual(broadcasts, expected) {
+		t.Errorf("Expected %v, got %v", expected, broadcasts)
+	}
+}
+
+type eventUDPMessagesProcess struct {
+	sentUDPMessagesProcess
+
+	event RaceEvent
+}
+
+func (p eventUDPMessagesProcess) Event() RaceEvent {
+	return p.event
+}
+
+func TestRaceControl_ChatBroadcasts(t *testing.T) {
+	oldUnit := chatBroadcastIntervalUnit
+	chatBroadcastIntervalUnit = time.Millisecond * 20
+
+	defer func() {
+		chatBroadcastIntervalUnit = oldUnit
+	}()
+
+	process := eventUDPMessagesProcess{
+		sentUDPMessagesProcess: sentUDPMessagesProcess{sent: make(chan udp.Message, 10)},
+		event: &CustomRace{RaceConfig: CurrentRaceConfig{
+			ChatBroadcastInterval: 1,
+			ChatBroadcasts: []ChatBroadcast{
+				{Message: "Rules reminder", Trigger: ChatBroadcastRotate},
+				{Message: "Practice only", Trigger: ChatBroadcastSessionStart, SessionType: SessionTypePractice},
+				{Message: "Good luck", Trigger: ChatBroadcastSessionStart, SessionType: SessionTypeRace},
+				{Message: "Join our Discord", Trigger: ChatBroadcastRotate},
+				{Message: "Thanks for racing", Trigger: ChatBroadcastSessionEnd},
+				{Message: "Welcome", Trigger: ChatBroadcastDriverJoin},
+			},
+		}},
+	}
+
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, process, testStore, NewPenaltiesManager(testStore))
+	raceControl.SessionInfo.Type = udp.SessionTypeRace
+
+	expectBroadcast := func(text string) {
+		t.Helper()
+
+		expected, err := udp.NewBroadcastChat(text)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case message := <-process.sent:
+			if !reflect.DeepEqual(message, expected) {
+				t.Errorf("Expected a broadcast of %q, got %#v", text, message)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a broadcast of %q, nothing was sent", text)
+		}
+	}
+
+	raceControl.startChatBroadcasts()
+
+	for _, text := range []string{"Good luck", "Rules reminder", "Join our Discord", "Rules reminder"} {
+		expectBroadcast(text)
+	}
+
+	raceControl.endChatBroadcasts()
+
+	// a rotating broadcast may have been sent before the rotation stopped.
+	for len(process.sent) > 1 {
+		<-process.sent
+	}
+
+	expectBroadcast("Thanks for racing")
+
+	time.Sleep(chatBroadcastIntervalUnit * 3)
+
+	if len(process.sent) != 0 {
+		t.Errorf("Expected the rotation to stop when the session ends")
+	}
+
+	if err := raceControl.sendDriverJoinChatBroadcasts(3); err != nil {
+		t.Fatal(err)
+	}
+
+	expectChatReply(t, process.sent, 3, "Welcome")
+}
@@ -0,0 +1,327 @@
+package servermanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// chatCommandPrefix marks a chat message as a command for Server Manager, e.g. "!laps". Commands for acServer itself
+// start with adminCommandPrefix instead.
+const chatCommandPrefix = "!"
+
+// ChatCommandHandlerFunc runs a chat command, replying to the driver who sent it with request.Reply.
+type ChatCommandHandlerFunc func(request *ChatCommandRequest) error
+
+// ChatCommand is a command which drivers can run from the in-game chat by sending "!" followed by its Name.
+type ChatCommand struct {
+	Name        string
+	Description string
+
+	// Permission is the account group a driver needs to run the command. The driver's account is found by their GUID,
+	// so drivers who don't have an account can only run commands which don't need a permission.
+	Permission Group
+
+	Handler ChatCommandHandlerFunc
+}
+
+// ChatCommandRequest is a chat command sent by a driver.
+type ChatCommandRequest struct {
+	RaceControl *RaceControl
+
+	CarID      udp.CarID
+	DriverGUID udp.DriverGUID
+	DriverName string
+
+	Command string
+	Args    []string
+}
+
+// Reply sends message to the driver who sent the command, and only to them.
+func (r *ChatCommandRequest) Reply(message string) error {
+	return r.RaceControl.sendChatToCar(r.CarID, message)
+}
+
+// Replyf formats a message for Reply.
+func (r *ChatCommandRequest) Replyf(format string, args ...interface{}) error {
+	return r.Reply(fmt.Sprintf(format, args...))
+}
+
+var (
+	chatCommands      = make(map[string]*ChatCommand)
+	chatCommandsMutex sync.RWMutex
+)
+
+// RegisterChatCommand adds a command which drivers can run from the in-game chat. Like RegisterUDPHandler, it is meant
+// to be called from an init function, e.g. in a file added to cmd/server-manager. Registering a command with the same
+// name as an existing command replaces it, so the built in commands can be overridden.
+func RegisterChatCommand(command ChatCommand) {
+	if command.Handler == nil {
+		panic("servermanager: nil chat command handler")
+	}
+
+	command.Name = strings.ToLower(strings.TrimPrefix(command.Name, chatCommandPrefix))
+
+	if command.Name == "" {
+		panic("servermanager: chat command has no name")
+	}
+
+	chatCommandsMutex.Lock()
+	defer chatCommandsMutex.Unlock()
+
+	chatCommands[command.Name] = &command
+}
+
+func findChatCommand(name string) (*ChatCommand, bool) {
+	chatCommandsMutex.RLock()
+	defer chatCommandsMutex.RUnlock()
+
+	command, ok := chatCommands[strings.ToLower(name)]
+
+	return command, ok
+}
+
+// parseChatCommand splits a chat message into a command name and its arguments. It is false if message isn't a
+// command.
+func parseChatCommand(message string) (string, []string, bool) {
+	if !strings.HasPrefix(message, chatCommandPrefix) {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(message, chatCommandPrefix))
+
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+
+	return fields[0], fields[1:], true
+}
+
+// driverGroup is the account group of the account with driverGUID. Drivers without an account have GroupNoAccess.
+func (rc *RaceControl) driverGroup(driverGUID udp.DriverGUID) (Group, error) {
+	accounts, err := rc.store.ListAccounts()
+
+	if err != nil {
+		return GroupNoAccess, err
+	}
+
+	for _, account := range accounts {
+		if account.GUID != "" && account.GUID == string(driverGUID) && account.Deleted.IsZero() {
+			return account.Group(), nil
+		}
+	}
+
+	return GroupNoAccess, nil
+}
+
+// canRunChatCommand is whether the driver with driverGUID has the permission needed to run command.
+func (rc *RaceControl) canRunChatCommand(command *ChatCommand, driverGUID udp.DriverGUID) (bool, error) {
+	if command.Permission == "" || command.Permission == GroupNoAccess {
+		return true, nil
+	}
+
+	group, err := rc.driverGroup(driverGUID)
+
+	if err != nil {
+		return false, err
+	}
+
+	return Account{Groups: map[ServerID]Group{serverID: group}}.HasGroupPrivilege(command.Permission), nil
+}
+
+// OnChatCommand runs the command in chat, if it is one.
+func (rc *RaceControl) OnChatCommand(chat udp.Chat) error {
+	name, args, ok := parseChatCommand(chat.Message)
+
+	if !ok {
+		return nil
+	}
+
+	request := &ChatCommandRequest{
+		RaceControl: rc,
+		CarID:       chat.CarID,
+		DriverGUID:  chat.DriverGUID,
+		DriverName:  chat.DriverName,
+		Command:     strings.ToLower(name),
+		Args:        args,
+	}
+
+	command, ok := findChatCommand(name)
+
+	if !ok {
+		return request.Replyf("Unknown command %s%s, send %shelp to see the commands you can use", chatCommandPrefix, name, chatCommandPrefix)
+	}
+
+	allowed, err := rc.canRunChatCommand(command, chat.DriverGUID)
+
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return request.Replyf("You don't have permission to use %s%s", chatCommandPrefix, command.Name)
+	}
+
+	logrus.Debugf("Driver %s (%s) ran chat command %s%s", chat.DriverName, chat.DriverGUID, chatCommandPrefix, command.Name)
+
+	var handlerErr error
+
+	panicCapture(func() {
+		handlerErr = command.Handler(request)
+	})
+
+	return handlerErr
+}
+
+// sendChatToCar sends message to carID only, split into lines which fit in the in-game chat.
+func (rc *RaceControl) sendChatToCar(carID udp.CarID, message string) error {
+	for _, line := range strings.Split(wordwrap.WrapString(message, 60), "\n") {
+		sendChat, err := udp.NewSendChat(carID, line)
+
+		if err != nil {
+			return err
+		}
+
+		if err := rc.process.SendUDPMessage(sendChat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	RegisterChatCommand(ChatCommand{
+		Name:        "help",
+		Description: "lists the commands you can use",
+		Handler:     chatCommandHelp,
+	})
+
+	RegisterChatCommand(ChatCommand{
+		Name:        "laps",
+		Description: "shows your laps, last lap and best lap",
+		Handler:     chatCommandLaps,
+	})
+
+	RegisterChatCommand(ChatCommand{
+		Name:        "gap",
+		Description: "shows your position and gap to the car ahead",
+		Handler:     chatCommandGap,
+	})
+
+	RegisterChatCommand(ChatCommand{
+		Name:        "report",
+		Description: "reports an incident to the stewards, e.g. !report car 3 pushed me off at turn 1",
+		Handler:     chatCommandReport,
+	})
+}
+
+func chatCommandHelp(request *ChatCommandRequest) error {
+	chatCommandsMutex.RLock()
+	commands := make([]*ChatCommand, 0, len(chatCommands))
+
+	for _, command := range chatCommands {
+		commands = append(commands, command)
+	}
+	chatCommandsMutex.RUnlock()
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+
+	for _, command := range commands {
+		allowed, err := request.RaceControl.canRunChatCommand(command, request.DriverGUID)
+
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			continue
+		}
+
+		if err := request.Replyf("%s%s: %s", chatCommandPrefix, command.Name, command.Description); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func chatCommandLaps(request *ChatCommandRequest) error {
+	driver, err := request.RaceControl.findConnectedDriverByCarID(request.CarID)
+
+	if err != nil {
+		return err
+	}
+
+	driver.mutex.Lock()
+	car := *driver.CurrentCar()
+	driver.mutex.Unlock()
+
+	if car.NumLaps == 0 {
+		return request.Reply("You haven't completed a lap yet")
+	}
+
+	return request.Replyf("Laps: %d, last lap: %s, best lap: %s", car.NumLaps, formatChatLapTime(car.LastLap), formatChatLapTime(car.BestLap))
+}
+
+func chatCommandGap(request *ChatCommandRequest) error {
+	driver, err := request.RaceControl.findConnectedDriverByCarID(request.CarID)
+
+	if err != nil {
+		return err
+	}
+
+	driver.mutex.Lock()
+	position, split := driver.Position, driver.Split
+	driver.mutex.Unlock()
+
+	switch {
+	case position == 0:
+		return request.Reply("You don't have a position yet")
+	case position == 1:
+		return request.Reply("You are P1")
+	case split == "":
+		return request.Replyf("You are P%d", position)
+	default:
+		return request.Replyf("You are P%d, %s to the car ahead", position, split)
+	}
+}
+
+func chatCommandReport(request *ChatCommandRequest) error {
+	if len(request.Args) == 0 {
+		return request.Replyf("Say what happened, e.g. %sreport car 3 pushed me off at turn 1", chatCommandPrefix)
+	}
+
+	err := request.RaceControl.recordIncidentReport(IncidentReport{
+		Time:       time.Now(),
+		CarID:      request.CarID,
+		DriverGUID: request.DriverGUID,
+		DriverName: request.DriverName,
+		Message:    strings.Join(request.Args, " "),
+	})
+
+	if err == ErrNoIncidentSession {
+		return request.Reply("Reports can't be made until the session has started")
+	} else if err != nil {
+		return err
+	}
+
+	return request.Reply("Thanks, your report has been sent to the stewards")
+}
+
+func formatChatLapTime(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d:%06.3f", int(d.Minutes()), (d % time.Minute).Seconds())
+}
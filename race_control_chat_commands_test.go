@@ -0,0 +1,174 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestParseChatCommand(t *testing.T) {
+	for message, expected := range map[string]struct {
+		name string
+		args []string
+		ok   bool
+	}{
+		"!laps":                  {name: "laps", ok: true},
+		"!report  car 3  hit me": {name: "report", args: []string{"car", "3", "hit", "me"}, ok: true},
+		"hello !laps":            {},
+		"!":                      {},
+		"/admin password":        {},
+	} {
+		name, args, ok := parseChatCommand(message)
+
+		if name != expected.name || ok != expected.ok || len(args) != len(expected.args) || (len(args) > 0 && !reflect.DeepEqual(args, expected.args)) {
+			t.Errorf("%q: expected %s %v %t, got %s %v %t", message, expected.name, expected.args, expected.ok, name, args, ok)
+		}
+	}
+}
+
+func expectChatReply(t *testing.T, sent chan udp.Message, carID udp.CarID, text string) {
+	t.Helper()
+
+	expected, err := udp.NewSendChat(carID, text)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case message := <-sent:
+		if !reflect.DeepEqual(message, expected) {
+			t.Errorf("Expected reply %q to car %d, got %#v", text, carID, message)
+		}
+	default:
+		t.Errorf("Expected reply %q to car %d, nothing was sent", text, carID)
+	}
+}
+
+func TestRaceControl_OnChatCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-chat-commands")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(dir, dir)
+	process := sentUDPMessagesProcess{sent: make(chan udp.Message, 10)}
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, process, store, NewPenaltiesManager(store))
+
+	if err := raceControl.OnNewSession(udp.SessionInfo{Type: udp.SessionTypeRace, EventType: udp.EventNewSession}); err != nil {
+		t.Fatal(err)
+	}
+
+	driver := drivers[0]
+
+	if err := raceControl.OnClientConnect(driver); err != nil {
+		t.Fatal(err)
+	}
+
+	chat := func(message string) error {
+		return raceControl.OnChatCommand(udp.Chat{CarID: driver.CarID, DriverGUID: driver.DriverGUID, DriverName: driver.DriverName, Message: message})
+	}
+
+	ran := false
+
+	RegisterChatCommand(ChatCommand{
+		Name:        "!Test-Admin",
+		Description: "an admin command",
+		Permission:  GroupAdmin,
+		Handler: func(request *ChatCommandRequest) error {
+			ran = true
+
+			return request.Replyf("%s ran %s with %v", request.DriverName, request.Command, request.Args)
+		},
+	})
+
+	defer func() {
+		chatCommandsMutex.Lock()
+		delete(chatCommands, "test-admin")
+		chatCommandsMutex.Unlock()
+	}()
+
+	t.Run("Not a command", func(t *testing.T) {
+		if err := chat("hello"); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(process.sent) != 0 {
+			t.Errorf("Expected no reply to a chat message")
+		}
+	})
+
+	t.Run("Unknown command", func(t *testing.T) {
+		if err := chat("!nope"); err != nil {
+			t.Fatal(err)
+		}
+
+		expectChatReply(t, process.sent, driver.CarID, "Unknown command !nope, send !help to see the commands you")
+		expectChatReply(t, process.sent, driver.CarID, "can use")
+	})
+
+	t.Run("Gap", func(t *testing.T) {
+		if err := chat("!gap"); err != nil {
+			t.Fatal(err)
+		}
+
+		expectChatReply(t, process.sent, driver.CarID, "You are P1")
+	})
+
+	t.Run("No permission", func(t *testing.T) {
+		if err := chat("!test-admin"); err != nil {
+			t.Fatal(err)
+		}
+
+		if ran {
+			t.Error("Expected the command not to run for a driver without an account")
+		}
+
+		expectChatReply(t, process.sent, driver.CarID, "You don't have permission to use !test-admin")
+	})
+
+	t.Run("Permission from the driver's account", func(t *testing.T) {
+		account := NewAccount()
+		account.Name = "admin driver"
+		account.GUID = string(driver.DriverGUID)
+		account.Groups = map[ServerID]Group{serverID: GroupAdmin}
+
+		if err := store.UpsertAccount(account); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := chat("!TEST-ADMIN a b"); err != nil {
+			t.Fatal(err)
+		}
+
+		if !ran {
+			t.Error("Expected the command to run for an admin")
+		}
+
+		expectChatReply(t, process.sent, driver.CarID, "Test 1 ran test-admin with [a b]")
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		if err := chat("!report car 2 pushed me off"); err != nil {
+			t.Fatal(err)
+		}
+
+		expectChatReply(t, process.sent, driver.CarID, "Thanks, your report has been sent to the stewards")
+
+		stored, err := store.LoadSessionIncidents(raceControl.incidents.ID)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stored.Reports) != 1 || stored.Reports[0].Message != "car 2 pushed me off" || stored.Reports[0].DriverGUID != driver.DriverGUID {
+			t.Errorf("Expected the report to be stored, got %#v", stored.Reports)
+		}
+	})
+}
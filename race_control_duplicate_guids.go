@@ -0,0 +1,117 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DuplicateGUIDPolicyDisambiguate allows a connection whose GUID is already connected in another car, and gives it
+	// its own entry in the roster by suffixing its GUID with its car ID.
+	DuplicateGUIDPolicyDisambiguate = "disambiguate"
+
+	// DuplicateGUIDPolicyReject kicks a connection whose GUID is already connected in another car.
+	DuplicateGUIDPolicyReject = "reject"
+)
+
+var ErrInvalidDuplicateGUIDPolicy = errors.New("servermanager: duplicate_guid_policy must be either disambiguate or reject")
+
+func duplicateGUIDPolicy() string {
+	if config == nil || config.Server.DuplicateGUIDPolicy == "" {
+		return DuplicateGUIDPolicyDisambiguate
+	}
+
+	return config.Server.DuplicateGUIDPolicy
+}
+
+// DuplicateGUID is a connection which shared its GUID with a driver already connected in another car, usually
+// because the entry list has the same GUID in more than one slot.
+type DuplicateGUID struct {
+	DriverGUID udp.DriverGUID `json:"DriverGUID"`
+	DriverName string         `json:"DriverName"`
+	CarID      udp.CarID      `json:"CarID"`
+	OtherCarID udp.CarID      `json:"OtherCarID"`
+	Time       time.Time      `json:"Time" ts:"date"`
+
+	// RosterGUID is the GUID the connection is known by in the roster. It is empty if the connection was rejected.
+	RosterGUID udp.DriverGUID `json:"RosterGUID"`
+	Rejected   bool           `json:"Rejected"`
+}
+
+// handleDuplicateGUID applies the duplicate GUID policy to a client which shares its GUID with a driver that is
+// already connected in another car. It returns the client as it should be added to the roster, or false if the
+// client was rejected.
+func (rc *RaceControl) handleDuplicateGUID(client udp.SessionCarInfo) (udp.SessionCarInfo, bool) {
+	connectedDriver, ok := rc.ConnectedDrivers.Get(client.DriverGUID)
+
+	if !ok {
+		return client, true
+	}
+
+	connectedDriver.mutex.Lock()
+	otherCarID := connectedDriver.CarInfo.CarID
+	connectedDriver.mutex.Unlock()
+
+	if otherCarID == client.CarID {
+		// the same driver reconnecting in the same car
+		return client, true
+	}
+
+	duplicate := &DuplicateGUID{
+		DriverGUID: client.DriverGUID,
+		DriverName: client.DriverName,
+		CarID:      client.CarID,
+		OtherCarID: otherCarID,
+		Time:       time.Now(),
+	}
+
+	switch duplicateGUIDPolicy() {
+	case DuplicateGUIDPolicyReject:
+		duplicate.Rejected = true
+
+		logrus.Warnf("Driver %s (%s) connected in car %d, but their GUID is already connected in car %d. Kicking car %d, check the entry list for duplicate GUIDs", client.DriverName, client.DriverGUID, client.CarID, otherCarID, client.CarID)
+
+		if err := rc.process.SendUDPMessage(udp.NewKickUser(uint8(client.CarID))); err != nil {
+			logrus.WithError(err).Errorf("Could not kick car %d with duplicate GUID", client.CarID)
+		}
+	default:
+		duplicate.RosterGUID = udp.DriverGUID(fmt.Sprintf("%s-%d", client.DriverGUID, client.CarID))
+
+		logrus.Warnf("Driver %s (%s) connected in car %d, but their GUID is already connected in car %d. Tracking them as %s, check the entry list for duplicate GUIDs", client.DriverName, client.DriverGUID, client.CarID, otherCarID, duplicate.RosterGUID)
+
+		client.DriverGUID = duplicate.RosterGUID
+	}
+
+	rc.carIDToGUIDMutex.Lock()
+	rc.DuplicateGUIDs[client.CarID] = duplicate
+	rc.carIDToGUIDMutex.Unlock()
+
+	return client, !duplicate.Rejected
+}
+
+// resolveDuplicateGUID looks up a disconnecting client which was handled by handleDuplicateGUID when it connected.
+// It returns the client with the GUID it is known by in the roster, or false if it was rejected.
+func (rc *RaceControl) resolveDuplicateGUID(client udp.SessionCarInfo) (udp.SessionCarInfo, bool) {
+	rc.carIDToGUIDMutex.Lock()
+	defer rc.carIDToGUIDMutex.Unlock()
+
+	duplicate, ok := rc.DuplicateGUIDs[client.CarID]
+
+	if !ok || duplicate.DriverGUID != client.DriverGUID {
+		return client, true
+	}
+
+	delete(rc.DuplicateGUIDs, client.CarID)
+
+	if duplicate.Rejected {
+		return client, false
+	}
+
+	client.DriverGUID = duplicate.RosterGUID
+
+	return client, true
+}
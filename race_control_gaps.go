@@ -0,0 +1,253 @@
+package servermanager
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// liveGapPointsPerLap is how many points around a lap the time each car passes is recorded at. The gap between two
+// cars is the time between them passing the same point.
+const liveGapPointsPerLap = 100
+
+// liveGapPublishInterval is how often a driver's split is sent to live timing while they are on track.
+var liveGapPublishInterval = time.Second
+
+// EventRaceControlSplit is the udp.Event of a RaceControlSplit. Like RaceControl, it is only sent to live timing.
+const EventRaceControlSplit udp.Event = 242
+
+// RaceControlSplit is a driver's split to the car ahead, sent to live timing part way through a lap.
+type RaceControlSplit struct {
+	DriverGUID udp.DriverGUID `json:"DriverGUID"`
+	Split      string         `json:"Split"`
+}
+
+func (RaceControlSplit) Event() udp.Event {
+	return EventRaceControlSplit
+}
+
+type liveGapCar struct {
+	// lap is how many times the car has crossed the line, as of its last update. It is -1 for a car which is on the
+	// grid behind the line.
+	lap     int
+	spline  float64
+	updated time.Time
+	hasPos  bool
+
+	// passed is when the car passed each point, keyed by lap*liveGapPointsPerLap+point, for the last two laps.
+	passed map[int]time.Time
+	latest int
+
+	published time.Time
+}
+
+func (car *liveGapCar) distance() float64 {
+	return float64(car.lap) + car.spline
+}
+
+// liveGaps works out the time gaps between cars in a race from their positions around the track, so that live timing
+// can show a gap part way through a lap rather than only when a car completes one.
+type liveGaps struct {
+	mutex sync.Mutex
+	race  bool
+	cars  map[udp.CarID]*liveGapCar
+}
+
+// reset forgets every car at the start of a session. Splits are only published in races, other sessions' splits are
+// to the best lap ahead.
+func (g *liveGaps) reset(sessionType udp.SessionType) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.race = sessionType == udp.SessionTypeRace
+	g.cars = nil
+}
+
+func (g *liveGaps) remove(carID udp.CarID) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	delete(g.cars, carID)
+}
+
+// car must be called with g.mutex held.
+func (g *liveGaps) car(carID udp.CarID) *liveGapCar {
+	if g.cars == nil {
+		g.cars = make(map[udp.CarID]*liveGapCar)
+	}
+
+	car, ok := g.cars[carID]
+
+	if !ok {
+		car = &liveGapCar{passed: make(map[int]time.Time)}
+		g.cars[carID] = car
+	}
+
+	return car
+}
+
+// update records the points which carID has passed since its last update, at splinePos.
+func (g *liveGaps) update(carID udp.CarID, splinePos float32, now time.Time) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	car := g.car(carID)
+	spline := float64(splinePos)
+
+	if !car.hasPos {
+		car.hasPos = true
+		car.spline = spline
+		car.updated = now
+
+		if spline > 0.5 {
+			car.lap = -1
+		}
+
+		return
+	}
+
+	previous := car.distance()
+
+	if car.spline > 0.9 && spline < 0.1 {
+		car.lap++
+	} else if car.spline < 0.1 && spline > 0.9 {
+		// reversed back over the line.
+		car.lap--
+	}
+
+	car.spline = spline
+	current := car.distance()
+
+	// a car which has gone backwards, or jumped a long way (e.g. back to the pits), hasn't passed anywhere in between.
+	if current > previous && current-previous < 0.5 {
+		elapsed := now.Sub(car.updated)
+
+		for point := int(math.Floor(previous*liveGapPointsPerLap)) + 1; float64(point) <= current*liveGapPointsPerLap; point++ {
+			// the car passed the point somewhere between its two updates, assume at a constant speed.
+			fraction := (float64(point)/liveGapPointsPerLap - previous) / (current - previous)
+
+			car.passed[point] = car.updated.Add(time.Duration(fraction * float64(elapsed)))
+			car.latest = point
+		}
+
+		for point := range car.passed {
+			if point <= car.latest-2*liveGapPointsPerLap {
+				delete(car.passed, point)
+			}
+		}
+	}
+
+	car.updated = now
+}
+
+// lapCompleted lines carID's lap count up with acServer's. Cars' points can only be compared while their lap counts
+// agree, so the points recorded with a wrong lap count are thrown away.
+func (g *liveGaps) lapCompleted(carID udp.CarID, laps int) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	car := g.car(carID)
+
+	if car.hasPos && car.spline > 0.5 {
+		// the car hasn't been seen over the line yet.
+		laps--
+	}
+
+	if car.lap != laps {
+		car.lap = laps
+		car.passed = make(map[int]time.Time)
+	}
+}
+
+// split is how far behind ahead the car behind is, e.g. "+1.2s", or "1 lap" if ahead has lapped it. It is false if
+// the gap isn't known, e.g. if the cars haven't been seen at the same point.
+func (g *liveGaps) split(behind, ahead udp.CarID) (string, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	behindCar, ok := g.cars[behind]
+
+	if !ok || len(behindCar.passed) == 0 {
+		return "", false
+	}
+
+	aheadCar, ok := g.cars[ahead]
+
+	if !ok || len(aheadCar.passed) == 0 {
+		return "", false
+	}
+
+	if laps := (aheadCar.latest - behindCar.latest) / liveGapPointsPerLap; laps == 1 {
+		return "1 lap", true
+	} else if laps > 1 {
+		return fmt.Sprintf("%d laps", laps), true
+	}
+
+	aheadPassed, ok := aheadCar.passed[behindCar.latest]
+
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("+%.1fs", behindCar.passed[behindCar.latest].Sub(aheadPassed).Seconds()), true
+}
+
+// shouldPublish is true at most once every liveGapPublishInterval for each car in a race.
+func (g *liveGaps) shouldPublish(carID udp.CarID, now time.Time) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if !g.race {
+		return false
+	}
+
+	car := g.car(carID)
+
+	if now.Sub(car.published) < liveGapPublishInterval {
+		return false
+	}
+
+	car.published = now
+
+	return true
+}
+
+// publishLiveSplit works out driver's split to the car ahead of them from where both cars are on track, and sends it
+// to live timing if it has changed. It must be called with driver.mutex held.
+func (rc *RaceControl) publishLiveSplit(driver *RaceControlDriver) error {
+	if driver.Position <= 1 {
+		return nil
+	}
+
+	var ahead *RaceControlDriver
+
+	_ = rc.ConnectedDrivers.Each(func(otherDriverGUID udp.DriverGUID, otherDriver *RaceControlDriver) error {
+		if otherDriver.Position == driver.Position-1 {
+			ahead = otherDriver
+		}
+
+		return nil
+	})
+
+	if ahead == nil {
+		return nil
+	}
+
+	split, ok := rc.liveGaps.split(driver.CarInfo.CarID, ahead.CarInfo.CarID)
+
+	if !ok || split == driver.Split {
+		return nil
+	}
+
+	driver.Split = split
+
+	_, err := rc.broadcaster.Send(RaceControlSplit{
+		DriverGUID: driver.CarInfo.DriverGUID,
+		Split:      split,
+	})
+
+	return err
+}
@@ -0,0 +1,109 @@
+package servermanager
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// driveLiveGapCar moves carID around the track at a lap a minute, from start (a distance in laps) for duration,
+// updating every 250ms.
+func driveLiveGapCar(g *liveGaps, carID udp.CarID, raceStart time.Time, start float64, delay, duration time.Duration) {
+	for elapsed := time.Duration(0); elapsed <= duration; elapsed += 250 * time.Millisecond {
+		distance := start + elapsed.Minutes()
+
+		g.update(carID, float32(distance-math.Floor(distance)), raceStart.Add(delay+elapsed))
+	}
+}
+
+func TestLiveGaps(t *testing.T) {
+	raceStart := time.Now()
+
+	t.Run("Gap between two cars part way through a lap", func(t *testing.T) {
+		var g liveGaps
+		g.reset(udp.SessionTypeRace)
+
+		// both cars start on the grid behind the line, car 2 crosses the line 1.5s after car 1.
+		driveLiveGapCar(&g, 1, raceStart, -0.02, 0, 40*time.Second)
+		driveLiveGapCar(&g, 2, raceStart, -0.02, 1500*time.Millisecond, 38*time.Second)
+
+		split, ok := g.split(2, 1)
+
+		if !ok {
+			t.Fatal("Expected a split")
+		}
+
+		if split != "+1.5s" {
+			t.Errorf("Expected split to be +1.5s, was %s", split)
+		}
+	})
+
+	t.Run("Car ahead has lapped the car behind", func(t *testing.T) {
+		var g liveGaps
+		g.reset(udp.SessionTypeRace)
+
+		// car 2 stops half way around the first lap.
+		driveLiveGapCar(&g, 1, raceStart, -0.02, 0, 90*time.Second)
+		driveLiveGapCar(&g, 2, raceStart, -0.02, 0, 30*time.Second)
+
+		split, ok := g.split(2, 1)
+
+		if !ok || split != "1 lap" {
+			t.Errorf("Expected split to be 1 lap, was %s (%t)", split, ok)
+		}
+	})
+
+	t.Run("Unknown gaps", func(t *testing.T) {
+		var g liveGaps
+		g.reset(udp.SessionTypeRace)
+
+		if _, ok := g.split(2, 1); ok {
+			t.Error("Expected no split for cars which haven't been seen")
+		}
+
+		// car 2 is ahead of car 1 on track, so car 1 hasn't passed where car 2 is yet.
+		driveLiveGapCar(&g, 1, raceStart, 0.1, 0, 6*time.Second)
+		driveLiveGapCar(&g, 2, raceStart, 0.3, 0, 6*time.Second)
+
+		if _, ok := g.split(2, 1); ok {
+			t.Error("Expected no split for a car which is ahead on track")
+		}
+	})
+
+	t.Run("Lap count disagrees with acServer", func(t *testing.T) {
+		var g liveGaps
+		g.reset(udp.SessionTypeRace)
+
+		driveLiveGapCar(&g, 1, raceStart, 0.1, 0, 6*time.Second)
+		g.lapCompleted(1, 3)
+
+		if g.cars[1].lap != 3 || len(g.cars[1].passed) != 0 {
+			t.Errorf("Expected car to be on lap 3 with no points, was on lap %d with %d", g.cars[1].lap, len(g.cars[1].passed))
+		}
+	})
+
+	t.Run("Publishing", func(t *testing.T) {
+		var g liveGaps
+		g.reset(udp.SessionTypeRace)
+
+		if !g.shouldPublish(1, raceStart) {
+			t.Error("Expected the first split to be published")
+		}
+
+		if g.shouldPublish(1, raceStart.Add(liveGapPublishInterval/2)) {
+			t.Error("Expected splits to be published at most once an interval")
+		}
+
+		if !g.shouldPublish(1, raceStart.Add(liveGapPublishInterval)) {
+			t.Error("Expected the split to be published after an interval")
+		}
+
+		g.reset(udp.SessionTypeQualifying)
+
+		if g.shouldPublish(1, raceStart) {
+			t.Error("Expected splits not to be published outside of races")
+		}
+	})
+}
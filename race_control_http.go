@@ -2,6 +2,7 @@ package servermanager
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -353,13 +354,13 @@ func (rch *RaceControlHandler) kickUser(w http.ResponseWriter, r *http.Request)
 			return nil
 		}
 
-		command, err := udp.NewAdminCommand("/kick " + driver.CarInfo.DriverName)
-
-		if err != nil {
+		if err := rch.serverProcess.KickCar(driver.CarInfo.CarID); err != nil {
 			return err
 		}
 
-		return rch.serverProcess.SendUDPMessage(command)
+		auditAction(rch.store, r, fmt.Sprintf("Kicked car %d (%s, %s)", driver.CarInfo.CarID, driver.CarInfo.DriverName, driverGUID))
+
+		return nil
 	})
 
 	if err != nil {
@@ -386,24 +387,28 @@ func (rch *RaceControlHandler) sendChat(w http.ResponseWriter, r *http.Request)
 }
 
 func (rch *RaceControlHandler) restartSession(w http.ResponseWriter, r *http.Request) {
-	err := rch.serverProcess.SendUDPMessage(&udp.RestartSession{})
+	err := rch.serverProcess.RestartSession()
 
 	if err != nil {
 		logrus.WithError(err).Errorf("Unable to restart session")
 
 		AddErrorFlash(w, r, "The server was unable to restart the session!")
+	} else {
+		auditAction(rch.store, r, "Restarted the session")
 	}
 
 	http.Redirect(w, r, "/live-timing", http.StatusFound)
 }
 
 func (rch *RaceControlHandler) nextSession(w http.ResponseWriter, r *http.Request) {
-	err := rch.serverProcess.SendUDPMessage(&udp.NextSession{})
+	err := rch.serverProcess.NextSession()
 
 	if err != nil {
 		logrus.WithError(err).Errorf("Unable to move to next session")
 
 		AddErrorFlash(w, r, "The server was unable to move to the next session!")
+	} else {
+		auditAction(rch.store, r, "Moved to the next session")
 	}
 
 	http.Redirect(w, r, "/live-timing", http.StatusFound)
@@ -437,3 +442,10 @@ func (rch *RaceControlHandler) countdown(w http.ResponseWriter, r *http.Request)
 		}
 	}
 }
+
+// stints returns who has driven each car in the current session, and for how long.
+func (rch *RaceControlHandler) stints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(rch.raceControl.Stints())
+}
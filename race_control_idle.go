@@ -0,0 +1,164 @@
+package servermanager
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+const (
+	// idleMovementThreshold is how far (in metres) a car has to move from where it stopped to no longer be idle, so
+	// that a car rolling in its pit box still counts as stationary.
+	idleMovementThreshold = 5
+
+	idleDefaultWarning = time.Minute
+)
+
+// IdleKickConfig kicks drivers who have been stationary, in the pits or on track, for Timeout, to free up their slot
+// on busy servers. Drivers are warned in the chat Warning before they are kicked. Idle drivers are found from their
+// car updates, so the real time pos interval must be set in the server options.
+type IdleKickConfig struct {
+	// Timeout is how long a driver can be stationary before they are kicked. Drivers are never kicked if it is zero.
+	Timeout time.Duration `yaml:"timeout"`
+	Warning time.Duration `yaml:"warning"`
+
+	// ExemptGUIDs are drivers who are never kicked, e.g. admins who spectate from their car.
+	ExemptGUIDs []string `yaml:"exempt_guids"`
+}
+
+func idleKickConfig() IdleKickConfig {
+	if config == nil {
+		return IdleKickConfig{}
+	}
+
+	conf := config.Server.IdleKick
+
+	if conf.Warning <= 0 {
+		conf.Warning = idleDefaultWarning
+	}
+
+	if conf.Warning > conf.Timeout {
+		conf.Warning = conf.Timeout
+	}
+
+	return conf
+}
+
+func (c IdleKickConfig) exempt(driverGUID udp.DriverGUID) bool {
+	for _, guid := range c.ExemptGUIDs {
+		if guid == string(driverGUID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type idleAction int
+
+const (
+	idleActionNone idleAction = iota
+	idleActionWarn
+	idleActionKick
+)
+
+type idleCar struct {
+	// pos is where the car stopped, at since.
+	pos    udp.Vec
+	since  time.Time
+	warned bool
+	kicked bool
+}
+
+// idleDrivers tracks how long each car has been stationary for.
+type idleDrivers struct {
+	mutex sync.Mutex
+	cars  map[udp.CarID]*idleCar
+}
+
+// reset starts every car's idle time again at the start of a session, so that time spent waiting between sessions
+// isn't counted.
+func (d *idleDrivers) reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.cars = nil
+}
+
+func (d *idleDrivers) remove(carID udp.CarID) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.cars, carID)
+}
+
+// update records carID's position at now, and returns what should be done about the car being idle. Each car is
+// warned and kicked once.
+func (d *idleDrivers) update(carID udp.CarID, pos udp.Vec, now time.Time, conf IdleKickConfig) (idleAction, time.Duration) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.cars == nil {
+		d.cars = make(map[udp.CarID]*idleCar)
+	}
+
+	car, ok := d.cars[carID]
+
+	if !ok || vecDistance(car.pos, pos) > idleMovementThreshold {
+		d.cars[carID] = &idleCar{pos: pos, since: now}
+		return idleActionNone, 0
+	}
+
+	idle := now.Sub(car.since)
+
+	switch {
+	case car.kicked:
+		return idleActionNone, idle
+	case idle >= conf.Timeout:
+		car.kicked = true
+		return idleActionKick, idle
+	case !car.warned && idle >= conf.Timeout-conf.Warning:
+		car.warned = true
+		return idleActionWarn, idle
+	default:
+		return idleActionNone, idle
+	}
+}
+
+func vecDistance(a, b udp.Vec) float64 {
+	x, y, z := float64(a.X-b.X), float64(a.Y-b.Y), float64(a.Z-b.Z)
+
+	return math.Sqrt(x*x + y*y + z*z)
+}
+
+// checkIdle warns or kicks the driver of a car update if they have been idle for too long. It must be called with
+// driver.mutex held.
+func (rc *RaceControl) checkIdle(driver *RaceControlDriver, update udp.CarUpdate, now time.Time) error {
+	conf := idleKickConfig()
+
+	if conf.Timeout <= 0 || conf.exempt(driver.CarInfo.DriverGUID) {
+		return nil
+	}
+
+	action, idle := rc.idleDrivers.update(update.CarID, update.Pos, now, conf)
+
+	switch action {
+	case idleActionWarn:
+		return rc.sendChatToCar(update.CarID, fmt.Sprintf("Idle for %s, you will be kicked in %s unless you move", idle.Round(time.Second), (conf.Timeout-idle).Round(time.Second)))
+	case idleActionKick:
+		logrus.Infof("Kicking %s (%s) from car %d, they have been idle for %s", driver.CarInfo.DriverName, driver.CarInfo.DriverGUID, update.CarID, idle.Round(time.Second))
+
+		if err := rc.sendChatToCar(update.CarID, "You have been kicked for being idle"); err != nil {
+			logrus.WithError(err).Warnf("Could not tell car %d why it is being kicked", update.CarID)
+		}
+
+		return rc.process.KickCar(update.CarID)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestIdleDrivers_Update(t *testing.T) {
+	var idle idleDrivers
+
+	conf := IdleKickConfig{Timeout: time.Minute * 5, Warning: time.Minute}
+	start := time.Date(2020, 5, 1, 19, 0, 0, 0, time.UTC)
+	pitBox := udp.Vec{X: 100, Z: 200}
+
+	for _, step := range []struct {
+		after  time.Duration
+		pos    udp.Vec
+		action idleAction
+	}{
+		{0, pitBox, idleActionNone},
+		// rolling a little in the pit box doesn't count as moving.
+		{time.Minute * 3, udp.Vec{X: 102, Z: 200}, idleActionNone},
+		{time.Minute * 4, pitBox, idleActionWarn},
+		{time.Minute*4 + time.Second, pitBox, idleActionNone},
+		// moving away starts the idle time again.
+		{time.Minute*4 + time.Second*30, udp.Vec{X: 150, Z: 200}, idleActionNone},
+		{time.Minute * 9, udp.Vec{X: 150, Z: 200}, idleActionWarn},
+		{time.Minute * 10, udp.Vec{X: 150, Z: 200}, idleActionKick},
+		{time.Minute * 11, udp.Vec{X: 150, Z: 200}, idleActionNone},
+	} {
+		action, _ := idle.update(1, step.pos, start.Add(step.after), conf)
+
+		if action != step.action {
+			t.Errorf("After %s: expected action %d, got %d", step.after, step.action, action)
+		}
+	}
+}
+
+func TestRaceControl_CheckIdle(t *testing.T) {
+	oldConfig := config
+
+	defer func() {
+		config = oldConfig
+	}()
+
+	config = &Configuration{Server: ServerExtraConfig{IdleKick: IdleKickConfig{Timeout: time.Minute * 5, ExemptGUIDs: []string{string(drivers[1].DriverGUID)}}}}
+
+	process := sentUDPMessagesProcess{sent: make(chan udp.Message, 10)}
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, process, testStore, NewPenaltiesManager(testStore))
+
+	now := time.Now()
+
+	for _, driver := range drivers[:2] {
+		if err := raceControl.OnClientConnect(driver); err != nil {
+			t.Fatal(err)
+		}
+
+		connected, err := raceControl.findConnectedDriverByCarID(driver.CarID)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, after := range []time.Duration{0, time.Minute * 4} {
+			if err := raceControl.checkIdle(connected, udp.CarUpdate{CarID: driver.CarID}, now.Add(after)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	expectChatReply(t, process.sent, drivers[0].CarID, "Idle for 4m0s, you will be kicked in 1m0s unless you move")
+
+	if len(process.sent) != 0 {
+		t.Errorf("Expected the exempt driver not to be warned")
+	}
+}
@@ -0,0 +1,230 @@
+package servermanager
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// IncidentSeverity is how bad an incident was, judged by the fastest impact in it.
+type IncidentSeverity string
+
+const (
+	IncidentSeverityMinor    IncidentSeverity = "minor"
+	IncidentSeverityModerate IncidentSeverity = "moderate"
+	IncidentSeverityMajor    IncidentSeverity = "major"
+)
+
+const (
+	// incidentModerateSpeed and incidentMajorSpeed are the impact speeds, in km/h, from which an incident is moderate
+	// and major. Slower impacts are minor.
+	incidentModerateSpeed = 25
+	incidentMajorSpeed    = 60
+)
+
+// incidentWindow is how long after a collision another collision between the same cars is counted as part of the same
+// incident, e.g. a car bouncing off another car, or both cars reporting the same collision.
+var incidentWindow = 5 * time.Second
+
+var (
+	ErrSessionIncidentsNotFound = errors.New("servermanager: session incidents not found")
+	ErrNoIncidentSession        = errors.New("servermanager: no session to record incidents in")
+)
+
+func incidentSeverity(speed float64) IncidentSeverity {
+	switch {
+	case speed >= incidentMajorSpeed:
+		return IncidentSeverityMajor
+	case speed >= incidentModerateSpeed:
+		return IncidentSeverityModerate
+	default:
+		return IncidentSeverityMinor
+	}
+}
+
+type IncidentCar struct {
+	CarID      udp.CarID      `json:"CarID"`
+	DriverGUID udp.DriverGUID `json:"DriverGUID"`
+	DriverName string         `json:"DriverName"`
+}
+
+// Incident is one or more collisions between the same cars, or between a car and the environment, in quick succession.
+type Incident struct {
+	ID   string        `json:"ID"`
+	Type CollisionType `json:"Type"`
+
+	// Cars are the cars involved in the incident, in car ID order.
+	Cars []IncidentCar `json:"Cars"`
+
+	StartTime  time.Time `json:"StartTime" ts:"date"`
+	EndTime    time.Time `json:"EndTime" ts:"date"`
+	Collisions int       `json:"Collisions"`
+
+	// MaxSpeed is the fastest impact in the incident, in km/h.
+	MaxSpeed float64          `json:"MaxSpeed"`
+	Severity IncidentSeverity `json:"Severity"`
+
+	// WorldPos is where the first collision in the incident happened.
+	WorldPos udp.Vec `json:"WorldPos"`
+}
+
+func (i *Incident) involves(incidentType CollisionType, cars []IncidentCar) bool {
+	if i.Type != incidentType || len(i.Cars) != len(cars) {
+		return false
+	}
+
+	for index, car := range cars {
+		if i.Cars[index].CarID != car.CarID {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IncidentReport is a driver's report of an incident, sent from the in-game chat.
+type IncidentReport struct {
+	Time       time.Time      `json:"Time" ts:"date"`
+	CarID      udp.CarID      `json:"CarID"`
+	DriverGUID udp.DriverGUID `json:"DriverGUID"`
+	DriverName string         `json:"DriverName"`
+	Message    string         `json:"Message"`
+}
+
+// SessionIncidents are the incidents in a session, kept in the store for stewarding after the session has ended.
+type SessionIncidents struct {
+	ID          string          `json:"ID"`
+	SessionType udp.SessionType `json:"SessionType"`
+	SessionName string          `json:"SessionName"`
+	Track       string          `json:"Track"`
+	TrackLayout string          `json:"TrackLayout"`
+	StartTime   time.Time       `json:"StartTime" ts:"date"`
+
+	// ResultsFile is the name of the results file acServer wrote at the end of the session, once it has ended.
+	ResultsFile string `json:"ResultsFile"`
+
+	Incidents []*Incident       `json:"Incidents"`
+	Reports   []*IncidentReport `json:"Reports"`
+	Updated   time.Time         `json:"Updated" ts:"date"`
+}
+
+func NewSessionIncidents(sessionInfo udp.SessionInfo, startTime time.Time) *SessionIncidents {
+	return &SessionIncidents{
+		ID:          uuid.New().String(),
+		SessionType: sessionInfo.Type,
+		SessionName: sessionInfo.Name,
+		Track:       sessionInfo.Track,
+		TrackLayout: sessionInfo.TrackConfig,
+		StartTime:   startTime,
+	}
+}
+
+// AddCollision adds a collision to the incident it is part of, or to a new incident if the cars involved haven't
+// collided in the last incidentWindow.
+func (si *SessionIncidents) AddCollision(incidentType CollisionType, cars []IncidentCar, speed float64, worldPos udp.Vec, at time.Time) *Incident {
+	sort.Slice(cars, func(i, j int) bool {
+		return cars[i].CarID < cars[j].CarID
+	})
+
+	for i := len(si.Incidents) - 1; i >= 0; i-- {
+		incident := si.Incidents[i]
+
+		if at.Sub(incident.EndTime) > incidentWindow {
+			continue
+		}
+
+		if incident.involves(incidentType, cars) {
+			incident.EndTime = at
+			incident.Collisions++
+
+			if speed > incident.MaxSpeed {
+				incident.MaxSpeed = speed
+				incident.Severity = incidentSeverity(speed)
+			}
+
+			return incident
+		}
+	}
+
+	incident := &Incident{
+		ID:         uuid.New().String(),
+		Type:       incidentType,
+		Cars:       cars,
+		StartTime:  at,
+		EndTime:    at,
+		Collisions: 1,
+		MaxSpeed:   speed,
+		Severity:   incidentSeverity(speed),
+		WorldPos:   worldPos,
+	}
+
+	si.Incidents = append(si.Incidents, incident)
+
+	return incident
+}
+
+func (rc *RaceControl) incidentCar(carID udp.CarID) IncidentCar {
+	car := IncidentCar{CarID: carID}
+
+	if driver, err := rc.findConnectedDriverByCarID(carID); err == nil {
+		car.DriverGUID = driver.CarInfo.DriverGUID
+		car.DriverName = driver.CarInfo.DriverName
+	}
+
+	return car
+}
+
+// recordIncident adds a collision to the current session's incidents and stores them. Collisions before the first
+// session has started are ignored.
+func (rc *RaceControl) recordIncident(incidentType CollisionType, cars []IncidentCar, speed float64, worldPos udp.Vec) error {
+	rc.incidentsMutex.Lock()
+	defer rc.incidentsMutex.Unlock()
+
+	if rc.incidents == nil {
+		return nil
+	}
+
+	rc.incidents.AddCollision(incidentType, cars, speed, worldPos, time.Now())
+
+	return rc.store.UpsertSessionIncidents(rc.incidents)
+}
+
+// recordIncidentReport adds a driver's report to the current session's incidents and stores them.
+func (rc *RaceControl) recordIncidentReport(report IncidentReport) error {
+	rc.incidentsMutex.Lock()
+	defer rc.incidentsMutex.Unlock()
+
+	if rc.incidents == nil {
+		return ErrNoIncidentSession
+	}
+
+	rc.incidents.Reports = append(rc.incidents.Reports, &report)
+
+	return rc.store.UpsertSessionIncidents(rc.incidents)
+}
+
+// newIncidentSession starts keeping incidents for a new session.
+func (rc *RaceControl) newIncidentSession(sessionInfo udp.SessionInfo, startTime time.Time) {
+	rc.incidentsMutex.Lock()
+	defer rc.incidentsMutex.Unlock()
+
+	rc.incidents = NewSessionIncidents(sessionInfo, startTime)
+}
+
+// endIncidentSession links the current session's incidents to the session's results.
+func (rc *RaceControl) endIncidentSession(resultsFile string) error {
+	rc.incidentsMutex.Lock()
+	defer rc.incidentsMutex.Unlock()
+
+	if rc.incidents == nil || (len(rc.incidents.Incidents) == 0 && len(rc.incidents.Reports) == 0) {
+		return nil
+	}
+
+	rc.incidents.ResultsFile = resultsFile
+
+	return rc.store.UpsertSessionIncidents(rc.incidents)
+}
@@ -0,0 +1,108 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestSessionIncidents_AddCollision(t *testing.T) {
+	now := time.Now()
+
+	carA := IncidentCar{CarID: 1, DriverGUID: "1", DriverName: "A"}
+	carB := IncidentCar{CarID: 2, DriverGUID: "2", DriverName: "B"}
+
+	t.Run("Both cars reporting the same collision", func(t *testing.T) {
+		si := NewSessionIncidents(udp.SessionInfo{Type: udp.SessionTypeRace}, now)
+
+		si.AddCollision(CollisionWithCar, []IncidentCar{carA, carB}, 20, udp.Vec{X: 10}, now)
+		si.AddCollision(CollisionWithCar, []IncidentCar{carB, carA}, 65, udp.Vec{X: 11}, now.Add(100*time.Millisecond))
+
+		if len(si.Incidents) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(si.Incidents))
+		}
+
+		incident := si.Incidents[0]
+
+		if incident.Collisions != 2 || incident.MaxSpeed != 65 || incident.Severity != IncidentSeverityMajor {
+			t.Errorf("Unexpected incident: %d collisions, %f max speed, %s", incident.Collisions, incident.MaxSpeed, incident.Severity)
+		}
+
+		if incident.WorldPos.X != 10 || !incident.EndTime.Equal(now.Add(100*time.Millisecond)) {
+			t.Errorf("Expected incident to start at the first collision and end at the last")
+		}
+
+		if incident.Cars[0].CarID != 1 || incident.Cars[1].CarID != 2 {
+			t.Errorf("Expected incident cars to be in car ID order")
+		}
+	})
+
+	t.Run("Separate incidents", func(t *testing.T) {
+		si := NewSessionIncidents(udp.SessionInfo{Type: udp.SessionTypeRace}, now)
+
+		si.AddCollision(CollisionWithCar, []IncidentCar{carA, carB}, 10, udp.Vec{}, now)
+		// a different car
+		si.AddCollision(CollisionWithCar, []IncidentCar{carA, {CarID: 3}}, 10, udp.Vec{}, now.Add(time.Second))
+		// the environment
+		si.AddCollision(CollisionWithEnvironment, []IncidentCar{carA}, 30, udp.Vec{}, now.Add(2*time.Second))
+		// the same cars, long after their first collision
+		si.AddCollision(CollisionWithCar, []IncidentCar{carA, carB}, 10, udp.Vec{}, now.Add(incidentWindow+time.Second))
+
+		if len(si.Incidents) != 4 {
+			t.Fatalf("Expected 4 incidents, got %d", len(si.Incidents))
+		}
+
+		if si.Incidents[2].Severity != IncidentSeverityModerate {
+			t.Errorf("Expected the environment incident to be moderate, was %s", si.Incidents[2].Severity)
+		}
+	})
+}
+
+func TestRaceControl_Incidents(t *testing.T) {
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore))
+
+	if err := raceControl.OnNewSession(udp.SessionInfo{Track: "ks_laguna_seca", Type: udp.SessionTypeRace, EventType: udp.EventNewSession}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, driver := range drivers[:2] {
+		if err := raceControl.OnClientConnect(driver); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := raceControl.OnCollisionWithCar(udp.CollisionWithCar{CarID: 1, OtherCarID: 2, ImpactSpeed: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := raceControl.OnCollisionWithCar(udp.CollisionWithCar{CarID: 2, OtherCarID: 1, ImpactSpeed: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := raceControl.OnCollisionWithEnvironment(udp.CollisionWithEnvironment{CarID: 2, ImpactSpeed: 20}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := raceControl.OnEndSession("results/2020_1_1_12_0_RACE.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := testStore.LoadSessionIncidents(raceControl.incidents.ID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored.Incidents) != 2 {
+		t.Fatalf("Expected 2 stored incidents, got %d", len(stored.Incidents))
+	}
+
+	if stored.ResultsFile != "2020_1_1_12_0_RACE.json" || stored.Track != "ks_laguna_seca" {
+		t.Errorf("Unexpected session for incidents: %s at %s", stored.ResultsFile, stored.Track)
+	}
+
+	if carInfo := stored.Incidents[0].Cars[1]; carInfo.DriverGUID != drivers[1].DriverGUID || carInfo.DriverName != drivers[1].DriverName {
+		t.Errorf("Expected the other car's driver to be stored, got %s (%s)", carInfo.DriverName, carInfo.DriverGUID)
+	}
+}
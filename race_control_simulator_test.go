@@ -0,0 +1,91 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp/simulator"
+)
+
+func TestRaceControl_SimulatedEvent(t *testing.T) {
+	event := simulator.Event{
+		ServerName: "Simulated Server",
+		Track:      "ks_vallelunga",
+		Start:      time.Now(),
+		Sessions: []simulator.Session{
+			{
+				Name: "Race",
+				Type: udp.SessionTypeRace,
+				Laps: 3,
+				LapTimes: map[udp.CarID][]time.Duration{
+					drivers[0].CarID: {time.Second * 90, time.Second * 89, time.Second * 91},
+					drivers[1].CarID: {time.Second * 92, time.Second * 90, time.Second * 87},
+				},
+				Collisions: []simulator.Collision{
+					{At: time.Second * 45, CarID: drivers[0].CarID, OtherCarID: drivers[1].CarID, ImpactSpeed: 30},
+				},
+			},
+		},
+		// car updates are left out: they are handled on a goroutine per car, which would race with live timings being
+		// saved as laps are completed, since the script is played much faster than acServer would send it.
+	}
+
+	for _, driver := range drivers[:2] {
+		event.Drivers = append(event.Drivers, simulator.Driver{
+			CarID:    driver.CarID,
+			Name:     driver.DriverName,
+			GUID:     driver.DriverGUID,
+			CarModel: driver.CarModel,
+			CarSkin:  driver.CarSkin,
+		})
+	}
+
+	dir, err := ioutil.TempDir("", "asm-simulated-event")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// live timings from an earlier run at the same track would be carried on, so use a store of our own.
+	store := NewJSONStore(dir, dir)
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, store, NewPenaltiesManager(store))
+
+	for _, step := range event.Script() {
+		if _, ok := step.Message.(udp.EndSession); ok {
+			// the simulator doesn't write results files.
+			break
+		}
+
+		raceControl.UDPCallback(step.Message)
+	}
+
+	if raceControl.ConnectedDrivers.Len() != 2 {
+		t.Fatalf("Expected 2 connected drivers, got %d", raceControl.ConnectedDrivers.Len())
+	}
+
+	for i, driver := range drivers[:2] {
+		connected, err := raceControl.findConnectedDriverByCarID(driver.CarID)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if connected.TotalNumLaps != 3 {
+			t.Errorf("Expected %s to have completed 3 laps, got %d", driver.DriverName, connected.TotalNumLaps)
+		}
+
+		// collisions are recorded against the car which reported them.
+		if expected := 1 - i; len(connected.Collisions) != expected {
+			t.Errorf("Expected %s to have %d collisions, got %d", driver.DriverName, expected, len(connected.Collisions))
+		}
+
+		if expected := 2 - i; connected.Position != expected {
+			t.Errorf("Expected %s to finish P%d, got P%d", driver.DriverName, expected, connected.Position)
+		}
+	}
+}
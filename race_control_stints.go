@@ -0,0 +1,314 @@
+package servermanager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// stintMaximumWarning is how long before the end of the maximum stint time a driver is told to swap.
+const stintMaximumWarning = time.Minute * 5
+
+// Stint is a spell of one driver driving a car. A stint carries on if its driver reconnects, e.g. after their game
+// crashes, and ends when a different driver connects in the car.
+type Stint struct {
+	DriverGUID udp.DriverGUID
+	DriverName string
+	Start      time.Time
+
+	// End is when the driver disconnected. It is zero while they are driving.
+	End  time.Time
+	Laps int
+
+	warned, overMaximum bool
+}
+
+// Duration is how long the stint lasted, or has lasted so far.
+func (s Stint) Duration(now time.Time) time.Duration {
+	if !s.End.IsZero() {
+		now = s.End
+	}
+
+	return now.Sub(s.Start)
+}
+
+// CarStints are the stints of a car in the session so far, in the order they were driven.
+type CarStints struct {
+	CarID  udp.CarID
+	Stints []Stint
+}
+
+// carStints tracks who has driven each car during a session, so that driver swaps can be found from drivers
+// disconnecting and connecting in the same car.
+type carStints struct {
+	mutex sync.Mutex
+	cars  map[udp.CarID][]*Stint
+}
+
+// reset forgets the stints of the last session, starting a stint for each of drivers, who are already in their cars.
+func (c *carStints) reset(drivers []udp.SessionCarInfo, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.cars = make(map[udp.CarID][]*Stint)
+
+	for _, driver := range drivers {
+		c.cars[driver.CarID] = []*Stint{{
+			DriverGUID: driver.DriverGUID,
+			DriverName: driver.DriverName,
+			Start:      now,
+		}}
+	}
+}
+
+func (c *carStints) current(carID udp.CarID) *Stint {
+	stints := c.cars[carID]
+
+	if len(stints) == 0 {
+		return nil
+	}
+
+	return stints[len(stints)-1]
+}
+
+// driverConnected carries on the car's stint if driverGUID was the last to drive it, or starts a new one. If this is
+// a driver swap, it returns the stint which the swap ended.
+func (c *carStints) driverConnected(carID udp.CarID, driverGUID udp.DriverGUID, driverName string, now time.Time) (swappedOut *Stint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.cars == nil {
+		c.cars = make(map[udp.CarID][]*Stint)
+	}
+
+	last := c.current(carID)
+
+	if last != nil && last.DriverGUID == driverGUID {
+		last.End = time.Time{}
+		return nil
+	}
+
+	if last != nil {
+		if last.End.IsZero() {
+			last.End = now
+		}
+
+		ended := *last
+		swappedOut = &ended
+	}
+
+	c.cars[carID] = append(c.cars[carID], &Stint{
+		DriverGUID: driverGUID,
+		DriverName: driverName,
+		Start:      now,
+	})
+
+	return swappedOut
+}
+
+func (c *carStints) driverDisconnected(carID udp.CarID, driverGUID udp.DriverGUID, now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if stint := c.current(carID); stint != nil && stint.DriverGUID == driverGUID && stint.End.IsZero() {
+		stint.End = now
+	}
+}
+
+type stintAction int
+
+const (
+	stintActionNone stintAction = iota
+	stintActionWarn
+	stintActionPenalise
+)
+
+// lapCompleted counts a lap towards the stint of driverGUID in carID, and works out whether the stint is nearing or
+// over maximum. Drivers are warned and penalised once per stint.
+func (c *carStints) lapCompleted(carID udp.CarID, driverGUID udp.DriverGUID, maximum time.Duration, now time.Time) (stintAction, Stint) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stint := c.current(carID)
+
+	if stint == nil || stint.DriverGUID != driverGUID {
+		return stintActionNone, Stint{}
+	}
+
+	stint.Laps++
+
+	if maximum <= 0 || stint.overMaximum {
+		return stintActionNone, *stint
+	}
+
+	if stint.Duration(now) > maximum {
+		stint.overMaximum = true
+		return stintActionPenalise, *stint
+	}
+
+	if !stint.warned && stint.Duration(now) > maximum-stintMaximumWarning {
+		stint.warned = true
+		return stintActionWarn, *stint
+	}
+
+	return stintActionNone, *stint
+}
+
+func (c *carStints) all() []CarStints {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	cars := make([]CarStints, 0, len(c.cars))
+
+	for carID, stints := range c.cars {
+		car := CarStints{CarID: carID}
+
+		for _, stint := range stints {
+			car.Stints = append(car.Stints, *stint)
+		}
+
+		cars = append(cars, car)
+	}
+
+	sort.Slice(cars, func(i, j int) bool {
+		return cars[i].CarID < cars[j].CarID
+	})
+
+	return cars
+}
+
+// Stints returns who has driven each car in the session so far.
+func (rc *RaceControl) Stints() []CarStints {
+	return rc.stints.all()
+}
+
+// resetStints starts the stints of a new session, in which the connected drivers carry on driving their cars.
+func (rc *RaceControl) resetStints() {
+	var drivers []udp.SessionCarInfo
+
+	_ = rc.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
+		driver.mutex.Lock()
+		defer driver.mutex.Unlock()
+
+		drivers = append(drivers, driver.CarInfo)
+
+		return nil
+	})
+
+	rc.stints.reset(drivers, time.Now())
+}
+
+// enforcesStintLengths is true if the minimum and maximum stint times of config apply to the current session.
+func (rc *RaceControl) enforcesStintLengths(config CurrentRaceConfig) bool {
+	return config.DriverSwapEnabled == 1 && rc.SessionInfo.Type == udp.SessionTypeRace
+}
+
+// addDriverSwapPenalty adds penalty to the driver swap penalties which are applied to driverGUID's result when the
+// session ends.
+func (rc *RaceControl) addDriverSwapPenalty(driverGUID udp.DriverGUID, carModel string, penalty time.Duration) {
+	rc.driverSwapPenaltiesMutex.Lock()
+	defer rc.driverSwapPenaltiesMutex.Unlock()
+
+	if rc.driverSwapPenalties == nil {
+		rc.driverSwapPenalties = make(map[udp.DriverGUID]*driverSwapPenalty)
+	}
+
+	if existing, ok := rc.driverSwapPenalties[driverGUID]; ok {
+		existing.penalty += penalty
+	} else {
+		rc.driverSwapPenalties[driverGUID] = &driverSwapPenalty{
+			penalty:  penalty,
+			carModel: carModel,
+		}
+	}
+}
+
+// stintDriverConnected records client as driving their car, penalising the driver they swapped with if that
+// driver's stint was shorter than the minimum.
+func (rc *RaceControl) stintDriverConnected(client udp.SessionCarInfo) {
+	swappedOut := rc.stints.driverConnected(client.CarID, client.DriverGUID, client.DriverName, time.Now())
+
+	if swappedOut == nil {
+		return
+	}
+
+	config := rc.process.Event().GetRaceConfig()
+
+	if config.DriverSwapEnabled != 1 {
+		// cars are handed on to whoever joins next on servers without driver swaps.
+		return
+	}
+
+	logrus.Infof("Driver swap in car %d: %s (%s) has taken over from %s (%s) after %d laps", client.CarID, client.DriverName, client.DriverGUID, swappedOut.DriverName, swappedOut.DriverGUID, swappedOut.Laps)
+
+	minimum := time.Duration(config.DriverSwapMinimumStintTime) * time.Minute
+
+	if !rc.enforcesStintLengths(config) || minimum <= 0 || swappedOut.Duration(time.Time{}) >= minimum {
+		return
+	}
+
+	penalty := time.Duration(config.DriverSwapStintPenalty) * time.Second
+
+	rc.addDriverSwapPenalty(swappedOut.DriverGUID, client.CarModel, penalty)
+
+	logrus.Infof("Driver: %s has been penalised for a stint of %s, shorter than the minimum of %s", swappedOut.DriverGUID, swappedOut.Duration(time.Time{}).Round(time.Second), minimum)
+
+	if err := rc.sendStintChat(client.CarID, fmt.Sprintf("%s's stint was shorter than the minimum of %s, they will be penalised at the end of the race", swappedOut.DriverName, minimum)); err != nil {
+		logrus.WithError(err).Errorf("Unable to send stint penalty message to car %d", client.CarID)
+	}
+}
+
+// stintLapCompleted counts a lap towards driver's stint, warning them as they near the maximum stint time and
+// penalising them once they have gone over it.
+func (rc *RaceControl) stintLapCompleted(carID udp.CarID, driver udp.SessionCarInfo) {
+	config := rc.process.Event().GetRaceConfig()
+
+	var maximum time.Duration
+
+	if rc.enforcesStintLengths(config) {
+		maximum = time.Duration(config.DriverSwapMaximumStintTime) * time.Minute
+	}
+
+	now := time.Now()
+	action, stint := rc.stints.lapCompleted(carID, driver.DriverGUID, maximum, now)
+
+	var message string
+
+	switch action {
+	case stintActionWarn:
+		message = fmt.Sprintf("Your stint has lasted %s, swap drivers before it reaches the maximum of %s", stint.Duration(now).Round(time.Minute), maximum)
+	case stintActionPenalise:
+		rc.addDriverSwapPenalty(driver.DriverGUID, driver.CarModel, time.Duration(config.DriverSwapStintPenalty)*time.Second)
+
+		logrus.Infof("Driver: %s has been penalised for a stint longer than the maximum of %s", driver.DriverGUID, maximum)
+
+		message = fmt.Sprintf("Your stint is longer than the maximum of %s, you will be penalised at the end of the race", maximum)
+	default:
+		return
+	}
+
+	if err := rc.sendStintChat(carID, message); err != nil {
+		logrus.WithError(err).Errorf("Unable to send stint message to car %d", carID)
+	}
+}
+
+func (rc *RaceControl) sendStintChat(carID udp.CarID, message string) error {
+	for _, line := range chatLines(message) {
+		chat, err := udp.NewSendChat(carID, line)
+
+		if err != nil {
+			return err
+		}
+
+		if err := rc.process.SendUDPMessage(chat); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
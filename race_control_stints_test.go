@@ -0,0 +1,149 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestCarStints(t *testing.T) {
+	var stints carStints
+
+	start := time.Date(2020, 5, 1, 19, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time {
+		return start.Add(d)
+	}
+
+	if swappedOut := stints.driverConnected(1, "alice", "Alice", at(0)); swappedOut != nil {
+		t.Errorf("Expected the first driver not to be a swap, got %+v", swappedOut)
+	}
+
+	for _, step := range []struct {
+		after  time.Duration
+		action stintAction
+	}{
+		{time.Minute * 40, stintActionNone},
+		{time.Minute * 56, stintActionWarn},
+		{time.Minute * 58, stintActionNone},
+		{time.Minute * 61, stintActionPenalise},
+		{time.Minute * 63, stintActionNone},
+	} {
+		if action, _ := stints.lapCompleted(1, "alice", time.Hour, at(step.after)); action != step.action {
+			t.Errorf("After %s: expected action %d, got %d", step.after, step.action, action)
+		}
+	}
+
+	// a driver reconnecting carries on their stint.
+	stints.driverDisconnected(1, "alice", at(time.Minute*64))
+
+	if swappedOut := stints.driverConnected(1, "alice", "Alice", at(time.Minute*65)); swappedOut != nil {
+		t.Errorf("Expected a reconnect not to be a swap, got %+v", swappedOut)
+	}
+
+	stints.driverDisconnected(1, "alice", at(time.Minute*70))
+
+	swappedOut := stints.driverConnected(1, "bob", "Bob", at(time.Minute*71))
+
+	if swappedOut == nil || swappedOut.DriverGUID != "alice" || swappedOut.Laps != 5 || swappedOut.Duration(time.Time{}) != time.Minute*70 {
+		t.Fatalf("Expected Bob to swap out Alice after 5 laps and 70 minutes, got %+v", swappedOut)
+	}
+
+	// laps by a driver who isn't driving the car (e.g. a late message) aren't counted towards the stint.
+	if action, stint := stints.lapCompleted(1, "alice", time.Hour, at(time.Minute*72)); action != stintActionNone || stint.DriverGUID != "" {
+		t.Errorf("Expected Alice's lap not to count towards Bob's stint, got %+v", stint)
+	}
+
+	stints.lapCompleted(1, "bob", time.Hour, at(time.Minute*75))
+
+	cars := stints.all()
+
+	if len(cars) != 1 || len(cars[0].Stints) != 2 || cars[0].Stints[1].DriverGUID != "bob" || cars[0].Stints[1].Laps != 1 || !cars[0].Stints[1].End.IsZero() {
+		t.Errorf("Expected Alice's stint followed by Bob's running stint, got %+v", cars)
+	}
+}
+
+func TestRaceControl_MinimumStintTime(t *testing.T) {
+	process := eventUDPMessagesProcess{
+		sentUDPMessagesProcess: sentUDPMessagesProcess{sent: make(chan udp.Message, 10)},
+		event: &CustomRace{RaceConfig: CurrentRaceConfig{
+			DriverSwapEnabled:          1,
+			DriverSwapMinimumStintTime: 30,
+			DriverSwapStintPenalty:     20,
+		}},
+	}
+
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, process, testStore, NewPenaltiesManager(testStore))
+	raceControl.SessionInfo.Type = udp.SessionTypeRace
+
+	for _, driverGUID := range []udp.DriverGUID{"76561198000000101", "76561198000000102"} {
+		if err := raceControl.OnClientConnect(udp.SessionCarInfo{CarID: 4, DriverGUID: driverGUID, DriverName: string(driverGUID), CarModel: "ks_porsche_911_gt3_r_2016"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	raceControl.driverSwapPenaltiesMutex.Lock()
+	penalty, ok := raceControl.driverSwapPenalties["76561198000000101"]
+	raceControl.driverSwapPenaltiesMutex.Unlock()
+
+	if !ok || penalty.penalty != time.Second*20 || penalty.carModel != "ks_porsche_911_gt3_r_2016" {
+		t.Errorf("Expected the first driver to be given a 20s penalty for a short stint, got %+v", penalty)
+	}
+
+	select {
+	case message := <-process.sent:
+		if chat, ok := message.(*udp.SendChat); !ok || chat.CarID != 4 {
+			t.Errorf("Expected car 4 to be told about the penalty, got %#v", message)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected car 4 to be told about the penalty, nothing was sent")
+	}
+
+	if stints := raceControl.Stints(); len(stints) != 1 || len(stints[0].Stints) != 2 {
+		t.Errorf("Expected two stints in car 4, got %+v", stints)
+	}
+}
+
+func TestRaceControl_StintsCarriedIntoNewSession(t *testing.T) {
+	process := eventUDPMessagesProcess{
+		sentUDPMessagesProcess: sentUDPMessagesProcess{sent: make(chan udp.Message, 10)},
+		event: &CustomRace{RaceConfig: CurrentRaceConfig{
+			DriverSwapEnabled:          1,
+			DriverSwapMinimumStintTime: 30,
+			DriverSwapStintPenalty:     20,
+		}},
+	}
+
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, process, testStore, NewPenaltiesManager(testStore))
+
+	if err := raceControl.OnNewSession(udp.SessionInfo{Type: udp.SessionTypeQualifying, EventType: udp.EventNewSession}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := raceControl.OnClientConnect(udp.SessionCarInfo{CarID: 4, DriverGUID: "76561198000000101", DriverName: "First Driver", CarModel: "ks_porsche_911_gt3_r_2016"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the driver stays in their car from qualifying into the race.
+	if err := raceControl.OnNewSession(udp.SessionInfo{Type: udp.SessionTypeRace, EventType: udp.EventNewSession}); err != nil {
+		t.Fatal(err)
+	}
+
+	stints := raceControl.Stints()
+
+	if len(stints) != 1 || stints[0].CarID != 4 || len(stints[0].Stints) != 1 || stints[0].Stints[0].DriverGUID != "76561198000000101" {
+		t.Fatalf("Expected the connected driver to start a stint with the race, got %+v", stints)
+	}
+
+	if err := raceControl.OnClientConnect(udp.SessionCarInfo{CarID: 4, DriverGUID: "76561198000000102", DriverName: "Second Driver", CarModel: "ks_porsche_911_gt3_r_2016"}); err != nil {
+		t.Fatal(err)
+	}
+
+	raceControl.driverSwapPenaltiesMutex.Lock()
+	_, penalised := raceControl.driverSwapPenalties["76561198000000101"]
+	raceControl.driverSwapPenaltiesMutex.Unlock()
+
+	if !penalised {
+		t.Error("Expected the first driver's race stint to be shorter than the minimum")
+	}
+}
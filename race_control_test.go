@@ -203,6 +203,136 @@ func TestRaceControl_OnClientConnect(t *testing.T) {
 	})
 }
 
+type recordingUDPServerProcess struct {
+	dummyServerProcess
+
+	messages []udp.Message
+}
+
+func (sp *recordingUDPServerProcess) SendUDPMessage(message udp.Message) error {
+	sp.messages = append(sp.messages, message)
+
+	return nil
+}
+
+func TestRaceControl_DuplicateGUIDs(t *testing.T) {
+	oldConfig := config
+	defer func() {
+		config = oldConfig
+	}()
+
+	duplicate := drivers[1]
+	duplicate.DriverGUID = drivers[0].DriverGUID
+	duplicate.DriverName = "Test Duplicate"
+
+	t.Run("Disambiguate", func(t *testing.T) {
+		config = &Configuration{Server: ServerExtraConfig{DuplicateGUIDPolicy: DuplicateGUIDPolicyDisambiguate}}
+
+		raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore))
+
+		if err := raceControl.OnClientConnect(drivers[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := raceControl.OnClientConnect(duplicate); err != nil {
+			t.Fatal(err)
+		}
+
+		if raceControl.ConnectedDrivers.Len() != 2 {
+			t.Fatalf("Expected both drivers to be connected, got %d", raceControl.ConnectedDrivers.Len())
+		}
+
+		original, err := raceControl.findConnectedDriverByCarID(drivers[0].CarID)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if original.CarInfo.DriverGUID != drivers[0].DriverGUID {
+			t.Errorf("Expected original driver to keep their roster entry, got: %s", original.CarInfo.DriverGUID)
+		}
+
+		second, err := raceControl.findConnectedDriverByCarID(duplicate.CarID)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if second == original || second.CarInfo.DriverGUID == drivers[0].DriverGUID {
+			t.Errorf("Expected duplicate driver to have a separate roster entry, got: %s", second.CarInfo.DriverGUID)
+		}
+
+		if d, ok := raceControl.DuplicateGUIDs[duplicate.CarID]; !ok || d.Rejected || d.OtherCarID != drivers[0].CarID {
+			t.Errorf("Expected duplicate GUID to be reported, got: %+v", d)
+		}
+
+		if err := raceControl.OnClientDisconnect(duplicate); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := raceControl.ConnectedDrivers.Get(drivers[0].DriverGUID); !ok {
+			t.Error("Expected original driver to still be connected after the duplicate disconnected")
+		}
+
+		if raceControl.ConnectedDrivers.Len() != 1 {
+			t.Errorf("Expected only the original driver to be connected, got %d", raceControl.ConnectedDrivers.Len())
+		}
+
+		if len(raceControl.DuplicateGUIDs) != 0 {
+			t.Error("Expected duplicate GUID to be cleared after disconnecting")
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		config = &Configuration{Server: ServerExtraConfig{DuplicateGUIDPolicy: DuplicateGUIDPolicyReject}}
+
+		process := &recordingUDPServerProcess{}
+		raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, process, testStore, NewPenaltiesManager(testStore))
+
+		if err := raceControl.OnClientConnect(drivers[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := raceControl.OnClientConnect(duplicate); err != nil {
+			t.Fatal(err)
+		}
+
+		if raceControl.ConnectedDrivers.Len() != 1 {
+			t.Fatalf("Expected only the original driver to be connected, got %d", raceControl.ConnectedDrivers.Len())
+		}
+
+		driver, ok := raceControl.ConnectedDrivers.Get(drivers[0].DriverGUID)
+
+		if !ok || driver.CarInfo.CarID != drivers[0].CarID {
+			t.Fatal("Expected original driver's roster entry to be unchanged")
+		}
+
+		if _, err := raceControl.findConnectedDriverByCarID(duplicate.CarID); err == nil {
+			t.Error("Expected rejected car not to be found in the roster")
+		}
+
+		if len(process.messages) != 1 {
+			t.Fatalf("Expected one kick message to be sent, got %d messages", len(process.messages))
+		}
+
+		if kick, ok := process.messages[0].(*udp.KickUser); !ok || kick.CarID != uint8(duplicate.CarID) {
+			t.Errorf("Expected car %d to be kicked, got: %+v", duplicate.CarID, process.messages[0])
+		}
+
+		if d, ok := raceControl.DuplicateGUIDs[duplicate.CarID]; !ok || !d.Rejected {
+			t.Errorf("Expected rejected duplicate GUID to be reported, got: %+v", d)
+		}
+
+		if err := raceControl.OnClientDisconnect(duplicate); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := raceControl.ConnectedDrivers.Get(drivers[0].DriverGUID); !ok {
+			t.Error("Expected original driver to still be connected after the rejected car disconnected")
+		}
+	})
+}
+
 func TestRaceControl_OnClientLoaded(t *testing.T) {
 	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore))
 
@@ -1,6 +1,7 @@
 package servermanager
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -36,6 +37,10 @@ type CustomRace struct {
 	EntryList  EntryList
 
 	ScheduledEvents map[ServerID]*ScheduledEventBase
+
+	// ScheduledOnServer is the name of the server in the pool which the race is started on when it is scheduled. It is
+	// empty for the default server.
+	ScheduledOnServer string
 }
 
 func (cr *CustomRace) GetRaceConfig() CurrentRaceConfig {
@@ -149,6 +154,9 @@ type customRaceListTemplateVars struct {
 	BaseTemplateVars
 
 	Recent, Starred, Loop, Scheduled []*CustomRace
+
+	// Servers are the names of the servers a race can be started on.
+	Servers []string
 }
 
 func (crh *CustomRaceHandler) list(w http.ResponseWriter, r *http.Request) {
@@ -165,6 +173,7 @@ func (crh *CustomRaceHandler) list(w http.ResponseWriter, r *http.Request) {
 		Starred:   starred,
 		Loop:      looped,
 		Scheduled: scheduled,
+		Servers:   crh.raceManager.ServerNames(),
 	})
 }
 
@@ -299,6 +308,21 @@ func (crh *CustomRaceHandler) submit(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// validate checks the race in the race setup form, without saving or starting it, and responds with a
+// ValidationReport.
+func (crh *CustomRaceHandler) validate(w http.ResponseWriter, r *http.Request) {
+	report, err := crh.raceManager.ValidateCustomRaceForm(r)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't validate custom race")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(report)
+}
+
 func (crh *CustomRaceHandler) schedule(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
 		logrus.WithError(err).Errorf("couldn't parse schedule race form")
@@ -327,9 +351,12 @@ func (crh *CustomRaceHandler) schedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = crh.raceManager.ScheduleRace(raceID, date, r.FormValue("action"), r.FormValue("event-schedule-recurrence"))
+	err = crh.raceManager.ScheduleRaceOnServer(raceID, r.FormValue("event-schedule-server"), date, r.FormValue("action"), r.FormValue("event-schedule-recurrence"))
 
-	if err != nil {
+	if err == ErrUnknownServer {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
 		logrus.WithError(err).Errorf("couldn't schedule race")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -352,14 +379,25 @@ func (crh *CustomRaceHandler) removeSchedule(w http.ResponseWriter, r *http.Requ
 }
 
 func (crh *CustomRaceHandler) start(w http.ResponseWriter, r *http.Request) {
-	_, err := crh.raceManager.StartCustomRace(chi.URLParam(r, "uuid"), false)
+	serverName := r.URL.Query().Get("server")
 
-	if err != nil {
+	_, err := crh.raceManager.StartCustomRaceOnServer(chi.URLParam(r, "uuid"), serverName)
+
+	if err == ErrUnknownServer {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
 		logrus.WithError(err).Errorf("couldn't apply custom race")
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	if serverName != "" && serverName != DefaultServerName {
+		AddFlash(w, r, fmt.Sprintf("Custom race started on %s!", serverName))
+		http.Redirect(w, r, "/custom", http.StatusFound)
+		return
+	}
+
 	AddFlash(w, r, "Custom race started!")
 
 	if config.Server.PerformanceMode {
@@ -1,6 +1,7 @@
 package servermanager
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -27,6 +28,7 @@ var ErrCustomRaceNotFound = errors.New("servermanager: custom race not found")
 
 type RaceManager struct {
 	process             ServerProcess
+	pool                *ServerPool
 	store               Store
 	carManager          *CarManager
 	trackManager        *TrackManager
@@ -56,10 +58,12 @@ func NewRaceManager(
 	trackManager *TrackManager,
 	notificationManager NotificationDispatcher,
 	raceControl *RaceControl,
+	pool *ServerPool,
 ) *RaceManager {
 	return &RaceManager{
 		store:                    store,
 		process:                  process,
+		pool:                     pool,
 		carManager:               carManager,
 		trackManager:             trackManager,
 		notificationManager:      notificationManager,
@@ -83,6 +87,11 @@ func (rm *RaceManager) CurrentRace() (*ServerConfig, EntryList) {
 var ErrEntryListTooBig = errors.New("servermanager: EntryList exceeds MaxClients setting")
 
 func (rm *RaceManager) applyConfigAndStart(event RaceEvent) error {
+	return rm.applyConfigAndStartContext(context.Background(), event)
+}
+
+// applyConfigAndStartContext is applyConfigAndStart, with ctx passed on to the server process' StartContext.
+func (rm *RaceManager) applyConfigAndStartContext(ctx context.Context, event RaceEvent) error {
 	rm.mutex.Lock()
 	defer rm.mutex.Unlock()
 
@@ -113,6 +122,109 @@ func (rm *RaceManager) applyConfigAndStart(event RaceEvent) error {
 		}
 	}
 
+	config, entryList, err := rm.buildServerConfig(event, serverOpts)
+
+	if err != nil {
+		return err
+	}
+
+	// drs zones management
+	err = ToggleDRSForTrack(config.CurrentRaceConfig.Track, config.CurrentRaceConfig.TrackLayout, !config.CurrentRaceConfig.DisableDRSZones)
+
+	if err != nil {
+		return err
+	}
+
+	forwardingAddress := config.GlobalServerConfig.UDPPluginAddress
+	forwardListenPort := config.GlobalServerConfig.UDPPluginLocalPort
+
+	if serverOpts.AutoUDPPluginPorts == 1 {
+		forwardingAddress, forwardListenPort, err = autoUDPPluginPorts(serverOpts)
+
+		if err != nil {
+			return err
+		}
+
+		logrus.Infof("Using automatic UDP plugin ports. Plugins should listen on %s and send to port %d", forwardingAddress, forwardListenPort)
+	}
+
+	config.GlobalServerConfig.UDPPluginAddress = config.GlobalServerConfig.FreeUDPPluginAddress
+	config.GlobalServerConfig.UDPPluginLocalPort = config.GlobalServerConfig.FreeUDPPluginLocalPort
+
+	err = config.Write()
+
+	if err != nil {
+		return err
+	}
+
+	err = entryList.Write()
+
+	if err != nil {
+		return err
+	}
+
+	rm.currentRace = &config
+	rm.currentEntryList = entryList
+
+	err = rm.process.StartContext(ctx, event, config.GlobalServerConfig.UDPPluginAddress, config.GlobalServerConfig.UDPPluginLocalPort, forwardingAddress, forwardListenPort)
+
+	if err != nil {
+		return err
+	}
+
+	if !event.IsLooping() {
+		_ = rm.notificationManager.SendRaceStartMessage(config, event)
+	}
+
+	// existing timer needs to be stopped in all cases
+	if rm.forceStopTimer != nil {
+		rm.forceStopTimer.Stop()
+	}
+
+	if event.GetForceStopTime() != 0 {
+		// initiate force stop timer
+		var withDrivers string
+
+		if !event.GetForceStopWithDrivers() {
+			withDrivers = "(unless there are drivers on the server at that time)"
+		} else {
+			withDrivers = "(even if there are drivers on the server at that time)"
+		}
+
+		logrus.Infof("Force Stop timer initialised, the server will be forcibly stopped after %.2f minutes %s.", event.GetForceStopTime().Minutes(), withDrivers)
+
+		rm.forceStopTimer, err = when.When(time.Now().Add(event.GetForceStopTime()), func() {
+			if rm.process.IsRunning() {
+
+				if (event.GetForceStopWithDrivers()) || (rm.raceControl.ConnectedDrivers.Len() == 0) {
+					err := rm.process.Stop()
+
+					if err != nil {
+						logrus.WithError(err).Error("couldn't forcibly stop the server!")
+						return
+					}
+
+					logrus.Infof("Force Stop time expired, the server has been successfully stopped.")
+				} else {
+					logrus.Infof("Force Stop time expired, but %d drivers are on the server! Force stop aborted. "+
+						"The server should stop automatically on event completion.", rm.raceControl.ConnectedDrivers.Len())
+				}
+
+			}
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildServerConfig builds the server_cfg.ini and entry_list.ini for event, using serverOpts.
+func (rm *RaceManager) buildServerConfig(event RaceEvent, serverOpts *GlobalServerConfig) (ServerConfig, EntryList, error) {
+	var err error
+
 	raceConfig := event.GetRaceConfig()
 	entryList := event.GetEntryList()
 
@@ -171,17 +283,11 @@ func (rm *RaceManager) applyConfigAndStart(event RaceEvent) error {
 		GlobalServerConfig: *serverOpts,
 	}
 
-	forwardingAddress := config.GlobalServerConfig.UDPPluginAddress
-	forwardListenPort := config.GlobalServerConfig.UDPPluginLocalPort
-
-	config.GlobalServerConfig.UDPPluginAddress = config.GlobalServerConfig.FreeUDPPluginAddress
-	config.GlobalServerConfig.UDPPluginLocalPort = config.GlobalServerConfig.FreeUDPPluginLocalPort
-
 	if MaxClientsOverride > 0 {
 		config.CurrentRaceConfig.MaxClients = MaxClientsOverride
 
 		if len(entryList) > MaxClientsOverride {
-			return ErrEntryListTooBig
+			return ServerConfig{}, nil, ErrEntryListTooBig
 		}
 	}
 
@@ -225,13 +331,6 @@ func (rm *RaceManager) applyConfigAndStart(event RaceEvent) error {
 		}
 	}
 
-	// drs zones management
-	err = ToggleDRSForTrack(config.CurrentRaceConfig.Track, config.CurrentRaceConfig.TrackLayout, !config.CurrentRaceConfig.DisableDRSZones)
-
-	if err != nil {
-		return err
-	}
-
 	if config.GlobalServerConfig.ShowRaceNameInServerLobby == 1 {
 		// append the race name to the server name
 		if name := event.EventName(); name != "" {
@@ -243,12 +342,6 @@ func (rm *RaceManager) applyConfigAndStart(event RaceEvent) error {
 		config.GlobalServerConfig.Name += fmt.Sprintf(" %c%d", contentManagerWrapperSeparator, config.GlobalServerConfig.ContentManagerWrapperPort)
 	}
 
-	err = config.Write()
-
-	if err != nil {
-		return err
-	}
-
 	numEntrantsWithAnyCar := 0
 
 	for _, entrant := range entryList {
@@ -261,68 +354,7 @@ func (rm *RaceManager) applyConfigAndStart(event RaceEvent) error {
 		}
 	}
 
-	err = entryList.Write()
-
-	if err != nil {
-		return err
-	}
-
-	rm.currentRace = &config
-	rm.currentEntryList = entryList
-
-	err = rm.process.Start(event, config.GlobalServerConfig.UDPPluginAddress, config.GlobalServerConfig.UDPPluginLocalPort, forwardingAddress, forwardListenPort)
-
-	if err != nil {
-		return err
-	}
-
-	if !event.IsLooping() {
-		_ = rm.notificationManager.SendRaceStartMessage(config, event)
-	}
-
-	// existing timer needs to be stopped in all cases
-	if rm.forceStopTimer != nil {
-		rm.forceStopTimer.Stop()
-	}
-
-	if event.GetForceStopTime() != 0 {
-		// initiate force stop timer
-		var withDrivers string
-
-		if !event.GetForceStopWithDrivers() {
-			withDrivers = "(unless there are drivers on the server at that time)"
-		} else {
-			withDrivers = "(even if there are drivers on the server at that time)"
-		}
-
-		logrus.Infof("Force Stop timer initialised, the server will be forcibly stopped after %.2f minutes %s.", event.GetForceStopTime().Minutes(), withDrivers)
-
-		rm.forceStopTimer, err = when.When(time.Now().Add(event.GetForceStopTime()), func() {
-			if rm.process.IsRunning() {
-
-				if (event.GetForceStopWithDrivers()) || (rm.raceControl.ConnectedDrivers.Len() == 0) {
-					err := rm.process.Stop()
-
-					if err != nil {
-						logrus.WithError(err).Error("couldn't forcibly stop the server!")
-						return
-					}
-
-					logrus.Infof("Force Stop time expired, the server has been successfully stopped.")
-				} else {
-					logrus.Infof("Force Stop time expired, but %d drivers are on the server! Force stop aborted. "+
-						"The server should stop automatically on event completion.", rm.raceControl.ConnectedDrivers.Len())
-				}
-
-			}
-		})
-
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return config, entryList, nil
 }
 
 func eventStartPlugin(raceConfig *CurrentRaceConfig, serverOpts *GlobalServerConfig, entryList *EntryList) error {
@@ -663,6 +695,11 @@ func (rm *RaceManager) BuildCustomRaceFromForm(r *http.Request) (*CurrentRaceCon
 		TimeAttack: timeAttack,
 	}
 
+	// chat broadcasts
+	raceConfig.ChatBroadcasts = chatBroadcastsFromForm(r.Form)
+	raceConfig.ChatBroadcastInterval = formValueAsInt(r.FormValue("ChatBroadcastInterval"))
+	raceConfig.WeatherTimeline = weatherTimelineFromForm(r.Form)
+
 	if Premium() {
 		// driver swap
 		raceConfig.DriverSwapEnabled = formValueAsInt(r.FormValue("DriverSwapEnabled"))
@@ -671,6 +708,9 @@ func (rm *RaceManager) BuildCustomRaceFromForm(r *http.Request) (*CurrentRaceCon
 		raceConfig.DriverSwapPenaltyTime = formValueAsInt(r.FormValue("DriverSwapPenaltyTime"))
 		raceConfig.DriverSwapMinimumNumberOfSwaps = formValueAsInt(r.FormValue("DriverSwapMinimumNumberOfSwaps"))
 		raceConfig.DriverSwapNotEnoughSwapsPenalty = formValueAsInt(r.FormValue("DriverSwapNotEnoughSwapsPenalty"))
+		raceConfig.DriverSwapMinimumStintTime = formValueAsInt(r.FormValue("DriverSwapMinimumStintTime"))
+		raceConfig.DriverSwapMaximumStintTime = formValueAsInt(r.FormValue("DriverSwapMaximumStintTime"))
+		raceConfig.DriverSwapStintPenalty = formValueAsInt(r.FormValue("DriverSwapStintPenalty"))
 
 		raceConfig.ExportSecondRaceToACSR = formValueAsInt(r.FormValue("ExportSecondRaceToACSR")) == 1
 	} else {
@@ -1241,6 +1281,10 @@ func (rm *RaceManager) SaveCustomRace(
 }
 
 func (rm *RaceManager) StartCustomRace(uuid string, forceRestart bool) (*CustomRace, error) {
+	return rm.startCustomRace(context.Background(), uuid, forceRestart)
+}
+
+func (rm *RaceManager) startCustomRace(ctx context.Context, uuid string, forceRestart bool) (*CustomRace, error) {
 	race, err := rm.store.FindCustomRaceByID(uuid)
 
 	if err != nil {
@@ -1261,7 +1305,175 @@ func (rm *RaceManager) StartCustomRace(uuid string, forceRestart bool) (*CustomR
 		race.LoopServer[serverID] = forceRestart
 	}
 
-	return race, rm.applyConfigAndStart(race)
+	return race, rm.applyConfigAndStartContext(ctx, race)
+}
+
+// ServerNames returns the names of the servers which events can be started on.
+func (rm *RaceManager) ServerNames() []string {
+	if rm.pool == nil {
+		return []string{DefaultServerName}
+	}
+
+	return rm.pool.Names()
+}
+
+// ServerStatuses returns the status of each server which events can be started on.
+func (rm *RaceManager) ServerStatuses() []ServerPoolStatus {
+	if rm.pool == nil {
+		return []ServerPoolStatus{{Name: DefaultServerName, Status: rm.process.Status()}}
+	}
+
+	return rm.pool.Statuses()
+}
+
+// LoadServerOptionsFor loads the server options of the server called serverName. Pooled servers have their own server
+// options, which start as a copy of the default server's options.
+func (rm *RaceManager) LoadServerOptionsFor(serverName string) (*GlobalServerConfig, error) {
+	if serverName == "" || serverName == DefaultServerName {
+		return rm.LoadServerOptions()
+	}
+
+	if rm.pool == nil {
+		return nil, ErrUnknownServer
+	}
+
+	server, err := rm.pool.pooledServer(serverName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return server.store.LoadServerOptions()
+}
+
+// SaveServerOptionsFor saves the server options of the server called serverName. The options of a pooled server are
+// used the next time an event is started on it.
+func (rm *RaceManager) SaveServerOptionsFor(serverName string, so *GlobalServerConfig) error {
+	if serverName == "" || serverName == DefaultServerName {
+		return rm.SaveServerOptions(so)
+	}
+
+	if rm.pool == nil {
+		return ErrUnknownServer
+	}
+
+	server, err := rm.pool.pooledServer(serverName)
+
+	if err != nil {
+		return err
+	}
+
+	return server.store.UpsertServerOptions(so)
+}
+
+// StartCustomRaceOnServer starts a custom race on the server called serverName. Races started on a pooled server
+// aren't followed by race control, and aren't looped.
+func (rm *RaceManager) StartCustomRaceOnServer(uuid string, serverName string) (*CustomRace, error) {
+	if serverName == "" || serverName == DefaultServerName {
+		return rm.StartCustomRace(uuid, false)
+	}
+
+	race, err := rm.store.FindCustomRaceByID(uuid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return race, rm.applyConfigAndStartOnServer(serverName, race)
+}
+
+func (rm *RaceManager) applyConfigAndStartOnServer(serverName string, event RaceEvent) error {
+	if rm.pool == nil {
+		return ErrUnknownServer
+	}
+
+	server, err := rm.pool.pooledServer(serverName)
+
+	if err != nil {
+		return err
+	}
+
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	defaultOpts, err := rm.store.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	// the default server's ports may have been changed in the server options since the server was added to the pool.
+	if err := checkPortClash(serverName, pooledServerPorts(server.config, server.instance), DefaultServerName, defaultServerPorts(defaultOpts)); err != nil {
+		return err
+	}
+
+	serverOpts, err := server.store.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	server.config.applyTo(serverOpts, server.instance)
+
+	config, entryList, err := rm.buildServerConfig(event, serverOpts)
+
+	if err != nil {
+		return err
+	}
+
+	// drs zones management
+	err = ToggleDRSForTrack(config.CurrentRaceConfig.Track, config.CurrentRaceConfig.TrackLayout, !config.CurrentRaceConfig.DisableDRSZones)
+
+	if err != nil {
+		return err
+	}
+
+	err = config.WriteTo(server.instance.InstallPath)
+
+	if err != nil {
+		return err
+	}
+
+	err = entryList.WriteTo(server.instance.InstallPath)
+
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Starting %s on server %s", event.EventName(), serverName)
+
+	return server.process.Start(event, server.instance.UDPPluginAddress, server.instance.UDPPluginLocalPort, "", 0)
+}
+
+// ScheduleRaceOnServer schedules a custom race as ScheduleRace does, to be started on the server called serverName.
+func (rm *RaceManager) ScheduleRaceOnServer(uuid string, serverName string, date time.Time, action string, recurrence string) error {
+	if serverName == DefaultServerName {
+		serverName = ""
+	}
+
+	if serverName != "" {
+		if rm.pool == nil {
+			return ErrUnknownServer
+		}
+
+		if _, err := rm.pool.pooledServer(serverName); err != nil {
+			return err
+		}
+	}
+
+	race, err := rm.store.FindCustomRaceByID(uuid)
+
+	if err != nil {
+		return err
+	}
+
+	race.ScheduledOnServer = serverName
+
+	if err := rm.store.UpsertCustomRace(race); err != nil {
+		return err
+	}
+
+	return rm.ScheduleRace(uuid, date, action, recurrence)
 }
 
 func (rm *RaceManager) ScheduleRace(uuid string, date time.Time, action string, recurrence string) error {
@@ -1355,6 +1567,7 @@ func (rm *RaceManager) ScheduleRace(uuid string, date time.Time, action string,
 	} else {
 		_ = rm.notificationManager.SendRaceCancelledMessage(race, originalDate)
 		race.ClearRecurrenceRule()
+		race.ScheduledOnServer = ""
 	}
 
 	return rm.store.UpsertCustomRace(race)
@@ -1377,8 +1590,17 @@ func eventSchedulePlugin(race *CustomRace) error {
 	return nil
 }
 
+// StartScheduledRace starts race at its scheduled time. If another event is starting, it goes ahead of any
+// manual or looped starts which are waiting.
 func (rm *RaceManager) StartScheduledRace(race *CustomRace) error {
-	startedRace, err := rm.StartCustomRace(race.UUID.String(), false)
+	var startedRace *CustomRace
+	var err error
+
+	if race.ScheduledOnServer != "" {
+		startedRace, err = rm.StartCustomRaceOnServer(race.UUID.String(), race.ScheduledOnServer)
+	} else {
+		startedRace, err = rm.startCustomRace(WithStartPriority(context.Background(), StartPriorityScheduled), race.UUID.String(), false)
+	}
 
 	if err != nil {
 		return err
@@ -1500,6 +1722,16 @@ func (rm *RaceManager) SaveServerOptions(newServerOpts *GlobalServerConfig) erro
 	return nil
 }
 
+// RestartActiveEvent starts the running event again, rewriting server_cfg.ini and entry_list.ini first, so that
+// changes to the server options take effect.
+func (rm *RaceManager) RestartActiveEvent() error {
+	if !rm.process.IsRunning() {
+		return ErrServerProcessNotRunning
+	}
+
+	return rm.applyConfigAndStart(rm.process.Event())
+}
+
 func (rm *RaceManager) LoadServerOptions() (*GlobalServerConfig, error) {
 	serverOpts, err := rm.store.LoadServerOptions()
 
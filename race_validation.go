@@ -0,0 +1,190 @@
+package servermanager
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValidationProblem is something which would stop an event from starting, or which might go wrong once it has.
+type ValidationProblem struct {
+	Message string
+
+	// Warning is set if the event can still be started.
+	Warning bool
+}
+
+// ValidationReport is the result of checking that an event can be started, without starting it.
+type ValidationReport struct {
+	OK       bool
+	Problems []ValidationProblem
+}
+
+func (r *ValidationReport) errorf(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, ValidationProblem{Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationReport) warnf(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, ValidationProblem{Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+func (r *ValidationReport) finish() *ValidationReport {
+	r.OK = true
+
+	for _, problem := range r.Problems {
+		if !problem.Warning {
+			r.OK = false
+			break
+		}
+	}
+
+	return r
+}
+
+// ValidateEvent checks that event could be started as it is configured: that its config files can be built, that its
+// track and cars are installed, and that acServer and the ports it needs are available. Nothing is written and the
+// server isn't started.
+func (rm *RaceManager) ValidateEvent(event RaceEvent) (*ValidationReport, error) {
+	report := &ValidationReport{}
+
+	serverOpts, err := rm.LoadServerOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	serverConfig, entryList, err := rm.buildServerConfig(event, serverOpts)
+
+	if err != nil {
+		report.errorf("The server configuration could not be built: %s", err)
+		return report.finish(), nil
+	}
+
+	if _, err := serverConfig.ReadString(); err != nil {
+		report.errorf("server_cfg.ini could not be built: %s", err)
+	}
+
+	if _, err := entryList.ReadString(); err != nil {
+		report.errorf("entry_list.ini could not be built: %s", err)
+	}
+
+	validateTrack(serverConfig.CurrentRaceConfig, report)
+	validateCars(serverConfig.CurrentRaceConfig, entryList, report)
+
+	report.Problems = append(report.Problems, rm.process.ValidateStart(serverConfig)...)
+
+	return report.finish(), nil
+}
+
+// ValidateCustomRaceForm checks the custom race in the race setup form in r, see ValidateEvent. The race isn't saved.
+func (rm *RaceManager) ValidateCustomRaceForm(r *http.Request) (*ValidationReport, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	raceConfig, err := rm.BuildCustomRaceFromForm(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entryList EntryList
+
+	if !raceConfig.HasSession(SessionTypeBooking) {
+		entryList, err = rm.BuildEntryList(r, 0, len(r.Form["EntryList.Name"]))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rm.ValidateEvent(&CustomRace{
+		Name:                r.FormValue("CustomRaceName"),
+		OverridePassword:    r.FormValue("OverridePassword") == "1",
+		ReplacementPassword: r.FormValue("ReplacementPassword"),
+		RaceConfig:          *raceConfig,
+		EntryList:           entryList,
+	})
+}
+
+func validateTrack(raceConfig CurrentRaceConfig, report *ValidationReport) {
+	trackPath := filepath.Join(ServerInstallPath, "content", "tracks", raceConfig.Track)
+
+	if raceConfig.Track == "" {
+		report.errorf("No track is selected")
+		return
+	}
+
+	if _, err := os.Stat(trackPath); err != nil {
+		report.errorf("The track %s is not installed", raceConfig.Track)
+		return
+	}
+
+	layoutPath := filepath.Join(trackPath, raceConfig.TrackLayout)
+
+	if _, err := os.Stat(layoutPath); raceConfig.TrackLayout != "" && err != nil {
+		report.errorf("The layout %s of %s is not installed", raceConfig.TrackLayout, raceConfig.Track)
+		return
+	}
+
+	// acServer checksums surfaces.ini against the drivers' copy of the track.
+	if _, err := os.Stat(filepath.Join(layoutPath, "data", "surfaces.ini")); err != nil {
+		report.warnf("%s has no data/surfaces.ini, so acServer can't check that drivers have the same version of the track. Upload the track again with its data files", prettifyName(raceConfig.Track, true))
+	}
+}
+
+func validateCars(raceConfig CurrentRaceConfig, entryList EntryList, report *ValidationReport) {
+	cars := make(map[string]bool)
+
+	for _, car := range strings.Split(raceConfig.Cars, ";") {
+		if car == "" {
+			continue
+		}
+
+		cars[car] = true
+		carPath := filepath.Join(ServerInstallPath, "content", "cars", car)
+
+		if _, err := os.Stat(carPath); err != nil {
+			report.errorf("The car %s is not installed", car)
+			continue
+		}
+
+		// acServer checksums data.acd against the drivers' copy of the car.
+		if _, err := os.Stat(filepath.Join(carPath, "data.acd")); err != nil {
+			report.warnf("%s has no data.acd, so acServer can't check that drivers have the same version of the car. Upload the car again with its data files", prettifyName(car, true))
+		}
+	}
+
+	if len(cars) == 0 {
+		report.errorf("No cars are selected")
+	}
+
+	for _, entrant := range entryList.AsSlice() {
+		if entrant.Model == "" || entrant.Model == AnyCarModel {
+			continue
+		}
+
+		if !cars[entrant.Model] {
+			report.errorf("%s's car, %s, is not one of the event's cars", entrantDescription(entrant), entrant.Model)
+			continue
+		}
+
+		if entrant.Skin == "" || entrant.Skin == "random_skin" {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(ServerInstallPath, "content", "cars", entrant.Model, "skins", entrant.Skin)); err != nil {
+			report.warnf("%s's skin, %s, is not installed for %s", entrantDescription(entrant), entrant.Skin, entrant.Model)
+		}
+	}
+}
+
+func entrantDescription(entrant *Entrant) string {
+	if entrant.Name != "" {
+		return entrant.Name
+	}
+
+	return "An open slot"
+}
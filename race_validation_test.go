@@ -0,0 +1,115 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateCarsAndTrack(t *testing.T) {
+	_, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	for _, dir := range []string{
+		"content/tracks/ks_vallelunga/club_circuit/data",
+		"content/cars/ks_ferrari_488_gt3/skins/red",
+		"content/cars/ks_audi_r8_lms",
+	} {
+		if err := os.MkdirAll(filepath.Join(ServerInstallPath, filepath.FromSlash(dir)), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, file := range []string{
+		"content/tracks/ks_vallelunga/club_circuit/data/surfaces.ini",
+		"content/cars/ks_ferrari_488_gt3/data.acd",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(ServerInstallPath, filepath.FromSlash(file)), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := &ValidationReport{}
+
+	validateTrack(CurrentRaceConfig{Track: "ks_vallelunga", TrackLayout: "club_circuit"}, report)
+	validateCars(CurrentRaceConfig{Cars: "ks_ferrari_488_gt3"}, EntryList{"CAR_0": {Name: "Driver", Model: "ks_ferrari_488_gt3", Skin: "red"}}, report)
+
+	if !report.finish().OK || len(report.Problems) != 0 {
+		t.Errorf("expected an installed track and car to be valid, got %v", report.Problems)
+	}
+
+	report = &ValidationReport{}
+
+	validateTrack(CurrentRaceConfig{Track: "ks_vallelunga", TrackLayout: "extended_circuit"}, report)
+	validateCars(CurrentRaceConfig{Cars: "ks_ferrari_488_gt3;ks_audi_r8_lms;ks_porsche_911_gt3_r"}, EntryList{
+		"CAR_0": {Name: "Driver", Model: "ks_ferrari_488_gt3", Skin: "blue"},
+		"CAR_1": {Name: "Another Driver", Model: "ks_mclaren_650_gt3"},
+	}, report)
+
+	expected := []ValidationProblem{
+		{Message: "The layout extended_circuit of ks_vallelunga is not installed"},
+		{Message: "The car ks_porsche_911_gt3_r is not installed"},
+		{Message: "Another Driver's car, ks_mclaren_650_gt3, is not one of the event's cars"},
+		{Message: "Driver's skin, blue, is not installed for ks_ferrari_488_gt3", Warning: true},
+		{Message: "data.acd", Warning: true},
+	}
+
+	if report.finish().OK {
+		t.Error("expected a missing layout and cars not to be valid")
+	}
+
+	for _, problem := range expected {
+		found := false
+
+		for _, reported := range report.Problems {
+			if strings.Contains(reported.Message, problem.Message) && reported.Warning == problem.Warning {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Errorf("expected %q to be reported, got %v", problem.Message, report.Problems)
+		}
+	}
+}
+
+func TestAssettoServerProcess_ValidateStart(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	listener, err := net.Listen("tcp", ":0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	config.Steam.ExecutablePath = "acServer"
+
+	serverConfig := ConfigIniDefault()
+	serverConfig.GlobalServerConfig.TCPPort = listener.Addr().(*net.TCPAddr).Port
+	serverConfig.GlobalServerConfig.UDPPort = 0
+	serverConfig.GlobalServerConfig.HTTPPort = 0
+
+	problems := sp.ValidateStart(serverConfig)
+
+	if len(problems) != 2 {
+		t.Fatalf("expected the missing executable and the TCP port in use to be reported, got %v", problems)
+	}
+
+	if !strings.Contains(problems[0].Message, "executable") || !strings.Contains(problems[1].Message, "acServer TCP port") {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+
+	useTestServerScript(t, testServerScript)
+	listener.Close()
+
+	if problems := sp.ValidateStart(serverConfig); len(problems) != 0 {
+		t.Errorf("expected acServer to be able to start, got %v", problems)
+	}
+}
@@ -831,6 +831,46 @@ func (rwm *RaceWeekendManager) RestartActiveSession() error {
 	return rwm.RestartSession(rwm.activeRaceWeekend.RaceWeekendID.String(), rwm.activeRaceWeekend.SessionID.String())
 }
 
+// RestartActiveRaceWeekend restarts the race weekend of the running session from its first session.
+func (rwm *RaceWeekendManager) RestartActiveRaceWeekend() error {
+	if !rwm.RaceWeekendSessionIsRunning() {
+		return ErrNoActiveRaceWeekendSession
+	}
+
+	return rwm.RestartRaceWeekend(rwm.activeRaceWeekend.RaceWeekendID.String())
+}
+
+// RestartRaceWeekend clears the results of every session in the race weekend, then starts its first session.
+func (rwm *RaceWeekendManager) RestartRaceWeekend(raceWeekendID string) error {
+	raceWeekend, err := rwm.LoadRaceWeekend(raceWeekendID)
+
+	if err != nil {
+		return err
+	}
+
+	sessions := raceWeekend.SortedSessions()
+
+	if len(sessions) == 0 {
+		return ErrRaceWeekendSessionNotFound
+	}
+
+	for _, session := range raceWeekend.Sessions {
+		session.StartedTime = time.Time{}
+		session.CompletedTime = time.Time{}
+		session.Results = nil
+	}
+
+	if err := rwm.process.Stop(); err != nil {
+		return err
+	}
+
+	if err := rwm.UpsertRaceWeekend(raceWeekend); err != nil {
+		return err
+	}
+
+	return rwm.StartSession(raceWeekendID, sessions[0].ID.String(), false)
+}
+
 func (rwm *RaceWeekendManager) ImportSession(raceWeekendID string, raceWeekendSessionID string, r *http.Request) error {
 	if !Premium() {
 		return errors.New("servermanager: premium required")
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
 )
 
 type Resolver struct {
@@ -23,6 +25,8 @@ type Resolver struct {
 
 	viewRenderer          *Renderer
 	serverProcess         ServerProcess
+	serverPool            *ServerPool
+	telemetrySink         TelemetrySink
 	raceControl           *RaceControl
 	raceControlHub        *RaceControlHub
 	contentManagerWrapper *ContentManagerWrapper
@@ -59,6 +63,10 @@ func NewResolver(templateLoader TemplateLoader, reloadTemplates bool, store Stor
 		store:           store,
 	}
 
+	if err := r.initServerProcess(); err != nil {
+		return nil, err
+	}
+
 	if err := r.initViewRenderer(); err != nil {
 		return nil, err
 	}
@@ -81,6 +89,8 @@ func (r *Resolver) UDPCallback(message udp.Message) {
 		r.resolveRaceManager().LoopCallback(message)
 		r.resolveContentManagerWrapper().UDPCallback(message)
 	}
+
+	callUDPHandlers(r.resolveServerProcess(), message)
 }
 
 func (r *Resolver) initViewRenderer() error {
@@ -115,16 +125,131 @@ func (r *Resolver) ResolveStore() Store {
 	return r.store
 }
 
-func (r *Resolver) resolveServerProcess() ServerProcess {
-	if r.serverProcess != nil {
-		return r.serverProcess
+func (r *Resolver) initServerProcess() error {
+	opts := []ServerProcessOption{
+		WithStopTimeouts(config.Server.StopGraceTimeout, config.Server.StopHardTimeout),
+		WithCrashRestartPolicy(config.Server.CrashRestart),
+		WithStartupTimeout(config.Server.StartupTimeout),
+		WithUDPSendInterval(config.Server.UDPSendInterval),
+		WithUDPSendBatchSize(config.Server.UDPSendBatchSize),
+		WithUDPSendRetries(config.Server.UDPSendRetries),
+		WithMaintenanceWindows(config.Server.MaintenanceWindows),
+		WithHookScripts(config.Server.Hooks),
+		WithStopWarning(config.Server.StopWarning),
+		WithForwardingTargets(config.Server.UDPForwardingTargets),
+	}
+
+	if config.Server.Docker.Image != "" {
+		opts = append(opts, WithDocker(config.Server.Docker))
 	}
 
-	r.serverProcess = NewAssettoServerProcess(r.UDPCallback, r.ResolveStore(), r.resolveContentManagerWrapper())
+	if telemetry := r.resolveTelemetrySink(); telemetry != nil {
+		opts = append(opts, WithTelemetry(telemetry, config.Server.Telemetry.SampleInterval))
+	}
+
+	if config.Server.Remote.Host != "" {
+		opts = append(opts, WithRemote(config.Server.Remote))
+	}
+
+	serverProcess, err := NewAssettoServerProcess(r.UDPCallback, r.ResolveStore(), r.resolveContentManagerWrapper(), opts...)
 
+	if err != nil {
+		return err
+	}
+
+	if config.Server.LifecycleEvents.NATSURL != "" {
+		natsSink, err := NewNATSEventSink(config.Server.LifecycleEvents.NATSURL, config.Server.LifecycleEvents.Subject)
+
+		if err != nil {
+			logrus.WithError(err).Error("Could not set up lifecycle events, they will not be published")
+		} else {
+			serverProcess.AddEventSink(natsSink)
+		}
+	}
+
+	if config.Server.ResultsUpload.URL != "" {
+		resultsUploader, err := NewResultsUploader(config.Server.ResultsUpload, serverProcess.emit)
+
+		if err != nil {
+			logrus.WithError(err).Error("Could not set up results upload, results will not be uploaded")
+		} else {
+			serverProcess.AddFeatureEventSink(FeatureResultsUpload, resultsUploader)
+		}
+	}
+
+	r.serverProcess = serverProcess
+
+	if config.Monitoring.Enabled {
+		serverProcess.AddEventSink(processMetricsSink{})
+
+		if err := prometheus.Register(newServerMetricsCollector(serverProcess, r.ResolveRaceControl())); err != nil {
+			logrus.WithError(err).Error("Could not register server metrics")
+		}
+	}
+
+	return nil
+}
+
+// resolveTelemetrySink returns the sink which the main server and the server pool write telemetry to, or nil if
+// telemetry isn't captured.
+func (r *Resolver) resolveTelemetrySink() TelemetrySink {
+	if r.telemetrySink != nil || config.Server.Telemetry.Sink == "" {
+		return r.telemetrySink
+	}
+
+	sink, err := NewTelemetrySink(config.Server.Telemetry)
+
+	if err != nil {
+		logrus.WithError(err).Error("Could not set up telemetry, it will not be captured")
+		return nil
+	}
+
+	r.telemetrySink = sink
+
+	return sink
+}
+
+func (r *Resolver) resolveServerProcess() ServerProcess {
 	return r.serverProcess
 }
 
+func (r *Resolver) resolveServerPool() *ServerPool {
+	if r.serverPool != nil {
+		return r.serverPool
+	}
+
+	r.serverPool = NewServerPool(r.resolveServerProcess())
+
+	opts := []ServerProcessOption{
+		WithStopTimeouts(config.Server.StopGraceTimeout, config.Server.StopHardTimeout),
+		WithCrashRestartPolicy(config.Server.CrashRestart),
+		WithStartupTimeout(config.Server.StartupTimeout),
+		WithUDPSendInterval(config.Server.UDPSendInterval),
+		WithUDPSendBatchSize(config.Server.UDPSendBatchSize),
+		WithUDPSendRetries(config.Server.UDPSendRetries),
+		WithHookScripts(config.Server.Hooks),
+		WithStopWarning(config.Server.StopWarning),
+	}
+
+	if config.Server.Docker.Image != "" {
+		opts = append(opts, WithDocker(config.Server.Docker))
+	}
+
+	if telemetry := r.resolveTelemetrySink(); telemetry != nil {
+		opts = append(opts, WithTelemetry(telemetry, config.Server.Telemetry.SampleInterval))
+	}
+
+	for _, server := range config.Server.Servers {
+		err := r.serverPool.AddServer(server, r.ResolveStore(), opts...)
+
+		if err != nil {
+			logrus.WithError(err).Errorf("Could not add server %s to the pool, events can't be started on it", server.Name)
+		}
+	}
+
+	return r.serverPool
+}
+
 func (r *Resolver) resolveContentManagerWrapper() *ContentManagerWrapper {
 	if r.contentManagerWrapper != nil {
 		return r.contentManagerWrapper
@@ -151,6 +276,7 @@ func (r *Resolver) resolveRaceManager() *RaceManager {
 		r.resolveTrackManager(),
 		r.resolveNotificationManager(),
 		r.ResolveRaceControl(),
+		r.resolveServerPool(),
 	)
 
 	return r.raceManager
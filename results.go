@@ -38,6 +38,10 @@ type SessionResults struct {
 	SessionFile    string           `json:"SessionFile"`
 	ChampionshipID string           `json:"ChampionshipID"`
 	RaceWeekendID  string           `json:"RaceWeekendID"`
+
+	// ServerBuild is the build of acServer which ran the session. It is nil for results from before builds were
+	// recorded.
+	ServerBuild *ServerBuild `json:"ServerBuild,omitempty"`
 }
 
 var ErrSessionCarNotFound = errors.New("servermanager: session car not found")
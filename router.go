@@ -100,6 +100,13 @@ func Router(
 		http.NotFound(w, r)
 	})
 
+	// live UDP messages, for readers or anything with a udp_stream token
+	r.Group(func(r chi.Router) {
+		r.Use(udpStreamTokenMiddleware(accountHandler.ReadAccessMiddleware))
+
+		r.Get("/api/udp/stream", serverAdministrationHandler.streamUDPMessages)
+	})
+
 	// readers
 	r.Group(func(r chi.Router) {
 		r.Use(accountHandler.ReadAccessMiddleware)
@@ -159,6 +166,7 @@ func Router(
 
 			r.Get("/live-timing", raceControlHandler.liveTiming)
 			r.Get("/api/race-control", raceControlHandler.websocket)
+			r.Get("/api/race-control/stints", raceControlHandler.stints)
 		})
 
 		// calendar
@@ -220,12 +228,29 @@ func Router(
 		r.Get("/custom/star/{uuid}", customRaceHandler.star)
 		r.Get("/custom/loop/{uuid}", customRaceHandler.loop)
 		r.Post("/custom/new/submit", customRaceHandler.submit)
+		r.Post("/custom/new/validate", customRaceHandler.validate)
 
 		// server management
 		r.Get("/process/{action}", serverAdministrationHandler.serverProcess)
 		r.Get("/logs", serverAdministrationHandler.logs)
+		r.Get("/plugins", serverAdministrationHandler.pluginsPage)
+		r.Get("/crashes/{id}", serverAdministrationHandler.crashReport)
 		r.Get("/api/logs", serverAdministrationHandler.logsAPI)
+		r.Get("/api/logs/structured", serverAdministrationHandler.structuredLogsAPI)
+		r.Get("/api/logs/stream", serverAdministrationHandler.logsStream)
+		r.Get("/api/logs/lines", serverAdministrationHandler.logLines)
+		r.Get("/api/logs/filtered", serverAdministrationHandler.filteredLogsAPI)
 		r.Get("/api/log-download/{logFile}", serverAdministrationHandler.logsDownload)
+		r.Get("/api/log-files", serverAdministrationHandler.logFiles)
+		r.Get("/api/log-files/{name}", serverAdministrationHandler.logFileDownload)
+		r.Get("/api/servers", serverAdministrationHandler.servers)
+		r.Get("/api/health", serverAdministrationHandler.health)
+		r.Get("/api/session-state", serverAdministrationHandler.sessionState)
+		r.Get("/api/plugins", serverAdministrationHandler.plugins)
+		r.Get("/api/plugins/{name}/log", serverAdministrationHandler.pluginLog)
+		r.Get("/api/crashes", serverAdministrationHandler.crashes)
+		r.Get("/api/incidents", serverAdministrationHandler.incidents)
+		r.Get("/api/incidents/{id}", serverAdministrationHandler.sessionIncidents)
 
 		// championships
 		r.Get("/championships/new", championshipsHandler.createOrEdit)
@@ -328,9 +353,33 @@ func Router(
 		}
 
 		r.HandleFunc("/server-options", serverAdministrationHandler.options)
+		r.Post("/server-options/refresh-cache", serverAdministrationHandler.refreshOptionsCache)
 		r.HandleFunc("/blacklist", serverAdministrationHandler.blacklist)
 		r.HandleFunc("/motd", serverAdministrationHandler.motd)
 		r.HandleFunc("/current-config", serverAdministrationHandler.currentConfig)
+		r.Get("/api/diagnostics", serverAdministrationHandler.diagnostics)
+		r.Post("/process/simulate-crash", serverAdministrationHandler.simulateCrash)
+		r.Post("/process/force-stop", serverAdministrationHandler.forceStop)
+		r.Get("/api/udp-recordings", serverAdministrationHandler.udpRecordings)
+		r.Post("/api/udp-recordings/{name}/replay", serverAdministrationHandler.replayUDPRecording)
+		r.Get("/api/forwarding-targets", serverAdministrationHandler.forwardingTargets)
+		r.Get("/udp-link", serverAdministrationHandler.udpLinkPage)
+		r.Get("/api/udp-link", serverAdministrationHandler.udpLink)
+		r.Get("/api/availability", serverAdministrationHandler.availability)
+		r.Put("/api/forwarding-targets", serverAdministrationHandler.setForwardingTargets)
+		r.Get("/api/features", serverAdministrationHandler.features)
+		r.Put("/api/features", serverAdministrationHandler.setFeatures)
+		r.Post("/api/admin/kick/{carID}", serverAdministrationHandler.kickCar)
+		r.Post("/api/admin/ban/{carID}", serverAdministrationHandler.banCar)
+		r.Post("/api/admin/next-session", serverAdministrationHandler.nextSession)
+		r.Post("/api/admin/restart-session", serverAdministrationHandler.restartSession)
+		r.Post("/api/admin/restart-event", serverAdministrationHandler.restartEvent)
+		r.Get("/api/ballast", serverAdministrationHandler.driverBallasts)
+		r.Put("/api/ballast/{driverGUID}", serverAdministrationHandler.setDriverBallast)
+		r.Delete("/api/ballast/{driverGUID}", serverAdministrationHandler.deleteDriverBallast)
+		r.HandleFunc("/live-weather", serverAdministrationHandler.liveWeatherPage)
+		r.Get("/api/weather/live", serverAdministrationHandler.liveWeather)
+		r.Put("/api/weather/live", serverAdministrationHandler.setLiveWeather)
 		r.HandleFunc("/audit-logs", auditLogHandler.viewLogs)
 		r.HandleFunc("/accounts/new", accountHandler.createOrEditAccount)
 		r.HandleFunc("/accounts/edit/{id}", accountHandler.createOrEditAccount)
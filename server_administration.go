@@ -2,15 +2,19 @@ package servermanager
 
 import (
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
 	"github.com/go-chi/chi"
+	"github.com/gorilla/websocket"
 	"github.com/mitchellh/go-wordwrap"
 	"github.com/sirupsen/logrus"
 )
@@ -51,6 +55,10 @@ type homeTemplateVars struct {
 
 	RaceDetails     *CustomRace
 	PerformanceMode bool
+
+	// Health is the health of acServer, and Plugins the status of each of its plugins, if an event is in progress.
+	Health  *ProcessHealth
+	Plugins []PluginHealthStatus
 }
 
 // homeHandler serves content to /
@@ -58,14 +66,22 @@ func (sah *ServerAdministrationHandler) home(w http.ResponseWriter, r *http.Requ
 	currentRace, entryList := sah.raceManager.CurrentRace()
 
 	var customRace *CustomRace
+	var health *ProcessHealth
+	var plugins []PluginHealthStatus
 
 	if currentRace != nil {
 		customRace = &CustomRace{EntryList: entryList, RaceConfig: currentRace.CurrentRaceConfig}
+
+		processHealth := sah.process.Health()
+		health = &processHealth
+		plugins = sah.process.PluginHealth()
 	}
 
 	sah.viewRenderer.MustLoadTemplate(w, r, "home.html", &homeTemplateVars{
 		RaceDetails:     customRace,
 		PerformanceMode: config.Server.PerformanceMode,
+		Health:          health,
+		Plugins:         plugins,
 	})
 }
 
@@ -168,10 +184,21 @@ func (sah *ServerAdministrationHandler) currentConfig(w http.ResponseWriter, r *
 type serverOptionsTemplateVars struct {
 	BaseTemplateVars
 
-	Form template.HTML
+	Form          template.HTML
+	IsRunning     bool
+	OptionsCached bool
+
+	// Server is the name of the pooled server whose options are shown. It is empty for the default server.
+	Server  string
+	Servers []string
 }
 
 func (sah *ServerAdministrationHandler) options(w http.ResponseWriter, r *http.Request) {
+	if serverName := r.URL.Query().Get("server"); serverName != "" && serverName != DefaultServerName {
+		sah.pooledServerOptions(w, r, serverName)
+		return
+	}
+
 	serverOpts, err := sah.raceManager.LoadServerOptions()
 
 	if err != nil {
@@ -181,23 +208,31 @@ func (sah *ServerAdministrationHandler) options(w http.ResponseWriter, r *http.R
 	}
 
 	if r.Method == http.MethodPost {
+		previousOpts := *serverOpts
+
 		err := DecodeFormData(serverOpts, r)
 
 		if err != nil {
 			logrus.WithError(err).Errorf("couldn't submit form")
 		}
 
+		serverOpts.CPUQuotaPercent = clampCPUQuotaPercent(serverOpts.CPUQuotaPercent)
+
 		UseShortenedDriverNames = serverOpts.UseShortenedDriverNames == 1
 		UseFallBackSorting = serverOpts.FallBackResultsSorting == 1
 
-		// save the config
-		err = sah.raceManager.SaveServerOptions(serverOpts)
-
-		if err != nil {
-			logrus.WithError(err).Errorf("couldn't save config")
-			AddErrorFlash(w, r, "Failed to save server options")
+		if _, err := parseExitCodeRestartPolicy(serverOpts.AutoRestartExitCodes); err != nil {
+			AddErrorFlash(w, r, "Failed to save server options, the auto restart exit codes are invalid: "+err.Error())
 		} else {
-			AddFlash(w, r, "Server options successfully saved!")
+			// save the config
+			err = sah.raceManager.SaveServerOptions(serverOpts)
+
+			if err != nil {
+				logrus.WithError(err).Errorf("couldn't save config")
+				AddErrorFlash(w, r, "Failed to save server options")
+			} else {
+				sah.applyServerOptions(w, r, changedServerOptions(&previousOpts, serverOpts))
+			}
 		}
 
 		// update ACSR options to the client
@@ -214,11 +249,113 @@ func (sah *ServerAdministrationHandler) options(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	_, optionsCached := sah.store.(*OptionsCacheStore)
+
+	sah.viewRenderer.MustLoadTemplate(w, r, "server/options.html", &serverOptionsTemplateVars{
+		Form:          form,
+		IsRunning:     sah.process.IsRunning(),
+		OptionsCached: optionsCached,
+		Servers:       sah.raceManager.ServerNames(),
+	})
+}
+
+// pooledServerOptions shows and saves the server options of a pooled server. They are used the next time an event is
+// started on the server, with the server's own game and UDP plugin ports from config.yml.
+func (sah *ServerAdministrationHandler) pooledServerOptions(w http.ResponseWriter, r *http.Request, serverName string) {
+	serverOpts, err := sah.raceManager.LoadServerOptionsFor(serverName)
+
+	if err == ErrUnknownServer {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("couldn't load server options for server %s", serverName)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		err := DecodeFormData(serverOpts, r)
+
+		if err != nil {
+			logrus.WithError(err).Errorf("couldn't submit form")
+		}
+
+		serverOpts.CPUQuotaPercent = clampCPUQuotaPercent(serverOpts.CPUQuotaPercent)
+
+		if _, err := parseExitCodeRestartPolicy(serverOpts.AutoRestartExitCodes); err != nil {
+			AddErrorFlash(w, r, "Failed to save server options, the auto restart exit codes are invalid: "+err.Error())
+		} else if err := sah.raceManager.SaveServerOptionsFor(serverName, serverOpts); err != nil {
+			logrus.WithError(err).Errorf("couldn't save config for server %s", serverName)
+			AddErrorFlash(w, r, "Failed to save server options")
+		} else {
+			AddFlash(w, r, fmt.Sprintf("Server options for %s successfully saved, they will be used the next time an event is started on it.", serverName))
+		}
+	}
+
+	form, err := EncodeFormData(serverOpts, r)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't encode form data")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
 	sah.viewRenderer.MustLoadTemplate(w, r, "server/options.html", &serverOptionsTemplateVars{
-		Form: form,
+		Form:    form,
+		Server:  serverName,
+		Servers: sah.raceManager.ServerNames(),
 	})
 }
 
+// refreshOptionsCache discards the options cached in memory, so that changes made to the store outside of Server
+// Manager (e.g. by editing its files by hand) are picked up without restarting Server Manager.
+func (sah *ServerAdministrationHandler) refreshOptionsCache(w http.ResponseWriter, r *http.Request) {
+	if cache, ok := sah.store.(*OptionsCacheStore); ok {
+		cache.Refresh()
+
+		auditAction(sah.store, r, "Refreshed the options cache")
+		AddFlash(w, r, "The options cache was refreshed, options will next be loaded from the store")
+	} else {
+		AddErrorFlash(w, r, "Options aren't cached, there is nothing to refresh")
+	}
+
+	http.Redirect(w, r, "/server-options", http.StatusFound)
+}
+
+// applyServerOptions tells the user how saved server options take effect on the running event. acServer is only
+// restarted if a change needs it and the user asked for it, in which case server_cfg.ini is rewritten first.
+func (sah *ServerAdministrationHandler) applyServerOptions(w http.ResponseWriter, r *http.Request, changes []ServerOptionChange) {
+	needingRestart := serverOptionsNeedingRestart(changes)
+
+	switch {
+	case !sah.process.IsRunning() || len(changes) == 0:
+		AddFlash(w, r, "Server options successfully saved!")
+	case needingRestart == "":
+		AddFlash(w, r, "Server options successfully saved and applied, the server didn't need to be restarted.")
+	case r.FormValue("apply") != "restart":
+		AddFlash(w, r, "Server options successfully saved. The server needs to be restarted for these changes to take effect: "+needingRestart)
+	default:
+		event := sah.process.Event()
+
+		var err error
+
+		if event.IsChampionship() && !event.IsPractice() {
+			err = sah.championshipManager.RestartActiveEvent()
+		} else if event.IsRaceWeekend() && !event.IsPractice() {
+			err = sah.raceWeekendManager.RestartActiveSession()
+		} else {
+			err = sah.raceManager.RestartActiveEvent()
+		}
+
+		if err != nil {
+			logrus.WithError(err).Error("couldn't restart the server to apply server options")
+			AddErrorFlash(w, r, "Server options successfully saved, but the server couldn't be restarted to apply them")
+		} else {
+			AddFlash(w, r, "Server options successfully saved, and the server was restarted to apply them")
+		}
+	}
+}
+
 type serverBlacklistTemplateVars struct {
 	BaseTemplateVars
 
@@ -301,12 +438,124 @@ func (sah *ServerAdministrationHandler) autoFillEntrantDelete(w http.ResponseWri
 	http.Redirect(w, r, r.Referer(), http.StatusFound)
 }
 
+type logsTemplateVars struct {
+	BaseTemplateVars
+
+	// Crashes are the most recent crashes of acServer, newest first.
+	Crashes []*CrashReport
+
+	// LogFiles are the server log files kept on disk, newest first. LogFilesEnabled is false if no log file
+	// directory is configured.
+	LogFiles        []ServerLogFile
+	LogFilesEnabled bool
+}
+
 func (sah *ServerAdministrationHandler) logs(w http.ResponseWriter, r *http.Request) {
-	sah.viewRenderer.MustLoadTemplate(w, r, "server/logs.html", &BaseTemplateVars{
-		WideContainer: true,
+	logFiles, err := sah.process.ServerLogFiles()
+
+	if err != nil && err != ErrServerLogFilesDisabled {
+		logrus.WithError(err).Error("could not list server log files")
+	}
+
+	sah.viewRenderer.MustLoadTemplate(w, r, "server/logs.html", &logsTemplateVars{
+		BaseTemplateVars: BaseTemplateVars{
+			WideContainer: true,
+		},
+		Crashes:         sah.crashHistory(),
+		LogFiles:        logFiles,
+		LogFilesEnabled: err != ErrServerLogFilesDisabled,
 	})
 }
 
+// crashHistory returns the crash reports kept in the store, newest first, without their diagnostics bundles. The
+// bundles of the most recent crashes are included in the diagnostics export.
+func (sah *ServerAdministrationHandler) crashHistory() []*CrashReport {
+	reports, err := sah.store.ListCrashReports()
+
+	if err != nil {
+		logrus.WithError(err).Error("could not load crash reports, only showing crashes since Server Manager was started")
+		reports = sah.process.CrashReports()
+	}
+
+	history := make([]*CrashReport, 0, len(reports))
+
+	for i := len(reports) - 1; i >= 0; i-- {
+		report := *reports[i]
+		report.Bundle = nil
+
+		history = append(history, &report)
+	}
+
+	return history
+}
+
+type crashReportTemplateVars struct {
+	BaseTemplateVars
+
+	Report *CrashReport
+}
+
+// crashReport shows everything that was captured when acServer crashed, for a post-mortem.
+func (sah *ServerAdministrationHandler) crashReport(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	for _, report := range sah.crashHistory() {
+		if report.ID.String() != id {
+			continue
+		}
+
+		sah.viewRenderer.MustLoadTemplate(w, r, "server/crash.html", &crashReportTemplateVars{
+			BaseTemplateVars: BaseTemplateVars{
+				WideContainer: true,
+			},
+			Report: report,
+		})
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
+
+// crashes returns the crash history of acServer as JSON.
+func (sah *ServerAdministrationHandler) crashes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.crashHistory())
+}
+
+// incidents returns the incidents in each session, most recent session first.
+func (sah *ServerAdministrationHandler) incidents(w http.ResponseWriter, r *http.Request) {
+	sessions, err := sah.store.ListSessionIncidents()
+
+	if err != nil {
+		logrus.WithError(err).Error("could not load incidents")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sessions)
+}
+
+// sessionIncidents returns the incidents in a single session.
+func (sah *ServerAdministrationHandler) sessionIncidents(w http.ResponseWriter, r *http.Request) {
+	session, err := sah.store.LoadSessionIncidents(chi.URLParam(r, "id"))
+
+	if err == ErrSessionIncidentsNotFound {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("could not load session incidents")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(session)
+}
+
 type logData struct {
 	ServerLog, ManagerLog, PluginsLog string
 }
@@ -321,6 +570,159 @@ func (sah *ServerAdministrationHandler) logsAPI(w http.ResponseWriter, r *http.R
 	})
 }
 
+// logsStream streams the server log over a websocket, one TailLine per message. Clients which reconnect can pass the
+// offset of the next line they need, so that they aren't sent the lines they already have.
+func (sah *ServerAdministrationHandler) logsStream(w http.ResponseWriter, r *http.Request) {
+	var offset int64
+
+	if o := r.URL.Query().Get("offset"); o != "" {
+		var err error
+
+		offset, err = strconv.ParseInt(o, 10, 64)
+
+		if err != nil || offset < 0 {
+			http.Error(w, "offset must be a number of zero or more", http.StatusBadRequest)
+			return
+		}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		logrus.WithError(err).Error("could not upgrade server log stream to a websocket")
+		return
+	}
+
+	defer conn.Close()
+
+	lines, cancel := sah.process.TailFrom(offset)
+	defer cancel()
+
+	// nothing is read from the client, but reading is needed to notice that it has gone away.
+	disconnected := make(chan struct{})
+
+	go func() {
+		defer close(disconnected)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second * 10)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := conn.WriteJSON(line); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-disconnected:
+			return
+		}
+	}
+}
+
+// logLinesResponse is a page of the server log. Offset is what to pass as the offset query parameter for the next page.
+type logLinesResponse struct {
+	Offset int64
+	Lines  []TailLine
+}
+
+// logLines returns the lines of the server log from the offset query parameter onwards as JSON, at most max of them,
+// so that clients can tail the log by polling.
+func (sah *ServerAdministrationHandler) logLines(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var offset int64
+
+	if o := query.Get("offset"); o != "" {
+		var err error
+
+		offset, err = strconv.ParseInt(o, 10, 64)
+
+		if err != nil || offset < 0 {
+			http.Error(w, "offset must be a number of zero or more", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var maxLines int
+
+	if m := query.Get("max"); m != "" {
+		var err error
+
+		maxLines, err = strconv.Atoi(m)
+
+		if err != nil || maxLines < 0 {
+			http.Error(w, "max must be a number of zero or more", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var resp logLinesResponse
+
+	resp.Offset, resp.Lines = sah.process.LogLines(offset, maxLines)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (sah *ServerAdministrationHandler) structuredLogsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.process.LogsJSON())
+}
+
+// filteredLogsAPI returns the lines of acServer output selected by the match, regex, level and lines query parameters.
+func (sah *ServerAdministrationHandler) filteredLogsAPI(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := LogFilter{
+		Match:    query.Get("match"),
+		Regex:    query.Get("regex") == "on" || query.Get("regex") == "1",
+		MinLevel: query.Get("level"),
+	}
+
+	if lines := query.Get("lines"); lines != "" {
+		maxLines, err := strconv.Atoi(lines)
+
+		if err != nil || maxLines < 0 {
+			http.Error(w, "lines must be a number of zero or more", http.StatusBadRequest)
+			return
+		}
+
+		filter.MaxLines = maxLines
+	}
+
+	logs, err := sah.process.FilteredLogs(filter)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	_, _ = w.Write([]byte(logs))
+}
+
 // downloading logfiles
 func (sah *ServerAdministrationHandler) logsDownload(w http.ResponseWriter, r *http.Request) {
 	logFile := chi.URLParam(r, "logFile")
@@ -328,10 +730,18 @@ func (sah *ServerAdministrationHandler) logsDownload(w http.ResponseWriter, r *h
 
 	if logFile == "server" {
 		outputString = sah.process.Logs()
+	} else if logFile == "server-previous" {
+		outputString = sah.process.PreviousLogs()
 	} else if logFile == "manager" {
 		outputString = logOutput.String()
 	} else if logFile == "plugins" {
-		outputString = pluginsOutput.String()
+		if plugin := r.URL.Query().Get("plugin"); plugin != "" {
+			// the output of a single plugin
+			outputString = sah.process.PluginLogs(plugin)
+			logFile += "_" + logFileNameRegex.ReplaceAllString(strings.ToLower(plugin), "-")
+		} else {
+			outputString = pluginsOutput.String()
+		}
 	} else {
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 		return
@@ -349,61 +759,442 @@ func (sah *ServerAdministrationHandler) logsDownload(w http.ResponseWriter, r *h
 	}
 }
 
-// serverProcessHandler modifies the server process.
-func (sah *ServerAdministrationHandler) serverProcess(w http.ResponseWriter, r *http.Request) {
-	var err error
-	var txt string
-
-	event := sah.process.Event()
+// logFiles lists the server log files kept on disk as JSON, newest first.
+func (sah *ServerAdministrationHandler) logFiles(w http.ResponseWriter, r *http.Request) {
+	logFiles, err := sah.process.ServerLogFiles()
 
-	switch chi.URLParam(r, "action") {
-	case "stop":
-		if event.IsChampionship() && !event.IsPractice() {
-			err = sah.championshipManager.StopActiveEvent()
-		} else if event.IsRaceWeekend() && !event.IsPractice() {
-			err = sah.raceWeekendManager.StopActiveSession()
-		} else {
-			err = sah.process.Stop()
-		}
-		txt = "stopped"
-	case "restart":
-		if event.IsChampionship() && !event.IsPractice() {
-			err = sah.championshipManager.RestartActiveEvent()
-		} else if event.IsRaceWeekend() && !event.IsPractice() {
-			err = sah.raceWeekendManager.RestartActiveSession()
-		} else {
-			err = sah.process.Restart()
-		}
-		txt = "restarted"
+	if err == ErrServerLogFilesDisabled {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("could not list server log files")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
 	}
 
-	noun := "Server"
+	w.Header().Set("Content-Type", "application/json")
 
-	if event.IsChampionship() {
-		noun = "Championship"
-	} else if event.IsRaceWeekend() {
-		noun = "Race Weekend"
-	}
+	_ = json.NewEncoder(w).Encode(logFiles)
+}
 
-	if event.IsPractice() {
-		noun += " Practice"
+// logFileDownload downloads one of the server log files kept on disk.
+func (sah *ServerAdministrationHandler) logFileDownload(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	f, err := sah.process.OpenServerLogFile(name)
+
+	if err == ErrServerLogFilesDisabled || err == ErrServerLogFileNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("could not open server log file %s", name)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
 	}
 
+	defer f.Close()
+
+	info, err := f.Stat()
+
 	if err != nil {
-		logrus.WithError(err).Errorf("could not change " + noun + " status")
-		AddErrorFlash(w, r, "Unable to change "+noun+" status")
-	} else {
-		AddFlash(w, r, noun+" successfully "+txt)
+		logrus.WithError(err).Errorf("could not stat server log file %s", name)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
 	}
 
-	http.Redirect(w, r, r.Referer(), http.StatusFound)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+name+"\"")
+
+	http.ServeContent(w, r, name, info.ModTime(), f)
 }
 
-type changelogTemplateVars struct {
-	BaseTemplateVars
+// diagnostics exports a redacted bundle of the server process state, config and recent logs.
+func (sah *ServerAdministrationHandler) diagnostics(w http.ResponseWriter, r *http.Request) {
+	bundle, err := sah.process.DiagnosticsBundle()
 
-	Changelog template.HTML
-}
+	if err != nil {
+		logrus.WithError(err).Error("could not build diagnostics bundle")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"diagnostics_"+time.Now().Format(time.RFC3339)+".json\"")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(bundle); err != nil {
+		logrus.WithError(err).Error("could not encode diagnostics bundle")
+	}
+}
+
+type udpLinkTemplateVars struct {
+	BaseTemplateVars
+
+	Link            *udp.LinkStats
+	NumObservers    int
+	DroppedMessages uint64
+	CallbackError   string
+}
+
+// udpLinkPage shows how well messages from acServer are being received and handled, for diagnosing laggy live timing.
+func (sah *ServerAdministrationHandler) udpLinkPage(w http.ResponseWriter, r *http.Request) {
+	status := sah.process.Status()
+
+	sah.viewRenderer.MustLoadTemplate(w, r, "server/udp-link.html", &udpLinkTemplateVars{
+		Link:            status.UDPLink,
+		NumObservers:    status.NumUDPObservers,
+		DroppedMessages: status.UDPObserverDroppedMessages,
+		CallbackError:   status.UDPCallbackError,
+	})
+}
+
+// udpLink returns the UDP link stats as JSON, or null if there is no UDP connection to acServer.
+func (sah *ServerAdministrationHandler) udpLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.process.Status().UDPLink)
+}
+
+// forwardingTargets returns the status of each additional UDP forwarding target.
+func (sah *ServerAdministrationHandler) forwardingTargets(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.process.Status().ForwardingTargets)
+}
+
+// setForwardingTargets replaces the additional UDP forwarding targets with a JSON list of targets, e.g.
+// [{"Name": "stracker", "Address": "127.0.0.1:12000", "ListenPort": 12001, "Disabled": true}]
+func (sah *ServerAdministrationHandler) setForwardingTargets(w http.ResponseWriter, r *http.Request) {
+	var targets []udp.ForwardTarget
+
+	if err := json.NewDecoder(r.Body).Decode(&targets); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := sah.process.SetForwardingTargets(targets); err != nil {
+		logrus.WithError(err).Error("could not set forwarding targets")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sah.forwardingTargets(w, r)
+}
+
+// health returns the health of acServer as JSON. The status is 503 if an event is running but acServer isn't healthy.
+func (sah *ServerAdministrationHandler) health(w http.ResponseWriter, r *http.Request) {
+	health := sah.process.Health()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if sah.process.IsRunning() && !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(health)
+}
+
+// sessionState returns the state of the session which is running, see SessionState.
+func (sah *ServerAdministrationHandler) sessionState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.process.SessionState())
+}
+
+// pluginRecentLogLines is how many lines of each plugin's output are shown on the plugins page.
+const pluginRecentLogLines = 50
+
+type pluginStatus struct {
+	PluginHealthStatus
+
+	RecentLogs string
+}
+
+// pluginStatuses are the statuses of each plugin started with the current event, with the end of their output.
+func (sah *ServerAdministrationHandler) pluginStatuses() []pluginStatus {
+	health := sah.process.PluginHealth()
+	statuses := make([]pluginStatus, 0, len(health))
+
+	for _, plugin := range health {
+		statuses = append(statuses, pluginStatus{
+			PluginHealthStatus: plugin,
+			RecentLogs:         lastLines(strings.TrimRight(sah.process.PluginLogs(plugin.Name), "\n"), pluginRecentLogLines),
+		})
+	}
+
+	return statuses
+}
+
+type pluginsTemplateVars struct {
+	BaseTemplateVars
+
+	Plugins   []pluginStatus
+	IsRunning bool
+}
+
+// pluginsPage shows whether each plugin launched for the current event, and what it has output recently.
+func (sah *ServerAdministrationHandler) pluginsPage(w http.ResponseWriter, r *http.Request) {
+	sah.viewRenderer.MustLoadTemplate(w, r, "server/plugins.html", &pluginsTemplateVars{
+		BaseTemplateVars: BaseTemplateVars{
+			WideContainer: true,
+		},
+		Plugins:   sah.pluginStatuses(),
+		IsRunning: sah.process.IsRunning(),
+	})
+}
+
+// pluginLog shows the whole output of a single plugin as plain text.
+func (sah *ServerAdministrationHandler) pluginLog(w http.ResponseWriter, r *http.Request) {
+	logs := sah.process.PluginLogs(chi.URLParam(r, "name"))
+
+	if logs == "" {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	_, _ = w.Write([]byte(logs))
+}
+
+// plugins returns the status of each plugin started with the current event as JSON.
+func (sah *ServerAdministrationHandler) plugins(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.pluginStatuses())
+}
+
+// servers returns the status of each server which events can be started on.
+func (sah *ServerAdministrationHandler) servers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.raceManager.ServerStatuses())
+}
+
+// features returns whether each feature of the server process is enabled.
+func (sah *ServerAdministrationHandler) features(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(sah.process.Status().Features)
+}
+
+// setFeatures enables or disables the features in a JSON object of feature names, e.g. {"host-metrics": false}.
+// Features which aren't in the object are left as they are.
+func (sah *ServerAdministrationHandler) setFeatures(w http.ResponseWriter, r *http.Request) {
+	var features map[Feature]bool
+
+	if err := json.NewDecoder(r.Body).Decode(&features); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateFeatures(features); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for feature, enabled := range features {
+		if err := sah.process.SetFeature(feature, enabled); err != nil {
+			logrus.WithError(err).Errorf("could not set feature %s", feature)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	sah.features(w, r)
+}
+
+// availabilityPeriod is the period covered by the availability API if no 'from' is given.
+const availabilityPeriod = time.Hour * 24 * 7
+
+// availability returns the uptime of acServer between the RFC3339 times 'from' and 'to'. By default it covers the
+// last week.
+func (sah *ServerAdministrationHandler) availability(w http.ResponseWriter, r *http.Request) {
+	to := time.Now()
+	from := to.Add(-availabilityPeriod)
+
+	var err error
+
+	if toParam := r.URL.Query().Get("to"); toParam != "" {
+		to, err = time.Parse(time.RFC3339, toParam)
+
+		if err != nil {
+			http.Error(w, "invalid 'to' time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		from = to.Add(-availabilityPeriod)
+	}
+
+	if fromParam := r.URL.Query().Get("from"); fromParam != "" {
+		from, err = time.Parse(time.RFC3339, fromParam)
+
+		if err != nil {
+			http.Error(w, "invalid 'from' time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	availability, err := sah.process.Availability(from, to)
+
+	if err != nil {
+		logrus.WithError(err).Error("could not compute availability")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(availability)
+}
+
+// simulateCrash kills acServer as if it had crashed, so that crash alerting and restarts can be checked.
+func (sah *ServerAdministrationHandler) simulateCrash(w http.ResponseWriter, r *http.Request) {
+	if err := sah.process.SimulateCrash(); err != nil {
+		logrus.WithError(err).Error("could not simulate crash")
+		AddErrorFlash(w, r, "Could not simulate a crash: "+err.Error())
+	} else {
+		AddFlash(w, r, "acServer was killed to simulate a crash")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+// forceStop kills acServer without waiting for it to exit cleanly, for when a normal stop is taking too long.
+func (sah *ServerAdministrationHandler) forceStop(w http.ResponseWriter, r *http.Request) {
+	if err := sah.process.ForceStop(); err != nil {
+		logrus.WithError(err).Error("could not force stop server")
+		AddErrorFlash(w, r, "Could not force stop the server: "+err.Error())
+	} else {
+		AddFlash(w, r, "acServer was killed")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+// udpRecordings lists the UDP recordings kept on disk as JSON, newest first.
+func (sah *ServerAdministrationHandler) udpRecordings(w http.ResponseWriter, r *http.Request) {
+	recordings, err := sah.process.UDPRecordings()
+
+	if err == ErrUDPRecordingDisabled {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("could not list UDP recordings")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(recordings)
+}
+
+// replayUDPRecording replays a UDP recording through live timing and championships, sped up by the speed query
+// parameter. The replay carries on in the background after the response is sent.
+func (sah *ServerAdministrationHandler) replayUDPRecording(w http.ResponseWriter, r *http.Request) {
+	speed := 1
+
+	if s := r.URL.Query().Get("speed"); s != "" {
+		var err error
+
+		speed, err = strconv.Atoi(s)
+
+		if err != nil || speed < 1 {
+			http.Error(w, "speed must be a number of one or more", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := sah.process.ReplayUDPRecording(chi.URLParam(r, "name"), speed)
+
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusAccepted)
+	case ErrUDPRecordingDisabled, ErrUDPRecordingNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case ErrUDPReplayServerRunning, ErrUDPReplayInProgress:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logrus.WithError(err).Error("could not replay UDP recording")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// serverProcessHandler modifies the server process.
+func (sah *ServerAdministrationHandler) serverProcess(w http.ResponseWriter, r *http.Request) {
+	var err error
+	var txt string
+
+	event := sah.process.Event()
+
+	switch chi.URLParam(r, "action") {
+	case "stop":
+		if event.IsChampionship() && !event.IsPractice() {
+			err = sah.championshipManager.StopActiveEvent()
+		} else if event.IsRaceWeekend() && !event.IsPractice() {
+			err = sah.raceWeekendManager.StopActiveSession()
+		} else {
+			err = sah.process.Stop()
+		}
+		txt = "stopped"
+	case "restart":
+		err = sah.restartActiveEvent(event)
+		txt = "restarted"
+	case "pause-forwarding":
+		sah.process.SetForwardingEnabled(false)
+		AddFlash(w, r, "UDP forwarding paused")
+		http.Redirect(w, r, r.Referer(), http.StatusFound)
+		return
+	case "resume-forwarding":
+		sah.process.SetForwardingEnabled(true)
+		AddFlash(w, r, "UDP forwarding resumed")
+		http.Redirect(w, r, r.Referer(), http.StatusFound)
+		return
+	}
+
+	noun := "Server"
+
+	if event.IsChampionship() {
+		noun = "Championship"
+	} else if event.IsRaceWeekend() {
+		noun = "Race Weekend"
+	}
+
+	if event.IsPractice() {
+		noun += " Practice"
+	}
+
+	if err != nil {
+		logrus.WithError(err).Errorf("could not change " + noun + " status")
+		AddErrorFlash(w, r, "Unable to change "+noun+" status")
+	} else {
+		auditAction(sah.store, r, noun+" "+txt)
+		AddFlash(w, r, noun+" successfully "+txt)
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+// restartActiveEvent starts event again from its first session. Championship events and race weekends are restarted
+// through their managers, so that their progress is reset too.
+func (sah *ServerAdministrationHandler) restartActiveEvent(event RaceEvent) error {
+	if event.IsChampionship() && !event.IsPractice() {
+		return sah.championshipManager.RestartActiveEvent()
+	} else if event.IsRaceWeekend() && !event.IsPractice() {
+		return sah.raceWeekendManager.RestartActiveRaceWeekend()
+	}
+
+	return sah.process.Restart()
+}
+
+type changelogTemplateVars struct {
+	BaseTemplateVars
+
+	Changelog template.HTML
+}
 
 func (sah *ServerAdministrationHandler) changelog(w http.ResponseWriter, r *http.Request) {
 	sah.viewRenderer.MustLoadTemplate(w, r, "changelog.html", &changelogTemplateVars{
@@ -439,3 +1230,282 @@ func (sah *ServerAdministrationHandler) robots(w http.ResponseWriter, r *http.Re
 		return
 	}
 }
+
+// adminActionResult replies to an admin action API request, and records the action in the audit log if it was sent to
+// acServer.
+func (sah *ServerAdministrationHandler) adminActionResult(w http.ResponseWriter, r *http.Request, action string, err error) {
+	if err == ErrNoOpenUDPConnection || err == ErrServerProcessNotRunning {
+		http.Error(w, "acServer is not running", http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("could not send admin action: %s", action)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	auditAction(sah.store, r, action)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminActionCar reads the carID URL parameter, describing the car for the audit log with its driver's name if it is
+// known.
+func (sah *ServerAdministrationHandler) adminActionCar(r *http.Request) (udp.CarID, string, error) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "carID"), 10, 8)
+
+	if err != nil {
+		return 0, "", fmt.Errorf("servermanager: invalid car ID: %s", chi.URLParam(r, "carID"))
+	}
+
+	carID := udp.CarID(id)
+	description := fmt.Sprintf("car %d", carID)
+
+	for _, car := range sah.process.SessionState().Cars {
+		if car.CarID == carID && car.DriverName != "" {
+			description = fmt.Sprintf("car %d (%s, %s)", carID, car.DriverName, car.DriverGUID)
+			break
+		}
+	}
+
+	return carID, description, nil
+}
+
+// kickCar kicks the driver of a car from the server.
+func (sah *ServerAdministrationHandler) kickCar(w http.ResponseWriter, r *http.Request) {
+	carID, car, err := sah.adminActionCar(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sah.adminActionResult(w, r, "Kicked "+car, sah.process.KickCar(carID))
+}
+
+// banCar bans the driver of a car from the server.
+func (sah *ServerAdministrationHandler) banCar(w http.ResponseWriter, r *http.Request) {
+	carID, car, err := sah.adminActionCar(r)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sah.adminActionResult(w, r, "Banned "+car, sah.process.BanCar(carID))
+}
+
+// nextSession moves the server on to its next session. acServer has no admin command to extend a running session or
+// to append sessions to it, so the session controls are limited to moving on and restarting.
+func (sah *ServerAdministrationHandler) nextSession(w http.ResponseWriter, r *http.Request) {
+	sah.adminActionResult(w, r, "Moved to the next session", sah.process.NextSession())
+}
+
+// restartSession restarts the server's current session.
+func (sah *ServerAdministrationHandler) restartSession(w http.ResponseWriter, r *http.Request) {
+	sah.adminActionResult(w, r, "Restarted the session", sah.process.RestartSession())
+}
+
+// restartEvent starts the running event again from its first session. A race weekend is restarted from the first
+// session of the weekend. Any results from the sessions so far are lost.
+func (sah *ServerAdministrationHandler) restartEvent(w http.ResponseWriter, r *http.Request) {
+	if !sah.process.IsRunning() {
+		sah.adminActionResult(w, r, "", ErrServerProcessNotRunning)
+		return
+	}
+
+	event := sah.process.Event()
+	action := "Restarted the event"
+
+	if event.IsRaceWeekend() && !event.IsPractice() {
+		action = "Restarted the race weekend"
+	}
+
+	sah.adminActionResult(w, r, action, sah.restartActiveEvent(event))
+}
+
+// driverBallasts returns the ballast and restrictor each driver is given at the start of a session.
+func (sah *ServerAdministrationHandler) driverBallasts(w http.ResponseWriter, r *http.Request) {
+	ballasts, err := sah.store.ListDriverBallasts()
+
+	if err != nil {
+		logrus.WithError(err).Error("could not list driver ballast")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(ballasts)
+}
+
+// setDriverBallast overrides a driver's ballast and restrictor, so that they are no longer changed by results. The
+// ballast is sent to acServer straight away if the driver is connected.
+func (sah *ServerAdministrationHandler) setDriverBallast(w http.ResponseWriter, r *http.Request) {
+	var ballast DriverBallast
+
+	if err := json.NewDecoder(r.Body).Decode(&ballast); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if ballast.BallastKG < 0 || ballast.Restrictor < 0 || ballast.Restrictor > maxRestrictor {
+		http.Error(w, "servermanager: ballast must not be negative, and restrictor must be between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
+	ballast.DriverGUID = chi.URLParam(r, "driverGUID")
+	ballast.Override = true
+	ballast.Updated = time.Now()
+
+	state := sah.process.SessionState()
+
+	for _, car := range state.Cars {
+		if string(car.DriverGUID) != ballast.DriverGUID {
+			continue
+		}
+
+		if ballast.DriverName == "" {
+			ballast.DriverName = car.DriverName
+		}
+
+		if car.Connected {
+			if err := sendBallast(sah.process, car.CarID, &ballast); err != nil && err != ErrNoOpenUDPConnection {
+				logrus.WithError(err).Errorf("could not send ballast to car %d", car.CarID)
+			}
+		}
+	}
+
+	if err := sah.store.UpsertDriverBallast(&ballast); err != nil {
+		logrus.WithError(err).Error("could not save driver ballast")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	auditAction(sah.store, r, fmt.Sprintf("Set ballast of %s to %dkg, %d%% restrictor", ballast.DriverGUID, ballast.BallastKG, ballast.Restrictor))
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(ballast)
+}
+
+// deleteDriverBallast removes a driver's ballast, including an override, and takes it off their car if they are
+// connected.
+func (sah *ServerAdministrationHandler) deleteDriverBallast(w http.ResponseWriter, r *http.Request) {
+	driverGUID := chi.URLParam(r, "driverGUID")
+
+	err := sah.store.DeleteDriverBallast(driverGUID)
+
+	if err == ErrDriverBallastNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Error("could not delete driver ballast")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	for _, car := range sah.process.SessionState().Cars {
+		if string(car.DriverGUID) == driverGUID && car.Connected {
+			if err := sendBallast(sah.process, car.CarID, &DriverBallast{}); err != nil && err != ErrNoOpenUDPConnection {
+				logrus.WithError(err).Errorf("could not remove ballast from car %d", car.CarID)
+			}
+		}
+	}
+
+	auditAction(sah.store, r, "Removed ballast of "+driverGUID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type liveWeatherTemplateVars struct {
+	BaseTemplateVars
+
+	Weather    LiveWeather
+	Changed    bool
+	Running    bool
+	CSPClients int
+}
+
+// liveWeatherPage shows the weather which CSP clients have been sent this session, and changes it when the form is
+// submitted.
+func (sah *ServerAdministrationHandler) liveWeatherPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		weather := liveWeatherFromForm(r.Form, "LiveWeather", 0)
+
+		sent, err := sah.process.SetLiveWeather(weather)
+
+		switch err {
+		case nil:
+			auditAction(sah.store, r, fmt.Sprintf("Set the live weather to %s", weather.WeatherType))
+			AddFlash(w, r, fmt.Sprintf("Live weather sent to %d CSP client(s)", sent))
+		case ErrInvalidLiveWeather:
+			AddErrorFlash(w, r, "Wind direction must be between 0 and 359, and wind speed and transition time must not be negative")
+		case ErrNoOpenUDPConnection:
+			AddErrorFlash(w, r, "The weather can only be changed while the server is running")
+		default:
+			logrus.WithError(err).Error("could not set live weather")
+			AddErrorFlash(w, r, "Unable to change the weather")
+		}
+
+		http.Redirect(w, r, r.URL.Path, http.StatusFound)
+		return
+	}
+
+	weather, changed := sah.process.LiveWeather()
+
+	if !changed {
+		weather = LiveWeather{WeatherType: udp.CSPWeatherClear, TemperatureAmbient: 20, TemperatureRoad: 25, TransitionSeconds: 60}
+	}
+
+	sah.viewRenderer.MustLoadTemplate(w, r, "server/live-weather.html", &liveWeatherTemplateVars{
+		Weather:    weather,
+		Changed:    changed,
+		Running:    sah.process.IsRunning(),
+		CSPClients: len(sah.process.CSPClients()),
+	})
+}
+
+type liveWeatherResponse struct {
+	Weather    *LiveWeather
+	CSPClients int
+}
+
+// liveWeather returns the weather which CSP clients have been sent this session, or a null Weather if it hasn't been
+// changed.
+func (sah *ServerAdministrationHandler) liveWeather(w http.ResponseWriter, r *http.Request) {
+	response := liveWeatherResponse{
+		CSPClients: len(sah.process.CSPClients()),
+	}
+
+	if weather, changed := sah.process.LiveWeather(); changed {
+		response.Weather = &weather
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// setLiveWeather sends a JSON LiveWeather to every CSP client, and to CSP clients which join later in the session.
+func (sah *ServerAdministrationHandler) setLiveWeather(w http.ResponseWriter, r *http.Request) {
+	var weather LiveWeather
+
+	if err := json.NewDecoder(r.Body).Decode(&weather); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err := sah.process.SetLiveWeather(weather)
+
+	if err == ErrInvalidLiveWeather {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sah.adminActionResult(w, r, fmt.Sprintf("Set the live weather to %s", weather.WeatherType), err)
+}
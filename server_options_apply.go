@@ -0,0 +1,67 @@
+package servermanager
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/cj123/formulate"
+)
+
+// serverOptionsAppliedAtStart are server options which aren't written to server_cfg.ini, but which Server Manager
+// only reads as an event is started. Like the options in server_cfg.ini, changing them while an event is running
+// needs acServer to be restarted.
+var serverOptionsAppliedAtStart = map[string]bool{
+	"ShowRaceNameInServerLobby":   true,
+	"ServerNameTemplate":          true,
+	"EnableContentManagerWrapper": true,
+	"ContentManagerWrapperPort":   true,
+	"LogACServerOutputToFile":     true,
+	"CPUQuotaPercent":             true,
+	"AutoUDPPluginPorts":          true,
+}
+
+// ServerOptionChange is a server option which was changed when the server options were saved.
+type ServerOptionChange struct {
+	Name string
+
+	// RestartRequired is true if acServer has to be restarted for the change to take effect. Other changes are
+	// picked up by Server Manager straight away.
+	RestartRequired bool
+}
+
+// changedServerOptions lists the server options which differ between before and after. Options which aren't shown
+// on the options form are ignored, as they are either worked out for each event or edited elsewhere.
+func changedServerOptions(before, after *GlobalServerConfig) []ServerOptionChange {
+	beforeVal, afterVal := reflect.ValueOf(*before), reflect.ValueOf(*after)
+	t := beforeVal.Type()
+
+	var changes []ServerOptionChange
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Tag.Get("show") == "-" || reflect.DeepEqual(beforeVal.Field(i).Interface(), afterVal.Field(i).Interface()) {
+			continue
+		}
+
+		changes = append(changes, ServerOptionChange{
+			Name:            formulate.StructField{StructField: field}.GetName(),
+			RestartRequired: field.Tag.Get("ini") != "-" || serverOptionsAppliedAtStart[field.Name],
+		})
+	}
+
+	return changes
+}
+
+// serverOptionsNeedingRestart returns the names of the changes which need acServer to be restarted, comma separated.
+func serverOptionsNeedingRestart(changes []ServerOptionChange) string {
+	var names []string
+
+	for _, change := range changes {
+		if change.RestartRequired {
+			names = append(names, change.Name)
+		}
+	}
+
+	return strings.Join(names, ", ")
+}
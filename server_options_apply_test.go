@@ -0,0 +1,36 @@
+package servermanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedServerOptions(t *testing.T) {
+	before := ConfigIniDefault().GlobalServerConfig
+	after := before
+
+	after.AdminPassword = "new-admin-password"
+	after.ServerNameTemplate = "{{ .ServerName }}"
+	after.UseMPH = 1
+	after.FreeUDPPluginLocalPort = 12000
+
+	expected := []ServerOptionChange{
+		{Name: "Admin Password", RestartRequired: true},
+		{Name: "Server Name Template", RestartRequired: true},
+		{Name: "Use MPH", RestartRequired: false},
+	}
+
+	changes := changedServerOptions(&before, &after)
+
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %+v, got %+v", expected, changes)
+	}
+
+	if needingRestart := serverOptionsNeedingRestart(changes); needingRestart != "Admin Password, Server Name Template" {
+		t.Errorf("expected the admin password and server name template to need a restart, got %q", needingRestart)
+	}
+
+	if changes := changedServerOptions(&before, &before); len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
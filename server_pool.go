@@ -0,0 +1,373 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultServerName is the name of the server which is run from ServerInstallPath, using the server options.
+const DefaultServerName = "default"
+
+var (
+	ErrUnknownServer          = errors.New("servermanager: unknown server")
+	ErrServerNameInUse        = errors.New("servermanager: a server with this name is already in the pool")
+	ErrPooledServerPortsUnset = errors.New("servermanager: pooled servers must set their own tcp_port, udp_port and http_port")
+)
+
+// ErrPooledServerPortClash is returned when a pooled server is configured with a game or UDP plugin port which another
+// server in the pool, including the default server, already uses.
+type ErrPooledServerPortClash struct {
+	Server, Other string
+	Port          int
+}
+
+func (e ErrPooledServerPortClash) Error() string {
+	return fmt.Sprintf("servermanager: server %s uses port %d, which is already used by server %s", e.Server, e.Port, e.Other)
+}
+
+// PooledServerConfig describes an acServer which is run alongside the default server. Each pooled server is run from
+// its own install path, which needs its own copy of (or links to) the content used by the events it runs.
+type PooledServerConfig struct {
+	Name           string `yaml:"name"`
+	InstallPath    string `yaml:"install_path"`
+	ExecutablePath string `yaml:"executable_path"`
+
+	// ServerName replaces the server name from the server options, if it is set.
+	ServerName string `yaml:"server_name"`
+
+	// TCPPort, UDPPort and HTTPPort replace the game ports from the server options, which are used by the default
+	// server.
+	TCPPort  int `yaml:"tcp_port"`
+	UDPPort  int `yaml:"udp_port"`
+	HTTPPort int `yaml:"http_port"`
+
+	// UDPPluginLocalPort and UDPPluginAddress are allocated when the server is created if they are empty.
+	UDPPluginLocalPort int    `yaml:"udp_plugin_local_port"`
+	UDPPluginAddress   string `yaml:"udp_plugin_address"`
+
+	// OptionsPath is the folder the server keeps its own server options in. It defaults to a server-manager folder in
+	// InstallPath.
+	OptionsPath string `yaml:"options_path"`
+}
+
+func (c PooledServerConfig) optionsPath() string {
+	if c.OptionsPath != "" {
+		return c.OptionsPath
+	}
+
+	return filepath.Join(c.InstallPath, "server-manager")
+}
+
+func (c PooledServerConfig) instance() ServerInstanceConfig {
+	return ServerInstanceConfig{
+		InstallPath:        c.InstallPath,
+		ExecutablePath:     c.ExecutablePath,
+		UDPPluginLocalPort: c.UDPPluginLocalPort,
+		UDPPluginAddress:   c.UDPPluginAddress,
+	}
+}
+
+func (c PooledServerConfig) ports() []int {
+	return []int{c.TCPPort, c.UDPPort, c.HTTPPort}
+}
+
+// pooledServerPorts are the game ports of conf and the UDP plugin ports of instance. UDP plugin ports which haven't
+// been allocated yet are zero.
+func pooledServerPorts(conf PooledServerConfig, instance ServerInstanceConfig) []int {
+	return append(conf.ports(), instance.UDPPluginLocalPort, addressPort(instance.UDPPluginAddress))
+}
+
+// defaultServerPorts are the game ports and UDP plugin ports which the default server is given by the server options.
+func defaultServerPorts(opts *GlobalServerConfig) []int {
+	return []int{opts.TCPPort, opts.UDPPort, opts.HTTPPort, opts.UDPPluginLocalPort, addressPort(opts.UDPPluginAddress)}
+}
+
+// addressPort is the port of a host:port address, or zero if address doesn't have one.
+func addressPort(address string) int {
+	_, portStr, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return 0
+	}
+
+	port, err := strconv.Atoi(portStr)
+
+	if err != nil {
+		return 0
+	}
+
+	return port
+}
+
+// checkPortClash returns an ErrPooledServerPortClash if any of the ports of server are also used by other.
+func checkPortClash(server string, ports []int, other string, otherPorts []int) error {
+	for _, port := range ports {
+		if port == 0 {
+			continue
+		}
+
+		for _, otherPort := range otherPorts {
+			if port == otherPort {
+				return ErrPooledServerPortClash{Server: server, Other: other, Port: port}
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyTo replaces the parts of opts which each pooled server needs its own value for.
+func (c PooledServerConfig) applyTo(opts *GlobalServerConfig, instance ServerInstanceConfig) {
+	if c.ServerName != "" {
+		opts.Name = c.ServerName
+	}
+
+	opts.TCPPort = c.TCPPort
+	opts.UDPPort = c.UDPPort
+	opts.HTTPPort = c.HTTPPort
+	opts.UDPPluginLocalPort = instance.UDPPluginLocalPort
+	opts.UDPPluginAddress = instance.UDPPluginAddress
+
+	// the Content Manager wrapper and its port are shared by the whole manager, so it is only run by the default server.
+	opts.EnableContentManagerWrapper = 0
+}
+
+type pooledServer struct {
+	config   PooledServerConfig
+	instance ServerInstanceConfig
+	process  ServerProcess
+	store    Store
+}
+
+// pooledServerStore is the Store of a pooled server. The server keeps its own server options in its options path, and
+// shares everything else, e.g. custom races and accounts, with the default server. The first time its options are
+// loaded they are copied from the default server's options.
+type pooledServerStore struct {
+	Store
+
+	options *JSONStore
+	mutex   sync.Mutex
+}
+
+func newPooledServerStore(store Store, optionsPath string) *pooledServerStore {
+	return &pooledServerStore{
+		Store:   store,
+		options: &JSONStore{base: optionsPath, shared: optionsPath},
+	}
+}
+
+func (s *pooledServerStore) UpsertServerOptions(so *GlobalServerConfig) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.options.UpsertServerOptions(so)
+}
+
+func (s *pooledServerStore) LoadServerOptions() (*GlobalServerConfig, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var out *GlobalServerConfig
+
+	err := s.options.decodeFile(s.options.base, serverOptionsFile, &out)
+
+	if os.IsNotExist(err) {
+		out, err = s.Store.LoadServerOptions()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return out, s.options.UpsertServerOptions(out)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// ServerPool supervises the acServers run by Server Manager: the default server, and any pooled servers configured in
+// config.Server.Servers. Custom races can be started or scheduled on any server. Race control, live timing,
+// championships and race weekends follow the default server, as their results are recorded from its UDP messages.
+type ServerPool struct {
+	defaultProcess ServerProcess
+
+	mutex   sync.RWMutex
+	servers map[string]*pooledServer
+}
+
+// ServerPoolStatus is the status of one server in the pool.
+type ServerPoolStatus struct {
+	Name   string
+	Status ProcessStatus
+}
+
+func NewServerPool(defaultProcess ServerProcess) *ServerPool {
+	return &ServerPool{
+		defaultProcess: defaultProcess,
+		servers:        make(map[string]*pooledServer),
+	}
+}
+
+// AddServer creates a server process for conf and adds it to the pool. UDP messages from the server are logged and
+// passed to the handlers added with RegisterUDPHandler, but are not passed on to race control. The server's ports must
+// not clash with those of the default server, which are read from the server options in store. The server shares
+// store with the default server, apart from its server options, which it keeps in its options path.
+func (p *ServerPool) AddServer(conf PooledServerConfig, store Store, opts ...ServerProcessOption) error {
+	defaultOpts, err := store.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	defaultPorts := defaultServerPorts(defaultOpts)
+
+	if err := p.checkServer(conf, conf.instance(), defaultPorts); err != nil {
+		return err
+	}
+
+	var process *AssettoServerProcess
+
+	callback := func(message udp.Message) {
+		logrus.Debugf("Server %s: received UDP message: %T", conf.Name, message)
+
+		callUDPHandlers(process, message)
+	}
+
+	serverStore := newPooledServerStore(store, conf.optionsPath())
+
+	process, err = NewAssettoServerProcess(callback, serverStore, NewContentManagerWrapper(serverStore, nil, nil), append(opts, WithInstance(conf.instance()))...)
+
+	if err != nil {
+		return err
+	}
+
+	instance, _ := process.Instance()
+
+	return p.add(conf, instance, process, serverStore, defaultPorts)
+}
+
+func (p *ServerPool) add(conf PooledServerConfig, instance ServerInstanceConfig, process ServerProcess, store Store, defaultPorts []int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if err := p.checkServerLocked(conf, instance, defaultPorts); err != nil {
+		return err
+	}
+
+	p.servers[conf.Name] = &pooledServer{
+		config:   conf,
+		instance: instance,
+		process:  process,
+		store:    store,
+	}
+
+	return nil
+}
+
+func (p *ServerPool) checkServer(conf PooledServerConfig, instance ServerInstanceConfig, defaultPorts []int) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.checkServerLocked(conf, instance, defaultPorts)
+}
+
+func (p *ServerPool) checkServerLocked(conf PooledServerConfig, instance ServerInstanceConfig, defaultPorts []int) error {
+	if conf.Name == "" || conf.Name == DefaultServerName {
+		return ErrServerNameInUse
+	}
+
+	if _, ok := p.servers[conf.Name]; ok {
+		return ErrServerNameInUse
+	}
+
+	for _, port := range conf.ports() {
+		if port == 0 {
+			return ErrPooledServerPortsUnset
+		}
+	}
+
+	ports := pooledServerPorts(conf, instance)
+
+	if err := checkPortClash(conf.Name, ports, DefaultServerName, defaultPorts); err != nil {
+		return err
+	}
+
+	for _, other := range p.servers {
+		if err := checkPortClash(conf.Name, ports, other.config.Name, pooledServerPorts(other.config, other.instance)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns the server process called name. An empty name is the default server.
+func (p *ServerPool) Get(name string) (ServerProcess, error) {
+	if name == "" || name == DefaultServerName {
+		return p.defaultProcess, nil
+	}
+
+	server, err := p.pooledServer(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return server.process, nil
+}
+
+func (p *ServerPool) pooledServer(name string) (*pooledServer, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	server, ok := p.servers[name]
+
+	if !ok {
+		return nil, ErrUnknownServer
+	}
+
+	return server, nil
+}
+
+// Names returns the names of the servers in the pool, starting with the default server.
+func (p *ServerPool) Names() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	names := make([]string, 0, len(p.servers))
+
+	for name := range p.servers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return append([]string{DefaultServerName}, names...)
+}
+
+// Statuses returns the status of each server in the pool, in the same order as Names.
+func (p *ServerPool) Statuses() []ServerPoolStatus {
+	var statuses []ServerPoolStatus
+
+	for _, name := range p.Names() {
+		process, err := p.Get(name)
+
+		if err != nil {
+			continue
+		}
+
+		statuses = append(statuses, ServerPoolStatus{Name: name, Status: process.Status()})
+	}
+
+	return statuses
+}
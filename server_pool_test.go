@@ -0,0 +1,225 @@
+package servermanager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestServerPool(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	pool := NewServerPool(sp)
+
+	practice := PooledServerConfig{
+		Name:           "practice",
+		InstallPath:    ServerInstallPath,
+		ExecutablePath: "acServer.sh",
+		TCPPort:        9700,
+		UDPPort:        9701,
+		HTTPPort:       8181,
+	}
+
+	if err := pool.AddServer(practice, sp.store); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Invalid servers", func(t *testing.T) {
+		clash := PooledServerConfig{Name: "race", TCPPort: 9800, UDPPort: 9801, HTTPPort: 9701}
+
+		if err := pool.AddServer(clash, sp.store); err != (ErrPooledServerPortClash{Server: "race", Other: "practice", Port: 9701}) {
+			t.Errorf("expected a port clash, got: %v", err)
+		}
+
+		defaultOpts, err := sp.store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defaultOpts.UDPPluginLocalPort = 11000
+		defaultOpts.UDPPluginAddress = "127.0.0.1:12000"
+
+		if err := sp.store.UpsertServerOptions(defaultOpts); err != nil {
+			t.Fatal(err)
+		}
+
+		defaultGamePort := PooledServerConfig{Name: "race", TCPPort: 9800, UDPPort: 9801, HTTPPort: defaultOpts.HTTPPort}
+
+		if err := pool.AddServer(defaultGamePort, sp.store); err != (ErrPooledServerPortClash{Server: "race", Other: DefaultServerName, Port: defaultOpts.HTTPPort}) {
+			t.Errorf("expected a port clash with the default server's HTTP port, got: %v", err)
+		}
+
+		defaultPluginPort := PooledServerConfig{Name: "race", TCPPort: 9800, UDPPort: 9801, HTTPPort: 8281, UDPPluginLocalPort: defaultOpts.UDPPluginLocalPort}
+
+		if err := pool.AddServer(defaultPluginPort, sp.store); err != (ErrPooledServerPortClash{Server: "race", Other: DefaultServerName, Port: defaultOpts.UDPPluginLocalPort}) {
+			t.Errorf("expected a port clash with the default server's UDP plugin local port, got: %v", err)
+		}
+
+		server, err := pool.pooledServer(practice.Name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pluginPort := addressPort(server.instance.UDPPluginAddress)
+		pooledPluginPort := PooledServerConfig{Name: "race", TCPPort: 9800, UDPPort: 9801, HTTPPort: 8281, UDPPluginAddress: fmt.Sprintf("127.0.0.1:%d", pluginPort)}
+
+		if err := pool.AddServer(pooledPluginPort, sp.store); err != (ErrPooledServerPortClash{Server: "race", Other: practice.Name, Port: pluginPort}) {
+			t.Errorf("expected a port clash with the practice server's UDP plugin address, got: %v", err)
+		}
+
+		unset := practice
+		unset.Name = "race"
+		unset.TCPPort = 0
+
+		if err := pool.AddServer(unset, sp.store); err != ErrPooledServerPortsUnset {
+			t.Errorf("expected %v, got: %v", ErrPooledServerPortsUnset, err)
+		}
+
+		for _, name := range []string{"", DefaultServerName, practice.Name} {
+			named := PooledServerConfig{Name: name, TCPPort: 9800, UDPPort: 9801, HTTPPort: 8281}
+
+			if err := pool.AddServer(named, sp.store); err != ErrServerNameInUse {
+				t.Errorf("expected %v for name %q, got: %v", ErrServerNameInUse, name, err)
+			}
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		for _, name := range []string{"", DefaultServerName} {
+			if process, err := pool.Get(name); err != nil || process != ServerProcess(sp) {
+				t.Errorf("expected %q to be the default server, got: %v, %v", name, process, err)
+			}
+		}
+
+		process, err := pool.Get(practice.Name)
+
+		if err != nil || process == ServerProcess(sp) {
+			t.Errorf("expected the practice server to have its own process, got: %v", err)
+		}
+
+		if _, err := pool.Get("race"); err != ErrUnknownServer {
+			t.Errorf("expected %v, got: %v", ErrUnknownServer, err)
+		}
+
+		if names := pool.Names(); len(names) != 2 || names[0] != DefaultServerName || names[1] != practice.Name {
+			t.Errorf("expected the default server then the practice server, got: %v", names)
+		}
+
+		if statuses := pool.Statuses(); len(statuses) != 2 || statuses[1].Name != practice.Name || statuses[1].Status.Running {
+			t.Errorf("expected the status of both servers, got: %+v", statuses)
+		}
+	})
+
+	t.Run("Server options", func(t *testing.T) {
+		server, err := pool.pooledServer(practice.Name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if server.instance.UDPPluginLocalPort == 0 || server.instance.UDPPluginAddress == "" {
+			t.Fatalf("expected UDP plugin ports to be allocated, got: %+v", server.instance)
+		}
+
+		opts := &GlobalServerConfig{
+			Name:                        "Server Manager",
+			TCPPort:                     9600,
+			UDPPort:                     9600,
+			HTTPPort:                    8081,
+			EnableContentManagerWrapper: 1,
+		}
+
+		server.config.applyTo(opts, server.instance)
+
+		if opts.TCPPort != practice.TCPPort || opts.UDPPort != practice.UDPPort || opts.HTTPPort != practice.HTTPPort {
+			t.Errorf("expected the practice server's game ports, got: %d, %d, %d", opts.TCPPort, opts.UDPPort, opts.HTTPPort)
+		}
+
+		if opts.UDPPluginLocalPort != server.instance.UDPPluginLocalPort || opts.UDPPluginAddress != server.instance.UDPPluginAddress {
+			t.Errorf("expected the practice server's UDP plugin ports, got: %d, %s", opts.UDPPluginLocalPort, opts.UDPPluginAddress)
+		}
+
+		if opts.Name != "Server Manager" || opts.EnableContentManagerWrapper != 0 {
+			t.Errorf("expected the server name to be kept and the Content Manager wrapper to be disabled, got: %+v", opts)
+		}
+	})
+
+	raceManager := NewRaceManager(sp.store, sp, nil, nil, &dummyNotificationManager{}, nil, pool)
+
+	t.Run("Own server options", func(t *testing.T) {
+		defaultOpts, err := sp.store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts, err := raceManager.LoadServerOptionsFor(practice.Name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if opts.Name != defaultOpts.Name {
+			t.Errorf("expected the practice server's options to start as a copy of the default server's, got: %s", opts.Name)
+		}
+
+		opts.Name = "Practice Only"
+
+		if err := raceManager.SaveServerOptionsFor(practice.Name, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if opts, err := raceManager.LoadServerOptionsFor(practice.Name); err != nil || opts.Name != "Practice Only" {
+			t.Errorf("expected the practice server's own options, got: %+v, %v", opts, err)
+		}
+
+		if opts, err := sp.store.LoadServerOptions(); err != nil || opts.Name != defaultOpts.Name {
+			t.Errorf("expected the default server's options to be unchanged, got: %+v, %v", opts, err)
+		}
+
+		if _, err := raceManager.LoadServerOptionsFor("race"); err != ErrUnknownServer {
+			t.Errorf("expected %v, got: %v", ErrUnknownServer, err)
+		}
+	})
+
+	t.Run("Schedule on server", func(t *testing.T) {
+		race := &CustomRace{Name: "Scheduled Practice", UUID: uuid.New()}
+
+		if err := sp.store.UpsertCustomRace(race); err != nil {
+			t.Fatal(err)
+		}
+
+		scheduled := time.Now().Add(time.Hour)
+
+		if err := raceManager.ScheduleRaceOnServer(race.UUID.String(), "race", scheduled, "add", ""); err != ErrUnknownServer {
+			t.Errorf("expected %v, got: %v", ErrUnknownServer, err)
+		}
+
+		if err := raceManager.ScheduleRaceOnServer(race.UUID.String(), practice.Name, scheduled, "add", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		race, err := sp.store.FindCustomRaceByID(race.UUID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if race.ScheduledOnServer != practice.Name || !race.Scheduled.Equal(scheduled) {
+			t.Errorf("expected the race to be scheduled on the practice server, got: %q at %s", race.ScheduledOnServer, race.Scheduled)
+		}
+
+		if err := raceManager.ScheduleRace(race.UUID.String(), time.Time{}, "remove", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if race, err := sp.store.FindCustomRaceByID(race.UUID.String()); err != nil || race.ScheduledOnServer != "" {
+			t.Errorf("expected removing the schedule to clear the server, got: %+v, %v", race, err)
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package servermanager
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -15,9 +14,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp/replay"
 	"github.com/sirupsen/logrus"
 )
 
@@ -25,37 +26,184 @@ const MaxLogSizeBytes = 1e6
 
 type ServerProcess interface {
 	Start(event RaceEvent, udpPluginAddress string, udpPluginLocalPort int, forwardingAddress string, forwardListenPort int) error
+	StartContext(ctx context.Context, event RaceEvent, udpPluginAddress string, udpPluginLocalPort int, forwardingAddress string, forwardListenPort int) error
 	Stop() error
+	ForceStop() error
+	Adopt() (bool, error)
+	ValidateStart(serverConfig ServerConfig) []ValidationProblem
 	Restart() error
 	IsRunning() bool
+	StartedAt() (time.Time, bool)
+	Uptime() time.Duration
 	Event() RaceEvent
 	UDPCallback(message udp.Message)
 	SendUDPMessage(message udp.Message) error
+	SendUDPMessageImmediate(message udp.Message) error
+	KickCar(carID udp.CarID) error
+	BanCar(carID udp.CarID) error
+	NextSession() error
+	RestartSession() error
+	CSPClients() []udp.CarID
+	LiveWeather() (LiveWeather, bool)
+	SetLiveWeather(weather LiveWeather) (int, error)
+	RealtimePosInterval() int
 	NotifyDone(chan struct{})
+	NotifyStart(chan RaceEvent)
+	Done() <-chan struct{}
 	Logs() string
+	PreviousLogs() string
+	ServerLogFiles() ([]ServerLogFile, error)
+	OpenServerLogFile(name string) (*os.File, error)
+	UDPRecordings() ([]UDPRecording, error)
+	ReplayUDPRecording(name string, multiplier int) error
+	LogsJSON() []LogLine
+	FilteredLogs(filter LogFilter) (string, error)
+	PluginLogs(name string) string
+	Tail() (<-chan string, func())
+	TailFrom(offset int64) (<-chan TailLine, func())
+	LogLines(offset int64, max int) (int64, []TailLine)
+	SessionState() SessionState
+	AddObserver(ch chan<- udp.Message)
+	RemoveObserver(ch chan<- udp.Message)
+	Subscribe() (<-chan ProcessEvent, func())
+	Status() ProcessStatus
+	DiagnosticsBundle() (*DiagnosticsBundle, error)
+	Forwarding() ForwardingStatus
+	SetForwardingEnabled(enabled bool)
+	SetForwardingTargets(targets []udp.ForwardTarget) error
+	NotifyCrash(chan *CrashReport)
+	Health() ProcessHealth
+	PluginHealth() []PluginHealthStatus
+	CrashReports() []*CrashReport
+	SimulateCrash() error
+	Availability(from, to time.Time) (*Availability, error)
+	LastExit() (ExitInfo, bool)
+	IsFeatureEnabled(feature Feature) bool
+	SetFeature(feature Feature, enabled bool) error
 }
 
 // AssettoServerProcess manages the Assetto Corsa Server process.
 type AssettoServerProcess struct {
+	// StopGraceTimeout is how long Stop waits for acServer to exit after asking it to, before killing it.
+	// StopHardTimeout is how long Stop waits in total before giving up. They are set with WithStopTimeouts.
+	StopGraceTimeout time.Duration
+	StopHardTimeout  time.Duration
+
+	CrashRestartPolicy CrashRestartPolicy
+
+	// StopWarning warns drivers before Stop stops acServer. It is set with WithStopWarning.
+	StopWarning StopWarningConfig
+
+	// pluginLogs hold the output of each plugin, by name.
+	pluginLogs      map[string]*logBuffer
+	pluginLogsMutex sync.Mutex
+
+	startHooks []func(RaceEvent) error
+	stopHooks  []func(RaceEvent)
+	hooksMutex sync.Mutex
+
+	// instance is set with WithInstance. If it is nil, acServer is run from ServerInstallPath.
+	instance *ServerInstanceConfig
+
+	// StartupTimeout is how long Start waits for acServer to report that it is ready. It is set with
+	// WithStartupTimeout. If it is zero, Start only waits up to gamePortBindWait, so that acServer failing to bind
+	// its game ports is returned from Start.
+	StartupTimeout   time.Duration
+	gamePortBindWait time.Duration
+
+	// UDPSendInterval is the shortest time between messages sent to acServer by SendUDPMessage. It is set with
+	// WithUDPSendInterval, if it is zero messages aren't queued. UDPSendBatchSize messages are sent each interval,
+	// and admin messages which can't be sent are tried UDPSendRetries more times.
+	UDPSendInterval  time.Duration
+	UDPSendBatchSize int
+	UDPSendRetries   int
+	udpSendQueue     *udpSendQueue
+
+	// launcher is set with WithDocker. If it is nil, acServer's executable is run directly.
+	launcher serverLauncher
+
+	// MaintenanceWindows are when acServer is restarted for maintenance. They are set with WithMaintenanceWindows.
+	MaintenanceWindows []MaintenanceWindow
+
 	store                 Store
 	contentManagerWrapper *ContentManagerWrapper
 
-	start                 chan RaceEvent
-	startMutex            sync.Mutex
-	started, stopped, run chan error
-	notifyDoneChs         []chan struct{}
+	start          chan startRequest
+	startQueue     *startQueue
+	run            chan error
+	notifyDoneChs  []chan struct{}
+	notifyStartChs []chan RaceEvent
+
+	// done is closed when the event which is running ends. It is nil if no event is running.
+	done chan struct{}
+
+	// gamePorts, lobbyRegistered and udpPluginMessageReceived are used to check the health of the event which is
+	// running. lobbyRegistered and udpPluginMessageReceived are set from acServer's output and UDP messages, so they
+	// are accessed atomically rather than with sp.mutex.
+	gamePorts                gamePorts
+	gamePortsErr             error
+	lobbyRegistered          int32
+	udpPluginMessageReceived int32
+
+	// stopWaiters each receive the result of onStop when the acServer process next ends. They are buffered
+	// so that a caller which has stopped waiting (e.g. after a timeout) can never block the process loop.
+	stopWaiters []chan error
+
+	stopRequested, crashSimulated bool
+	stopReason                    StopReason
+	lastExit                      *ExitInfo
+	crashReports                  []*CrashReport
+	build                         ServerBuild
+	notifyCrashChs                []chan *CrashReport
+	crashRestartAttempts          int
+	crashRestartCancel            chan struct{}
+	crashRestartMutex             sync.Mutex
+
+	// memoryLimitExceeded is set when acServer is restarted for going over its memory limit. memoryWatchdogDone is
+	// closed to stop watching its memory.
+	memoryLimitExceeded bool
+	memoryWatchdogDone  chan struct{}
+
+	// hangDetected is set when acServer is restarted or stopped by the hang watchdog, which is stopped by closing
+	// hangWatchdogDone. lastUDPMessage is when acServer last sent a UDP message, in Unix nanoseconds, and is accessed
+	// atomically.
+	hangDetected     bool
+	hangWatchdogDone chan struct{}
+	lastUDPMessage   int64
+
+	cspClients   cspClients
+	liveWeather  liveWeather
+	sessionState sessionState
+
+	// telemetry is set with WithTelemetry, it is nil if telemetry isn't captured.
+	telemetry *telemetryRecorder
 
 	ctx context.Context
 	cfn context.CancelFunc
 
-	logBuffer *logBuffer
+	logBuffer  *logBuffer
+	startupErr error
+	readiness  *startupReadiness
 
 	raceEvent      RaceEvent
+	startedAt      time.Time
+	raceFinish     raceFinish
 	cmd            *exec.Cmd
+	launchCommand  LaunchCommand
+	resourceLimits processLimits
 	mutex          sync.Mutex
 	extraProcesses []*pluginProcess
+	failedPlugins  []PluginHealthStatus
 
 	logFile, errorLogFile io.WriteCloser
+	rotatingLogFile       *rotatingLogFile
+
+	// startDone is open while Start is waiting for the process loop to start an event. startCancelled is accessed
+	// atomically, it is set by a Stop which arrives in the meantime. startAborted is set if acServer was then killed.
+	startDone      chan struct{}
+	startDoneMutex sync.Mutex
+	startCancelled int32
+	startAborted   bool
 
 	// udp
 	callbackFunc       udp.CallbackFunc
@@ -64,20 +212,123 @@ type AssettoServerProcess struct {
 	udpPluginLocalPort int
 	forwardingAddress  string
 	forwardListenPort  int
+	forwardingDisabled bool
+	forwardingTargets  []udp.ForwardTarget
+
+	// forwarding caches the ForwardingStatus so that it can be read without waiting for sp.mutex.
+	forwarding atomic.Value
+
+	// pluginForwardingTargets are opened for the plugins Server Manager runs when multiplex_udp_plugins is set.
+	pluginForwardingTargets []udp.ForwardTarget
+
+	// udpRecorder records the messages from acServer while it runs, see UDPRecordingConfig. replayingUDP is accessed
+	// atomically, it is set while a recording is replayed.
+	udpRecorder  *replay.Recorder
+	replayingUDP int32
 
+	callbackBreaker    callbackBreaker
 	sessionStartedChan chan struct{}
+
+	eventSinks      []ProcessEventSink
+	eventSinksMutex sync.Mutex
+
+	observers      []*udpObserver
+	observersMutex sync.Mutex
+
+	hostMetrics *hostMetricsSampler
+	features    *FeatureFlags
 }
 
 type pluginProcess struct {
-	cmd   *exec.Cmd
-	stdin io.WriteCloser
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	launch LaunchCommand
+	name   string
+
+	// limits are the resource limits applied to the process. They are released once it has been stopped.
+	limits processLimits
+
+	// plugin is what the process was started from, so that it can be started again if it exits. It is nil for
+	// processes started from run_on_start.
+	plugin *CommandPlugin
+	wd     string
+
+	// exited is closed once the plugin has exited, after which exitErr holds the result of cmd.Wait.
+	exited  chan struct{}
+	exitErr error
+
+	// the following are guarded by AssettoServerProcess.mutex
+	state       PluginState
+	stopping    bool
+	startedAt   time.Time
+	lastErr     error
+	lastErrTime time.Time
+
+	// restarts counts every restart, restartsInARow only those since the plugin last ran for pluginRestartResetAfter.
+	restarts       int
+	restartsInARow int
+
+	// hasExited is set once the plugin has exited on its own, after which exitCode is the code it exited with.
+	hasExited bool
+	exitCode  int
 }
 
-func NewAssettoServerProcess(callbackFunc udp.CallbackFunc, store Store, contentManagerWrapper *ContentManagerWrapper) *AssettoServerProcess {
+// LaunchCommand records exactly how a process was launched, so that it can be reproduced when debugging.
+type LaunchCommand struct {
+	Args []string
+	Dir  string
+}
+
+func newLaunchCommand(cmd *exec.Cmd) LaunchCommand {
+	return LaunchCommand{
+		Args: append([]string(nil), cmd.Args...),
+		Dir:  cmd.Dir,
+	}
+}
+
+func (lc LaunchCommand) String() string {
+	return strings.Join(lc.Args, " ")
+}
+
+const (
+	defaultStopGraceTimeout = time.Second * 15
+	defaultStopHardTimeout  = time.Second * 30
+)
+
+// ServerProcessOption customises an AssettoServerProcess when it is created.
+type ServerProcessOption func(sp *AssettoServerProcess)
+
+// WithStopTimeouts sets StopGraceTimeout and StopHardTimeout. A zero duration keeps the default.
+func WithStopTimeouts(grace, hard time.Duration) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		if grace > 0 {
+			sp.StopGraceTimeout = grace
+		}
+
+		if hard > 0 {
+			sp.StopHardTimeout = hard
+		}
+	}
+}
+
+// WithForwardingTargets forwards UDP messages from acServer to each of targets as well as the forwarding address, so
+// that several plugins can listen to acServer at once.
+func WithForwardingTargets(targets []udp.ForwardTarget) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.forwardingTargets = targets
+	}
+}
+
+var ErrInvalidStopTimeouts = errors.New("servermanager: the stop grace timeout must be shorter than the stop hard timeout")
+
+func NewAssettoServerProcess(callbackFunc udp.CallbackFunc, store Store, contentManagerWrapper *ContentManagerWrapper, opts ...ServerProcessOption) (*AssettoServerProcess, error) {
 	sp := &AssettoServerProcess{
-		start:                 make(chan RaceEvent),
-		started:               make(chan error),
-		stopped:               make(chan error),
+		StopGraceTimeout:      defaultStopGraceTimeout,
+		StopHardTimeout:       defaultStopHardTimeout,
+		gamePortBindWait:      defaultGamePortBindWait,
+		CrashRestartPolicy:    defaultCrashRestartPolicy,
+		start:                 make(chan startRequest),
+		startQueue:            newStartQueue(),
 		run:                   make(chan error),
 		logBuffer:             newLogBuffer(MaxLogSizeBytes),
 		callbackFunc:          callbackFunc,
@@ -86,14 +337,88 @@ func NewAssettoServerProcess(callbackFunc udp.CallbackFunc, store Store, content
 		sessionStartedChan:    make(chan struct{}),
 	}
 
+	for _, opt := range opts {
+		opt(sp)
+	}
+
+	if sp.StopGraceTimeout >= sp.StopHardTimeout {
+		return nil, ErrInvalidStopTimeouts
+	}
+
+	if sp.CrashRestartPolicy.MaxDelay < sp.CrashRestartPolicy.BaseDelay {
+		return nil, ErrInvalidCrashRestartPolicy
+	}
+
+	var maintenanceWindows []*maintenanceWindow
+
+	for _, w := range sp.MaintenanceWindows {
+		window, err := parseMaintenanceWindow(w)
+
+		if err != nil {
+			return nil, err
+		}
+
+		maintenanceWindows = append(maintenanceWindows, window)
+	}
+
+	if err := sp.allocateInstancePorts(); err != nil {
+		return nil, err
+	}
+
+	var featureOverrides map[Feature]bool
+
+	if config != nil {
+		featureOverrides = config.Server.Features
+	}
+
+	sp.features = NewFeatureFlags(featureOverrides)
+
+	sp.AddFeatureEventSink(FeatureEventHistory, newStoreEventSink(store))
+
+	if config != nil && config.Server.HostMetricsInterval > 0 {
+		sp.hostMetrics = newHostMetricsSampler(&gopsutilHostMetricsReader{}, config.Server.HostMetricsInterval)
+
+		if sp.IsFeatureEnabled(FeatureHostMetrics) {
+			sp.hostMetrics.start()
+		}
+	}
+
 	go sp.loop()
 
-	return sp
+	if len(maintenanceWindows) > 0 {
+		go sp.scheduleMaintenance(maintenanceWindows)
+	}
+
+	return sp, nil
 }
 
 func (sp *AssettoServerProcess) UDPCallback(message udp.Message) {
+	atomic.StoreInt32(&sp.udpPluginMessageReceived, 1)
+	sp.touchUDP()
+	serverProcessUDPMessagesCounter.Inc()
+
+	if endSession, ok := message.(udp.EndSession); ok {
+		sp.fetchRemoteResults(string(endSession))
+		sp.addServerBuildToResults(string(endSession))
+	}
+
+	now := time.Now()
+
+	sp.cspClients.handle(message)
+	sp.handleLiveWeather(message)
+	sp.sessionState.handle(message, now)
+	sp.recordTelemetry(message, now)
+	sp.notifyObservers(message)
+	sp.callUDPCallback(message)
+
 	panicCapture(func() {
-		sp.callbackFunc(message)
+		sp.trackRaceFinish(message)
+
+		if endSession, ok := message.(udp.EndSession); ok {
+			raceEvent := sp.Event()
+
+			sp.emit(ProcessEvent{Type: ProcessEventResultsReady, EventName: raceEvent.EventName(), RaceEvent: raceEvent, ResultsFile: filepath.Base(string(endSession))})
+		}
 
 		if config.Server.PersistMidSessionResults && message.Event() == udp.EventNewSession {
 			// on new session, push down the sessionStartedChan so that if server stop is waiting to hear about
@@ -107,25 +432,76 @@ func (sp *AssettoServerProcess) UDPCallback(message udp.Message) {
 }
 
 func (sp *AssettoServerProcess) Start(event RaceEvent, udpPluginAddress string, udpPluginLocalPort int, forwardingAddress string, forwardListenPort int) error {
-	sp.startMutex.Lock()
-	defer sp.startMutex.Unlock()
+	return sp.StartContext(context.Background(), event, udpPluginAddress, udpPluginLocalPort, forwardingAddress, forwardListenPort)
+}
+
+// StartContext is Start, but gives up and returns ctx.Err() if ctx is done before the event has started. A start
+// which the process loop has already picked up is cancelled in the background, and acServer is stopped if it was
+// launched anyway.
+//
+// If another start is in progress, the start waits in a queue for it to finish. The queue is ordered by the
+// StartPriority set on ctx with WithStartPriority, and is listed in ProcessStatus.StartQueue.
+func (sp *AssettoServerProcess) StartContext(ctx context.Context, event RaceEvent, udpPluginAddress string, udpPluginLocalPort int, forwardingAddress string, forwardListenPort int) error {
+	sp.cancelCrashRestart()
+
+	if atomic.LoadInt32(&sp.replayingUDP) == 1 {
+		return ErrUDPReplayInProgress
+	}
+
+	if err := sp.startQueue.acquire(ctx, startPriority(ctx, event), event.EventName()); err != nil {
+		return err
+	}
+
+	udpPluginAddress, udpPluginLocalPort = sp.instanceUDPPorts(udpPluginAddress, udpPluginLocalPort)
 
 	sp.mutex.Lock()
 	sp.udpPluginAddress = udpPluginAddress
 	sp.udpPluginLocalPort = udpPluginLocalPort
 	sp.forwardingAddress = forwardingAddress
 	sp.forwardListenPort = forwardListenPort
+	sp.storeForwarding()
 	sp.mutex.Unlock()
 
 	if sp.IsRunning() {
 		if err := sp.Stop(); err != nil {
+			sp.startQueue.release()
 			return err
 		}
 	}
 
-	sp.start <- event
+	if err := ctx.Err(); err != nil {
+		sp.startQueue.release()
+		return err
+	}
+
+	req := startRequest{event: event, result: make(chan error, 1)}
+
+	sp.beginStart()
+
+	select {
+	case sp.start <- req:
+	case <-ctx.Done():
+		sp.endStart()
+		sp.startQueue.release()
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-req.result:
+		sp.endStart()
+		sp.startQueue.release()
+
+		if err != nil {
+			return err
+		}
+
+		return sp.waitUntilReady(ctx)
+	case <-ctx.Done():
+		atomic.StoreInt32(&sp.startCancelled, 1)
+		go sp.finishCancelledStart(req)
 
-	return <-sp.started
+		return ctx.Err()
+	}
 }
 
 var ErrPluginConfigurationRequiresUDPPortSetup = errors.New("servermanager: kissmyrank and stracker configuration requires UDP plugin configuration in Server Options")
@@ -137,13 +513,60 @@ func (sp *AssettoServerProcess) IsRunning() bool {
 	return sp.raceEvent != nil
 }
 
+// StartedAt returns when acServer was started. The bool is false if it is not running.
+func (sp *AssettoServerProcess) StartedAt() (time.Time, bool) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.raceEvent == nil || sp.startedAt.IsZero() {
+		return time.Time{}, false
+	}
+
+	return sp.startedAt, true
+}
+
+// Uptime returns how long acServer has been running for, or zero if it is not running.
+func (sp *AssettoServerProcess) Uptime() time.Duration {
+	startedAt, ok := sp.StartedAt()
+
+	if !ok {
+		return 0
+	}
+
+	return time.Since(startedAt)
+}
+
 var ErrServerProcessTimeout = errors.New("servermanager: server process did not stop even after manual kill. please check your server configuration")
 
 func (sp *AssettoServerProcess) Stop() error {
-	if !sp.IsRunning() {
+	sp.cancelCrashRestart()
+	sp.cancelStart()
+
+	stopped, isRunning := sp.waitForStop()
+
+	if !isRunning {
 		return nil
 	}
 
+	if sp.wasStartAborted() {
+		// acServer has already been killed by the cancelled start, wait for the loop to finish cleaning up after it.
+		select {
+		case err := <-stopped:
+			return err
+		case <-time.After(sp.StopHardTimeout * 2):
+			return ErrServerProcessTimeout
+		}
+	}
+
+	raceEvent := sp.Event()
+
+	sp.emit(ProcessEvent{Type: ProcessEventStopping, EventName: raceEvent.EventName(), RaceEvent: raceEvent})
+
+	if stoppedDuringWarning, err := sp.warnBeforeStop(stopped); stoppedDuringWarning {
+		logrus.Info("Server stopped of its own accord while drivers were being warned that it is stopping")
+		return err
+	}
+
 	if config.Server.PersistMidSessionResults {
 		nextSessionTimeout := time.After(time.Second * 2)
 
@@ -160,18 +583,18 @@ func (sp *AssettoServerProcess) Stop() error {
 			logrus.Info("Session advanced, shutting down server")
 		case <-nextSessionTimeout:
 			logrus.Info("Session timeout reached, shutting down server")
-		case err := <-sp.stopped:
+		case err := <-stopped:
 			logrus.Info("Server stopped of its own accord - likely was the last session in a non loop mode race")
 			return err
 		}
 	}
 
-	timeout := time.After(time.Second * 60)
-	errCh := make(chan error)
+	timeout := time.After(sp.StopHardTimeout * 2)
+	errCh := make(chan error, 1)
 
 	go func() {
 		select {
-		case err := <-sp.stopped:
+		case err := <-stopped:
 			errCh <- err
 			return
 		case <-timeout:
@@ -180,8 +603,13 @@ func (sp *AssettoServerProcess) Stop() error {
 		}
 	}()
 
+	if sp.cmd.Process == nil {
+		// acServer failed to start, so there is nothing to signal. wait for the loop to clean up.
+		return <-errCh
+	}
+
 	logrus.Infof("Shutting down server process: %d", sp.cmd.Process.Pid)
-	stopErr := stopCommand(sp.cmd, errCh, 30)
+	stopErr := stopCommand(sp.cmd, errCh, sp.StopGraceTimeout, sp.StopHardTimeout)
 	if stopErr != nil {
 		logrus.WithError(stopErr).Errorf("Failed to stop server process: %d", sp.cmd.Process.Pid)
 	}
@@ -191,6 +619,56 @@ func (sp *AssettoServerProcess) Stop() error {
 	return stopErr
 }
 
+// ForceStop kills acServer straight away, without warning drivers or giving it time to write its results. It is for
+// when acServer is stuck, and can be called while a Stop is waiting for acServer to exit, which then returns too.
+func (sp *AssettoServerProcess) ForceStop() error {
+	sp.cancelCrashRestart()
+	sp.cancelStart()
+
+	stopped, isRunning := sp.waitForStop()
+
+	if !isRunning {
+		return nil
+	}
+
+	sp.mutex.Lock()
+	cmd := sp.cmd
+	sp.mutex.Unlock()
+
+	if cmd != nil && cmd.Process != nil {
+		logrus.Warnf("Force stopping server process: %d", cmd.Process.Pid)
+
+		if err := kill(getProcess(cmd)); err != nil {
+			logrus.WithError(err).Errorf("Failed to kill server process: %d", cmd.Process.Pid)
+			return err
+		}
+	}
+
+	select {
+	case err := <-stopped:
+		return err
+	case <-time.After(sp.StopHardTimeout):
+		return ErrServerProcessTimeout
+	}
+}
+
+// waitForStop marks the acServer process as intentionally stopped and registers a channel which receives the
+// result of onStop when the process ends. If the process is not running, false is returned and nothing is changed.
+func (sp *AssettoServerProcess) waitForStop() (chan error, bool) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.raceEvent == nil {
+		return nil, false
+	}
+
+	sp.stopRequested = true
+	ch := make(chan error, 1)
+	sp.stopWaiters = append(sp.stopWaiters, ch)
+
+	return ch, true
+}
+
 func (sp *AssettoServerProcess) Restart() error {
 	sp.mutex.Lock()
 	raceEvent := sp.raceEvent
@@ -207,49 +685,105 @@ func (sp *AssettoServerProcess) loop() {
 	for {
 		select {
 		case err := <-sp.run:
-			if err != nil {
+			if startupErr := sp.StartupError(); startupErr != nil {
+				logrus.WithError(startupErr).Error("acServer process ended after failing to start")
+			} else if err != nil {
 				logrus.WithError(err).Warn("acServer process ended with error. If everything seems fine, you can safely ignore this error.")
 			}
 
-			select {
-			case sp.stopped <- sp.onStop():
-			default:
+			raceEvent := sp.Event()
+			eventName := raceEvent.EventName()
+			reason, crashReport, crashRestart := sp.classifyStop(err)
+
+			if err := sp.onStop(); err != nil {
+				logrus.WithError(err).Error("Could not clean up after acServer process ended")
 			}
-		case raceEvent := <-sp.start:
-			sp.started <- sp.startRaceEvent(raceEvent)
+
+			if reason == StopReasonCrashed {
+				sp.emit(ProcessEvent{Type: ProcessEventCrashed, EventName: eventName, RaceEvent: raceEvent, Reason: reason, Error: crashReport.Error})
+				sp.onCrash(crashReport, crashRestart)
+			} else {
+				sp.emit(ProcessEvent{Type: ProcessEventStopped, EventName: eventName, RaceEvent: raceEvent, Reason: reason})
+			}
+		case req := <-sp.start:
+			req.result <- sp.startRaceEvent(req.event)
 		}
 	}
 }
 
-func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
+func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) (err error) {
 	sp.mutex.Lock()
 	defer sp.mutex.Unlock()
 
+	sp.startAborted = false
+
+	defer func() {
+		if err != nil {
+			sp.abortStart(raceEvent, err)
+		}
+	}()
+
+	if err := sp.checkStartCancelled(); err != nil {
+		return err
+	}
+
 	logrus.Infof("Starting Server Process with event: %s", describeRaceEvent(raceEvent))
-	var executablePath string
 
-	if filepath.IsAbs(config.Steam.ExecutablePath) {
-		executablePath = config.Steam.ExecutablePath
-	} else {
-		executablePath = filepath.Join(ServerInstallPath, config.Steam.ExecutablePath)
+	sp.emit(ProcessEvent{Type: ProcessEventStarting, EventName: raceEvent.EventName(), RaceEvent: raceEvent})
+
+	if err := sp.runStartHooks(raceEvent); err != nil {
+		return err
 	}
 
+	executablePath := sp.executablePath()
+	sp.recordServerBuild()
+
 	serverOptions, err := sp.store.LoadServerOptions()
 
 	if err != nil {
 		return err
 	}
 
+	if err := sp.checkPorts(serverOptions); err != nil {
+		return err
+	}
+
 	sp.ctx, sp.cfn = context.WithCancel(context.Background())
-	sp.cmd = buildCommand(sp.ctx, executablePath)
-	sp.cmd.Dir = ServerInstallPath
+
+	if sp.launcher != nil {
+		sp.cmd, err = sp.launcher.command(sp.ctx, sp, serverOptions)
+
+		if err != nil {
+			return err
+		}
+	} else {
+		sp.cmd = buildCommand(sp.ctx, executablePath)
+		sp.cmd.Dir = sp.installPath()
+	}
+
+	sp.launchCommand = newLaunchCommand(sp.cmd)
 
 	var logOutput io.Writer
 	var errorOutput io.Writer
 
+	// the output of the last session is kept separately, so that it isn't mixed up with the output of this one.
+	sp.logBuffer.rotate()
+
+	var bufferOutput io.Writer = sp.logBuffer
+
+	if logFileConfig := sp.logFileConfig(); logFileConfig.Directory != "" {
+		sp.rotatingLogFile, err = newRotatingLogFile(sp.logBuffer, logFileConfig, raceEvent)
+
+		if err != nil {
+			return err
+		}
+
+		bufferOutput = sp.rotatingLogFile
+	}
+
 	if serverOptions.LogACServerOutputToFile {
-		logDirectory := filepath.Join(ServerInstallPath, "logs", "session")
-		errorDirectory := filepath.Join(ServerInstallPath, "logs", "error")
+		logDirectory := filepath.Join(sp.installPath(), "logs", "session")
+		errorDirectory := filepath.Join(sp.installPath(), "logs", "error")
 
 		if err := os.MkdirAll(logDirectory, 0755); err != nil {
 			return err
@@ -263,7 +797,7 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 			return err
 		}
 
-		timestamp := time.Now().Format("2006-02-01_15-04-05")
+		timestamp := time.Now().Format("2006-01-02_15-04-05")
 
 		sp.logFile, err = os.Create(filepath.Join(logDirectory, "output_"+timestamp+".log"))
 
@@ -277,32 +811,95 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 			return err
 		}
 
-		logOutput = io.MultiWriter(sp.logBuffer, sp.logFile)
-		errorOutput = io.MultiWriter(sp.logBuffer, sp.errorLogFile)
+		logOutput = io.MultiWriter(bufferOutput, sp.logFile)
+		errorOutput = io.MultiWriter(bufferOutput, sp.errorLogFile)
 	} else {
-		logOutput = sp.logBuffer
-		errorOutput = sp.logBuffer
+		logOutput = bufferOutput
+		errorOutput = bufferOutput
 	}
 
-	sp.cmd.Stdout = logOutput
-	sp.cmd.Stderr = errorOutput
+	sp.startupErr = nil
+	sp.readiness = newStartupReadiness()
+	sp.resetHealth()
+	startupLogScanner := sp.newStartupLogScanner(sp.readiness)
+
+	sp.cmd.Stdout = io.MultiWriter(logOutput, startupLogScanner)
+	sp.cmd.Stderr = io.MultiWriter(errorOutput, startupLogScanner)
+
+	sp.pluginForwardingTargets = nil
 
 	if err := sp.startUDPListener(); err != nil {
 		return err
 	}
 
+	sp.startUDPSendQueue()
+
 	wd, err := os.Getwd()
 
 	if err != nil {
 		return err
 	}
 
+	if err := sp.checkStartCancelled(); err != nil {
+		return err
+	}
+
 	sp.raceEvent = raceEvent
+	sp.startedAt = time.Now()
+	sp.raceFinish = raceFinish{}
+	sp.done = make(chan struct{})
+	sp.stopRequested = false
+	sp.crashSimulated = false
+	sp.memoryLimitExceeded = false
+	sp.hangDetected = false
+
+	// start acServer before returning so that a call to Stop straight after Start always has a process to stop.
+	runErr := sp.cmd.Start()
+
+	// a launcher applies the limits and scheduling itself, as they would only apply to the process which it runs.
+	if runErr == nil && sp.launcher == nil {
+		limits := acServerResourceLimits(serverOptions)
+
+		sp.resourceLimits, err = applyResourceLimits(sp.cmd.Process.Pid, "acServer", limits)
+
+		if err != nil {
+			logrus.WithError(err).Errorf("Could not apply resource limits (%+v) to acServer", limits)
+		}
+	}
+
+	if runErr == nil && sp.launcher == nil && config != nil {
+		applyProcessScheduling(sp.cmd.Process.Pid, "acServer", config.Server.CPUAffinity, config.Server.ProcessPriority)
+	}
+
+	if runErr == nil && sp.launcher == nil {
+		if err := sp.writeRunningProcessFile(); err != nil {
+			logrus.WithError(err).Warn("Could not record the running acServer, it won't be adopted if Server Manager stops unexpectedly")
+		}
+	}
+
+	if runErr == nil {
+		sp.emit(ProcessEvent{Type: ProcessEventStarted, EventName: raceEvent.EventName(), RaceEvent: raceEvent})
+		sp.notifyStart(raceEvent)
+	}
 
 	go func() {
-		sp.run <- sp.cmd.Run()
+		if runErr != nil {
+			sp.run <- runErr
+			return
+		}
+
+		sp.run <- sp.cmd.Wait()
 	}()
 
+	if config.Server.MaxEventDuration > 0 && sp.IsFeatureEnabled(FeatureMaxEventDuration) {
+		go sp.enforceMaxEventDuration(sp.ctx, sp.startedAt)
+	}
+
+	if runErr == nil {
+		sp.startMemoryWatchdog()
+		sp.startHangWatchdog()
+	}
+
 	if serverOptions.EnableContentManagerWrapper == 1 && serverOptions.ContentManagerWrapperPort > 0 {
 		go panicCapture(func() {
 			err := sp.contentManagerWrapper.Start(serverOptions.ContentManagerWrapperPort, sp.raceEvent, sp)
@@ -320,7 +917,7 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 	udp.PosIntervalModifierEnabled = !strackerEnabled
 
 	kissMyRankOptions, err := sp.store.LoadKissMyRankOptions()
-	kissMyRankEnabled := err == nil && kissMyRankOptions.EnableKissMyRank && IsKissMyRankInstalled()
+	kissMyRankEnabled := err == nil && kissMyRankOptions.EnableKissMyRank && isKissMyRankInstalled(sp.installPath())
 
 	realPenaltyOptions, err := sp.store.LoadRealPenaltyOptions()
 	realPenaltyEnabled := err == nil && realPenaltyOptions.RealPenaltyAppConfig.General.EnableRealPenalty && IsRealPenaltyInstalled()
@@ -331,13 +928,27 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 		logrus.WithError(ErrPluginConfigurationRequiresUDPPortSetup).Error("Please check your server configuration")
 	}
 
+	if err := sp.checkStartCancelled(); err != nil {
+		return err
+	}
+
+	// with multiplexing, each plugin talks to Server Manager directly rather than through the plugin before it.
+	multiplex := multiplexUDPPlugins()
+	pluginPorts := &udpPluginPorts{process: sp}
+
 	if strackerEnabled && strackerOptions != nil && udpPluginPortsSetup {
-		strackerOptions.InstanceConfiguration.ACServerConfigIni = filepath.Join(ServerInstallPath, "cfg", serverConfigIniPath)
-		strackerOptions.InstanceConfiguration.ACServerWorkingDir = ServerInstallPath
+		strackerOptions.InstanceConfiguration.ACServerConfigIni = filepath.Join(sp.installPath(), "cfg", serverConfigIniPath)
+		strackerOptions.InstanceConfiguration.ACServerWorkingDir = sp.installPath()
 		strackerOptions.ACPlugin.SendPort = sp.forwardListenPort
 		strackerOptions.ACPlugin.ReceivePort = formValueAsInt(strings.Split(sp.forwardingAddress, ":")[1])
 
-		if kissMyRankEnabled || realPenaltyEnabled {
+		if multiplex {
+			strackerOptions.ACPlugin.ReceivePort, strackerOptions.ACPlugin.SendPort, err = pluginPorts.next("stracker")
+
+			if err != nil {
+				return err
+			}
+		} else if kissMyRankEnabled || realPenaltyEnabled {
 			// kissmyrank and real penalty use stracker's forwarding to chain the plugins. make sure that it is set up.
 			if strackerOptions.ACPlugin.ProxyPluginLocalPort <= 0 {
 				strackerOptions.ACPlugin.ProxyPluginLocalPort, err = FreeUDPPort()
@@ -376,6 +987,10 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 	}
 
 	if realPenaltyEnabled && realPenaltyOptions != nil && udpPluginPortsSetup {
+		if err := sp.checkStartCancelled(); err != nil {
+			return err
+		}
+
 		if err := fixRealPenaltyExecutablePermissions(); err != nil {
 			return err
 		}
@@ -385,7 +1000,17 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 			response string
 		)
 
-		if !strackerEnabled {
+		if multiplex {
+			var sendPort int
+
+			port, sendPort, err = pluginPorts.next("realpenalty")
+
+			if err != nil {
+				return err
+			}
+
+			response = fmt.Sprintf("127.0.0.1:%d", sendPort)
+		} else if !strackerEnabled {
 			// connect to the forwarding address
 			port, err = strconv.Atoi(strings.Split(sp.forwardingAddress, ":")[1])
 
@@ -402,7 +1027,7 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 			response = fmt.Sprintf("127.0.0.1:%d", strackerOptions.ACPlugin.ProxyPluginLocalPort)
 		}
 
-		if kissMyRankEnabled {
+		if kissMyRankEnabled && !multiplex {
 			// proxy from real penalty to kmr
 			freeUDPPort, err := FreeUDPPort()
 
@@ -423,10 +1048,10 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 
 		realPenaltyOptions.RealPenaltyAppConfig.General.UDPPort = port
 		realPenaltyOptions.RealPenaltyAppConfig.General.UDPResponse = response
-		realPenaltyOptions.RealPenaltyAppConfig.General.ACServerPath = ServerInstallPath
-		realPenaltyOptions.RealPenaltyAppConfig.General.ACCFGFile = filepath.Join(ServerInstallPath, "cfg", "server_cfg.ini")
-		realPenaltyOptions.RealPenaltyAppConfig.General.ACTracksFolder = filepath.Join(ServerInstallPath, "content", "tracks")
-		realPenaltyOptions.RealPenaltyAppConfig.General.ACWeatherFolder = filepath.Join(ServerInstallPath, "content", "weather")
+		realPenaltyOptions.RealPenaltyAppConfig.General.ACServerPath = sp.installPath()
+		realPenaltyOptions.RealPenaltyAppConfig.General.ACCFGFile = filepath.Join(sp.installPath(), "cfg", "server_cfg.ini")
+		realPenaltyOptions.RealPenaltyAppConfig.General.ACTracksFolder = filepath.Join(sp.installPath(), "content", "tracks")
+		realPenaltyOptions.RealPenaltyAppConfig.General.ACWeatherFolder = filepath.Join(sp.installPath(), "content", "weather")
 		realPenaltyOptions.RealPenaltyAppConfig.General.AppFile = filepath.Join(RealPenaltyFolderPath(), "files", "app")
 		realPenaltyOptions.RealPenaltyAppConfig.General.ImagesFile = filepath.Join(RealPenaltyFolderPath(), "files", "images")
 		realPenaltyOptions.RealPenaltyAppConfig.General.SoundsFile = filepath.Join(RealPenaltyFolderPath(), "files", "sounds")
@@ -451,14 +1076,18 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 	}
 
 	if kissMyRankEnabled && kissMyRankOptions != nil && udpPluginPortsSetup {
-		if err := fixKissMyRankExecutablePermissions(); err != nil {
+		if err := sp.checkStartCancelled(); err != nil {
+			return err
+		}
+
+		if err := fixKissMyRankExecutablePermissions(sp.installPath()); err != nil {
 			return err
 		}
 
 		kissMyRankOptions.ACServerIP = "127.0.0.1"
 		kissMyRankOptions.ACServerHTTPPort = serverOptions.HTTPPort
 		kissMyRankOptions.UpdateInterval = config.LiveMap.IntervalMs
-		kissMyRankOptions.ACServerResultsBasePath = ServerInstallPath
+		kissMyRankOptions.ACServerResultsBasePath = sp.installPath()
 
 		raceConfig := sp.raceEvent.GetRaceConfig()
 		entryList := sp.raceEvent.GetEntryList()
@@ -469,7 +1098,13 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 			kissMyRankOptions.MaxPlayers = len(entryList)
 		}
 
-		if realPenaltyEnabled && realPenaltyOptions != nil {
+		if multiplex {
+			kissMyRankOptions.ACServerPluginAddressPort, kissMyRankOptions.ACServerPluginLocalPort, err = pluginPorts.next("kissmyrank")
+
+			if err != nil {
+				return err
+			}
+		} else if realPenaltyEnabled && realPenaltyOptions != nil {
 			// realPenalty is enabled, use its relay port
 			logrus.Infof("Real Penalty and KissMyRank both enabled. Using plugin forwarding method: [Previous Plugin/Server Manager] <-> [Real Penalty] <-> [KissMyRank]")
 
@@ -486,12 +1121,12 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 			kissMyRankOptions.ACServerPluginAddressPort = formValueAsInt(strings.Split(sp.forwardingAddress, ":")[1])
 		}
 
-		if err := kissMyRankOptions.Write(); err != nil {
+		if err := kissMyRankOptions.Write(sp.installPath()); err != nil {
 			return err
 		}
 
 		err = sp.startPlugin(wd, &CommandPlugin{
-			Executable: KissMyRankExecutablePath(),
+			Executable: kissMyRankExecutablePath(sp.installPath()),
 		})
 
 		if err != nil {
@@ -502,10 +1137,15 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 	}
 
 	for _, plugin := range config.Server.Plugins {
+		if err := sp.checkStartCancelled(); err != nil {
+			return err
+		}
+
 		err = sp.startPlugin(wd, plugin)
 
 		if err != nil {
 			logrus.WithError(err).Errorf("Could not run extra command: %s", plugin.String())
+			sp.pluginFailed(plugin, err)
 		}
 	}
 
@@ -513,6 +1153,10 @@ func (sp *AssettoServerProcess) startRaceEvent(raceEvent RaceEvent) error {
 		logrus.Warnf("Use of run_on_start in config.yml is deprecated. Please use 'plugins' instead")
 
 		for _, command := range config.Server.RunOnStart {
+			if err := sp.checkStartCancelled(); err != nil {
+				return err
+			}
+
 			err = sp.startChildProcess(wd, command)
 
 			if err != nil {
@@ -553,8 +1197,8 @@ func (sp *AssettoServerProcess) deleteOldLogFiles(numFilesToKeep int) error {
 		return nil
 	}
 
-	logDirectory := filepath.Join(ServerInstallPath, "logs", "session")
-	errorDirectory := filepath.Join(ServerInstallPath, "logs", "error")
+	logDirectory := filepath.Join(sp.installPath(), "logs", "session")
+	errorDirectory := filepath.Join(sp.installPath(), "logs", "error")
 
 	if err := tidyFunc(logDirectory); err != nil {
 		return err
@@ -567,12 +1211,35 @@ func (sp *AssettoServerProcess) deleteOldLogFiles(numFilesToKeep int) error {
 	return nil
 }
 
-func (sp *AssettoServerProcess) onStop() error {
+func (sp *AssettoServerProcess) onStop() (err error) {
 	sp.mutex.Lock()
 	defer sp.mutex.Unlock()
 	logrus.Debugf("Server stopped. Stopping UDP listener and child processes.")
 
+	if sp.raceEvent != nil {
+		sp.runStopHooks(sp.raceEvent)
+	}
+
+	// stop waiters are taken at the same time as the race event is cleared, so that every waiter registered
+	// while the process was running is guaranteed to receive a result.
 	sp.raceEvent = nil
+	sp.startedAt = time.Time{}
+	sp.stopMemoryWatchdog()
+	sp.stopHangWatchdog()
+
+	if sp.readiness != nil {
+		sp.readiness.finish(false)
+	}
+	stopWaiters := sp.stopWaiters
+	sp.stopWaiters = nil
+
+	defer func() {
+		for _, stopped := range stopWaiters {
+			stopped <- err
+		}
+	}()
+
+	sp.stopUDPSendQueue()
 
 	if err := sp.stopUDPListener(); err != nil {
 		logrus.WithError(err).Error("UDP listener close errored")
@@ -580,13 +1247,20 @@ func (sp *AssettoServerProcess) onStop() error {
 
 	sp.stopChildProcesses()
 
-	for _, doneCh := range sp.notifyDoneChs {
-		select {
-		case doneCh <- struct{}{}:
-		default:
-		}
+	if err := sp.resourceLimits.release(); err != nil {
+		logrus.WithError(err).Warn("Could not release acServer's resource limits")
 	}
 
+	sp.resourceLimits = processLimits{}
+
+	if sp.launcher != nil {
+		sp.launcher.cleanup()
+	} else {
+		sp.removeRunningProcessFile()
+	}
+
+	sp.closeDone()
+
 	if sp.logFile != nil {
 		if err := sp.logFile.Close(); err != nil {
 			return err
@@ -603,6 +1277,14 @@ func (sp *AssettoServerProcess) onStop() error {
 		sp.errorLogFile = nil
 	}
 
+	if sp.rotatingLogFile != nil {
+		if err := sp.rotatingLogFile.Close(); err != nil {
+			return err
+		}
+
+		sp.rotatingLogFile = nil
+	}
+
 	return nil
 }
 
@@ -610,6 +1292,12 @@ func (sp *AssettoServerProcess) Logs() string {
 	return sp.logBuffer.String()
 }
 
+// PreviousLogs returns the complete output of the session before the current one, e.g. what acServer printed before it
+// crashed and was restarted. Logs only has the output of the current session, or the last session once acServer stops.
+func (sp *AssettoServerProcess) PreviousLogs() string {
+	return sp.logBuffer.Previous()
+}
+
 func (sp *AssettoServerProcess) Event() RaceEvent {
 	sp.mutex.Lock()
 	defer sp.mutex.Unlock()
@@ -623,6 +1311,8 @@ func (sp *AssettoServerProcess) Event() RaceEvent {
 
 var ErrNoOpenUDPConnection = errors.New("servermanager: no open UDP connection found")
 
+// SendUDPMessage sends message to acServer. If a UDPSendInterval is set, the message is queued and sent in order with
+// the other queued messages.
 func (sp *AssettoServerProcess) SendUDPMessage(message udp.Message) error {
 	sp.mutex.Lock()
 	defer sp.mutex.Unlock()
@@ -631,9 +1321,48 @@ func (sp *AssettoServerProcess) SendUDPMessage(message udp.Message) error {
 		return ErrNoOpenUDPConnection
 	}
 
+	if sp.udpSendQueue != nil {
+		return sp.udpSendQueue.enqueue(message)
+	}
+
 	return sp.udpServerConn.SendMessage(message)
 }
 
+// RealtimePosInterval is the real time pos interval currently requested from acServer, in milliseconds.
+func (sp *AssettoServerProcess) RealtimePosInterval() int {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.udpServerConn == nil {
+		return udp.RealtimePosIntervalMs
+	}
+
+	return sp.udpServerConn.RealtimePosInterval()
+}
+
+// closedDoneCh is returned by Done when no event is running.
+var closedDoneCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+
+	return ch
+}()
+
+// Done returns a channel which is closed when the event which is running ends. If no event is running, the channel
+// is already closed. Each event gets its own channel, so Done should be called again after the next Start.
+func (sp *AssettoServerProcess) Done() <-chan struct{} {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.done == nil {
+		return closedDoneCh
+	}
+
+	return sp.done
+}
+
+// NotifyDone makes ch receive a value, if it is ready to, each time an event ends. Done is simpler to use for a
+// single event.
 func (sp *AssettoServerProcess) NotifyDone(ch chan struct{}) {
 	sp.mutex.Lock()
 	defer sp.mutex.Unlock()
@@ -641,13 +1370,77 @@ func (sp *AssettoServerProcess) NotifyDone(ch chan struct{}) {
 	sp.notifyDoneChs = append(sp.notifyDoneChs, ch)
 }
 
+// NotifyStart makes ch receive the event, if it is ready to, each time an event starts running, i.e. once acServer
+// has been started for it. Events which fail to start are not sent.
+func (sp *AssettoServerProcess) NotifyStart(ch chan RaceEvent) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.notifyStartChs = append(sp.notifyStartChs, ch)
+}
+
+// notifyStart notifies the channels registered with NotifyStart. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) notifyStart(raceEvent RaceEvent) {
+	for _, startCh := range sp.notifyStartChs {
+		select {
+		case startCh <- raceEvent:
+		default:
+		}
+	}
+}
+
+// closeDone closes the Done channel of the event which has just ended, and notifies the channels registered with
+// NotifyDone. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) closeDone() {
+	if sp.done != nil {
+		close(sp.done)
+		sp.done = nil
+	}
+
+	for _, doneCh := range sp.notifyDoneChs {
+		select {
+		case doneCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
 func (sp *AssettoServerProcess) startPlugin(wd string, plugin *CommandPlugin) error {
-	commandFullPath, err := filepath.Abs(plugin.Executable)
+	cmd, stdin, err := buildPluginCommand(wd, plugin, sp.pluginEventEnv(), sp.pluginOutput(plugin.DisplayName()))
 
 	if err != nil {
 		return err
 	}
 
+	err = startPluginCommand(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	extraProcess := newPluginProcess(plugin.DisplayName(), cmd, stdin)
+	extraProcess.plugin = plugin
+	extraProcess.wd = wd
+	extraProcess.limitResources()
+	extraProcess.schedule()
+	sp.extraProcesses = append(sp.extraProcesses, extraProcess)
+
+	sp.emit(ProcessEvent{Type: ProcessEventPluginStarted, EventName: sp.raceEvent.EventName(), RaceEvent: sp.raceEvent, Plugin: extraProcess.name})
+
+	go sp.monitorPlugin(extraProcess)
+
+	return nil
+}
+
+// buildPluginCommand prepares the command for a plugin, which is run from its WorkingDir, or the plugin's own
+// directory, with eventEnv in its environment, and writes its stdout and stderr to output.
+func buildPluginCommand(wd string, plugin *CommandPlugin, eventEnv []string, output io.Writer) (*exec.Cmd, io.WriteCloser, error) {
+	commandFullPath, err := filepath.Abs(plugin.Executable)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
 	ctx := context.Background()
 
 	cmd := buildCommand(ctx, commandFullPath, plugin.Arguments...)
@@ -659,35 +1452,42 @@ func (sp *AssettoServerProcess) startPlugin(wd string, plugin *CommandPlugin) er
 		pluginDir = wd
 	}
 
-	cmd.Stdout = pluginsOutput
-	cmd.Stderr = pluginsOutput
+	if plugin.WorkingDir != "" {
+		pluginDir, err = filepath.Abs(plugin.WorkingDir)
 
-	cmd.Dir = pluginDir
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 
-	stdin, err := cmd.StdinPipe()
+	env, err := pluginEnvironment(plugin, eventEnv)
 
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	err = cmd.Start()
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	cmd.Dir = pluginDir
+	cmd.Env = env
+
+	stdin, err := cmd.StdinPipe()
 
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	sp.extraProcesses = append(sp.extraProcesses, &pluginProcess{
-		cmd:   cmd,
-		stdin: stdin,
-	})
-
-	return nil
+	return cmd, stdin, nil
 }
 
 // Deprecated: use startPlugin instead
 func (sp *AssettoServerProcess) startChildProcess(wd string, command string) error {
-	// BUG(cj): splitting commands on spaces breaks child processes that have a space in their path name
-	parts := strings.Split(command, " ")
+	parts, err := splitCommand(command)
+
+	if err != nil {
+		return err
+	}
 
 	if len(parts) == 0 {
 		return nil
@@ -715,8 +1515,11 @@ func (sp *AssettoServerProcess) startChildProcess(wd string, command string) err
 		pluginDir = wd
 	}
 
-	cmd.Stdout = pluginsOutput
-	cmd.Stderr = pluginsOutput
+	name := filepath.Base(commandFullPath)
+	output := sp.pluginOutput(name)
+
+	cmd.Stdout = output
+	cmd.Stderr = output
 
 	cmd.Dir = pluginDir
 	stdin, err := cmd.StdinPipe()
@@ -725,56 +1528,135 @@ func (sp *AssettoServerProcess) startChildProcess(wd string, command string) err
 		return err
 	}
 
-	err = cmd.Start()
+	err = startPluginCommand(cmd)
 
 	if err != nil {
 		return err
 	}
 
-	sp.extraProcesses = append(sp.extraProcesses, &pluginProcess{
-		cmd:   cmd,
-		stdin: stdin,
-	})
+	extraProcess := newPluginProcess(name, cmd, stdin)
+	extraProcess.limitResources()
+	sp.extraProcesses = append(sp.extraProcesses, extraProcess)
+
+	sp.emit(ProcessEvent{Type: ProcessEventPluginStarted, EventName: sp.raceEvent.EventName(), RaceEvent: sp.raceEvent, Plugin: extraProcess.name})
+
+	go sp.monitorPlugin(extraProcess)
 
 	return nil
 }
 
+var ErrUnterminatedQuote = errors.New("servermanager: command has an unterminated quote")
+
+// splitCommand splits a command line into its executable and arguments. Arguments are separated by spaces, unless
+// they are inside double or single quotes. A backslash only escapes a quote, so that Windows paths can be given
+// without quoting every backslash.
+func splitCommand(command string) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+
+	var quote rune
+	inPart := false
+	runes := []rune(command)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\'') && quote != '\'':
+			current.WriteRune(runes[i+1])
+			inPart = true
+			i++
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inPart = true
+		case r == ' ' || r == '\t':
+			if inPart {
+				parts = append(parts, current.String())
+				current.Reset()
+				inPart = false
+			}
+		default:
+			current.WriteRune(r)
+			inPart = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, ErrUnterminatedQuote
+	}
+
+	if inPart {
+		parts = append(parts, current.String())
+	}
+
+	return parts, nil
+}
+
 func (sp *AssettoServerProcess) stopChildProcesses() {
 	sp.contentManagerWrapper.Stop()
 
 	for _, command := range sp.extraProcesses {
-		waitDone := make(chan error, 1)
-		go func() {
-			waitDone <- command.cmd.Wait()
-		}()
+		sp.stopPluginProcess(command)
 
-		if command.cmd.Dir == filepath.Join(ServerInstallPath, "kissmyrank") {
-			_, _ = fmt.Fprintf(command.stdin, "exit\r\n")
+		// plugins such as stracker start their own subprocesses, which may ignore the signal used to stop the plugin
+		// or outlive it. anything left in the plugin's process group is killed so that it doesn't keep holding ports.
+		if err := killProcessGroup(command.cmd); err != nil {
+			logrus.WithError(err).Warnf("Could not kill the remaining processes of plugin: %s", filepath.Base(command.cmd.Path))
+		}
 
-			kmrStopTimeout := time.After(time.Second * 15)
+		command.releaseLimits()
+	}
 
-			select {
-			case err := <-waitDone:
-				if err != nil {
-					logrus.WithError(err).Errorf("KissMyRank stopped with an error")
-				} else {
-					logrus.Infof("KissMyRank stopped correctly")
-				}
-				continue
-			case <-kmrStopTimeout:
-				logrus.Infof("KissMyRank did not stop correctly, manually killing...")
-			}
-		}
+	sp.extraProcesses = make([]*pluginProcess, 0)
+	sp.failedPlugins = nil
+}
+
+func (sp *AssettoServerProcess) stopPluginProcess(command *pluginProcess) {
+	command.stopping = true
+
+	select {
+	case <-command.exited:
+		// the plugin has already exited, there is nothing to stop.
+		return
+	default:
+	}
 
-		if err := stopCommand(command.cmd, waitDone, 30); err != nil {
-			if _, isExit := err.(*exec.ExitError); !isExit {
-				name := filepath.Base(command.cmd.Path)
-				logrus.WithError(err).Warnf("Command stop problem: %s [pid: %d]", name, command.cmd.Process.Pid)
+	waitDone := make(chan error, 1)
+	go func(command *pluginProcess) {
+		<-command.exited
+		waitDone <- command.exitErr
+	}(command)
+
+	if kissMyRankDir, err := filepath.Abs(kissMyRankFolderPath(sp.installPath())); err == nil && command.cmd.Dir == kissMyRankDir {
+		_, _ = fmt.Fprintf(command.stdin, "exit\r\n")
+
+		kmrStopTimeout := time.After(time.Second * 15)
+
+		select {
+		case err := <-waitDone:
+			if err != nil {
+				logrus.WithError(err).Errorf("KissMyRank stopped with an error")
+			} else {
+				logrus.Infof("KissMyRank stopped correctly")
 			}
+			return
+		case <-kmrStopTimeout:
+			logrus.Infof("KissMyRank did not stop correctly, manually killing...")
 		}
 	}
 
-	sp.extraProcesses = make([]*pluginProcess, 0)
+	if err := stopCommand(command.cmd, waitDone, defaultStopGraceTimeout, defaultStopHardTimeout); err != nil {
+		if _, isExit := err.(*exec.ExitError); !isExit {
+			name := filepath.Base(command.cmd.Path)
+			logrus.WithError(err).Warnf("Command stop problem: %s [pid: %d]", name, command.cmd.Process.Pid)
+		}
+	}
 }
 
 func (sp *AssettoServerProcess) startUDPListener() error {
@@ -792,52 +1674,126 @@ func (sp *AssettoServerProcess) startUDPListener() error {
 		return err
 	}
 
-	sp.udpServerConn, err = udp.NewServerClient(host, int(port), sp.udpPluginLocalPort, true, sp.forwardingAddress, sp.forwardListenPort, sp.UDPCallback)
+	var conn *udp.AssettoServerUDP
+
+	if remote, ok := sp.remote(); ok {
+		conn, err = udp.NewRemoteServerClient(host, int(port), remote.remoteHost(), sp.udpPluginLocalPort, true, sp.forwardingAddress, sp.forwardListenPort, sp.UDPCallback)
+	} else {
+		conn, err = udp.NewServerClient(host, int(port), sp.udpPluginLocalPort, true, sp.forwardingAddress, sp.forwardListenPort, sp.UDPCallback)
+	}
 
 	if err != nil {
 		return err
 	}
 
+	sp.udpServerConn = conn
+
+	sp.startUDPRecording()
+
+	if sp.udpRecorder != nil {
+		conn.SetRecorder(sp.udpRecorder.Record)
+	}
+
+	sp.udpServerConn.SetForwardingEnabled(!sp.forwardingDisabled)
+
+	go sp.superviseUDPListener(sp.udpServerConn, currentUDPLiveness())
+
+	if len(sp.forwardingTargets) > 0 {
+		if err := sp.applyForwardingTargets(); err != nil {
+			logrus.WithError(err).Error("Could not open UDP forwarding targets")
+		}
+	}
+
 	return nil
 }
 
-func (sp *AssettoServerProcess) stopUDPListener() error {
-	return sp.udpServerConn.Close()
+// ForwardingStatus describes where UDP messages from acServer are forwarded to, and whether forwarding is paused.
+type ForwardingStatus struct {
+	Address string
+	Enabled bool
 }
 
-func newLogBuffer(maxSize int) *logBuffer {
-	return &logBuffer{
-		size: maxSize,
-		buf:  new(bytes.Buffer),
+// Forwarding returns the current ForwardingStatus. Unlike Status, it doesn't wait for acServer to finish starting or
+// stopping, so it can be used when rendering every page.
+func (sp *AssettoServerProcess) Forwarding() ForwardingStatus {
+	status, _ := sp.forwarding.Load().(ForwardingStatus)
+
+	return status
+}
+
+// storeForwarding caches the ForwardingStatus for Forwarding. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) storeForwarding() {
+	sp.forwarding.Store(ForwardingStatus{Address: sp.forwardingAddress, Enabled: !sp.forwardingDisabled})
+}
+
+// SetForwardingEnabled pauses or resumes forwarding of UDP messages to the forwarding address without restarting
+// acServer. The setting is kept across server restarts.
+func (sp *AssettoServerProcess) SetForwardingEnabled(enabled bool) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.forwardingDisabled = !enabled
+	sp.storeForwarding()
+
+	if sp.udpServerConn != nil {
+		sp.udpServerConn.SetForwardingEnabled(enabled)
+	}
+
+	if enabled {
+		logrus.Infof("UDP forwarding to %s resumed", sp.forwardingAddress)
+	} else {
+		logrus.Infof("UDP forwarding to %s paused", sp.forwardingAddress)
 	}
 }
 
-type logBuffer struct {
-	buf *bytes.Buffer
+// SetForwardingTargets replaces the additional addresses that UDP messages are forwarded to, without restarting
+// acServer. Targets which are already being forwarded to keep their socket and counters, so a target can be disabled
+// and enabled again without losing its stats. The targets are kept across server restarts, and replace the targets
+// from WithForwardingTargets until Server Manager is restarted.
+func (sp *AssettoServerProcess) SetForwardingTargets(targets []udp.ForwardTarget) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
 
-	size int
+	sp.forwardingTargets = targets
 
-	mutex sync.Mutex
+	logrus.Infof("UDP forwarding targets set to: %v", targets)
+
+	return sp.applyForwardingTargets()
 }
 
-func (lb *logBuffer) Write(p []byte) (n int, err error) {
-	lb.mutex.Lock()
-	defer lb.mutex.Unlock()
+// forwardingTargetStatuses returns the status of each forwarding target. Targets which aren't open, e.g. because
+// the server is stopped, are reported as inactive. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) forwardingTargetStatuses() []udp.ForwardTargetStatus {
+	var open []udp.ForwardTargetStatus
+
+	if sp.udpServerConn != nil {
+		open = sp.udpServerConn.ForwardingTargets()
+	}
 
-	b := lb.buf.Bytes()
+	var statuses []udp.ForwardTargetStatus
 
-	if len(b) > lb.size {
-		lb.buf = bytes.NewBuffer(b[len(b)-lb.size:])
+	for _, target := range append(append([]udp.ForwardTarget(nil), sp.forwardingTargets...), sp.pluginForwardingTargets...) {
+		status := udp.ForwardTargetStatus{ForwardTarget: target}
+
+		for _, openStatus := range open {
+			if openStatus.Same(target) {
+				status = openStatus
+				break
+			}
+		}
+
+		statuses = append(statuses, status)
 	}
 
-	return lb.buf.Write(p)
+	return statuses
 }
 
-func (lb *logBuffer) String() string {
-	lb.mutex.Lock()
-	defer lb.mutex.Unlock()
+func (sp *AssettoServerProcess) stopUDPListener() error {
+	err := sp.udpServerConn.Close()
+
+	sp.stopUDPRecording()
 
-	return strings.Replace(lb.buf.String(), "\n\n", "\n", -1)
+	return err
 }
 
 func FreeUDPPort() (int, error) {
@@ -860,7 +1816,8 @@ func FreeUDPPort() (int, error) {
 
 var ErrCommandUnstoppable = errors.New("servermanager: command is unstoppable")
 
-func stopCommand(cmd *exec.Cmd, waiter chan error, timeout float32) error {
+// stopCommand asks cmd to exit, and kills it if it hasn't exited after grace. It gives up once hard has passed.
+func stopCommand(cmd *exec.Cmd, waiter chan error, grace, hard time.Duration) error {
 	name := filepath.Base(cmd.Path)
 	proc := getProcess(cmd)
 	pid := proc.Pid
@@ -869,18 +1826,16 @@ func stopCommand(cmd *exec.Cmd, waiter chan error, timeout float32) error {
 		logrus.WithError(err).Errorf("Failed to terminate command: %s [pid: %d]", name, pid)
 		return err
 	}
-	termWait := timeout / 2
-	killWait := timeout - termWait
 	select {
-	case <-time.After(time.Duration(termWait) * time.Second):
-		logrus.Warnf("Process %d did not terminate after %g seconds. Killing...", pid, termWait)
+	case <-time.After(grace):
+		logrus.Warnf("Process %d did not terminate after %s. Killing...", pid, grace)
 		if err := kill(proc); err != nil {
 			logrus.WithError(err).Warnf("Failed to kill command: %s [pid: %d]", name, pid)
 			return err
 		}
 		select {
-		case <-time.After(time.Duration(killWait) * time.Second):
-			logrus.Errorf("Process %d could not be killed after %g seconds.", pid, timeout)
+		case <-time.After(hard - grace):
+			logrus.Errorf("Process %d could not be killed after %s.", pid, hard)
 			return ErrCommandUnstoppable
 		case err := <-waiter:
 			return err
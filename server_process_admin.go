@@ -0,0 +1,34 @@
+package servermanager
+
+import (
+	"fmt"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// KickCar kicks the driver of carID from the server. They can rejoin straight away.
+func (sp *AssettoServerProcess) KickCar(carID udp.CarID) error {
+	return sp.SendUDPMessage(udp.NewKickUser(uint8(carID)))
+}
+
+// BanCar kicks the driver of carID from the server and has acServer add their GUID to its blacklist.txt, so that they
+// can't rejoin until they are removed from it.
+func (sp *AssettoServerProcess) BanCar(carID udp.CarID) error {
+	command, err := udp.NewAdminCommand(fmt.Sprintf("/ban_id %d", carID))
+
+	if err != nil {
+		return err
+	}
+
+	return sp.SendUDPMessage(command)
+}
+
+// NextSession ends the current session and moves on to the next one.
+func (sp *AssettoServerProcess) NextSession() error {
+	return sp.SendUDPMessage(&udp.NextSession{})
+}
+
+// RestartSession restarts the current session.
+func (sp *AssettoServerProcess) RestartSession() error {
+	return sp.SendUDPMessage(&udp.RestartSession{})
+}
@@ -0,0 +1,142 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_AdminActions(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	if err := sp.KickCar(1); err != ErrNoOpenUDPConnection {
+		t.Errorf("Expected ErrNoOpenUDPConnection before acServer has started, got %v", err)
+	}
+
+	received, stop := startWithUDPStandIn(t, sp)
+	defer stop()
+
+	actions := []struct {
+		action func() error
+		event  udp.Event
+	}{
+		{func() error { return sp.KickCar(3) }, udp.EventKickUser},
+		{func() error { return sp.BanCar(3) }, udp.EventAdminCommand},
+		{sp.NextSession, udp.EventNextSession},
+		{sp.RestartSession, udp.EventRestartSession},
+	}
+
+	for _, action := range actions {
+		if err := action.action(); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case event := <-received:
+			if event != action.event {
+				t.Errorf("Expected acServer to receive event %d, got %d", action.event, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("acServer did not receive event %d", action.event)
+		}
+	}
+}
+
+func TestServerAdministrationHandler_AdminActions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-admin-actions")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(dir, dir)
+	sah := &ServerAdministrationHandler{process: dummyServerProcess{}, store: store}
+
+	r := chi.NewRouter()
+	r.Post("/api/admin/kick/{carID}", sah.kickCar)
+	r.Post("/api/admin/restart-session", sah.restartSession)
+
+	for url, status := range map[string]int{
+		"/api/admin/kick/3":          http.StatusNoContent,
+		"/api/admin/kick/300":        http.StatusBadRequest,
+		"/api/admin/restart-session": http.StatusNoContent,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, url, nil))
+
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, got %d", url, status, w.Code)
+		}
+	}
+
+	entries, err := store.GetAuditEntries()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actions := make(map[string]bool)
+
+	for _, entry := range entries {
+		actions[entry.Action] = true
+	}
+
+	if len(entries) != 2 || !actions["Kicked car 3"] || !actions["Restarted the session"] {
+		t.Errorf("Expected the kick and restart to be audited, got %v", actions)
+	}
+}
+
+type restartCountingProcess struct {
+	eventUDPMessagesProcess
+
+	restarts *int
+}
+
+func (p restartCountingProcess) Restart() error {
+	*p.restarts++
+	return nil
+}
+
+func TestServerAdministrationHandler_RestartEvent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-admin-actions")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(dir, dir)
+	restarts := 0
+	sah := &ServerAdministrationHandler{
+		process: restartCountingProcess{eventUDPMessagesProcess: eventUDPMessagesProcess{event: &CustomRace{}}, restarts: &restarts},
+		store:   store,
+	}
+
+	w := httptest.NewRecorder()
+	sah.restartEvent(w, httptest.NewRequest(http.MethodPost, "/api/admin/restart-event", nil))
+
+	if w.Code != http.StatusNoContent || restarts != 1 {
+		t.Errorf("Expected the custom race to be restarted, got status %d and %d restart(s)", w.Code, restarts)
+	}
+
+	entries, err := store.GetAuditEntries()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Action != "Restarted the event" {
+		t.Errorf("Expected the restart to be audited, got %+v", entries)
+	}
+}
@@ -0,0 +1,200 @@
+package servermanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runningProcessFile is written in the install path while acServer is running, so that an acServer which is left
+// running when Server Manager stops unexpectedly can be adopted by the next Server Manager to start.
+const runningProcessFile = "server-manager-acserver.json"
+
+// adoptedProcessPollInterval is how often an adopted acServer is checked to see if it is still running. It isn't a
+// child of this Server Manager, so it can't be waited on.
+const adoptedProcessPollInterval = time.Second
+
+// runningProcess is the contents of the runningProcessFile.
+type runningProcess struct {
+	PID       int
+	StartedAt time.Time
+	Event     json.RawMessage
+
+	UDPPluginAddress   string
+	UDPPluginLocalPort int
+	ForwardingAddress  string
+	ForwardListenPort  int
+}
+
+// writeRunningProcessFile records the acServer which has just been started. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) writeRunningProcessFile() error {
+	event, err := marshalRaceEvent(sp.raceEvent)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(runningProcess{
+		PID:                sp.cmd.Process.Pid,
+		StartedAt:          sp.startedAt,
+		Event:              event,
+		UDPPluginAddress:   sp.udpPluginAddress,
+		UDPPluginLocalPort: sp.udpPluginLocalPort,
+		ForwardingAddress:  sp.forwardingAddress,
+		ForwardListenPort:  sp.forwardListenPort,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(sp.installPath(), runningProcessFile), data, 0644)
+}
+
+func (sp *AssettoServerProcess) removeRunningProcessFile() {
+	if err := os.Remove(filepath.Join(sp.installPath(), runningProcessFile)); err != nil && !os.IsNotExist(err) {
+		logrus.WithError(err).Warn("Could not remove the running acServer file")
+	}
+}
+
+// Adopt takes over an acServer which was left running by a Server Manager which stopped without stopping it, e.g.
+// because it crashed. It is only adopted if its process is still running and its TCP port is still in use, and the
+// bool is true if it was. The UDP listener is started again so that live timings work, but plugins are not, and the
+// output of an adopted acServer can't be read.
+func (sp *AssettoServerProcess) Adopt() (bool, error) {
+	if sp.launcher != nil {
+		// docker and remote launchers clean up their acServer as they start the next one instead.
+		return false, nil
+	}
+
+	if err := sp.startQueue.acquire(context.Background(), StartPriorityNormal, ""); err != nil {
+		return false, err
+	}
+
+	defer sp.startQueue.release()
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.raceEvent != nil {
+		return false, nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(sp.installPath(), runningProcessFile))
+
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var running runningProcess
+
+	if err := json.Unmarshal(data, &running); err != nil {
+		return false, err
+	}
+
+	if err := checkAdoptable(sp.installPath(), running.PID); err != nil {
+		logrus.WithError(err).Infof("Not adopting acServer process %d", running.PID)
+		sp.removeRunningProcessFile()
+
+		return false, nil
+	}
+
+	raceEvent, err := unmarshalRaceEvent(running.Event)
+
+	if err != nil {
+		return false, err
+	}
+
+	process, err := os.FindProcess(running.PID)
+
+	if err != nil {
+		return false, err
+	}
+
+	logrus.Infof("Adopting acServer process %d, which was left running with event: %s", running.PID, describeRaceEvent(raceEvent))
+
+	sp.ctx, sp.cfn = context.WithCancel(context.Background())
+	sp.cmd = &exec.Cmd{Path: sp.executablePath(), Dir: sp.installPath(), Process: process}
+	sp.launchCommand = newLaunchCommand(sp.cmd)
+
+	sp.udpPluginAddress = running.UDPPluginAddress
+	sp.udpPluginLocalPort = running.UDPPluginLocalPort
+	sp.forwardingAddress = running.ForwardingAddress
+	sp.forwardListenPort = running.ForwardListenPort
+	sp.storeForwarding()
+
+	sp.logBuffer.rotate()
+	_, _ = fmt.Fprintf(sp.logBuffer, "Server Manager adopted this acServer (pid: %d) after being restarted. Its output can't be shown.\n", running.PID)
+
+	sp.startupErr = nil
+	sp.readiness = newStartupReadiness()
+	sp.readiness.finish(true)
+	sp.resetHealth()
+
+	if err := sp.startUDPListener(); err != nil {
+		return false, err
+	}
+
+	sp.startUDPSendQueue()
+
+	sp.raceEvent = raceEvent
+	sp.startedAt = running.StartedAt
+	sp.raceFinish = raceFinish{}
+	sp.done = make(chan struct{})
+	sp.stopRequested = false
+	sp.crashSimulated = false
+	sp.memoryLimitExceeded = false
+
+	sp.emit(ProcessEvent{Type: ProcessEventStarted, EventName: raceEvent.EventName(), RaceEvent: raceEvent})
+	sp.notifyStart(raceEvent)
+
+	go sp.watchAdoptedProcess(running.PID)
+
+	return true, nil
+}
+
+var errAdoptedProcessNotRunning = errors.New("servermanager: the acServer process is no longer running")
+
+// checkAdoptable checks that pid is still running acServer, rather than being a pid which has been reused.
+func checkAdoptable(installPath string, pid int) error {
+	if !processAlive(pid) {
+		return errAdoptedProcessNotRunning
+	}
+
+	ports, err := readGamePorts(installPath)
+
+	if err != nil {
+		return err
+	}
+
+	if err := probeTCPPort(ports.TCP, PortPurposeACServerTCP); err == nil {
+		return fmt.Errorf("servermanager: nothing is listening on acServer's TCP port (%d)", ports.TCP)
+	}
+
+	return nil
+}
+
+// watchAdoptedProcess waits for an adopted acServer to exit, and passes its exit to the process loop. Its exit code
+// can't be known, so an adopted acServer which stops of its own accord is treated as having exited cleanly.
+func (sp *AssettoServerProcess) watchAdoptedProcess(pid int) {
+	ticker := time.NewTicker(adoptedProcessPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !processAlive(pid) {
+			logrus.Infof("Adopted acServer process %d has stopped", pid)
+			sp.run <- nil
+			return
+		}
+	}
+}
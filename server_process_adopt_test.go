@@ -0,0 +1,114 @@
+package servermanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAssettoServerProcess_Adopt(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	// acServer's TCP port is in use by the orphaned acServer.
+	listener, err := net.Listen("tcp", ":0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer listener.Close()
+
+	if err := os.MkdirAll(filepath.Join(ServerInstallPath, ServerConfigPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	serverConfig := fmt.Sprintf("[SERVER]\nTCP_PORT=%d\n", listener.Addr().(*net.TCPAddr).Port)
+
+	if err := ioutil.WriteFile(filepath.Join(ServerInstallPath, ServerConfigPath, serverConfigIniPath), []byte(serverConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	orphan := buildCommand(context.Background(), "sleep", "600")
+
+	if err := orphan.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the orphan is a child of the test, so it has to be waited on for it to stop being seen as alive.
+	go func() {
+		_ = orphan.Wait()
+	}()
+
+	writeRunningProcessFile := func(pid int) {
+		event, err := marshalRaceEvent(&QuickRace{RaceConfig: CurrentRaceConfig{Track: "ks_vallelunga"}})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := json.Marshal(runningProcess{PID: pid, StartedAt: time.Now().Add(-time.Hour), Event: event, UDPPluginAddress: "127.0.0.1:0"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(ServerInstallPath, runningProcessFile), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Not adopted if the process has stopped", func(t *testing.T) {
+		stopped := buildCommand(context.Background(), "true")
+
+		if err := stopped.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		writeRunningProcessFile(stopped.Process.Pid)
+
+		if adopted, err := sp.Adopt(); adopted || err != nil {
+			t.Fatalf("expected a stopped acServer not to be adopted, got %t, %v", adopted, err)
+		}
+
+		if _, err := os.Stat(filepath.Join(ServerInstallPath, runningProcessFile)); !os.IsNotExist(err) {
+			t.Errorf("expected the running acServer file to be removed, got %v", err)
+		}
+	})
+
+	t.Run("Adopted", func(t *testing.T) {
+		writeRunningProcessFile(orphan.Process.Pid)
+
+		adopted, err := sp.Adopt()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !adopted || !sp.IsRunning() || sp.Event().GetRaceConfig().Track != "ks_vallelunga" {
+			t.Fatal("expected the orphaned acServer to be adopted with its event")
+		}
+
+		if uptime := sp.Uptime(); uptime < time.Hour {
+			t.Errorf("expected the uptime to be from when the orphaned acServer was started, got %s", uptime)
+		}
+
+		if err := sp.Stop(); err != nil {
+			t.Fatal(err)
+		}
+
+		if processAlive(orphan.Process.Pid) {
+			t.Error("expected the adopted acServer to be stopped")
+		}
+
+		if _, err := os.Stat(filepath.Join(ServerInstallPath, runningProcessFile)); !os.IsNotExist(err) {
+			t.Errorf("expected the running acServer file to be removed once it stopped, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package servermanager
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const storeEventSinkBufferSize = 100
+
+// storeEventSink persists lifecycle events to the store, so that the availability of the server can be worked out
+// later. Events are written from a background goroutine so that Publish never blocks the process loop.
+type storeEventSink struct {
+	store  Store
+	events chan ProcessEvent
+}
+
+func newStoreEventSink(store Store) *storeEventSink {
+	sink := &storeEventSink{
+		store:  store,
+		events: make(chan ProcessEvent, storeEventSinkBufferSize),
+	}
+
+	go sink.run()
+
+	return sink
+}
+
+func (s *storeEventSink) Publish(event ProcessEvent) {
+	select {
+	case s.events <- event:
+	default:
+		logrus.Warnf("Lifecycle event history is full, dropping %s event", event.Type)
+	}
+}
+
+func (s *storeEventSink) run() {
+	for event := range s.events {
+		event := event
+
+		if err := s.store.AddProcessEvent(&event); err != nil {
+			logrus.WithError(err).Errorf("Could not persist %s lifecycle event", event.Type)
+		}
+	}
+}
+
+// DowntimeInterval is a period in which acServer was not running.
+type DowntimeInterval struct {
+	Start, End time.Time
+
+	// Reason is why acServer stopped at the start of the interval. It is empty if the interval started before the
+	// earliest known event.
+	Reason StopReason `json:",omitempty"`
+
+	// Open is true if acServer was still not running at the end of the period.
+	Open bool
+}
+
+func (d DowntimeInterval) Duration() time.Duration {
+	return d.End.Sub(d.Start)
+}
+
+// Availability is the uptime of acServer over a period.
+type Availability struct {
+	From, To time.Time
+
+	Uptime        time.Duration
+	UptimePercent float64
+	Downtime      []DowntimeInterval
+}
+
+// Availability works out how much of the period between from and to acServer was running for, using the lifecycle
+// events in the store. If to is in the future, the period ends now.
+func (sp *AssettoServerProcess) Availability(from, to time.Time) (*Availability, error) {
+	events, err := sp.store.ListProcessEvents()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return computeAvailability(events, from, to, time.Now()), nil
+}
+
+// computeAvailability replays the started, stopped and crashed events to find the periods in which acServer was
+// not running. acServer is up from a started event until the next stopped or crashed event, so if the last event is
+// a start, it is up until the end of the period.
+func computeAvailability(events []*ProcessEvent, from, to, now time.Time) *Availability {
+	if to.After(now) {
+		to = now
+	}
+
+	if to.Before(from) {
+		to = from
+	}
+
+	availability := &Availability{From: from, To: to}
+
+	sorted := append([]*ProcessEvent(nil), events...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	running := false
+	var downReason StopReason
+
+	i := 0
+
+	// work out whether acServer was running at the start of the period
+	for ; i < len(sorted) && !sorted[i].Time.After(from); i++ {
+		switch sorted[i].Type {
+		case ProcessEventStarted:
+			running = true
+		case ProcessEventStopped, ProcessEventCrashed:
+			running = false
+			downReason = sorted[i].Reason
+		}
+	}
+
+	downSince := from
+
+	for ; i < len(sorted) && !sorted[i].Time.After(to); i++ {
+		event := sorted[i]
+
+		switch event.Type {
+		case ProcessEventStarted:
+			if running {
+				continue
+			}
+
+			if event.Time.After(downSince) {
+				availability.Downtime = append(availability.Downtime, DowntimeInterval{
+					Start:  downSince,
+					End:    event.Time,
+					Reason: downReason,
+				})
+			}
+
+			running = true
+		case ProcessEventStopped, ProcessEventCrashed:
+			if !running {
+				continue
+			}
+
+			running = false
+			downSince = event.Time
+			downReason = event.Reason
+		}
+	}
+
+	if !running && to.After(downSince) {
+		availability.Downtime = append(availability.Downtime, DowntimeInterval{
+			Start:  downSince,
+			End:    to,
+			Reason: downReason,
+			Open:   true,
+		})
+	}
+
+	period := to.Sub(from)
+	availability.Uptime = period
+
+	for _, downtime := range availability.Downtime {
+		availability.Uptime -= downtime.Duration()
+	}
+
+	if period > 0 {
+		availability.UptimePercent = float64(availability.Uptime) / float64(period) * 100
+	}
+
+	return availability
+}
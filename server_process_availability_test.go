@@ -0,0 +1,137 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestComputeAvailability(t *testing.T) {
+	base := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	at := func(hours float64) time.Time {
+		return base.Add(time.Duration(hours * float64(time.Hour)))
+	}
+
+	events := []*ProcessEvent{
+		{Type: ProcessEventStarted, Time: at(-2)},
+		{Type: ProcessEventStopping, Time: at(1.9)},
+		{Type: ProcessEventStopped, Time: at(2), Reason: StopReasonRequested},
+		{Type: ProcessEventStarted, Time: at(3)},
+		{Type: ProcessEventPluginExited, Time: at(4), Plugin: "stracker"},
+		{Type: ProcessEventCrashed, Time: at(6), Reason: StopReasonCrashed},
+		{Type: ProcessEventStarted, Time: at(6.5)},
+	}
+
+	t.Run("Closed period", func(t *testing.T) {
+		availability := computeAvailability(events, at(0), at(10), at(20))
+
+		if len(availability.Downtime) != 2 {
+			t.Fatalf("expected two downtime intervals, got: %+v", availability.Downtime)
+		}
+
+		expected := []DowntimeInterval{
+			{Start: at(2), End: at(3), Reason: StopReasonRequested},
+			{Start: at(6), End: at(6.5), Reason: StopReasonCrashed},
+		}
+
+		for i, downtime := range availability.Downtime {
+			if downtime != expected[i] {
+				t.Errorf("expected downtime %+v, got: %+v", expected[i], downtime)
+			}
+		}
+
+		if availability.Uptime != time.Hour*17/2 || availability.UptimePercent != 85 {
+			t.Errorf("expected 8h30m (85%%) uptime, got: %s (%f%%)", availability.Uptime, availability.UptimePercent)
+		}
+	})
+
+	t.Run("Currently running", func(t *testing.T) {
+		// the period ends in the future, so the open run since 6.5h counts up until now
+		availability := computeAvailability(events, at(5), at(100), at(8))
+
+		if !availability.To.Equal(at(8)) {
+			t.Errorf("expected period to end now, got: %s", availability.To)
+		}
+
+		if len(availability.Downtime) != 1 || availability.Downtime[0].Open {
+			t.Fatalf("expected one closed downtime interval, got: %+v", availability.Downtime)
+		}
+
+		if availability.Uptime != time.Hour*5/2 {
+			t.Errorf("expected 2h30m uptime, got: %s", availability.Uptime)
+		}
+	})
+
+	t.Run("Currently stopped", func(t *testing.T) {
+		stopped := append(events, &ProcessEvent{Type: ProcessEventStopped, Time: at(9), Reason: StopReasonExited})
+
+		availability := computeAvailability(stopped, at(8), at(12), at(20))
+
+		if len(availability.Downtime) != 1 {
+			t.Fatalf("expected one downtime interval, got: %+v", availability.Downtime)
+		}
+
+		if downtime := availability.Downtime[0]; !downtime.Open || !downtime.Start.Equal(at(9)) || !downtime.End.Equal(at(12)) || downtime.Reason != StopReasonExited {
+			t.Errorf("expected open downtime from 9h to 12h, got: %+v", downtime)
+		}
+
+		if availability.UptimePercent != 25 {
+			t.Errorf("expected 25%% uptime, got: %f%%", availability.UptimePercent)
+		}
+	})
+
+	t.Run("No history", func(t *testing.T) {
+		availability := computeAvailability(nil, at(0), at(1), at(20))
+
+		if len(availability.Downtime) != 1 || !availability.Downtime[0].Open || availability.Downtime[0].Reason != "" {
+			t.Errorf("expected the whole period to be downtime with no known reason, got: %+v", availability.Downtime)
+		}
+
+		if availability.Uptime != 0 || availability.UptimePercent != 0 {
+			t.Errorf("expected no uptime, got: %s", availability.Uptime)
+		}
+	})
+}
+
+func TestStoreEventSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-store-event-sink")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+	sink := newStoreEventSink(store)
+
+	sink.Publish(ProcessEvent{Type: ProcessEventStarted, Time: time.Now().Add(-time.Minute)})
+	sink.Publish(ProcessEvent{Type: ProcessEventCrashed, Time: time.Now(), Reason: StopReasonCrashed})
+
+	deadline := time.Now().Add(time.Second * 5)
+
+	for {
+		events, err := store.ListProcessEvents()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) == 2 {
+			if events[0].Type != ProcessEventStarted || events[1].Type != ProcessEventCrashed || events[1].Reason != StopReasonCrashed {
+				t.Errorf("expected started and crashed events in order, got: %+v, %+v", events[0], events[1])
+			}
+
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected events to be persisted, got %d", len(events))
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
@@ -0,0 +1,145 @@
+package servermanager
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ServerBuild identifies the acServer executable which ran an event, so that results from different builds of
+// acServer (e.g. before and after a Steam update) can be told apart.
+type ServerBuild struct {
+	// Version is read from the banner acServer prints as it starts. It is empty if acServer didn't print one.
+	Version string
+
+	// Checksum is the SHA-256 of the acServer executable. It is empty if the executable couldn't be read, e.g.
+	// because acServer is run on another host.
+	Checksum string
+}
+
+// Changed reports whether b and other are known to be different builds of acServer.
+func (b ServerBuild) Changed(other ServerBuild) bool {
+	if b.Checksum != "" && other.Checksum != "" {
+		return b.Checksum != other.Checksum
+	}
+
+	return b.Version != "" && other.Version != "" && b.Version != other.Version
+}
+
+// ShortChecksum is the start of the checksum, which is enough to tell builds apart in the UI.
+func (b ServerBuild) ShortChecksum() string {
+	if len(b.Checksum) > 12 {
+		return b.Checksum[:12]
+	}
+
+	return b.Checksum
+}
+
+// serverVersionRegex matches the banner acServer prints as it starts, e.g. "Assetto Corsa Dedicated Server v1.16.3".
+var serverVersionRegex = regexp.MustCompile(`(?i)assetto corsa dedicated server\s+v?(\d+(?:\.\d+)+)`)
+
+func checksumFile(path string) (string, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// recordServerBuild checksums the acServer executable which is about to be started. It must be called with sp.mutex
+// held. The version is filled in from acServer's output once it has started.
+func (sp *AssettoServerProcess) recordServerBuild() {
+	sp.build = ServerBuild{}
+
+	checksum, err := checksumFile(sp.executablePath())
+
+	if err != nil {
+		logrus.WithError(err).Debug("Could not checksum the acServer executable")
+		return
+	}
+
+	sp.build.Checksum = checksum
+}
+
+func (sp *AssettoServerProcess) setServerVersion(line string) {
+	match := serverVersionRegex.FindStringSubmatch(line)
+
+	if len(match) < 2 {
+		return
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.build.Version = match[1]
+}
+
+// Build returns the build of acServer which is running, or which ran most recently.
+func (sp *AssettoServerProcess) Build() ServerBuild {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	return sp.build
+}
+
+// addServerBuildToResults adds the build of acServer to a results file it has just written, before the end of the
+// session is handled, so that the build is kept with the results wherever they are stored. The file is edited in
+// place so that fields Server Manager doesn't read are kept.
+func (sp *AssettoServerProcess) addServerBuildToResults(resultsFile string) {
+	build := sp.Build()
+
+	if build == (ServerBuild{}) {
+		return
+	}
+
+	path := filepath.Join(sp.installPath(), "results", filepath.Base(resultsFile))
+
+	if err := addServerBuildToResultsFile(path, build); err != nil {
+		logrus.WithError(err).Errorf("Could not add the acServer build to results file %s", resultsFile)
+	}
+}
+
+func addServerBuildToResultsFile(path string, build ServerBuild) error {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	var results map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &results); err != nil {
+		return err
+	}
+
+	results["ServerBuild"], err = json.Marshal(build)
+
+	if err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(results, "", "\t")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
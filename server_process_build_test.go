@@ -0,0 +1,101 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testServerBuildScript = `#!/bin/sh
+echo "Assetto Corsa Dedicated Server v1.16.3"
+exec sleep 600
+`
+
+func TestAssettoServerProcess_Build(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	startTestServerProcess(t, sp, testServerBuildScript)
+	defer sp.Stop()
+
+	checksum, err := checksumFile(sp.executablePath())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second * 2)
+
+	for sp.Build().Version == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if build := sp.Build(); build.Version != "1.16.3" || build.Checksum != checksum {
+		t.Fatalf("expected build v1.16.3 (%s), got v%s (%s)", checksum, build.Version, build.Checksum)
+	}
+
+	if err := os.MkdirAll(filepath.Join(ServerInstallPath, "results"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	resultsFile := filepath.Join(ServerInstallPath, "results", "2020_1_1_12_0_RACE.json")
+
+	if err := ioutil.WriteFile(resultsFile, []byte(`{"TrackName": "ks_vallelunga", "RaceLaps": 10}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sp.addServerBuildToResults("results/2020_1_1_12_0_RACE.json")
+
+	results, err := LoadResult("2020_1_1_12_0_RACE.json")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.ServerBuild == nil || *results.ServerBuild != sp.Build() {
+		t.Errorf("expected the build to be added to the results, got %v", results.ServerBuild)
+	}
+
+	data, err := ioutil.ReadFile(resultsFile)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `"RaceLaps": 10`) {
+		t.Errorf("expected fields Server Manager doesn't read to be kept, got %s", data)
+	}
+}
+
+func TestChampionship_ServerBuildChanges(t *testing.T) {
+	championship := NewChampionship("Test")
+
+	for _, build := range []*ServerBuild{
+		{Version: "1.16.2", Checksum: "a"},
+		nil,
+		{Version: "1.16.2", Checksum: "a"},
+		{Version: "1.16.3", Checksum: "b"},
+	} {
+		event := NewChampionshipEvent()
+		event.Sessions[SessionTypeRace] = &ChampionshipSession{Results: &SessionResults{ServerBuild: build}}
+
+		championship.Events = append(championship.Events, event)
+	}
+
+	changes := championship.ServerBuildChanges()
+
+	if len(changes) != 1 {
+		t.Fatalf("expected one change of build, got %d", len(changes))
+	}
+
+	if changes[0].Event != championship.Events[3] || changes[0].PreviousEvent != championship.Events[2] {
+		t.Error("expected the change to be between the last two events")
+	}
+
+	if !(ServerBuild{Version: "1.16.2"}).Changed(ServerBuild{Version: "1.16.3", Checksum: "b"}) {
+		t.Error("expected builds to be compared by version when a checksum is missing")
+	}
+}
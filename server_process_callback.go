@@ -0,0 +1,104 @@
+package servermanager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// callbackPanicThreshold is the number of panics within callbackPanicWindow which trips the callback breaker.
+	callbackPanicThreshold = 10
+	callbackPanicWindow    = time.Second * 30
+	// callbackBreakerCooldown is how long the UDP callback is disabled for once the breaker has tripped.
+	callbackBreakerCooldown = time.Minute
+)
+
+// callbackBreaker stops the UDP callback from being called after it has panicked repeatedly, so that a stream of
+// messages which the callback can't handle doesn't turn into a storm of recovered panics. After a cooldown the
+// callback is tried again.
+type callbackBreaker struct {
+	mutex sync.Mutex
+
+	panics    []time.Time
+	openUntil time.Time
+	lastPanic interface{}
+}
+
+// allow reports whether the callback should be called at the time now.
+func (cb *callbackBreaker) allow(now time.Time) bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	return !now.Before(cb.openUntil)
+}
+
+// recordPanic records a recovered panic, tripping the breaker if there have been too many recently.
+func (cb *callbackBreaker) recordPanic(now time.Time, recovered interface{}) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	cb.lastPanic = recovered
+
+	var recent []time.Time
+
+	for _, t := range cb.panics {
+		if now.Sub(t) < callbackPanicWindow {
+			recent = append(recent, t)
+		}
+	}
+
+	cb.panics = append(recent, now)
+
+	if len(cb.panics) >= callbackPanicThreshold {
+		cb.openUntil = now.Add(callbackBreakerCooldown)
+		cb.panics = nil
+
+		logrus.WithError(cb.errorLocked()).Errorf("UDP callback panicked %d times within %s. It is disabled for %s, UDP messages are still forwarded to plugins", callbackPanicThreshold, callbackPanicWindow, callbackBreakerCooldown)
+	}
+}
+
+// Err returns an error describing why the callback is disabled, or nil if it is not.
+func (cb *callbackBreaker) Err(now time.Time) error {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if !now.Before(cb.openUntil) {
+		return nil
+	}
+
+	return cb.errorLocked()
+}
+
+func (cb *callbackBreaker) errorLocked() error {
+	return fmt.Errorf("servermanager: UDP callback disabled until %s after repeated panics, last panic: %v", cb.openUntil.Format(time.RFC3339), cb.lastPanic)
+}
+
+// callUDPCallback calls the UDP callback with message unless the callback breaker is open.
+func (sp *AssettoServerProcess) callUDPCallback(message udp.Message) {
+	now := time.Now()
+
+	if !sp.callbackBreaker.allow(now) {
+		return
+	}
+
+	var recovered interface{}
+
+	panicCapture(func() {
+		defer func() {
+			// record the panic, then continue panicking so that panicCapture can report it as usual.
+			if recovered = recover(); recovered != nil {
+				panic(recovered)
+			}
+		}()
+
+		sp.callbackFunc(message)
+	})
+
+	if recovered != nil {
+		sp.callbackBreaker.recordPanic(now, recovered)
+	}
+}
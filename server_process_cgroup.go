@@ -0,0 +1,85 @@
+package servermanager
+
+import (
+	"errors"
+	"runtime"
+)
+
+// cpuQuotaPeriod is the cgroup cpu.max period in microseconds. A quota of cpuQuotaPeriod is one full CPU.
+const cpuQuotaPeriod = 100000
+
+var ErrResourceLimitsUnsupported = errors.New("servermanager: resource limits are only supported on linux and windows")
+
+// ResourceLimits cap the CPU and memory a process, and any processes it starts, can use. They are enforced with
+// cgroups on Linux and Job Objects on Windows. Zero means no limit.
+type ResourceLimits struct {
+	// CPUPercent is a percentage of one CPU, e.g. 50 is half of one CPU and 200 is two CPUs.
+	CPUPercent int `yaml:"cpu_percent"`
+
+	// MemoryMB is the most memory the process can use. Unlike the server's memory_limit, which restarts acServer,
+	// the operating system stops the process from using more than this, which usually means it is killed.
+	MemoryMB int `yaml:"memory_mb"`
+}
+
+func (l ResourceLimits) isZero() bool {
+	return clampCPUQuotaPercent(l.CPUPercent) == 0 && l.MemoryMB <= 0
+}
+
+// withDefaults fills in any limits which aren't set from defaults.
+func (l ResourceLimits) withDefaults(defaults ResourceLimits) ResourceLimits {
+	if l.CPUPercent <= 0 {
+		l.CPUPercent = defaults.CPUPercent
+	}
+
+	if l.MemoryMB <= 0 {
+		l.MemoryMB = defaults.MemoryMB
+	}
+
+	return l
+}
+
+// acServerResourceLimits are the resource_limits from config.yml, with the CPU quota from the server options in
+// place of cpu_percent if it is set.
+func acServerResourceLimits(serverOptions *GlobalServerConfig) ResourceLimits {
+	var limits ResourceLimits
+
+	if config != nil {
+		limits = config.Server.ResourceLimits
+	}
+
+	if serverOptions.CPUQuotaPercent > 0 {
+		limits.CPUPercent = serverOptions.CPUQuotaPercent
+	}
+
+	return limits
+}
+
+// pluginResourceLimits are the limits for a plugin process, which default to the plugin_resource_limits from
+// config.yml. plugin is nil for processes started from run_on_start.
+func pluginResourceLimits(plugin *CommandPlugin) ResourceLimits {
+	var limits, defaults ResourceLimits
+
+	if config != nil {
+		defaults = config.Server.PluginResourceLimits
+	}
+
+	if plugin != nil {
+		limits = plugin.ResourceLimits
+	}
+
+	return limits.withDefaults(defaults)
+}
+
+// clampCPUQuotaPercent limits a CPU quota (where 100 is one full CPU) to the CPUs available on this machine.
+// Values of zero or less mean no quota.
+func clampCPUQuotaPercent(percent int) int {
+	if percent <= 0 {
+		return 0
+	}
+
+	if max := 100 * runtime.NumCPU(); percent > max {
+		return max
+	}
+
+	return percent
+}
@@ -0,0 +1,76 @@
+// +build linux
+
+package servermanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+const cgroupParent = "assetto-server-manager"
+
+// processLimits is the cgroup a process was moved into to limit it.
+type processLimits struct {
+	cgroupDir string
+}
+
+// applyResourceLimits moves the process pid into its own cgroup, named after the process, with cpu.max set to
+// limits.CPUPercent of one CPU and memory.max set to limits.MemoryMB. The cgroup should be released once the process,
+// and anything it started, has exited.
+func applyResourceLimits(pid int, name string, limits ResourceLimits) (processLimits, error) {
+	percent := clampCPUQuotaPercent(limits.CPUPercent)
+
+	if percent == 0 && limits.MemoryMB <= 0 {
+		return processLimits{}, nil
+	}
+
+	parent := filepath.Join(cgroupRoot, cgroupParent)
+	dir := filepath.Join(parent, name+"-"+strconv.Itoa(pid))
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return processLimits{}, err
+	}
+
+	// controllers must be enabled for children of the parent group. on some systems they already are, and
+	// writing them again is an error, so only the writes to the process's own cgroup are treated as failures.
+	if percent > 0 {
+		_ = ioutil.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+cpu"), 0644)
+
+		cpuMax := fmt.Sprintf("%d %d", percent*cpuQuotaPeriod/100, cpuQuotaPeriod)
+
+		if err := ioutil.WriteFile(filepath.Join(dir, "cpu.max"), []byte(cpuMax), 0644); err != nil {
+			return processLimits{cgroupDir: dir}, err
+		}
+	}
+
+	if limits.MemoryMB > 0 {
+		_ = ioutil.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory"), 0644)
+
+		memoryMax := strconv.FormatInt(int64(limits.MemoryMB)*1024*1024, 10)
+
+		if err := ioutil.WriteFile(filepath.Join(dir, "memory.max"), []byte(memoryMax), 0644); err != nil {
+			return processLimits{cgroupDir: dir}, err
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		return processLimits{cgroupDir: dir}, err
+	}
+
+	return processLimits{cgroupDir: dir}, nil
+}
+
+// release removes the cgroup. It must have no processes left in it.
+func (l processLimits) release() error {
+	if l.cgroupDir == "" {
+		return nil
+	}
+
+	return os.Remove(l.cgroupDir)
+}
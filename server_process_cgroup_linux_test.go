@@ -0,0 +1,118 @@
+// +build linux
+
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestApplyResourceLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-cgroup")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldCgroupRoot := cgroupRoot
+	cgroupRoot = dir
+	defer func() {
+		cgroupRoot = oldCgroupRoot
+	}()
+
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return string(data)
+	}
+
+	t.Run("Writes cpu.max and cgroup.procs", func(t *testing.T) {
+		limits, err := applyResourceLimits(1234, "acServer", ResourceLimits{CPUPercent: 50})
+		cgroupDir := limits.cgroupDir
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cgroupDir != filepath.Join(dir, cgroupParent, "acServer-1234") {
+			t.Errorf("unexpected cgroup dir: %s", cgroupDir)
+		}
+
+		if cpuMax := readFile(filepath.Join(cgroupDir, "cpu.max")); cpuMax != "50000 100000" {
+			t.Errorf("expected cpu.max to be '50000 100000', got: %q", cpuMax)
+		}
+
+		if procs := readFile(filepath.Join(cgroupDir, "cgroup.procs")); procs != "1234" {
+			t.Errorf("expected cgroup.procs to contain pid, got: %q", procs)
+		}
+
+		if control := readFile(filepath.Join(dir, cgroupParent, "cgroup.subtree_control")); control != "+cpu" {
+			t.Errorf("expected cpu controller to be enabled, got: %q", control)
+		}
+	})
+
+	t.Run("Clamps to the available CPUs", func(t *testing.T) {
+		limits, err := applyResourceLimits(1235, "acServer", ResourceLimits{CPUPercent: 100000})
+		cgroupDir := limits.cgroupDir
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := strconv.Itoa(runtime.NumCPU()*cpuQuotaPeriod) + " 100000"
+
+		if cpuMax := readFile(filepath.Join(cgroupDir, "cpu.max")); cpuMax != expected {
+			t.Errorf("expected cpu.max to be %q, got: %q", expected, cpuMax)
+		}
+	})
+
+	t.Run("Writes memory.max", func(t *testing.T) {
+		limits, err := applyResourceLimits(1237, "plugin", ResourceLimits{MemoryMB: 512})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if limits.cgroupDir != filepath.Join(dir, cgroupParent, "plugin-1237") {
+			t.Errorf("unexpected cgroup dir: %s", limits.cgroupDir)
+		}
+
+		if memoryMax := readFile(filepath.Join(limits.cgroupDir, "memory.max")); memoryMax != "536870912" {
+			t.Errorf("expected memory.max to be 512MB, got: %q", memoryMax)
+		}
+
+		if _, err := os.Stat(filepath.Join(limits.cgroupDir, "cpu.max")); !os.IsNotExist(err) {
+			t.Errorf("expected no cpu quota to be set, got: %v", err)
+		}
+
+		if procs := readFile(filepath.Join(limits.cgroupDir, "cgroup.procs")); procs != "1237" {
+			t.Errorf("expected cgroup.procs to contain pid, got: %q", procs)
+		}
+	})
+
+	t.Run("No limits", func(t *testing.T) {
+		limits, err := applyResourceLimits(1236, "acServer", ResourceLimits{CPUPercent: -10})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if limits.cgroupDir != "" {
+			t.Errorf("expected no cgroup to be created, got: %s", limits.cgroupDir)
+		}
+
+		if err := limits.release(); err != nil {
+			t.Errorf("expected releasing no limits to do nothing, got: %v", err)
+		}
+	})
+}
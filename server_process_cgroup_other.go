@@ -0,0 +1,17 @@
+// +build !linux,!windows
+
+package servermanager
+
+type processLimits struct{}
+
+func applyResourceLimits(pid int, name string, limits ResourceLimits) (processLimits, error) {
+	if limits.isZero() {
+		return processLimits{}, nil
+	}
+
+	return processLimits{}, ErrResourceLimitsUnsupported
+}
+
+func (processLimits) release() error {
+	return nil
+}
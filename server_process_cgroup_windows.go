@@ -0,0 +1,137 @@
+// +build windows
+
+package servermanager
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	processSetQuota  = 0x0100
+	processTerminate = 0x0001
+
+	jobObjectExtendedLimitInformationClass  = 9
+	jobObjectCPURateControlInformationClass = 15
+
+	jobObjectLimitJobMemory = 0x00000200
+
+	jobObjectCPURateControlEnable  = 0x1
+	jobObjectCPURateControlHardCap = 0x4
+)
+
+var (
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32
+}
+
+// processLimits is the Job Object a process was assigned to to limit it.
+type processLimits struct {
+	job syscall.Handle
+}
+
+// applyResourceLimits assigns the process pid to a new Job Object which limits the CPU and memory it can use. Processes
+// which it starts are assigned to the same Job Object, and share its limits. The Job Object should be released once
+// the process has exited.
+func applyResourceLimits(pid int, name string, limits ResourceLimits) (processLimits, error) {
+	if limits.isZero() {
+		return processLimits{}, nil
+	}
+
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+
+	if job == 0 {
+		return processLimits{}, err
+	}
+
+	l := processLimits{job: syscall.Handle(job)}
+
+	if percent := clampCPUQuotaPercent(limits.CPUPercent); percent > 0 {
+		// the cpu rate is a share of every CPU on the machine, in hundredths of a percent.
+		rate := percent * 100 / runtime.NumCPU()
+
+		if rate < 1 {
+			rate = 1
+		}
+
+		info := jobObjectCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+			CPURate:      uint32(rate),
+		}
+
+		if ok, _, err := procSetInformationJobObject.Call(job, jobObjectCPURateControlInformationClass, uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info)); ok == 0 {
+			return l, err
+		}
+	}
+
+	if limits.MemoryMB > 0 {
+		info := jobObjectExtendedLimitInformation{
+			BasicLimitInformation: jobObjectBasicLimitInformation{LimitFlags: jobObjectLimitJobMemory},
+			JobMemoryLimit:        uintptr(limits.MemoryMB) * 1024 * 1024,
+		}
+
+		if ok, _, err := procSetInformationJobObject.Call(job, jobObjectExtendedLimitInformationClass, uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info)); ok == 0 {
+			return l, err
+		}
+	}
+
+	handle, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(pid))
+
+	if err != nil {
+		return l, err
+	}
+
+	defer syscall.CloseHandle(handle) //nolint:errcheck
+
+	if ok, _, err := procAssignProcessToJobObject.Call(job, uintptr(handle)); ok == 0 {
+		return l, err
+	}
+
+	return l, nil
+}
+
+// release closes the Job Object. Any processes still in it keep its limits until they exit.
+func (l processLimits) release() error {
+	if l.job == 0 {
+		return nil
+	}
+
+	return syscall.CloseHandle(l.job)
+}
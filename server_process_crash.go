@@ -0,0 +1,442 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// StopReason describes why the acServer process last stopped.
+type StopReason string
+
+const (
+	// StopReasonRequested means the process was stopped by a call to Stop.
+	StopReasonRequested StopReason = "requested"
+	// StopReasonExited means acServer exited cleanly of its own accord, e.g. at the end of a non-looping event.
+	StopReasonExited StopReason = "exited"
+	// StopReasonCrashed means acServer exited with an error without being asked to stop.
+	StopReasonCrashed StopReason = "crashed"
+	// StopReasonMemoryLimit means acServer was restarted because it went over config.Server.MemoryLimit.
+	StopReasonMemoryLimit StopReason = "memory-limit"
+	// StopReasonHung means acServer was restarted or stopped because it stopped sending UDP messages, see
+	// config.Server.HangDetection.
+	StopReasonHung StopReason = "hung"
+)
+
+// maxCrashReports is the number of most recent crash reports which are kept in memory.
+const maxCrashReports = 10
+
+// CrashReport describes an unexpected exit of the acServer process. Crash reports are kept in the store, without
+// their Bundle, so that they can be looked back on after Server Manager has been restarted.
+type CrashReport struct {
+	ID        uuid.UUID
+	Time      time.Time
+	Simulated bool
+	Error     string
+
+	// ExitCode is the exit code of acServer, or -1 if it was killed by a signal.
+	ExitCode int
+
+	// EventName is the name of the event which was running when acServer crashed, and RaceConfig its config.
+	EventName  string
+	RaceConfig *CurrentRaceConfig `json:",omitempty"`
+
+	// Plugins are the states of the plugins started with the event at the time of the crash.
+	Plugins []PluginHealthStatus `json:",omitempty"`
+
+	// LogTail is the end of the server log, with any secrets redacted.
+	LogTail string `json:",omitempty"`
+
+	// RestartAttempt is the number of consecutive crashes the restart counts as, and RestartDelay how long the restart
+	// waits before starting the event again. RestartAttempt is zero if the event was not restarted, in which case
+	// NotRestartedReason says why.
+	RestartAttempt     int           `json:",omitempty"`
+	RestartDelay       time.Duration `json:",omitempty"`
+	NotRestartedReason string        `json:",omitempty"`
+
+	// Bundle is a DiagnosticsBundle captured as the crash was detected, before the process state was cleared.
+	Bundle *DiagnosticsBundle `json:",omitempty"`
+}
+
+var (
+	ErrCrashSimulationDisabled = errors.New("servermanager: crash simulation is disabled, set allow_crash_simulation in config.yml to enable it")
+	ErrServerProcessNotRunning = errors.New("servermanager: server process is not running")
+)
+
+// CrashRestartPolicy controls how the event is restarted after acServer crashes, when restart_on_crash is set.
+// Each consecutive crash waits twice as long as the one before it to restart, so that a broken configuration can't
+// restart in a tight loop. Zero fields use the defaults.
+type CrashRestartPolicy struct {
+	// BaseDelay is how long to wait before restarting after the first crash.
+	BaseDelay time.Duration `yaml:"base_delay"`
+	// MaxDelay caps the wait between restarts.
+	MaxDelay time.Duration `yaml:"max_delay"`
+	// MaxAttempts is how many consecutive crashes are restarted before giving up. Negative means no limit.
+	MaxAttempts int `yaml:"max_attempts"`
+	// ResetAfter is how long acServer must run for before a crash is no longer counted as consecutive.
+	ResetAfter time.Duration `yaml:"reset_after"`
+}
+
+var defaultCrashRestartPolicy = CrashRestartPolicy{
+	BaseDelay:   time.Second * 2,
+	MaxDelay:    time.Minute * 2,
+	MaxAttempts: 5,
+	ResetAfter:  time.Minute * 10,
+}
+
+var ErrInvalidCrashRestartPolicy = errors.New("servermanager: the crash restart max delay must not be shorter than its base delay")
+
+// WithCrashRestartPolicy sets CrashRestartPolicy. Zero fields keep the default.
+func WithCrashRestartPolicy(policy CrashRestartPolicy) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		if policy.BaseDelay > 0 {
+			sp.CrashRestartPolicy.BaseDelay = policy.BaseDelay
+		}
+
+		if policy.MaxDelay > 0 {
+			sp.CrashRestartPolicy.MaxDelay = policy.MaxDelay
+		}
+
+		if policy.MaxAttempts != 0 {
+			sp.CrashRestartPolicy.MaxAttempts = policy.MaxAttempts
+		}
+
+		if policy.ResetAfter > 0 {
+			sp.CrashRestartPolicy.ResetAfter = policy.ResetAfter
+		}
+	}
+}
+
+// delay is how long to wait before the given consecutive restart attempt, starting from 1.
+func (p CrashRestartPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+// crashRestart holds what is needed to start the event which was running when acServer crashed.
+type crashRestart struct {
+	event                               RaceEvent
+	udpPluginAddress, forwardingAddress string
+	udpPluginLocalPort, forwardListen   int
+}
+
+// classifyStop determines why the acServer process ended, given the error returned from waiting on it. For crashes,
+// a CrashReport is built while the process state is still available.
+func (sp *AssettoServerProcess) classifyStop(runErr error) (StopReason, *CrashReport, *crashRestart) {
+	sp.mutex.Lock()
+
+	var reason StopReason
+
+	switch {
+	case sp.stopRequested && sp.memoryLimitExceeded:
+		reason = StopReasonMemoryLimit
+	case sp.stopRequested && sp.hangDetected:
+		reason = StopReasonHung
+	case sp.stopRequested:
+		reason = StopReasonRequested
+	case runErr != nil || sp.crashSimulated:
+		reason = StopReasonCrashed
+	default:
+		reason = StopReasonExited
+	}
+
+	sp.stopReason = reason
+	simulated := sp.crashSimulated
+	ranFor := time.Since(sp.startedAt)
+
+	sp.lastExit = newExitInfo(sp.cmd, runErr, reason, sp.stopRequested || simulated, ranFor)
+	plugins := sp.pluginHealth()
+
+	if reason != StopReasonCrashed || ranFor >= sp.CrashRestartPolicy.ResetAfter {
+		sp.crashRestartAttempts = 0
+	}
+
+	restart := &crashRestart{
+		event:              sp.raceEvent,
+		udpPluginAddress:   sp.udpPluginAddress,
+		udpPluginLocalPort: sp.udpPluginLocalPort,
+		forwardingAddress:  sp.forwardingAddress,
+		forwardListen:      sp.forwardListenPort,
+	}
+	sp.mutex.Unlock()
+
+	if reason != StopReasonCrashed {
+		return reason, nil, nil
+	}
+
+	report := &CrashReport{
+		ID:        uuid.New(),
+		Time:      time.Now(),
+		Simulated: simulated,
+		Plugins:   plugins,
+	}
+
+	if restart.event != nil {
+		raceConfig := restart.event.GetRaceConfig()
+
+		report.EventName = restart.event.EventName()
+		report.RaceConfig = &raceConfig
+	}
+
+	if runErr != nil {
+		report.Error = runErr.Error()
+
+		var exitErr *exec.ExitError
+
+		if errors.As(runErr, &exitErr) {
+			report.ExitCode = exitErr.ExitCode()
+		}
+	}
+
+	bundle, err := sp.DiagnosticsBundle()
+
+	if err != nil {
+		logrus.WithError(err).Error("Could not build diagnostics bundle for crash report")
+	} else {
+		report.Bundle = bundle
+		report.LogTail = bundle.ServerLog
+	}
+
+	return reason, report, restart
+}
+
+// onCrash records the crash report, notifies anyone waiting on crashes and, if configured, restarts the event.
+// It must be called after onStop.
+func (sp *AssettoServerProcess) onCrash(report *CrashReport, restart *crashRestart) {
+	logrus.Errorf("acServer crashed (simulated: %t): %s", report.Simulated, report.Error)
+
+	attempt, delay := sp.planCrashRestart(report, restart)
+
+	stored := *report
+	stored.Bundle = nil
+
+	if err := sp.store.AddCrashReport(&stored); err != nil {
+		logrus.WithError(err).Error("Could not save crash report")
+	}
+
+	sp.mutex.Lock()
+	sp.crashReports = append(sp.crashReports, report)
+
+	if len(sp.crashReports) > maxCrashReports {
+		sp.crashReports = sp.crashReports[len(sp.crashReports)-maxCrashReports:]
+	}
+
+	for _, crashCh := range sp.notifyCrashChs {
+		select {
+		case crashCh <- report:
+		default:
+		}
+	}
+	sp.mutex.Unlock()
+
+	if attempt == 0 {
+		if report.NotRestartedReason != "" {
+			logrus.Warnf("Not restarting event after acServer crash, %s", report.NotRestartedReason)
+		}
+
+		return
+	}
+
+	cancel := make(chan struct{})
+
+	sp.crashRestartMutex.Lock()
+	sp.crashRestartCancel = cancel
+	sp.crashRestartMutex.Unlock()
+
+	// onCrash is called from the process loop, which Start needs to be free to receive on.
+	go func() {
+		logrus.Infof("Restarting event after acServer crash in %s (attempt %d): %s", delay, attempt, describeRaceEvent(restart.event))
+
+		select {
+		case <-cancel:
+			logrus.Infof("Restart after acServer crash cancelled")
+			return
+		case <-time.After(delay):
+		}
+
+		sp.crashRestartMutex.Lock()
+
+		if sp.crashRestartCancel != cancel {
+			sp.crashRestartMutex.Unlock()
+			return
+		}
+
+		sp.crashRestartCancel = nil
+		sp.crashRestartMutex.Unlock()
+
+		if err := sp.Start(restart.event, restart.udpPluginAddress, restart.udpPluginLocalPort, restart.forwardingAddress, restart.forwardListen); err != nil {
+			logrus.WithError(err).Error("Could not restart event after acServer crash")
+		}
+	}()
+}
+
+// planCrashRestart decides whether the event is restarted after a crash, and records the decision in report. The
+// attempt is zero if the event is not restarted.
+func (sp *AssettoServerProcess) planCrashRestart(report *CrashReport, restart *crashRestart) (attempt int, delay time.Duration) {
+	if !sp.IsFeatureEnabled(FeatureAutoRestart) || !config.Server.RestartOnCrash || restart.event == nil {
+		return 0, 0
+	}
+
+	if !sp.exitCodeRestartPolicy().shouldRestart(report.ExitCode) {
+		report.NotRestartedReason = fmt.Sprintf("exit code %d is excluded by the auto restart exit codes in the server options", report.ExitCode)
+		return 0, 0
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	policy := sp.CrashRestartPolicy
+	attempt = sp.crashRestartAttempts + 1
+
+	if policy.MaxAttempts >= 0 && attempt > policy.MaxAttempts {
+		report.NotRestartedReason = fmt.Sprintf("it has crashed %d times in a row", attempt)
+		return 0, 0
+	}
+
+	sp.crashRestartAttempts = attempt
+
+	report.RestartAttempt = attempt
+	report.RestartDelay = policy.delay(attempt)
+
+	return attempt, report.RestartDelay
+}
+
+// cancelCrashRestart stops a restart which is waiting to happen after a crash, so that an event the user has
+// stopped or replaced isn't brought back. It doesn't use sp.mutex, which is held while an event is starting.
+func (sp *AssettoServerProcess) cancelCrashRestart() {
+	sp.crashRestartMutex.Lock()
+	defer sp.crashRestartMutex.Unlock()
+
+	if sp.crashRestartCancel != nil {
+		close(sp.crashRestartCancel)
+		sp.crashRestartCancel = nil
+	}
+}
+
+// exitCodeRestartPolicy decides which acServer exit codes restart the event after a crash. Codes in deny never
+// restart the event. If allow is not empty, only codes in it restart the event, otherwise any exit code does.
+type exitCodeRestartPolicy struct {
+	allow, deny map[int]bool
+}
+
+// parseExitCodeRestartPolicy parses a comma separated list of exit codes, e.g. "1, 2, !78". Codes prefixed with
+// '!' are denied, all others are allowed.
+func parseExitCodeRestartPolicy(list string) (*exitCodeRestartPolicy, error) {
+	policy := &exitCodeRestartPolicy{
+		allow: make(map[int]bool),
+		deny:  make(map[int]bool),
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+
+		if entry == "" {
+			continue
+		}
+
+		codes := policy.allow
+
+		if strings.HasPrefix(entry, "!") {
+			codes = policy.deny
+			entry = strings.TrimSpace(strings.TrimPrefix(entry, "!"))
+		}
+
+		code, err := strconv.Atoi(entry)
+
+		if err != nil {
+			return nil, fmt.Errorf("servermanager: invalid exit code %q", entry)
+		}
+
+		codes[code] = true
+	}
+
+	return policy, nil
+}
+
+func (p *exitCodeRestartPolicy) shouldRestart(exitCode int) bool {
+	if p.deny[exitCode] {
+		return false
+	}
+
+	if len(p.allow) > 0 {
+		return p.allow[exitCode]
+	}
+
+	return true
+}
+
+// exitCodeRestartPolicy loads the auto restart exit codes from the server options. If they can't be loaded, the
+// event is restarted for any exit code.
+func (sp *AssettoServerProcess) exitCodeRestartPolicy() *exitCodeRestartPolicy {
+	defaultPolicy, _ := parseExitCodeRestartPolicy("")
+
+	serverOptions, err := sp.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Error("Could not load server options for auto restart exit codes")
+		return defaultPolicy
+	}
+
+	policy, err := parseExitCodeRestartPolicy(serverOptions.AutoRestartExitCodes)
+
+	if err != nil {
+		logrus.WithError(err).Error("Invalid auto restart exit codes in server options")
+		return defaultPolicy
+	}
+
+	return policy
+}
+
+// NotifyCrash registers ch to receive a CrashReport each time acServer crashes. Sends to ch do not block, so it
+// should be buffered.
+func (sp *AssettoServerProcess) NotifyCrash(ch chan *CrashReport) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	sp.notifyCrashChs = append(sp.notifyCrashChs, ch)
+}
+
+// CrashReports returns the most recent crash reports, oldest first.
+func (sp *AssettoServerProcess) CrashReports() []*CrashReport {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	return append([]*CrashReport(nil), sp.crashReports...)
+}
+
+// SimulateCrash kills acServer without going through Stop, so that it is handled exactly as a real crash would be.
+// This lets operators check their crash alerting and restart setup. It only works if allow_crash_simulation is set
+// in config.yml.
+func (sp *AssettoServerProcess) SimulateCrash() error {
+	if !config.Server.AllowCrashSimulation {
+		return ErrCrashSimulationDisabled
+	}
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.raceEvent == nil || sp.cmd == nil || sp.cmd.Process == nil {
+		return ErrServerProcessNotRunning
+	}
+
+	logrus.Warnf("Simulating acServer crash, killing process: %d", sp.cmd.Process.Pid)
+
+	sp.crashSimulated = true
+
+	return kill(getProcess(sp.cmd))
+}
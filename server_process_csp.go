@@ -0,0 +1,94 @@
+package servermanager
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+var ErrCSPNotSupported = errors.New("servermanager: the client in that car hasn't said that it supports CSP extended messages")
+
+// cspClients tracks which cars have a Custom Shaders Patch client which supports extended messages, by the CSP
+// version each sent in its handshake.
+type cspClients struct {
+	versions map[udp.CarID]uint32
+	mutex    sync.Mutex
+}
+
+func (c *cspClients) handle(message udp.Message) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch m := message.(type) {
+	case udp.Version:
+		// acServer has (re)started, so none of the clients are connected any more.
+		c.versions = nil
+	case udp.SessionCarInfo:
+		if m.Event() == udp.EventNewConnection || m.Event() == udp.EventConnectionClosed {
+			delete(c.versions, m.CarID)
+		}
+	case udp.CSPClientMessage:
+		if handshake, ok := m.Message.(udp.CSPHandshake); ok {
+			if c.versions == nil {
+				c.versions = make(map[udp.CarID]uint32)
+			}
+
+			c.versions[m.CarID] = handshake.Version
+		}
+	}
+}
+
+func (c *cspClients) version(carID udp.CarID) (uint32, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	version, ok := c.versions[carID]
+
+	return version, ok
+}
+
+// carIDs returns the cars which have a CSP client.
+func (c *cspClients) carIDs() []udp.CarID {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	carIDs := make([]udp.CarID, 0, len(c.versions))
+
+	for carID := range c.versions {
+		carIDs = append(carIDs, carID)
+	}
+
+	sort.Slice(carIDs, func(i, j int) bool {
+		return carIDs[i] < carIDs[j]
+	})
+
+	return carIDs
+}
+
+// CSPClients returns the cars which are running a version of CSP which supports extended messages.
+func (sp *AssettoServerProcess) CSPClients() []udp.CarID {
+	return sp.cspClients.carIDs()
+}
+
+// CSPVersion returns the version of CSP the client in carID is running, if it supports extended messages.
+func (sp *AssettoServerProcess) CSPVersion(carID udp.CarID) (uint32, bool) {
+	return sp.cspClients.version(carID)
+}
+
+// SendCSPMessage sends an extended message to the client in carID. Clients without CSP would show extended messages
+// as garbled chat, so ErrCSPNotSupported is returned unless the client has sent a CSP handshake.
+func (sp *AssettoServerProcess) SendCSPMessage(carID udp.CarID, message udp.CSPMessage) error {
+	if _, ok := sp.CSPVersion(carID); !ok {
+		return ErrCSPNotSupported
+	}
+
+	sendChat, err := udp.NewCSPSendChat(carID, message)
+
+	if err != nil {
+		return err
+	}
+
+	return sp.SendUDPMessage(sendChat)
+}
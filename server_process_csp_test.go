@@ -0,0 +1,44 @@
+package servermanager
+
+import (
+	"testing"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestCSPClients(t *testing.T) {
+	var clients cspClients
+
+	clients.handle(udp.CSPClientMessage{CarID: 3, Type: udp.CSPMessageHandshake, Message: udp.CSPHandshake{Version: 1937}})
+	clients.handle(udp.CSPClientMessage{CarID: 5, Type: udp.CSPMessageHandshake, Message: udp.CSPHandshake{Version: 2100}})
+	clients.handle(udp.CSPClientMessage{CarID: 6, Type: udp.CSPMessageWeather, Message: udp.CSPWeather{}})
+
+	if version, ok := clients.version(3); !ok || version != 1937 {
+		t.Errorf("expected car 3 to support CSP 1937, got %d (%t)", version, ok)
+	}
+
+	if _, ok := clients.version(6); ok {
+		t.Error("expected car 6 not to support CSP without a handshake")
+	}
+
+	clients.handle(udp.SessionCarInfo{CarID: 3, EventType: udp.EventConnectionClosed})
+
+	if _, ok := clients.version(3); ok {
+		t.Error("expected car 3 to be forgotten once its client disconnected")
+	}
+
+	clients.handle(udp.Version(4))
+
+	if _, ok := clients.version(5); ok {
+		t.Error("expected every client to be forgotten once acServer restarted")
+	}
+}
+
+func TestAssettoServerProcess_SendCSPMessage(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	if err := sp.SendCSPMessage(2, udp.CSPExtendedChat{Message: "hi"}); err != ErrCSPNotSupported {
+		t.Errorf("expected ErrCSPNotSupported, got %v", err)
+	}
+}
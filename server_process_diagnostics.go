@@ -0,0 +1,367 @@
+package servermanager
+
+import (
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// diagnosticsLogLines is the number of most recent log lines included in a DiagnosticsBundle.
+const diagnosticsLogLines = 500
+
+const redactedValue = "[redacted]"
+
+// ProcessStatus is a point in time description of the acServer process.
+type ProcessStatus struct {
+	Running bool
+	PID     int
+
+	EventName        string
+	EventDescription string
+	StartedAt        time.Time
+
+	// EventTimeRemaining is how long the event has left before it is stopped by the maximum event duration.
+	// It is zero if no maximum event duration is configured.
+	EventTimeRemaining time.Duration
+
+	UDPPluginAddress   string
+	UDPPluginLocalPort int
+	ForwardingAddress  string
+	ForwardListenPort  int
+	ForwardingEnabled  bool
+	ForwardingTargets  []udp.ForwardTargetStatus
+
+	// NumUDPObservers is the number of observers attached with AddObserver, which have dropped
+	// UDPObserverDroppedMessages messages between them.
+	NumUDPObservers            int
+	UDPObserverDroppedMessages uint64
+
+	// UDPLink describes how well messages from acServer are being received and handled. It is nil while there is no
+	// UDP connection to acServer.
+	UDPLink *udp.LinkStats
+
+	// HostMetrics is the most recent sample of the host's health, if host metrics are enabled.
+	HostMetrics *HostMetrics
+
+	Features map[Feature]bool
+
+	NumPlugins int
+	Plugins    []PluginHealthStatus
+
+	// Command is the most recent command used to launch acServer
+	Command        LaunchCommand
+	PluginCommands []LaunchCommand
+
+	StartupError string
+
+	// StartQueue are the starts waiting for the start in progress to finish.
+	StartQueue []QueuedStart
+
+	// UDPCallbackError is set while the UDP callback is disabled after repeated panics.
+	UDPCallbackError string
+
+	// LastStopReason is why the acServer process most recently stopped. It is empty if it has not stopped yet.
+	LastStopReason StopReason
+	LastExit       *ExitInfo
+	Crashes        int
+
+	// Build is the build of acServer which is running, or which ran most recently.
+	Build ServerBuild
+}
+
+func (sp *AssettoServerProcess) Status() ProcessStatus {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	status := ProcessStatus{
+		Running:            sp.raceEvent != nil,
+		UDPPluginAddress:   sp.udpPluginAddress,
+		UDPPluginLocalPort: sp.udpPluginLocalPort,
+		ForwardingAddress:  sp.forwardingAddress,
+		ForwardListenPort:  sp.forwardListenPort,
+		ForwardingEnabled:  !sp.forwardingDisabled,
+		NumPlugins:         len(sp.extraProcesses),
+		Command:            sp.launchCommand,
+		LastStopReason:     sp.stopReason,
+		Crashes:            len(sp.crashReports),
+		Plugins:            sp.pluginHealth(),
+		HostMetrics:        sp.hostMetrics.Latest(),
+		Features:           sp.features.All(),
+		LastExit:           sp.lastExit,
+		Build:              sp.build,
+	}
+
+	status.ForwardingTargets = sp.forwardingTargetStatuses()
+	status.StartQueue = sp.startQueue.Queued()
+	status.NumUDPObservers, status.UDPObserverDroppedMessages = sp.observerStats()
+
+	if sp.udpServerConn != nil {
+		link := sp.udpServerConn.Stats()
+		status.UDPLink = &link
+	}
+
+	for _, plugin := range sp.extraProcesses {
+		status.PluginCommands = append(status.PluginCommands, plugin.launch)
+	}
+
+	if sp.startupErr != nil {
+		status.StartupError = sp.startupErr.Error()
+	}
+
+	if err := sp.callbackBreaker.Err(time.Now()); err != nil {
+		status.UDPCallbackError = err.Error()
+	}
+
+	if sp.raceEvent != nil {
+		status.EventName = sp.raceEvent.EventName()
+		status.EventDescription = describeRaceEvent(sp.raceEvent)
+		status.StartedAt = sp.startedAt
+
+		if config.Server.MaxEventDuration > 0 {
+			status.EventTimeRemaining = sp.eventTimeRemaining(time.Now())
+
+			if status.EventTimeRemaining < 0 {
+				status.EventTimeRemaining = 0
+			}
+		}
+
+		if sp.cmd != nil && sp.cmd.Process != nil {
+			status.PID = sp.cmd.Process.Pid
+		}
+	}
+
+	return status
+}
+
+// DiagnosticsBundle is a single export of everything needed to triage a problem with the server process.
+// All known secrets are redacted from it.
+type DiagnosticsBundle struct {
+	GeneratedAt time.Time
+	Version     string
+	OS          string
+	GoVersion   string
+
+	Status        ProcessStatus
+	Config        *Configuration
+	ServerOptions *GlobalServerConfig
+
+	ServerLog  string
+	PluginsLog string
+	ManagerLog string
+
+	// Crashes are the most recent crash reports, without their own diagnostics bundles.
+	Crashes []*CrashReport
+}
+
+func (sp *AssettoServerProcess) DiagnosticsBundle() (*DiagnosticsBundle, error) {
+	serverOptions, err := sp.store.LoadServerOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	redactor := newSecretRedactor(config, serverOptions)
+
+	status := sp.Status()
+	status.Command = redactLaunchCommand(status.Command, redactor)
+
+	for i, command := range status.PluginCommands {
+		status.PluginCommands[i] = redactLaunchCommand(command, redactor)
+	}
+
+	var crashes []*CrashReport
+
+	for _, crash := range sp.CrashReports() {
+		crashes = append(crashes, &CrashReport{
+			Time:      crash.Time,
+			Simulated: crash.Simulated,
+			Error:     redactor.Replace(crash.Error),
+		})
+	}
+
+	return &DiagnosticsBundle{
+		GeneratedAt: time.Now(),
+		Version:     BuildVersion,
+		OS:          runtime.GOOS + "/" + runtime.GOARCH,
+		GoVersion:   runtime.Version(),
+
+		Status:        status,
+		Config:        redactConfiguration(config),
+		ServerOptions: redactServerOptions(serverOptions),
+
+		ServerLog:  redactor.Replace(lastLines(sp.Logs(), diagnosticsLogLines)),
+		PluginsLog: redactor.Replace(lastLines(pluginsOutput.String(), diagnosticsLogLines)),
+		ManagerLog: redactor.Replace(lastLines(logOutput.String(), diagnosticsLogLines)),
+
+		Crashes: crashes,
+	}, nil
+}
+
+// newSecretRedactor builds a replacer which removes any secret values found in config or serverOptions from free text.
+func newSecretRedactor(config *Configuration, serverOptions *GlobalServerConfig) *strings.Replacer {
+	var secrets []string
+
+	if config != nil {
+		secrets = append(secrets,
+			config.Steam.Password,
+			config.HTTP.SessionKey,
+			config.Accounts.AdminPasswordOverride,
+			config.Championships.RecaptchaConfig.SecretKey,
+			config.Server.Telemetry.InfluxDB.Password,
+			config.Server.Telemetry.InfluxDB.Token,
+		)
+
+		for _, value := range config.Server.ResultsUpload.Headers {
+			secrets = append(secrets, value)
+		}
+
+		secrets = append(secrets, config.Server.UDPStream.Tokens...)
+
+		_, natsUserinfo := stripURLUserinfo(config.Server.LifecycleEvents.NATSURL)
+		secrets = append(secrets, natsUserinfo...)
+	}
+
+	if serverOptions != nil {
+		secrets = append(secrets,
+			serverOptions.Password,
+			serverOptions.AdminPassword,
+			serverOptions.ACSRAPIKey,
+			serverOptions.DiscordAPIToken,
+		)
+	}
+
+	var oldNew []string
+
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		oldNew = append(oldNew, secret, redactedValue)
+	}
+
+	return strings.NewReplacer(oldNew...)
+}
+
+func redactConfiguration(c *Configuration) *Configuration {
+	if c == nil {
+		return nil
+	}
+
+	redacted := *c
+
+	redacted.Steam.Password = redactString(redacted.Steam.Password)
+	redacted.HTTP.SessionKey = redactString(redacted.HTTP.SessionKey)
+	redacted.Accounts.AdminPasswordOverride = redactString(redacted.Accounts.AdminPasswordOverride)
+	redacted.Championships.RecaptchaConfig.SecretKey = redactString(redacted.Championships.RecaptchaConfig.SecretKey)
+	redacted.Server.Telemetry.InfluxDB.Password = redactString(redacted.Server.Telemetry.InfluxDB.Password)
+	redacted.Server.Telemetry.InfluxDB.Token = redactString(redacted.Server.Telemetry.InfluxDB.Token)
+
+	// results upload headers are usually Authorization or API key headers.
+	if c.Server.ResultsUpload.Headers != nil {
+		redacted.Server.ResultsUpload.Headers = make(map[string]string, len(c.Server.ResultsUpload.Headers))
+
+		for name, value := range c.Server.ResultsUpload.Headers {
+			redacted.Server.ResultsUpload.Headers[name] = redactString(value)
+		}
+	}
+
+	redacted.Server.LifecycleEvents.NATSURL, _ = stripURLUserinfo(c.Server.LifecycleEvents.NATSURL)
+	redacted.Server.UDPStream.Tokens = nil
+
+	for _, token := range c.Server.UDPStream.Tokens {
+		redacted.Server.UDPStream.Tokens = append(redacted.Server.UDPStream.Tokens, redactString(token))
+	}
+
+	// plugin environment variables are often used for API tokens, so only their names are kept.
+	redacted.Server.Plugins = nil
+
+	for _, plugin := range c.Server.Plugins {
+		redactedPlugin := *plugin
+		redactedPlugin.Env = nil
+
+		for _, variable := range plugin.Env {
+			redactedPlugin.Env = append(redactedPlugin.Env, strings.SplitN(variable, "=", 2)[0]+"="+redactedValue)
+		}
+
+		redacted.Server.Plugins = append(redacted.Server.Plugins, &redactedPlugin)
+	}
+
+	return &redacted
+}
+
+func redactServerOptions(so *GlobalServerConfig) *GlobalServerConfig {
+	if so == nil {
+		return nil
+	}
+
+	redacted := *so
+
+	redacted.Password = redactString(redacted.Password)
+	redacted.AdminPassword = redactString(redacted.AdminPassword)
+	redacted.ACSRAPIKey = redactString(redacted.ACSRAPIKey)
+	redacted.DiscordAPIToken = redactString(redacted.DiscordAPIToken)
+
+	return &redacted
+}
+
+func redactLaunchCommand(lc LaunchCommand, redactor *strings.Replacer) LaunchCommand {
+	redacted := LaunchCommand{
+		Dir: redactor.Replace(lc.Dir),
+	}
+
+	for _, arg := range lc.Args {
+		redacted.Args = append(redacted.Args, redactor.Replace(arg))
+	}
+
+	return redacted
+}
+
+// stripURLUserinfo removes the user:pass@ of each URL in a comma separated list of URLs, such as NATS server URLs,
+// which may leave out the scheme. It also returns the userinfo it removed.
+func stripURLUserinfo(urls string) (string, []string) {
+	var userinfo []string
+
+	parts := strings.Split(urls, ",")
+
+	for i, part := range parts {
+		scheme, rest := "", part
+
+		if j := strings.Index(part, "://"); j >= 0 {
+			scheme, rest = part[:j+3], part[j+3:]
+		}
+
+		host := rest
+
+		if j := strings.IndexAny(rest, "/?#"); j >= 0 {
+			host = rest[:j]
+		}
+
+		if at := strings.LastIndex(host, "@"); at >= 0 {
+			userinfo = append(userinfo, host[:at])
+			parts[i] = scheme + rest[at+1:]
+		}
+	}
+
+	return strings.Join(parts, ","), userinfo
+}
+
+func redactString(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return redactedValue
+}
+
+func lastLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+
+	if len(lines) <= n {
+		return s
+	}
+
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
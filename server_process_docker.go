@@ -0,0 +1,233 @@
+package servermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DockerConfig runs acServer in a Docker container rather than directly on the host. The install path (which holds
+// the server config, content and results) is mounted into the container, so Server Manager manages it in the same way
+// as it does for a server run on the host.
+type DockerConfig struct {
+	// Image is the image acServer is run from. It must contain the libraries the linux acServer needs. Docker is
+	// only used if Image is set.
+	Image string `yaml:"image"`
+
+	// Binary is the docker CLI, "docker" if empty.
+	Binary string `yaml:"binary"`
+
+	// Network is the network the container is attached to, "host" if empty. On any other network the game ports
+	// and the UDP plugin local port are published, and udp_plugin_address in the Server Options must be an
+	// address of the host which the container can reach.
+	Network string `yaml:"network"`
+
+	// InstallPath is where the install path is mounted in the container, "/assetto" if empty.
+	InstallPath string `yaml:"install_path"`
+
+	// Executable is the path to acServer in the container. If it is empty, the executable_path from the steam
+	// config is used, relative to InstallPath.
+	Executable string `yaml:"executable"`
+
+	// Mounts are extra volumes to mount, as given to docker run -v, e.g. "/srv/content:/content:ro".
+	Mounts []string `yaml:"mounts"`
+
+	// Ports are extra ports to publish, as given to docker run -p.
+	Ports []string `yaml:"ports"`
+
+	// ExtraArgs are passed to docker run before the image.
+	ExtraArgs []string `yaml:"extra_args"`
+}
+
+var ErrDockerImageRequired = errors.New("servermanager: docker config must set an image")
+
+const (
+	defaultDockerBinary      = "docker"
+	defaultDockerNetwork     = "host"
+	defaultDockerInstallPath = "/assetto"
+)
+
+func (d DockerConfig) withDefaults() DockerConfig {
+	if d.Binary == "" {
+		d.Binary = defaultDockerBinary
+	}
+
+	if d.Network == "" {
+		d.Network = defaultDockerNetwork
+	}
+
+	if d.InstallPath == "" {
+		d.InstallPath = defaultDockerInstallPath
+	}
+
+	return d
+}
+
+// serverLauncher runs acServer for a server process some way other than running its executable on the host.
+type serverLauncher interface {
+	// command builds the command which runs acServer. It is called with sp.mutex held.
+	command(ctx context.Context, sp *AssettoServerProcess, serverOptions *GlobalServerConfig) (*exec.Cmd, error)
+
+	// cleanup is called once the command has exited.
+	cleanup()
+}
+
+// WithDocker runs acServer in a Docker container, see DockerConfig.
+func WithDocker(conf DockerConfig) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.launcher = &dockerLauncher{config: conf.withDefaults()}
+	}
+}
+
+type dockerLauncher struct {
+	config DockerConfig
+
+	// containerName is the container which was last started.
+	containerName string
+}
+
+// containerNameFor names the container after the install path, so that each pooled server runs in its own container.
+func (d *dockerLauncher) containerNameFor(sp *AssettoServerProcess) string {
+	name := strings.Trim(logFileNameRegex.ReplaceAllString(strings.ToLower(filepath.Base(sp.installPath())), "-"), "-")
+
+	if name == "" {
+		name = "server"
+	}
+
+	return "assetto-server-manager-" + name
+}
+
+// executable is the path to acServer inside the container.
+func (d *dockerLauncher) executable(sp *AssettoServerProcess) string {
+	if d.config.Executable != "" {
+		return d.config.Executable
+	}
+
+	rel, err := filepath.Rel(sp.installPath(), sp.executablePath())
+
+	if err != nil || strings.HasPrefix(rel, "..") {
+		// acServer isn't in the install path, so it can only be found if the image has it in the same place.
+		return filepath.ToSlash(sp.executablePath())
+	}
+
+	return path.Join(d.config.InstallPath, filepath.ToSlash(rel))
+}
+
+// args are the arguments given to docker to run acServer.
+func (d *dockerLauncher) args(sp *AssettoServerProcess, serverOptions *GlobalServerConfig) ([]string, error) {
+	if d.config.Image == "" {
+		return nil, ErrDockerImageRequired
+	}
+
+	installPath, err := filepath.Abs(sp.installPath())
+
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"run", "--rm", "--init",
+		"--name", d.containerNameFor(sp),
+		"--network", d.config.Network,
+		"-v", installPath + ":" + d.config.InstallPath,
+		"-w", d.config.InstallPath,
+	}
+
+	for _, mount := range d.config.Mounts {
+		args = append(args, "-v", mount)
+	}
+
+	if d.config.Network != "host" {
+		ports, err := readGamePorts(sp.installPath())
+
+		if err != nil {
+			return nil, fmt.Errorf("servermanager: could not read the game ports to publish: %s", err)
+		}
+
+		publish := []string{
+			strconv.Itoa(ports.TCP) + ":" + strconv.Itoa(ports.TCP) + "/tcp",
+			strconv.Itoa(ports.UDP) + ":" + strconv.Itoa(ports.UDP) + "/udp",
+			strconv.Itoa(ports.HTTP) + ":" + strconv.Itoa(ports.HTTP) + "/tcp",
+		}
+
+		if sp.udpPluginLocalPort > 0 {
+			publish = append(publish, strconv.Itoa(sp.udpPluginLocalPort)+":"+strconv.Itoa(sp.udpPluginLocalPort)+"/udp")
+		}
+
+		for _, port := range append(publish, d.config.Ports...) {
+			args = append(args, "-p", port)
+		}
+	} else {
+		for _, port := range d.config.Ports {
+			args = append(args, "-p", port)
+		}
+	}
+
+	// resource limits and cpu affinity would only apply to the docker CLI, so docker applies them to the container.
+	limits := acServerResourceLimits(serverOptions)
+
+	if percent := clampCPUQuotaPercent(limits.CPUPercent); percent > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(float64(percent)/100, 'f', 2, 64))
+	}
+
+	if limits.MemoryMB > 0 {
+		args = append(args, "--memory", strconv.Itoa(limits.MemoryMB)+"m")
+	}
+
+	if config != nil && len(config.Server.CPUAffinity) > 0 {
+		cpus := make([]string, len(config.Server.CPUAffinity))
+
+		for i, cpu := range config.Server.CPUAffinity {
+			cpus[i] = strconv.Itoa(cpu)
+		}
+
+		args = append(args, "--cpuset-cpus", strings.Join(cpus, ","))
+	}
+
+	args = append(args, d.config.ExtraArgs...)
+	args = append(args, d.config.Image, d.executable(sp))
+
+	return args, nil
+}
+
+func (d *dockerLauncher) command(ctx context.Context, sp *AssettoServerProcess, serverOptions *GlobalServerConfig) (*exec.Cmd, error) {
+	args, err := d.args(sp, serverOptions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.containerName = d.containerNameFor(sp)
+
+	// a container left behind by a Server Manager which was killed would stop this one from starting.
+	d.removeContainer()
+
+	logrus.Debugf("Running acServer in docker: %s %s", d.config.Binary, strings.Join(args, " "))
+
+	cmd := buildCommand(ctx, d.config.Binary, args...)
+	cmd.Dir = sp.installPath()
+
+	return cmd, nil
+}
+
+// cleanup removes the container in case acServer was killed, which only kills the docker CLI.
+func (d *dockerLauncher) cleanup() {
+	d.removeContainer()
+}
+
+func (d *dockerLauncher) removeContainer() {
+	if d.containerName == "" {
+		return
+	}
+
+	if out, err := exec.Command(d.config.Binary, "rm", "-f", d.containerName).CombinedOutput(); err != nil {
+		logrus.WithError(err).Debugf("Could not remove container %s: %s", d.containerName, strings.TrimSpace(string(out)))
+	}
+}
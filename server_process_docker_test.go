@@ -0,0 +1,129 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDockerLauncher_Args(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	config.Steam.ExecutablePath = "acServer"
+	config.Server.CPUAffinity = []int{2, 3}
+	sp.udpPluginLocalPort = 11000
+
+	if err := os.MkdirAll(filepath.Join(ServerInstallPath, ServerConfigPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(ServerInstallPath, ServerConfigPath, serverConfigIniPath), []byte("[SERVER]\nTCP_PORT=9600\nUDP_PORT=9600\nHTTP_PORT=8081\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	launcher := &dockerLauncher{config: DockerConfig{
+		Image:   "acserver:test",
+		Network: "bridge",
+		Mounts:  []string{"/srv/content:/assetto/content:ro"},
+	}.withDefaults()}
+
+	args, err := launcher.args(sp, &GlobalServerConfig{CPUQuotaPercent: 50})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joined := strings.Join(args, " ")
+
+	for _, expected := range []string{
+		"run --rm --init --name assetto-server-manager-assetto --network bridge",
+		"-v " + ServerInstallPath + ":/assetto -w /assetto",
+		"-v /srv/content:/assetto/content:ro",
+		"-p 9600:9600/tcp -p 9600:9600/udp -p 8081:8081/tcp -p 11000:11000/udp",
+		"--cpus 0.50",
+		"--cpuset-cpus 2,3",
+	} {
+		if !strings.Contains(joined, expected) {
+			t.Errorf("expected docker args to contain %q, got %q", expected, joined)
+		}
+	}
+
+	if !strings.HasSuffix(joined, "acserver:test /assetto/acServer") {
+		t.Errorf("expected acServer to be run from the mounted install path, got %q", joined)
+	}
+
+	launcher.config.Network = "host"
+
+	args, err = launcher.args(sp, &GlobalServerConfig{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if joined := strings.Join(args, " "); strings.Contains(joined, "-p ") {
+		t.Errorf("expected no ports to be published on the host network, got %q", joined)
+	}
+
+	if _, err := (&dockerLauncher{}).args(sp, &GlobalServerConfig{}); err != ErrDockerImageRequired {
+		t.Errorf("expected a docker config without an image to be rejected, got %v", err)
+	}
+}
+
+func TestAssettoServerProcess_Docker(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	calls := filepath.Join(ServerInstallPath, "docker-calls")
+	docker := filepath.Join(ServerInstallPath, "docker-test.sh")
+
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\nif [ \"$1\" = run ]; then\necho 'Assetto Corsa Dedicated Server (docker)'\nexec sleep 600\nfi\n"
+
+	if err := ioutil.WriteFile(docker, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	WithDocker(DockerConfig{Image: "acserver:test", Binary: docker})(sp)
+
+	startTestServerProcess(t, sp, testServerScript)
+
+	if !sp.IsRunning() {
+		t.Fatal("expected acServer to be running in docker")
+	}
+
+	// acServer isn't waited on to be ready, so wait for the container to be run before stopping it.
+	for deadline := time.Now().Add(time.Second * 2); time.Now().Before(deadline); time.Sleep(time.Millisecond * 10) {
+		if out, _ := ioutil.ReadFile(calls); strings.Contains(string(out), "run ") {
+			break
+		}
+	}
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	out, err := ioutil.ReadFile(calls)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+
+	if len(lines) != 3 {
+		t.Fatalf("expected docker to be called to remove, run and remove the container, got %q", lines)
+	}
+
+	if !strings.HasPrefix(lines[1], "run --rm --init --name assetto-server-manager-assetto") || !strings.HasSuffix(lines[1], "acserver:test /assetto/acServer-test.sh") {
+		t.Errorf("expected acServer to be run in a container, got %q", lines[1])
+	}
+
+	if lines[2] != "rm -f assetto-server-manager-assetto" {
+		t.Errorf("expected the container to be removed once acServer stopped, got %q", lines[2])
+	}
+}
@@ -0,0 +1,119 @@
+package servermanager
+
+import (
+	"context"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+// maxEventDurationCheckInterval is how often a running event is checked against config.Server.MaxEventDuration.
+var maxEventDurationCheckInterval = time.Second * 10
+
+// raceFinish tracks just enough of the current session (from UDP messages) to know whether a race is finishing.
+type raceFinish struct {
+	isRace       bool
+	laps         uint16
+	time         time.Duration
+	sessionStart time.Time
+	leaderLaps   uint16
+	ended        bool
+}
+
+// inProgress reports whether the current session is a race which has reached its final lap (or for timed races,
+// its final time) but has not yet ended.
+func (rf raceFinish) inProgress(now time.Time) bool {
+	if !rf.isRace || rf.ended {
+		return false
+	}
+
+	if rf.laps > 0 {
+		return rf.leaderLaps+1 >= rf.laps
+	}
+
+	return rf.time > 0 && now.Sub(rf.sessionStart) >= rf.time
+}
+
+func (sp *AssettoServerProcess) trackRaceFinish(message udp.Message) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	switch m := message.(type) {
+	case udp.SessionInfo:
+		leaderLaps := sp.raceFinish.leaderLaps
+
+		if m.Event() == udp.EventNewSession {
+			leaderLaps = 0
+		}
+
+		sp.raceFinish = raceFinish{
+			isRace:       m.Type == udp.SessionTypeRace,
+			laps:         m.Laps,
+			time:         time.Duration(m.Time) * time.Minute,
+			sessionStart: time.Now().Add(-time.Duration(m.ElapsedMilliseconds) * time.Millisecond),
+			leaderLaps:   leaderLaps,
+		}
+	case udp.LapCompleted:
+		for _, car := range m.Cars {
+			if car.Laps > sp.raceFinish.leaderLaps {
+				sp.raceFinish.leaderLaps = car.Laps
+			}
+		}
+	case udp.EndSession:
+		sp.raceFinish.ended = true
+	}
+}
+
+// eventTimeRemaining returns how long the running event has left before it reaches config.Server.MaxEventDuration.
+// sp.mutex must be held by the caller.
+func (sp *AssettoServerProcess) eventTimeRemaining(now time.Time) time.Duration {
+	return sp.startedAt.Add(config.Server.MaxEventDuration).Sub(now)
+}
+
+// enforceMaxEventDuration stops the event which started at startedAt once it has run for longer than
+// config.Server.MaxEventDuration. Stopping is deferred while a race finish is in progress.
+func (sp *AssettoServerProcess) enforceMaxEventDuration(ctx context.Context, startedAt time.Time) {
+	ticker := time.NewTicker(maxEventDurationCheckInterval)
+	defer ticker.Stop()
+
+	deferred := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sp.mutex.Lock()
+			sameEvent := sp.raceEvent != nil && sp.startedAt.Equal(startedAt)
+			remaining := sp.eventTimeRemaining(now)
+			finishing := sp.raceFinish.inProgress(now)
+			sp.mutex.Unlock()
+
+			if !sameEvent {
+				return
+			}
+
+			if remaining > 0 {
+				continue
+			}
+
+			if finishing {
+				if !deferred {
+					logrus.Infof("Event has reached its maximum duration of %s, waiting for the race to finish before stopping", config.Server.MaxEventDuration)
+					deferred = true
+				}
+
+				continue
+			}
+
+			logrus.Infof("Event has reached its maximum duration of %s, stopping server process", config.Server.MaxEventDuration)
+
+			if err := sp.Stop(); err != nil {
+				logrus.WithError(err).Error("Could not stop server process after maximum event duration")
+			}
+
+			return
+		}
+	}
+}
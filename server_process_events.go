@@ -0,0 +1,160 @@
+package servermanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProcessEventType is the kind of lifecycle event a ProcessEvent describes.
+type ProcessEventType string
+
+const (
+	ProcessEventStarting     ProcessEventType = "starting"
+	ProcessEventStarted      ProcessEventType = "started"
+	ProcessEventStopping     ProcessEventType = "stopping"
+	ProcessEventStopped      ProcessEventType = "stopped"
+	ProcessEventCrashed      ProcessEventType = "crashed"
+	ProcessEventPluginExited ProcessEventType = "plugin-exited"
+
+	// ProcessEventStartFailed is emitted when an event fails to start before acServer is launched. If acServer was
+	// launched, ProcessEventStopped is emitted instead once it has been stopped.
+	ProcessEventStartFailed   ProcessEventType = "start-failed"
+	ProcessEventPluginStarted ProcessEventType = "plugin-started"
+
+	// ProcessEventResultsReady is emitted when acServer ends a session and has written its results file.
+	ProcessEventResultsReady        ProcessEventType = "results-ready"
+	ProcessEventResultsUploaded     ProcessEventType = "results-uploaded"
+	ProcessEventResultsUploadFailed ProcessEventType = "results-upload-failed"
+
+	// ProcessEventUDPFailed is emitted when the UDP connection to acServer stops working, and ProcessEventUDPReconnected
+	// once it has been opened again.
+	ProcessEventUDPFailed      ProcessEventType = "udp-failed"
+	ProcessEventUDPReconnected ProcessEventType = "udp-reconnected"
+
+	// ProcessEventMemoryLimitExceeded is emitted when acServer goes over its memory limit, just before it is restarted.
+	ProcessEventMemoryLimitExceeded ProcessEventType = "memory-limit-exceeded"
+
+	// ProcessEventHangDetected is emitted when acServer stops sending UDP messages, before the hang detection action
+	// is carried out.
+	ProcessEventHangDetected ProcessEventType = "hang-detected"
+
+	// ProcessEventMaintenance is emitted when a maintenance window starts, and ProcessEventMaintenanceSkipped when
+	// one is skipped because a race session is live.
+	ProcessEventMaintenance        ProcessEventType = "maintenance"
+	ProcessEventMaintenanceSkipped ProcessEventType = "maintenance-skipped"
+)
+
+// ProcessEvent is a change in the lifecycle of the acServer process or one of its plugins.
+type ProcessEvent struct {
+	Type ProcessEventType
+	Time time.Time
+
+	EventName   string     `json:",omitempty"`
+	Plugin      string     `json:",omitempty"`
+	Reason      StopReason `json:",omitempty"`
+	Error       string     `json:",omitempty"`
+	ResultsFile string     `json:",omitempty"`
+
+	// RaceEvent is the event which was running, or starting. It isn't stored or published outside of Server Manager.
+	RaceEvent RaceEvent `json:"-"`
+}
+
+// ProcessEventSink receives the lifecycle events of the server process. Publish is called from the process loop,
+// so it must not block.
+type ProcessEventSink interface {
+	Publish(event ProcessEvent)
+}
+
+// AddEventSink registers sink to receive all future lifecycle events.
+func (sp *AssettoServerProcess) AddEventSink(sink ProcessEventSink) {
+	sp.eventSinksMutex.Lock()
+	defer sp.eventSinksMutex.Unlock()
+
+	sp.eventSinks = append(sp.eventSinks, sink)
+}
+
+// emit sends a lifecycle event to each event sink. It does not use sp.mutex, so it is safe to call with it held.
+func (sp *AssettoServerProcess) emit(event ProcessEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	sp.eventSinksMutex.Lock()
+	sinks := sp.eventSinks
+	sp.eventSinksMutex.Unlock()
+
+	for _, sink := range sinks {
+		sink.Publish(event)
+	}
+}
+
+// processEventSubscriberBufferSize is how many events a Subscribe channel can fall behind by before events are
+// dropped for it.
+const processEventSubscriberBufferSize = 64
+
+// channelEventSink is the ProcessEventSink behind Subscribe. Publish never blocks and is safe to call after the
+// channel has been closed.
+type channelEventSink struct {
+	mutex   sync.Mutex
+	ch      chan ProcessEvent
+	closed  bool
+	dropped int
+}
+
+func (s *channelEventSink) Publish(event ProcessEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.ch <- event:
+	default:
+		s.dropped++
+		logrus.Warnf("Process event subscriber is not keeping up, dropped %s event (%d dropped so far)", event.Type, s.dropped)
+	}
+}
+
+func (s *channelEventSink) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Subscribe returns a channel which receives every future lifecycle event. Events are never queued beyond the
+// channel's buffer, a subscriber which falls too far behind misses events rather than holding up the server process.
+// The returned func unsubscribes and closes the channel, it is safe to call more than once.
+func (sp *AssettoServerProcess) Subscribe() (<-chan ProcessEvent, func()) {
+	sink := &channelEventSink{ch: make(chan ProcessEvent, processEventSubscriberBufferSize)}
+
+	sp.AddEventSink(sink)
+
+	return sink.ch, func() {
+		sp.removeEventSink(sink)
+		sink.close()
+	}
+}
+
+func (sp *AssettoServerProcess) removeEventSink(sink ProcessEventSink) {
+	sp.eventSinksMutex.Lock()
+	defer sp.eventSinksMutex.Unlock()
+
+	// emit ranges over the slice outside of the lock, so it is replaced rather than modified.
+	sinks := make([]ProcessEventSink, 0, len(sp.eventSinks))
+
+	for _, s := range sp.eventSinks {
+		if s != sink {
+			sinks = append(sinks, s)
+		}
+	}
+
+	sp.eventSinks = sinks
+}
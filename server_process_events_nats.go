@@ -0,0 +1,231 @@
+package servermanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	natsDefaultPort     = "4222"
+	natsEventBufferSize = 100
+	natsDialTimeout     = time.Second * 5
+	natsMaxBackoff      = time.Second * 30
+)
+
+// NATSEventSink publishes lifecycle events as JSON to a subject on a NATS server. Events are queued and published
+// from a background goroutine, which reconnects to the server whenever the connection is lost. If the queue is
+// full (e.g. the server is unreachable for a long time), new events are dropped.
+type NATSEventSink struct {
+	url     *url.URL
+	subject string
+
+	events chan ProcessEvent
+	done   chan struct{}
+
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+}
+
+func NewNATSEventSink(rawURL, subject string) (*NATSEventSink, error) {
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("servermanager: unsupported lifecycle events url scheme: %s", u.Scheme)
+	}
+
+	if subject == "" {
+		return nil, errors.New("servermanager: lifecycle events subject must not be empty")
+	}
+
+	sink := &NATSEventSink{
+		url:     u,
+		subject: subject,
+		events:  make(chan ProcessEvent, natsEventBufferSize),
+		done:    make(chan struct{}),
+	}
+
+	go sink.run()
+
+	return sink, nil
+}
+
+func (s *NATSEventSink) Publish(event ProcessEvent) {
+	select {
+	case s.events <- event:
+	default:
+		logrus.Warnf("Lifecycle event queue is full, dropping %s event", event.Type)
+	}
+}
+
+// Close stops publishing events and disconnects from the NATS server.
+func (s *NATSEventSink) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+func (s *NATSEventSink) run() {
+	var conn net.Conn
+	backoff := time.Second
+
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		var event ProcessEvent
+
+		select {
+		case <-s.done:
+			return
+		case event = <-s.events:
+		}
+
+		payload, err := json.Marshal(event)
+
+		if err != nil {
+			logrus.WithError(err).Error("Could not encode lifecycle event")
+			continue
+		}
+
+		// keep trying to publish the event until it has been sent, reconnecting as necessary.
+		for {
+			if conn == nil {
+				conn, err = s.connect()
+
+				if err != nil {
+					logrus.WithError(err).Warnf("Could not connect to NATS server for lifecycle events, retrying in %s", backoff)
+
+					select {
+					case <-s.done:
+						return
+					case <-time.After(backoff):
+					}
+
+					backoff *= 2
+
+					if backoff > natsMaxBackoff {
+						backoff = natsMaxBackoff
+					}
+
+					continue
+				}
+
+				backoff = time.Second
+			}
+
+			if err := s.write(conn, fmt.Sprintf("PUB %s %d\r\n%s\r\n", s.subject, len(payload), payload)); err != nil {
+				logrus.WithError(err).Warn("Lost connection to NATS server for lifecycle events, reconnecting")
+				_ = conn.Close()
+				conn = nil
+				continue
+			}
+
+			break
+		}
+	}
+}
+
+func (s *NATSEventSink) connect() (net.Conn, error) {
+	host := s.url.Host
+
+	if s.url.Port() == "" {
+		host = net.JoinHostPort(s.url.Hostname(), natsDefaultPort)
+	}
+
+	conn, err := net.DialTimeout("tcp", host, natsDialTimeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	_ = conn.SetReadDeadline(time.Now().Add(natsDialTimeout))
+	info, err := reader.ReadString('\n')
+
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	if !strings.HasPrefix(info, "INFO") {
+		_ = conn.Close()
+		return nil, fmt.Errorf("servermanager: unexpected greeting from NATS server: %s", strings.TrimSpace(info))
+	}
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "assetto-server-manager",
+	}
+
+	if s.url.User != nil {
+		options["user"] = s.url.User.Username()
+		options["pass"], _ = s.url.User.Password()
+	}
+
+	connectOptions, err := json.Marshal(options)
+
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if err := s.write(conn, fmt.Sprintf("CONNECT %s\r\n", connectOptions)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	go s.read(conn, reader)
+
+	return conn, nil
+}
+
+// read answers the NATS server's keepalive pings and logs any errors it sends, until the connection is closed.
+func (s *NATSEventSink) read(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			_ = conn.Close()
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			if err := s.write(conn, "PONG\r\n"); err != nil {
+				_ = conn.Close()
+				return
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			logrus.Errorf("NATS server returned an error for lifecycle events: %s", strings.TrimSpace(line))
+		}
+	}
+}
+
+func (s *NATSEventSink) write(conn net.Conn, message string) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	_, err := conn.Write([]byte(message))
+
+	return err
+}
@@ -0,0 +1,156 @@
+package servermanager
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type natsStubMessage struct {
+	subject string
+	payload []byte
+}
+
+// natsStubServer is a minimal in-memory stand-in for a NATS server, which records published messages.
+type natsStubServer struct {
+	listener net.Listener
+	messages chan natsStubMessage
+	conns    chan net.Conn
+}
+
+func newNATSStubServer(t *testing.T) *natsStubServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := &natsStubServer{
+		listener: listener,
+		messages: make(chan natsStubMessage, 10),
+		conns:    make(chan net.Conn, 10),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			stub.conns <- conn
+
+			go stub.serve(conn)
+		}
+	}()
+
+	return stub
+}
+
+func (s *natsStubServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("INFO {}\r\n")); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			return
+		}
+
+		if !strings.HasPrefix(line, "PUB ") {
+			continue
+		}
+
+		var subject string
+		var size int
+
+		if _, err := fmt.Sscanf(line, "PUB %s %d\r\n", &subject, &size); err != nil {
+			return
+		}
+
+		payload := make([]byte, size+2)
+
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return
+		}
+
+		s.messages <- natsStubMessage{subject: subject, payload: payload[:size]}
+	}
+}
+
+func (s *natsStubServer) URL() string {
+	return "nats://" + s.listener.Addr().String()
+}
+
+func (s *natsStubServer) receive(t *testing.T) ProcessEvent {
+	select {
+	case message := <-s.messages:
+		if message.subject != "test.lifecycle" {
+			t.Errorf("expected message on subject test.lifecycle, got: %s", message.subject)
+		}
+
+		var event ProcessEvent
+
+		if err := json.Unmarshal(message.payload, &event); err != nil {
+			t.Fatal(err)
+		}
+
+		return event
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected event to be published")
+	}
+
+	return ProcessEvent{}
+}
+
+func TestNATSEventSink(t *testing.T) {
+	stub := newNATSStubServer(t)
+	defer stub.listener.Close()
+
+	sink, err := NewNATSEventSink(stub.URL(), "test.lifecycle")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sink.Close()
+
+	sink.Publish(ProcessEvent{Type: ProcessEventStarted, EventName: "Test Race"})
+
+	if event := stub.receive(t); event.Type != ProcessEventStarted || event.EventName != "Test Race" {
+		t.Errorf("unexpected event published: %+v", event)
+	}
+
+	// drop the connection from the server side, the sink should reconnect and carry on publishing.
+	conn := <-stub.conns
+	_ = conn.Close()
+	time.Sleep(time.Millisecond * 100)
+
+	sink.Publish(ProcessEvent{Type: ProcessEventStopped, Reason: StopReasonRequested})
+
+	if event := stub.receive(t); event.Type != ProcessEventStopped || event.Reason != StopReasonRequested {
+		t.Errorf("unexpected event published after reconnect: %+v", event)
+	}
+}
+
+func TestNewNATSEventSink_InvalidConfig(t *testing.T) {
+	if _, err := NewNATSEventSink("amqp://localhost", "test.lifecycle"); err == nil {
+		t.Error("expected unsupported scheme to return an error")
+	}
+
+	if _, err := NewNATSEventSink("nats://localhost", ""); err == nil {
+		t.Error("expected empty subject to return an error")
+	}
+}
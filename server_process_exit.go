@@ -0,0 +1,57 @@
+package servermanager
+
+import (
+	"os/exec"
+	"time"
+)
+
+// ExitInfo describes how the acServer process most recently ended.
+type ExitInfo struct {
+	Time   time.Time
+	Reason StopReason
+
+	// ExitCode is the exit code of acServer, or -1 if it was ended by a signal.
+	ExitCode int
+
+	// Killed is true if Server Manager ended acServer, either because it was asked to stop or to simulate a crash.
+	Killed bool
+
+	// RanFor is how long acServer was running for.
+	RanFor time.Duration
+
+	// Error is the error returned from waiting on acServer, if there was one.
+	Error string
+}
+
+// newExitInfo builds the ExitInfo for cmd, which has just finished with runErr.
+func newExitInfo(cmd *exec.Cmd, runErr error, reason StopReason, killed bool, ranFor time.Duration) *ExitInfo {
+	info := &ExitInfo{
+		Time:     time.Now(),
+		Reason:   reason,
+		ExitCode: -1,
+		Killed:   killed,
+		RanFor:   ranFor,
+	}
+
+	if cmd != nil && cmd.ProcessState != nil {
+		info.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if runErr != nil {
+		info.Error = runErr.Error()
+	}
+
+	return info
+}
+
+// LastExit returns how the acServer process most recently ended. The bool is false if it has never run.
+func (sp *AssettoServerProcess) LastExit() (ExitInfo, bool) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.lastExit == nil {
+		return ExitInfo{}, false
+	}
+
+	return *sp.lastExit, true
+}
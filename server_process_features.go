@@ -0,0 +1,192 @@
+package servermanager
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+// Feature is an optional subsystem of the server process which can be turned off for an instance. Features still
+// need their own configuration to do anything, e.g. auto-restart also needs restart_on_crash, so every feature is
+// enabled unless it has been switched off.
+type Feature string
+
+const (
+	// FeatureAutoRestart restarts the event after acServer crashes.
+	FeatureAutoRestart Feature = "auto-restart"
+	// FeatureMaxEventDuration stops events which run for longer than max_event_duration.
+	FeatureMaxEventDuration Feature = "max-event-duration"
+	// FeatureHostMetrics samples the load, memory and disk IO of the host.
+	FeatureHostMetrics Feature = "host-metrics"
+	// FeatureUDPObservers sends UDP messages from acServer to the observers added with AddObserver.
+	FeatureUDPObservers Feature = "udp-observers"
+	// FeatureForwardingTargets forwards UDP messages to the additional forwarding targets.
+	FeatureForwardingTargets Feature = "forwarding-targets"
+	// FeatureEventHistory records lifecycle events in the store, for the availability report.
+	FeatureEventHistory Feature = "event-history"
+	// FeatureResultsUpload uploads the results of each session to the results_upload url.
+	FeatureResultsUpload Feature = "results-upload"
+)
+
+var allFeatures = []Feature{
+	FeatureAutoRestart,
+	FeatureMaxEventDuration,
+	FeatureHostMetrics,
+	FeatureUDPObservers,
+	FeatureForwardingTargets,
+	FeatureEventHistory,
+	FeatureResultsUpload,
+}
+
+func isKnownFeature(feature Feature) bool {
+	for _, f := range allFeatures {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateFeatures checks that each feature configured in config.yml exists.
+func validateFeatures(features map[Feature]bool) error {
+	for feature := range features {
+		if !isKnownFeature(feature) {
+			return fmt.Errorf("servermanager: unknown feature: %s", feature)
+		}
+	}
+
+	return nil
+}
+
+// FeatureFlags records which features are enabled for a server process.
+type FeatureFlags struct {
+	mutex   sync.RWMutex
+	enabled map[Feature]bool
+}
+
+// NewFeatureFlags enables every feature, apart from those switched off in overrides.
+func NewFeatureFlags(overrides map[Feature]bool) *FeatureFlags {
+	flags := &FeatureFlags{
+		enabled: make(map[Feature]bool),
+	}
+
+	for _, feature := range allFeatures {
+		enabled, ok := overrides[feature]
+
+		flags.enabled[feature] = !ok || enabled
+	}
+
+	return flags
+}
+
+func (f *FeatureFlags) IsEnabled(feature Feature) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.enabled[feature]
+}
+
+func (f *FeatureFlags) set(feature Feature, enabled bool) (changed bool, err error) {
+	if !isKnownFeature(feature) {
+		return false, fmt.Errorf("servermanager: unknown feature: %s", feature)
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	changed = f.enabled[feature] != enabled
+	f.enabled[feature] = enabled
+
+	return changed, nil
+}
+
+// All returns whether each feature is enabled.
+func (f *FeatureFlags) All() map[Feature]bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	all := make(map[Feature]bool, len(f.enabled))
+
+	for feature, enabled := range f.enabled {
+		all[feature] = enabled
+	}
+
+	return all
+}
+
+// IsFeatureEnabled reports whether feature is enabled for this server process.
+func (sp *AssettoServerProcess) IsFeatureEnabled(feature Feature) bool {
+	return sp.features.IsEnabled(feature)
+}
+
+// SetFeature enables or disables a feature while Server Manager is running. Features with a background goroutine
+// start or stop it straight away, max-event-duration takes effect from the next event.
+func (sp *AssettoServerProcess) SetFeature(feature Feature, enabled bool) error {
+	changed, err := sp.features.set(feature, enabled)
+
+	if err != nil || !changed {
+		return err
+	}
+
+	logrus.Infof("Feature %s enabled: %t", feature, enabled)
+
+	switch feature {
+	case FeatureHostMetrics:
+		if enabled {
+			sp.hostMetrics.start()
+		} else {
+			sp.hostMetrics.stop()
+		}
+	case FeatureForwardingTargets:
+		sp.mutex.Lock()
+		defer sp.mutex.Unlock()
+
+		return sp.applyForwardingTargets()
+	}
+
+	return nil
+}
+
+// applyForwardingTargets opens the forwarding targets on the UDP listener, or closes them all if forwarding targets
+// are disabled. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) applyForwardingTargets() error {
+	if sp.udpServerConn == nil {
+		return nil
+	}
+
+	var targets []udp.ForwardTarget
+
+	if sp.IsFeatureEnabled(FeatureForwardingTargets) {
+		targets = append(targets, sp.forwardingTargets...)
+	}
+
+	// the plugins Server Manager runs can't do without their targets, so they aren't behind the feature flag.
+	targets = append(targets, sp.pluginForwardingTargets...)
+
+	if err := sp.udpServerConn.SetForwardingTargets(targets); err != nil && err != udp.ErrConnectionClosed {
+		return err
+	}
+
+	return nil
+}
+
+// featureEventSink passes events on to sink only while feature is enabled.
+type featureEventSink struct {
+	feature Feature
+	flags   *FeatureFlags
+	sink    ProcessEventSink
+}
+
+func (s *featureEventSink) Publish(event ProcessEvent) {
+	if s.flags.IsEnabled(s.feature) {
+		s.sink.Publish(event)
+	}
+}
+
+// AddFeatureEventSink registers sink to receive lifecycle events while feature is enabled.
+func (sp *AssettoServerProcess) AddFeatureEventSink(feature Feature, sink ProcessEventSink) {
+	sp.AddEventSink(&featureEventSink{feature: feature, flags: sp.features, sink: sink})
+}
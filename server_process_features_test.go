@@ -0,0 +1,181 @@
+package servermanager
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+type countingHostMetricsReader struct {
+	reads int32
+}
+
+func (r *countingHostMetricsReader) Read() HostMetrics {
+	atomic.AddInt32(&r.reads, 1)
+
+	return HostMetrics{Time: time.Now()}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	flags := NewFeatureFlags(map[Feature]bool{FeatureHostMetrics: false, FeatureAutoRestart: true})
+
+	for _, feature := range allFeatures {
+		if enabled := flags.IsEnabled(feature); enabled != (feature != FeatureHostMetrics) {
+			t.Errorf("expected %s enabled to be %t, got %t", feature, feature != FeatureHostMetrics, enabled)
+		}
+	}
+
+	if _, err := flags.set("not-a-feature", true); err == nil {
+		t.Error("expected an error setting an unknown feature")
+	}
+
+	if err := validateFeatures(map[Feature]bool{"not-a-feature": false}); err == nil {
+		t.Error("expected an error validating an unknown feature")
+	}
+}
+
+func TestAssettoServerProcess_SetFeature(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	t.Run("Host metrics", func(t *testing.T) {
+		config.Server.HostMetricsInterval = time.Millisecond * 10
+		config.Server.Features = map[Feature]bool{FeatureHostMetrics: false}
+
+		defer func() {
+			config.Server.HostMetricsInterval = 0
+			config.Server.Features = nil
+		}()
+
+		sp, err := NewAssettoServerProcess(func(udp.Message) {}, sp.store, nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the sampler hasn't been started, so it is safe to swap its reader
+		reader := &countingHostMetricsReader{}
+		sp.hostMetrics.reader = reader
+
+		time.Sleep(time.Millisecond * 50)
+
+		if reads := atomic.LoadInt32(&reader.reads); reads != 0 {
+			t.Fatalf("expected no host metrics to be sampled while the feature is disabled, got %d reads", reads)
+		}
+
+		if err := sp.SetFeature(FeatureHostMetrics, true); err != nil {
+			t.Fatal(err)
+		}
+
+		deadline := time.Now().Add(time.Second * 5)
+
+		for atomic.LoadInt32(&reader.reads) < 2 {
+			if time.Now().After(deadline) {
+				t.Fatal("expected host metrics to be sampled once the feature is enabled")
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if err := sp.SetFeature(FeatureHostMetrics, false); err != nil {
+			t.Fatal(err)
+		}
+
+		// allow a sample which was in progress to finish
+		time.Sleep(time.Millisecond * 20)
+		reads := atomic.LoadInt32(&reader.reads)
+		time.Sleep(time.Millisecond * 50)
+
+		if after := atomic.LoadInt32(&reader.reads); after != reads {
+			t.Errorf("expected sampling to stop once the feature is disabled, got %d more reads", after-reads)
+		}
+
+		if !sp.Status().Features[FeatureAutoRestart] || sp.Status().Features[FeatureHostMetrics] {
+			t.Errorf("expected status to report features, got: %v", sp.Status().Features)
+		}
+	})
+
+	t.Run("UDP observers", func(t *testing.T) {
+		observer := make(chan udp.Message, 1)
+		sp.AddObserver(observer)
+		defer sp.RemoveObserver(observer)
+
+		if err := sp.SetFeature(FeatureUDPObservers, false); err != nil {
+			t.Fatal(err)
+		}
+
+		sp.UDPCallback(udp.Version(4))
+
+		if len(observer) != 0 {
+			t.Error("expected observers not to receive messages while the feature is disabled")
+		}
+
+		if err := sp.SetFeature(FeatureUDPObservers, true); err != nil {
+			t.Fatal(err)
+		}
+
+		sp.UDPCallback(udp.Version(4))
+
+		if len(observer) != 1 {
+			t.Error("expected observers to receive messages once the feature is enabled")
+		}
+	})
+
+	t.Run("Event sinks", func(t *testing.T) {
+		sink := &recordingEventSink{events: make(chan ProcessEvent, 10)}
+		sp.AddFeatureEventSink(FeatureResultsUpload, sink)
+
+		if err := sp.SetFeature(FeatureResultsUpload, false); err != nil {
+			t.Fatal(err)
+		}
+
+		sp.emit(ProcessEvent{Type: ProcessEventResultsReady, ResultsFile: "results.json"})
+
+		if len(sink.events) != 0 {
+			t.Error("expected sink not to receive events while its feature is disabled")
+		}
+
+		if err := sp.SetFeature(FeatureResultsUpload, true); err != nil {
+			t.Fatal(err)
+		}
+
+		sp.emit(ProcessEvent{Type: ProcessEventResultsReady, ResultsFile: "results.json"})
+
+		if len(sink.events) != 1 {
+			t.Error("expected sink to receive events once its feature is enabled")
+		}
+	})
+
+	t.Run("Auto restart", func(t *testing.T) {
+		config.Server.RestartOnCrash = true
+
+		if err := sp.SetFeature(FeatureAutoRestart, false); err != nil {
+			t.Fatal(err)
+		}
+
+		crashes := make(chan *CrashReport, 1)
+		sp.NotifyCrash(crashes)
+
+		startTestServerProcess(t, sp, "#!/bin/sh\nsleep 0.2\nexit 3\n")
+
+		select {
+		case <-crashes:
+		case <-time.After(time.Second * 5):
+			t.Fatal("expected crash to be notified")
+		}
+
+		time.Sleep(time.Millisecond * 500)
+
+		if sp.IsRunning() {
+			t.Error("expected the event not to be restarted while auto-restart is disabled")
+		}
+	})
+
+	t.Run("Unknown feature", func(t *testing.T) {
+		if err := sp.SetFeature("not-a-feature", true); err == nil {
+			t.Error("expected an error setting an unknown feature")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package servermanager
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+// HangDetectionConfig restarts or stops acServer when it is still running, but has stopped talking to Server Manager
+// over the UDP plugin.
+type HangDetectionConfig struct {
+	// Timeout is how long acServer can go without sending a UDP message before it is treated as hung. Hang detection
+	// is off if it is zero.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// ProbeAfter is how long acServer can be quiet before it is asked for its session info, which it answers even if
+	// no one is connected. It defaults to a third of Timeout.
+	ProbeAfter time.Duration `yaml:"probe_after"`
+
+	// Action is what is done once acServer has hung: "restart" (the default) restarts the event, "stop" stops it, and
+	// "log" only logs it.
+	Action HangAction `yaml:"action"`
+}
+
+// HangAction is what is done when acServer is found to have hung.
+type HangAction string
+
+const (
+	HangActionRestart HangAction = "restart"
+	HangActionStop    HangAction = "stop"
+	HangActionLog     HangAction = "log"
+)
+
+// hangCheckInterval is how often the hang watchdog checks when acServer last sent a UDP message.
+var hangCheckInterval = time.Second * 5
+
+func (conf HangDetectionConfig) probeAfter() time.Duration {
+	if conf.ProbeAfter > 0 && conf.ProbeAfter < conf.Timeout {
+		return conf.ProbeAfter
+	}
+
+	return conf.Timeout / 3
+}
+
+// touchUDP records that acServer has just sent a UDP message.
+func (sp *AssettoServerProcess) touchUDP() {
+	atomic.StoreInt64(&sp.lastUDPMessage, time.Now().UnixNano())
+}
+
+func (sp *AssettoServerProcess) udpQuietFor(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, atomic.LoadInt64(&sp.lastUDPMessage)))
+}
+
+// startHangWatchdog starts watching for acServer to hang, if hang detection is configured. It must be called with
+// sp.mutex held. The watchdog is stopped by onStop.
+func (sp *AssettoServerProcess) startHangWatchdog() {
+	if config == nil || config.Server.HangDetection.Timeout <= 0 {
+		return
+	}
+
+	// acServer is given the timeout from when it is started to send its first message.
+	sp.touchUDP()
+	sp.hangWatchdogDone = make(chan struct{})
+
+	go sp.detectHang(sp.hangWatchdogDone, config.Server.HangDetection, hangCheckInterval)
+}
+
+// stopHangWatchdog must be called with sp.mutex held.
+func (sp *AssettoServerProcess) stopHangWatchdog() {
+	if sp.hangWatchdogDone != nil {
+		close(sp.hangWatchdogDone)
+		sp.hangWatchdogDone = nil
+	}
+}
+
+// detectHang probes acServer when it has been quiet for conf.ProbeAfter, and carries out conf.Action once it has been
+// quiet for conf.Timeout. It checks every checkInterval, which is passed in so that the watchdog doesn't read
+// hangCheckInterval after it has been started.
+func (sp *AssettoServerProcess) detectHang(done chan struct{}, conf HangDetectionConfig, checkInterval time.Duration) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	hung := false
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			quietFor := sp.udpQuietFor(now)
+
+			if quietFor < conf.probeAfter() {
+				hung = false
+				continue
+			}
+
+			if quietFor < conf.Timeout {
+				if err := sp.SendUDPMessageImmediate(udp.GetSessionInfo{}); err != nil {
+					logrus.WithError(err).Debug("Could not probe acServer for its session info")
+				}
+
+				continue
+			}
+
+			if hung {
+				// only logged, and acServer is still quiet.
+				continue
+			}
+
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			hung = true
+
+			if !sp.handleHang(conf.Action, quietFor) {
+				return
+			}
+		}
+	}
+}
+
+// handleHang carries out action for a hung acServer. It returns true if the watchdog should carry on watching.
+func (sp *AssettoServerProcess) handleHang(action HangAction, quietFor time.Duration) bool {
+	if action == "" {
+		action = HangActionRestart
+	}
+
+	sp.mutex.Lock()
+	raceEvent := sp.raceEvent
+
+	if action != HangActionLog {
+		sp.hangDetected = true
+	}
+
+	sp.mutex.Unlock()
+
+	if raceEvent == nil {
+		return false
+	}
+
+	logrus.Warnf("acServer has not sent a UDP message for %s, and did not answer a request for its session info. It has probably hung (action: %s)", quietFor.Round(time.Second), action)
+
+	sp.emit(ProcessEvent{
+		Type:      ProcessEventHangDetected,
+		EventName: raceEvent.EventName(),
+		RaceEvent: raceEvent,
+		Reason:    StopReasonHung,
+		Error:     fmt.Sprintf("acServer sent no UDP messages for %s", quietFor.Round(time.Second)),
+	})
+
+	var err error
+
+	switch action {
+	case HangActionLog:
+		return true
+	case HangActionStop:
+		err = sp.Stop()
+	default:
+		err = sp.Restart()
+	}
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not %s acServer after it hung", action)
+	}
+
+	return false
+}
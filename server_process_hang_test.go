@@ -0,0 +1,99 @@
+package servermanager
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_HangDetection(t *testing.T) {
+	oldHangCheckInterval := hangCheckInterval
+	hangCheckInterval = time.Millisecond * 10
+	defer func() {
+		hangCheckInterval = oldHangCheckInterval
+	}()
+
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	config.Server.HangDetection = HangDetectionConfig{Timeout: time.Millisecond * 400, ProbeAfter: time.Millisecond * 100}
+
+	sink := &recordingEventSink{events: make(chan ProcessEvent, 100)}
+	sp.AddEventSink(sink)
+
+	startTestServerProcess(t, sp, testServerScript)
+	defer sp.Stop() //nolint:errcheck
+
+	sp.mutex.Lock()
+	udpPluginLocalPort := sp.udpPluginLocalPort
+	sp.mutex.Unlock()
+
+	// acServer would listen on the UDP plugin local port, the test listens there instead to see the probes.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: udpPluginLocalPort})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	waitForEvent := func(eventType ProcessEventType, timeout time.Duration) (ProcessEvent, bool) {
+		deadline := time.After(timeout)
+
+		for {
+			select {
+			case event := <-sink.events:
+				if event.Type == eventType {
+					return event, true
+				}
+			case <-deadline:
+				return ProcessEvent{}, false
+			}
+		}
+	}
+
+	// acServer is talking, so it isn't probed or treated as hung.
+	for i := 0; i < 10; i++ {
+		sp.UDPCallback(udp.Version(4))
+		time.Sleep(time.Millisecond * 50)
+	}
+
+	if _, ok := waitForEvent(ProcessEventHangDetected, time.Millisecond*10); ok {
+		t.Fatal("expected no hang to be detected while acServer is sending UDP messages")
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+
+	if n, err := conn.Read(buf); err != nil || n == 0 || buf[0] != byte(udp.EventGetSessionInfo) {
+		t.Fatalf("expected a quiet acServer to be asked for its session info, got %v, %v", buf[:n], err)
+	}
+
+	// the restart needs the port.
+	_ = conn.Close()
+
+	event, ok := waitForEvent(ProcessEventHangDetected, time.Second*5)
+
+	if !ok {
+		t.Fatal("expected a hang to be detected once acServer had been quiet for the timeout")
+	}
+
+	if event.Reason != StopReasonHung || event.Error == "" {
+		t.Errorf("expected the hang event to describe the hang, got: %+v", event)
+	}
+
+	stopped, ok := waitForEvent(ProcessEventStopped, time.Second*5)
+
+	if !ok || stopped.Reason != StopReasonHung {
+		t.Fatalf("expected acServer to be stopped for a hang restart, got: %+v", stopped)
+	}
+
+	if _, ok := waitForEvent(ProcessEventStarted, time.Second*5); !ok {
+		t.Fatal("expected acServer to be started again after it hung")
+	}
+}
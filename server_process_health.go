@@ -0,0 +1,182 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cj123/ini"
+)
+
+const (
+	HealthCheckRunning           = "running"
+	HealthCheckTCPPort           = "tcp-port"
+	HealthCheckUDPPort           = "udp-port"
+	HealthCheckHTTPPort          = "http-port"
+	HealthCheckLobbyRegistration = "lobby-registration"
+	HealthCheckUDPPlugin         = "udp-plugin"
+)
+
+var (
+	errHealthNotRunning          = errors.New("acServer is not running")
+	errHealthLobbyNotRegistered  = errors.New("acServer has not reported that it registered with the lobby")
+	errHealthNoUDPPluginMessages = errors.New("no UDP plugin messages have been received from acServer")
+)
+
+// healthDialTimeout is how long a health check waits to connect to one of acServer's ports.
+var healthDialTimeout = time.Second
+
+// lobbyRegistrationRegex matches the line acServer prints once it has registered with the lobby.
+var lobbyRegistrationRegex = regexp.MustCompile(`^Lobby registration successful`)
+
+// ProcessHealthCheck is the result of one check of the acServer process.
+type ProcessHealthCheck struct {
+	Name  string
+	OK    bool
+	Error string `json:",omitempty"`
+}
+
+// ProcessHealth describes whether acServer is working, rather than just running. It is healthy if every check is OK.
+type ProcessHealth struct {
+	Healthy   bool
+	CheckedAt time.Time
+	Checks    []ProcessHealthCheck
+}
+
+func (h *ProcessHealth) add(name string, err error) {
+	check := ProcessHealthCheck{Name: name, OK: err == nil}
+
+	if err != nil {
+		check.Error = err.Error()
+	}
+
+	h.Checks = append(h.Checks, check)
+}
+
+// gamePorts are the ports acServer was configured with for the event which is running.
+type gamePorts struct {
+	TCP, UDP, HTTP  int
+	RegisterToLobby bool
+}
+
+// readGamePorts reads the game ports from the server_cfg.ini in installPath, which was written for the event which is
+// starting. It is read rather than taken from the server options so that it is correct for pooled servers too.
+func readGamePorts(installPath string) (gamePorts, error) {
+	f, err := ini.Load(filepath.Join(installPath, ServerConfigPath, serverConfigIniPath))
+
+	if err != nil {
+		return gamePorts{}, err
+	}
+
+	server, err := f.GetSection("SERVER")
+
+	if err != nil {
+		return gamePorts{}, err
+	}
+
+	return gamePorts{
+		TCP:             server.Key("TCP_PORT").MustInt(0),
+		UDP:             server.Key("UDP_PORT").MustInt(0),
+		HTTP:            server.Key("HTTP_PORT").MustInt(0),
+		RegisterToLobby: server.Key("REGISTER_TO_LOBBY").MustInt(0) == 1,
+	}, nil
+}
+
+// resetHealth clears what is known about the health of the previous event. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) resetHealth() {
+	sp.gamePorts, sp.gamePortsErr = readGamePorts(sp.installPath())
+	atomic.StoreInt32(&sp.lobbyRegistered, 0)
+	atomic.StoreInt32(&sp.udpPluginMessageReceived, 0)
+}
+
+// Health checks that acServer is accepting connections on its game ports, registered with the lobby (if it is
+// configured to) and is talking to Server Manager over the UDP plugin.
+func (sp *AssettoServerProcess) Health() ProcessHealth {
+	sp.mutex.Lock()
+	running := sp.raceEvent != nil
+	ports, portsErr := sp.gamePorts, sp.gamePortsErr
+	sp.mutex.Unlock()
+
+	health := ProcessHealth{CheckedAt: time.Now()}
+
+	if !running {
+		health.add(HealthCheckRunning, errHealthNotRunning)
+		return health
+	}
+
+	health.add(HealthCheckRunning, nil)
+
+	if portsErr != nil {
+		portsErr = fmt.Errorf("could not read the game ports from the server config: %s", portsErr)
+
+		health.add(HealthCheckTCPPort, portsErr)
+		health.add(HealthCheckUDPPort, portsErr)
+		health.add(HealthCheckHTTPPort, portsErr)
+	} else {
+		host := "127.0.0.1"
+		remote, isRemote := sp.remote()
+
+		if isRemote {
+			host = remote.remoteHost()
+		}
+
+		health.add(HealthCheckTCPPort, dialHealthCheck(host, ports.TCP))
+
+		// the UDP port is checked by binding it, which can only be done on this host.
+		if !isRemote {
+			health.add(HealthCheckUDPPort, udpHealthCheck(ports.UDP))
+		}
+
+		health.add(HealthCheckHTTPPort, dialHealthCheck(host, ports.HTTP))
+
+		if ports.RegisterToLobby {
+			var err error
+
+			if atomic.LoadInt32(&sp.lobbyRegistered) == 0 {
+				err = errHealthLobbyNotRegistered
+			}
+
+			health.add(HealthCheckLobbyRegistration, err)
+		}
+	}
+
+	var udpErr error
+
+	if atomic.LoadInt32(&sp.udpPluginMessageReceived) == 0 {
+		udpErr = errHealthNoUDPPluginMessages
+	}
+
+	health.add(HealthCheckUDPPlugin, udpErr)
+
+	health.Healthy = true
+
+	for _, check := range health.Checks {
+		health.Healthy = health.Healthy && check.OK
+	}
+
+	return health
+}
+
+func dialHealthCheck(host string, port int) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), healthDialTimeout)
+
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// udpHealthCheck can't connect to acServer's UDP port, so it checks that the port is bound instead.
+func udpHealthCheck(port int) error {
+	if err := probeUDPPort("", port, "game"); err == nil {
+		return fmt.Errorf("acServer is not listening on UDP port %d", port)
+	}
+
+	return nil
+}
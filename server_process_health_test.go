@@ -0,0 +1,114 @@
+package servermanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_Health(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	if health := sp.Health(); health.Healthy || len(health.Checks) != 1 || health.Checks[0].Name != HealthCheckRunning {
+		t.Errorf("expected only the running check to fail before the server process has run, got: %+v", health)
+	}
+
+	// stand in for acServer's game ports.
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tcpListener.Close()
+
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer httpListener.Close()
+
+	udpListener, err := net.ListenUDP("udp", &net.UDPAddr{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer udpListener.Close()
+
+	serverConfig := fmt.Sprintf("[SERVER]\nTCP_PORT=%d\nUDP_PORT=%d\nHTTP_PORT=%d\nREGISTER_TO_LOBBY=1\n",
+		tcpListener.Addr().(*net.TCPAddr).Port,
+		udpListener.LocalAddr().(*net.UDPAddr).Port,
+		httpListener.Addr().(*net.TCPAddr).Port,
+	)
+
+	if err := os.MkdirAll(filepath.Join(ServerInstallPath, ServerConfigPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(ServerInstallPath, ServerConfigPath, serverConfigIniPath), []byte(serverConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	startTestServerProcess(t, sp, "#!/bin/sh\necho 'Lobby registration successful'\nexec sleep 600\n")
+
+	failing := func(health ProcessHealth) []string {
+		var names []string
+
+		for _, check := range health.Checks {
+			if !check.OK {
+				names = append(names, check.Name)
+			}
+		}
+
+		return names
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+
+	for {
+		health := sp.Health()
+		names := failing(health)
+
+		if len(names) == 1 && names[0] == HealthCheckUDPPlugin && !health.Healthy {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected only the UDP plugin check to fail before any UDP messages, got: %v", names)
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	sp.UDPCallback(udp.Version(4))
+
+	if health := sp.Health(); !health.Healthy {
+		t.Errorf("expected acServer to be healthy, failing checks: %v", failing(health))
+	}
+
+	if err := tcpListener.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := failing(sp.Health()); len(names) != 1 || names[0] != HealthCheckTCPPort {
+		t.Errorf("expected the TCP port check to fail once the port closed, got: %v", names)
+	}
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if health := sp.Health(); health.Healthy {
+		t.Error("expected acServer not to be healthy once it stopped")
+	}
+}
@@ -0,0 +1,170 @@
+package servermanager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// HookScript is a command which is run before an event starts or after acServer stops. The event it is run for is
+// described to it in SERVER_MANAGER_* environment variables, see hookScriptEnv.
+type HookScript struct {
+	// Command is run in the same way as run_on_start commands: the first part is the executable and the rest are
+	// its arguments.
+	Command string `yaml:"command"`
+
+	// Timeout is how long the command can run for before it is killed, a minute if empty.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// AbortOnFailure stops the event from starting if a pre_start command fails. Failures are otherwise logged, and
+	// the event starts anyway.
+	AbortOnFailure bool `yaml:"abort_on_failure"`
+}
+
+// HookScriptsConfig are the commands run either side of each event, in the order they are listed.
+type HookScriptsConfig struct {
+	PreStart []HookScript `yaml:"pre_start"`
+	PostStop []HookScript `yaml:"post_stop"`
+}
+
+const (
+	hookScriptPreStart = "pre_start"
+	hookScriptPostStop = "post_stop"
+
+	defaultHookScriptTimeout = time.Minute
+)
+
+// WithHookScripts runs the commands in conf before each event starts and after acServer stops. They are run as
+// OnStart and OnStop hooks, so they are run after any hooks which were registered before the server process was
+// created.
+func WithHookScripts(conf HookScriptsConfig) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		if len(conf.PreStart) > 0 {
+			sp.OnStart(func(raceEvent RaceEvent) error {
+				return sp.runHookScripts(hookScriptPreStart, conf.PreStart, raceEvent)
+			})
+		}
+
+		if len(conf.PostStop) > 0 {
+			sp.OnStop(func(raceEvent RaceEvent) {
+				_ = sp.runHookScripts(hookScriptPostStop, conf.PostStop, raceEvent)
+			})
+		}
+	}
+}
+
+// runHookScripts runs scripts one after another. It is called from a hook, so sp.mutex is held.
+func (sp *AssettoServerProcess) runHookScripts(hook string, scripts []HookScript, raceEvent RaceEvent) error {
+	env := sp.hookScriptEnv(hook, raceEvent)
+
+	for _, script := range scripts {
+		err := runHookScript(script, env)
+
+		if err == nil {
+			continue
+		}
+
+		if hook == hookScriptPreStart && script.AbortOnFailure {
+			return fmt.Errorf("servermanager: %s hook %q failed: %s", hook, script.Command, err)
+		}
+
+		logrus.WithError(err).Errorf("The %s hook %q failed", hook, script.Command)
+	}
+
+	return nil
+}
+
+func runHookScript(script HookScript, env []string) error {
+	parts, err := splitCommand(script.Command)
+
+	if err != nil {
+		return err
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	timeout := script.Timeout
+
+	if timeout <= 0 {
+		timeout = defaultHookScriptTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	executable, err := filepath.Abs(parts[0])
+
+	if err != nil {
+		return err
+	}
+
+	cmd := buildCommand(ctx, executable, parts[1:]...)
+	cmd.Dir = filepath.Dir(executable)
+	cmd.Env = append(os.Environ(), env...)
+
+	logrus.Infof("Running hook: %s", script.Command)
+
+	out, err := cmd.CombinedOutput()
+
+	if output := strings.TrimSpace(string(out)); output != "" {
+		logrus.Debugf("Output of hook %q: %s", script.Command, output)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+
+	return err
+}
+
+// hookScriptEnv describes raceEvent, and how acServer is run, to a hook script.
+func (sp *AssettoServerProcess) hookScriptEnv(hook string, raceEvent RaceEvent) []string {
+	raceConfig := raceEvent.GetRaceConfig()
+	_, sessionTypes := raceConfig.Sessions.AsSliceWithSessionTypes()
+
+	sessions := make([]string, len(sessionTypes))
+
+	for i, sessionType := range sessionTypes {
+		sessions[i] = string(sessionType)
+	}
+
+	env := map[string]string{
+		"HOOK":                  hook,
+		"EVENT_NAME":            raceEvent.EventName(),
+		"EVENT_DESCRIPTION":     raceEvent.EventDescription(),
+		"TRACK":                 raceConfig.Track,
+		"TRACK_LAYOUT":          raceConfig.TrackLayout,
+		"CARS":                  raceConfig.Cars,
+		"SESSION_TYPES":         strings.Join(sessions, ","),
+		"INSTALL_PATH":          sp.installPath(),
+		"UDP_PLUGIN_ADDRESS":    sp.udpPluginAddress,
+		"UDP_PLUGIN_LOCAL_PORT": strconv.Itoa(sp.udpPluginLocalPort),
+	}
+
+	if ports, err := readGamePorts(sp.installPath()); err == nil {
+		env["TCP_PORT"] = strconv.Itoa(ports.TCP)
+		env["UDP_PORT"] = strconv.Itoa(ports.UDP)
+		env["HTTP_PORT"] = strconv.Itoa(ports.HTTP)
+	}
+
+	if hook == hookScriptPostStop && sp.lastExit != nil {
+		env["STOP_REASON"] = string(sp.lastExit.Reason)
+		env["EXIT_CODE"] = strconv.Itoa(sp.lastExit.ExitCode)
+	}
+
+	out := make([]string, 0, len(env))
+
+	for key, value := range env {
+		out = append(out, "SERVER_MANAGER_"+key+"="+value)
+	}
+
+	return out
+}
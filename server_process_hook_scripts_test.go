@@ -0,0 +1,104 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAssettoServerProcess_HookScripts(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	useTestServerScript(t, testServerScript)
+
+	output := filepath.Join(ServerInstallPath, "hooks.log")
+	hook := filepath.Join(ServerInstallPath, "hook.sh")
+	failingHook := filepath.Join(ServerInstallPath, "failing-hook.sh")
+	checkHook := filepath.Join(ServerInstallPath, "check-hook.sh")
+	checkFails := filepath.Join(ServerInstallPath, "check-fails")
+
+	script := "#!/bin/sh\necho \"$1 $SERVER_MANAGER_HOOK $SERVER_MANAGER_TRACK $SERVER_MANAGER_SESSION_TYPES $SERVER_MANAGER_STOP_REASON\" >> " + output + "\n"
+
+	if err := ioutil.WriteFile(hook, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(failingHook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(checkHook, []byte("#!/bin/sh\n[ ! -f "+checkFails+" ]\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(checkFails, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	WithHookScripts(HookScriptsConfig{
+		PreStart: []HookScript{
+			{Command: hook + " before"},
+			{Command: checkHook, AbortOnFailure: true},
+		},
+		PostStop: []HookScript{
+			{Command: failingHook},
+			{Command: hook + " after"},
+		},
+	})(sp)
+
+	raceEvent := QuickRace{RaceConfig: CurrentRaceConfig{
+		Track:    "ks_vallelunga",
+		Sessions: Sessions{SessionTypePractice: {}, SessionTypeRace: {}},
+	}}
+
+	udpPluginLocalPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sp.Start(raceEvent, "127.0.0.1:0", udpPluginLocalPort, "", 0); err == nil || sp.IsRunning() {
+		t.Fatal("expected a failing pre_start hook with abort_on_failure to stop the event from starting")
+	}
+
+	if err := os.Remove(checkFails); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sp.Start(raceEvent, "127.0.0.1:0", udpPluginLocalPort, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 100)
+
+	out, err := ioutil.ReadFile(output)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"before pre_start ks_vallelunga PRACTICE,RACE",
+		"before pre_start ks_vallelunga PRACTICE,RACE",
+		"after post_stop ks_vallelunga PRACTICE,RACE requested",
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	// the failing post_stop hook is logged, and the hooks after it are still run.
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected hooks to be run with the event in their environment:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,51 @@
+package servermanager
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// OnStart registers fn to be run each time an event is started, before acServer is launched. If fn returns an error,
+// the event is not started and Start returns the error. Hooks are run in the order they were registered, while the
+// server process is locked, so they must not call back into the server process.
+func (sp *AssettoServerProcess) OnStart(fn func(RaceEvent) error) {
+	sp.hooksMutex.Lock()
+	defer sp.hooksMutex.Unlock()
+
+	sp.startHooks = append(sp.startHooks, fn)
+}
+
+// OnStop registers fn to be run each time acServer stops, with the event which was running. Like OnStart hooks, stop
+// hooks are run in the order they were registered and must not call back into the server process.
+func (sp *AssettoServerProcess) OnStop(fn func(RaceEvent)) {
+	sp.hooksMutex.Lock()
+	defer sp.hooksMutex.Unlock()
+
+	sp.stopHooks = append(sp.stopHooks, fn)
+}
+
+func (sp *AssettoServerProcess) runStartHooks(raceEvent RaceEvent) error {
+	sp.hooksMutex.Lock()
+	hooks := sp.startHooks
+	sp.hooksMutex.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(raceEvent); err != nil {
+			logrus.WithError(err).Error("Start hook failed, not starting the event")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sp *AssettoServerProcess) runStopHooks(raceEvent RaceEvent) {
+	sp.hooksMutex.Lock()
+	hooks := sp.stopHooks
+	sp.hooksMutex.Unlock()
+
+	for _, hook := range hooks {
+		panicCapture(func() {
+			hook(raceEvent)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package servermanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/sirupsen/logrus"
+)
+
+// HostMetrics is a sample of the health of the machine Server Manager is running on. Metrics which can't be read
+// on this platform are left empty and listed in Unavailable.
+type HostMetrics struct {
+	Time time.Time
+
+	Load1, Load5, Load15 float64
+
+	MemoryTotal     uint64
+	MemoryAvailable uint64
+
+	// DiskReadBytes and DiskWriteBytes are totals across all disks since the machine booted.
+	DiskReadBytes  uint64
+	DiskWriteBytes uint64
+
+	Unavailable []string `json:",omitempty"`
+}
+
+// hostMetricsReader reads a single HostMetrics sample.
+type hostMetricsReader interface {
+	Read() HostMetrics
+}
+
+type gopsutilHostMetricsReader struct {
+	// warned records which metrics have already been logged as unavailable, so that it only happens once.
+	warned map[string]bool
+}
+
+func (r *gopsutilHostMetricsReader) Read() HostMetrics {
+	metrics := HostMetrics{Time: time.Now()}
+
+	if avg, err := load.Avg(); err == nil {
+		metrics.Load1, metrics.Load5, metrics.Load15 = avg.Load1, avg.Load5, avg.Load15
+	} else {
+		r.unavailable(&metrics, "load", err)
+	}
+
+	if vm, err := mem.VirtualMemory(); err == nil {
+		metrics.MemoryTotal, metrics.MemoryAvailable = vm.Total, vm.Available
+	} else {
+		r.unavailable(&metrics, "memory", err)
+	}
+
+	if counters, err := disk.IOCounters(); err == nil {
+		for _, counter := range counters {
+			metrics.DiskReadBytes += counter.ReadBytes
+			metrics.DiskWriteBytes += counter.WriteBytes
+		}
+	} else {
+		r.unavailable(&metrics, "disk", err)
+	}
+
+	return metrics
+}
+
+func (r *gopsutilHostMetricsReader) unavailable(metrics *HostMetrics, name string, err error) {
+	metrics.Unavailable = append(metrics.Unavailable, name)
+
+	if r.warned == nil {
+		r.warned = make(map[string]bool)
+	}
+
+	if !r.warned[name] {
+		logrus.WithError(err).Warnf("Host %s metrics are unavailable on this platform", name)
+		r.warned[name] = true
+	}
+}
+
+var (
+	hostLoadGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "host_load_average",
+		Help: "The load average of the host, over 1, 5 and 15 minutes.",
+	}, []string{"period"})
+
+	hostMemoryAvailableGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "host_memory_available_bytes",
+		Help: "The memory available on the host.",
+	})
+
+	hostDiskBytesGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "host_disk_bytes",
+		Help: "The total bytes read from and written to the host's disks.",
+	}, []string{"direction"})
+)
+
+// hostMetricsSampler periodically reads HostMetrics, keeping the latest sample for Status and updating the
+// Prometheus host metrics.
+type hostMetricsSampler struct {
+	reader   hostMetricsReader
+	interval time.Duration
+
+	mutex  sync.Mutex
+	latest *HostMetrics
+
+	// done is non-nil while the sampler is running, closing it stops the sampler.
+	done chan struct{}
+}
+
+func newHostMetricsSampler(reader hostMetricsReader, interval time.Duration) *hostMetricsSampler {
+	return &hostMetricsSampler{
+		reader:   reader,
+		interval: interval,
+	}
+}
+
+// start begins sampling in the background, if the sampler isn't already running.
+func (s *hostMetricsSampler) start() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.done != nil {
+		return
+	}
+
+	s.done = make(chan struct{})
+
+	go s.run(s.done)
+}
+
+// stop ends sampling. The latest sample is kept.
+func (s *hostMetricsSampler) stop() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.done != nil {
+		close(s.done)
+		s.done = nil
+	}
+}
+
+func (s *hostMetricsSampler) run(done chan struct{}) {
+	s.sample()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.sample()
+		}
+	}
+}
+
+func (s *hostMetricsSampler) sample() {
+	metrics := s.reader.Read()
+
+	s.mutex.Lock()
+	s.latest = &metrics
+	s.mutex.Unlock()
+
+	hostLoadGauge.WithLabelValues("1m").Set(metrics.Load1)
+	hostLoadGauge.WithLabelValues("5m").Set(metrics.Load5)
+	hostLoadGauge.WithLabelValues("15m").Set(metrics.Load15)
+	hostMemoryAvailableGauge.Set(float64(metrics.MemoryAvailable))
+	hostDiskBytesGauge.WithLabelValues("read").Set(float64(metrics.DiskReadBytes))
+	hostDiskBytesGauge.WithLabelValues("write").Set(float64(metrics.DiskWriteBytes))
+}
+
+// Latest returns the most recent sample, or nil if no sample has been taken.
+func (s *hostMetricsSampler) Latest() *HostMetrics {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.latest
+}
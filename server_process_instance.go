@@ -0,0 +1,123 @@
+package servermanager
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ServerInstanceConfig describes an acServer install which is managed by its own AssettoServerProcess, so that
+// more than one acServer can be run by the same Server Manager.
+type ServerInstanceConfig struct {
+	// InstallPath is the directory acServer is installed in. It is run from here, and its logs are written here.
+	InstallPath string
+
+	// ExecutablePath is the acServer executable, relative to InstallPath unless it is absolute.
+	ExecutablePath string
+
+	// UDPPluginLocalPort and UDPPluginAddress are the ports acServer and Server Manager use to talk to each other,
+	// and replace those given to Start. They are allocated with FreeUDPPort if they are empty. The server_cfg.ini in
+	// InstallPath must use the same ports, see Instance.
+	UDPPluginLocalPort int
+	UDPPluginAddress   string
+}
+
+// WithInstance runs acServer from instance, rather than from ServerInstallPath with the ports given to Start.
+func WithInstance(instance ServerInstanceConfig) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.instance = &instance
+	}
+}
+
+// allocateInstancePorts allocates any UDP ports which were left empty in the instance config. It is called once, when
+// the server process is created, so that the ports stay the same for every event the instance runs.
+func (sp *AssettoServerProcess) allocateInstancePorts() error {
+	if sp.instance == nil {
+		return nil
+	}
+
+	if sp.instance.UDPPluginLocalPort == 0 {
+		port, err := FreeUDPPort()
+
+		if err != nil {
+			return err
+		}
+
+		sp.instance.UDPPluginLocalPort = port
+	}
+
+	if sp.instance.UDPPluginAddress == "" {
+		port, err := FreeUDPPort()
+
+		if err != nil {
+			return err
+		}
+
+		sp.instance.UDPPluginAddress = fmt.Sprintf("127.0.0.1:%d", port)
+	}
+
+	return nil
+}
+
+// Instance returns the instance this server process runs. The bool is false if it runs acServer from
+// ServerInstallPath.
+func (sp *AssettoServerProcess) Instance() (ServerInstanceConfig, bool) {
+	if sp.instance == nil {
+		return ServerInstanceConfig{}, false
+	}
+
+	return *sp.instance, true
+}
+
+// installPath is the directory acServer is run from.
+func (sp *AssettoServerProcess) installPath() string {
+	if sp.instance != nil {
+		return sp.instance.InstallPath
+	}
+
+	return ServerInstallPath
+}
+
+func (sp *AssettoServerProcess) executablePath() string {
+	executablePath := config.Steam.ExecutablePath
+
+	if sp.instance != nil {
+		executablePath = sp.instance.ExecutablePath
+	}
+
+	if filepath.IsAbs(executablePath) {
+		return executablePath
+	}
+
+	return filepath.Join(sp.installPath(), executablePath)
+}
+
+// logFileConfig returns where the server log files are kept. Each instance keeps its log files in its own directory,
+// named after its install path, so that instances don't delete each other's log files.
+func (sp *AssettoServerProcess) logFileConfig() LogFileConfig {
+	if config == nil || config.Server.LogFile.Directory == "" {
+		return LogFileConfig{}
+	}
+
+	conf := config.Server.LogFile
+
+	if sp.instance != nil {
+		conf.Directory = filepath.Join(conf.Directory, filepath.Base(sp.instance.InstallPath))
+	}
+
+	return conf
+}
+
+// instanceUDPPorts replaces the UDP plugin ports given to Start with the instance's own ports.
+func (sp *AssettoServerProcess) instanceUDPPorts(udpPluginAddress string, udpPluginLocalPort int) (string, int) {
+	if sp.instance == nil {
+		return udpPluginAddress, udpPluginLocalPort
+	}
+
+	if udpPluginAddress != sp.instance.UDPPluginAddress || udpPluginLocalPort != sp.instance.UDPPluginLocalPort {
+		logrus.Debugf("Using the instance's UDP plugin ports (%s, %d) in place of (%s, %d)", sp.instance.UDPPluginAddress, sp.instance.UDPPluginLocalPort, udpPluginAddress, udpPluginLocalPort)
+	}
+
+	return sp.instance.UDPPluginAddress, sp.instance.UDPPluginLocalPort
+}
@@ -0,0 +1,152 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_Instances(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	// the default install path isn't used by either instance
+	useTestServerScript(t, "#!/bin/sh\nexit 3\n")
+
+	var instances []*AssettoServerProcess
+
+	for _, name := range []string{"server-1", "server-2"} {
+		installPath := filepath.Join(ServerInstallPath, name)
+
+		if err := os.MkdirAll(installPath, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(installPath, "acServer.sh"), []byte("#!/bin/sh\npwd > started\nexec sleep 600\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		instance, err := NewAssettoServerProcess(func(udp.Message) {}, sp.store, NewContentManagerWrapper(sp.store, nil, nil), WithInstance(ServerInstanceConfig{
+			InstallPath:    installPath,
+			ExecutablePath: "acServer.sh",
+		}))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer instance.Stop() //nolint:errcheck
+
+		instance.gamePortBindWait = 0
+
+		instances = append(instances, instance)
+	}
+
+	for _, instance := range instances {
+		if err := instance.Start(QuickRace{}, "", 0, "", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, _ := instances[0].Instance()
+	second, _ := instances[1].Instance()
+
+	if first.UDPPluginLocalPort == 0 || first.UDPPluginAddress == "" || first.UDPPluginLocalPort == second.UDPPluginLocalPort || first.UDPPluginAddress == second.UDPPluginAddress {
+		t.Errorf("expected each instance to be allocated its own UDP ports, got: %+v and %+v", first, second)
+	}
+
+	for i, instance := range instances {
+		if !instance.IsRunning() {
+			t.Fatalf("expected instance %d to be running", i)
+		}
+
+		conf, _ := instance.Instance()
+		status := instance.Status()
+
+		if status.UDPPluginAddress != conf.UDPPluginAddress || status.UDPPluginLocalPort != conf.UDPPluginLocalPort {
+			t.Errorf("expected instance %d to use its own UDP ports, got: %s, %d", i, status.UDPPluginAddress, status.UDPPluginLocalPort)
+		}
+
+		var started []byte
+		deadline := time.Now().Add(time.Second * 5)
+
+		for {
+			data, err := ioutil.ReadFile(filepath.Join(conf.InstallPath, "started"))
+
+			if err == nil && len(data) > 0 {
+				started = data
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatalf("expected instance %d to run acServer from its install path", i)
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if dir := strings.TrimSpace(string(started)); filepath.Base(dir) != filepath.Base(conf.InstallPath) {
+			t.Errorf("expected instance %d to run in %s, ran in %s", i, conf.InstallPath, dir)
+		}
+	}
+
+	if err := instances[0].Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !instances[1].IsRunning() {
+		t.Error("expected stopping one instance to leave the other running")
+	}
+}
+
+func TestAssettoServerProcess_InstanceKissMyRankStopped(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	installPath := filepath.Join(ServerInstallPath, "server-1")
+	kissMyRank := kissMyRankExecutablePath(installPath)
+	stopped := filepath.Join(installPath, "stopped")
+
+	if err := os.MkdirAll(filepath.Dir(kissMyRank), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(installPath, "acServer.sh"), []byte("#!/bin/sh\nexec sleep 600\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// kissmyrank is asked to exit on its stdin, rather than being sent a signal.
+	if err := ioutil.WriteFile(kissMyRank, []byte("#!/bin/sh\nread command\necho \"$command\" > "+stopped+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Server.Plugins = []*CommandPlugin{{Executable: kissMyRank}}
+
+	instance, err := NewAssettoServerProcess(func(udp.Message) {}, sp.store, NewContentManagerWrapper(sp.store, nil, nil), WithInstance(ServerInstanceConfig{
+		InstallPath:    installPath,
+		ExecutablePath: "acServer.sh",
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instance.gamePortBindWait = 0
+
+	if err := instance.Start(QuickRace{}, "", 0, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := ioutil.ReadFile(stopped); err != nil || strings.TrimSpace(string(data)) != "exit" {
+		t.Errorf("expected the instance's kissmyrank to be asked to exit, got: %q (%v)", data, err)
+	}
+}
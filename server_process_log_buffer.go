@@ -0,0 +1,217 @@
+package servermanager
+
+import (
+	"strings"
+	"sync"
+)
+
+func newLogBuffer(maxSize int) *logBuffer {
+	return &logBuffer{
+		size: maxSize,
+	}
+}
+
+// logSpan is where a complete line is in a logBuffer. start and end are positions in everything which has been
+// written to the buffer, not in its ring, and end is before the line ending.
+type logSpan struct {
+	start, end int64
+}
+
+// logBuffer keeps the most recent output of a process in a fixed size ring, so that writes never copy what has
+// already been written. Each complete line is indexed by its offset, so that it can be read back without scanning
+// the whole buffer.
+type logBuffer struct {
+	size int
+
+	// ring holds the last held bytes which have been written, which end at position written. A byte at position p is
+	// at ring[p % size]. ring is allocated on the first write.
+	ring    []byte
+	written int64
+	held    int
+
+	// spans is a ring of the complete lines which are still held, oldest first. firstSpan is the index of the oldest
+	// in spans, and numSpans is how many there are. They are the numSpans lines before lines.
+	spans     []logSpan
+	firstSpan int
+	numSpans  int
+
+	// partial is the start of a line which hasn't been finished yet, subscribers receive it once it has. It starts at
+	// position partialStart.
+	partial      []byte
+	partialStart int64
+	subscribers  map[*logSubscriber]bool
+
+	// lines is the number of complete lines which have been published, which is the offset of the next line.
+	lines int64
+
+	// previous is the contents of the buffer before it was last rotated.
+	previous string
+
+	mutex sync.Mutex
+}
+
+func (lb *logBuffer) Write(p []byte) (n int, err error) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.writeRing(p)
+	lb.publishLines(p)
+	lb.dropOverwrittenSpans()
+
+	return len(p), nil
+}
+
+// writeRing copies p into the ring, over the oldest bytes once it is full. It must be called with lb.mutex held.
+func (lb *logBuffer) writeRing(p []byte) {
+	if lb.size <= 0 {
+		lb.written += int64(len(p))
+		return
+	}
+
+	if lb.ring == nil {
+		lb.ring = make([]byte, lb.size)
+	}
+
+	if len(p) > lb.size {
+		// only the end of p fits, the rest would be overwritten straight away.
+		lb.written += int64(len(p) - lb.size)
+		p = p[len(p)-lb.size:]
+	}
+
+	lb.held += len(p)
+
+	if lb.held > lb.size {
+		lb.held = lb.size
+	}
+
+	for len(p) > 0 {
+		n := copy(lb.ring[lb.written%int64(lb.size):], p)
+		p = p[n:]
+		lb.written += int64(n)
+	}
+}
+
+// read returns the bytes from position start to end, which must still be held. It must be called with lb.mutex held.
+func (lb *logBuffer) read(start, end int64) []byte {
+	out := make([]byte, 0, end-start)
+
+	for start < end {
+		i := start % int64(lb.size)
+		j := i + end - start
+
+		if j > int64(lb.size) {
+			j = int64(lb.size)
+		}
+
+		out = append(out, lb.ring[i:j]...)
+		start += j - i
+	}
+
+	return out
+}
+
+// addSpan indexes the next line. It must be called with lb.mutex held.
+func (lb *logBuffer) addSpan(span logSpan) {
+	if lb.numSpans == len(lb.spans) {
+		spans := make([]logSpan, len(lb.spans)*2+64)
+
+		for i := 0; i < lb.numSpans; i++ {
+			spans[i] = lb.spans[(lb.firstSpan+i)%len(lb.spans)]
+		}
+
+		lb.spans = spans
+		lb.firstSpan = 0
+	}
+
+	lb.spans[(lb.firstSpan+lb.numSpans)%len(lb.spans)] = span
+	lb.numSpans++
+}
+
+// dropOverwrittenSpans forgets the lines which no longer fit in the ring, including lines which are only partly
+// overwritten. It must be called with lb.mutex held.
+func (lb *logBuffer) dropOverwrittenSpans() {
+	oldest := lb.written - int64(lb.held)
+
+	for lb.numSpans > 0 && lb.spans[lb.firstSpan].start < oldest {
+		lb.firstSpan = (lb.firstSpan + 1) % len(lb.spans)
+		lb.numSpans--
+	}
+}
+
+// Lines returns up to max of the complete lines still in the buffer from offset onwards, or all of them if max is
+// zero, and the offset to read from next. If the lines from offset have already been dropped to make room, the
+// lines start from the oldest which is still held, so a gap in the offsets shows what was missed. A client can tail
+// the buffer by passing the returned offset to the next call.
+func (lb *logBuffer) Lines(offset int64, max int) (int64, []TailLine) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return lb.linesFrom(offset, max)
+}
+
+// linesFrom is Lines, it must be called with lb.mutex held.
+func (lb *logBuffer) linesFrom(offset int64, max int) (int64, []TailLine) {
+	first := lb.lines - int64(lb.numSpans)
+
+	if offset < first {
+		offset = first
+	}
+
+	if offset >= lb.lines {
+		return offset, nil
+	}
+
+	n := int(lb.lines - offset)
+
+	if max > 0 && n > max {
+		n = max
+	}
+
+	lines := make([]TailLine, n)
+
+	for i := range lines {
+		lineOffset := offset + int64(i)
+		span := lb.spans[(lb.firstSpan+int(lineOffset-first))%len(lb.spans)]
+
+		lines[i] = TailLine{Offset: lineOffset, Line: string(lb.read(span.start, span.end))}
+	}
+
+	return offset + int64(n), lines
+}
+
+func (lb *logBuffer) String() string {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return lb.string()
+}
+
+// string must be called with lb.mutex held.
+func (lb *logBuffer) string() string {
+	return strings.Replace(string(lb.read(lb.written-int64(lb.held), lb.written)), "\n\n", "\n", -1)
+}
+
+// rotate keeps the current contents of the buffer as the previous contents and empties it. Subscribers stay
+// subscribed, and line offsets carry on from where they were. An empty buffer is not rotated, so that the previous
+// contents aren't lost to a session with no output.
+func (lb *logBuffer) rotate() {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	if lb.held == 0 {
+		return
+	}
+
+	lb.previous = lb.string()
+	lb.held = 0
+	lb.numSpans = 0
+	lb.partial = nil
+	lb.partialStart = lb.written
+}
+
+func (lb *logBuffer) Previous() string {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	return lb.previous
+}
@@ -0,0 +1,151 @@
+package servermanager
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLogBuffer_Wraps(t *testing.T) {
+	buffer := newLogBuffer(32)
+
+	for i := 0; i < 10; i++ {
+		_, _ = buffer.Write([]byte(fmt.Sprintf("line %d\n", i)))
+	}
+
+	// each line is 7 bytes, so the last 32 bytes end with four whole lines, and the end of line 5.
+	if logs, expected := buffer.String(), "e 5\nline 6\nline 7\nline 8\nline 9\n"; logs != expected {
+		t.Errorf("expected the buffer to hold the last 32 bytes, got %q", logs)
+	}
+
+	offset, lines := buffer.Lines(0, 0)
+
+	if offset != 10 {
+		t.Errorf("expected the next offset to be 10, got %d", offset)
+	}
+
+	expected := []TailLine{{6, "line 6"}, {7, "line 7"}, {8, "line 8"}, {9, "line 9"}}
+
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Errorf("expected only the whole lines still held, got %+v", lines)
+	}
+
+	_, _ = buffer.Write([]byte(strings.Repeat("x", 100) + "\nlast\n"))
+
+	if _, lines := buffer.Lines(0, 0); len(lines) != 1 || lines[0] != (TailLine{11, "last"}) {
+		t.Errorf("expected a line longer than the buffer to be dropped, got %+v", lines)
+	}
+}
+
+func TestLogBuffer_Lines(t *testing.T) {
+	buffer := newLogBuffer(MaxLogSizeBytes)
+
+	_, _ = buffer.Write([]byte("line 0\r\n\nline 1\nline 2\nline "))
+
+	offset, lines := buffer.Lines(0, 2)
+
+	if offset != 2 || len(lines) != 2 || lines[0] != (TailLine{0, "line 0"}) || lines[1] != (TailLine{1, "line 1"}) {
+		t.Fatalf("expected the first two lines, got %+v (next offset %d)", lines, offset)
+	}
+
+	offset, lines = buffer.Lines(offset, 2)
+
+	if offset != 3 || len(lines) != 1 || lines[0] != (TailLine{2, "line 2"}) {
+		t.Fatalf("expected the unfinished line not to be read, got %+v (next offset %d)", lines, offset)
+	}
+
+	offset, lines = buffer.Lines(offset, 2)
+
+	if offset != 3 || len(lines) != 0 {
+		t.Fatalf("expected no lines until another is finished, got %+v (next offset %d)", lines, offset)
+	}
+
+	_, _ = buffer.Write([]byte("3\n"))
+
+	if _, lines = buffer.Lines(offset, 0); len(lines) != 1 || lines[0] != (TailLine{3, "line 3"}) {
+		t.Fatalf("expected the finished line, got %+v", lines)
+	}
+
+	buffer.rotate()
+
+	if offset, lines = buffer.Lines(0, 0); offset != 4 || len(lines) != 0 {
+		t.Errorf("expected a rotated buffer to hold no lines, got %+v (next offset %d)", lines, offset)
+	}
+
+	if previous := buffer.Previous(); previous != "line 0\r\nline 1\nline 2\nline 3\n" {
+		t.Errorf("expected the previous contents to be kept, got %q", previous)
+	}
+}
+
+// pluginOutputLine is roughly what a chatty plugin prints for every message acServer sends it.
+const pluginOutputLine = "2020-06-01 12:00:00.000 [INFO] car update: car_id=12 pos=(-123.456, 7.890, 456.789) vel=(12.3, 0.0, -45.6) gear=4 rpm=7345 normalized_spline_pos=0.4567\n"
+
+func BenchmarkLogBuffer_Write(b *testing.B) {
+	buffer := newLogBuffer(MaxLogSizeBytes)
+	line := []byte(pluginOutputLine)
+
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = buffer.Write(line)
+	}
+}
+
+func BenchmarkLogBuffer_WriteWithTail(b *testing.B) {
+	buffer := newLogBuffer(MaxLogSizeBytes)
+	line := []byte(pluginOutputLine)
+
+	sub, cancel := buffer.subscribe(0, func(backlog int) *logSubscriber {
+		return &logSubscriber{lines: make(chan TailLine, backlog+logTailBufferSize)}
+	})
+	defer cancel()
+
+	go func() {
+		for range sub.lines {
+		}
+	}()
+
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = buffer.Write(line)
+	}
+}
+
+// BenchmarkLogBuffer_PluginOutput writes a burst of plugin output through the same writers as a running plugin.
+func BenchmarkLogBuffer_PluginOutput(b *testing.B) {
+	plugins := newLogBuffer(MaxLogSizeBytes)
+	plugin := newLogBuffer(MaxLogSizeBytes)
+	w := io.MultiWriter(plugins, plugin)
+	burst := []byte(strings.Repeat(pluginOutputLine, 100))
+
+	b.SetBytes(int64(len(burst)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = w.Write(burst)
+	}
+}
+
+func BenchmarkLogBuffer_Lines(b *testing.B) {
+	buffer := newLogBuffer(MaxLogSizeBytes)
+
+	for buffer.held < MaxLogSizeBytes {
+		_, _ = buffer.Write([]byte(pluginOutputLine))
+	}
+
+	b.ReportAllocs()
+
+	var offset int64
+
+	// a client polling for new lines, which falls behind by one line each time.
+	for i := 0; i < b.N; i++ {
+		_, _ = buffer.Write([]byte(pluginOutputLine))
+		_, _ = buffer.Write([]byte(pluginOutputLine))
+
+		offset, _ = buffer.Lines(offset, 1)
+	}
+}
@@ -0,0 +1,186 @@
+package servermanager
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+// ServerLogEventType is the kind of thing acServer reported in a line of its output.
+type ServerLogEventType string
+
+const (
+	ServerLogEventClientConnected    ServerLogEventType = "client-connected"
+	ServerLogEventClientDisconnected ServerLogEventType = "client-disconnected"
+	ServerLogEventClientKicked       ServerLogEventType = "client-kicked"
+	ServerLogEventLobbyError         ServerLogEventType = "lobby-error"
+	ServerLogEventChecksumFailed     ServerLogEventType = "checksum-failed"
+)
+
+// EventServerLog is the udp.Event of a ServerLogEvent. acServer doesn't use it, and it is never sent over UDP.
+const EventServerLog udp.Event = 240
+
+// ServerLogEvent is something acServer only reports in its output, such as a driver failing a checksum. Server log
+// events are passed to the UDP callback and observers alongside the messages acServer sends over UDP, so that they
+// can be handled in the same place.
+type ServerLogEvent struct {
+	Type ServerLogEventType
+	Time time.Time
+
+	DriverName string `json:",omitempty"`
+	DriverGUID string `json:",omitempty"`
+	CarModel   string `json:",omitempty"`
+	Reason     string `json:",omitempty"`
+
+	// Line is the line of acServer output which the event was parsed from.
+	Line string
+}
+
+func (ServerLogEvent) Event() udp.Event {
+	return EventServerLog
+}
+
+// ServerLogEventRule turns lines of acServer output into ServerLogEvents. Pattern is a regular expression which may
+// contain the named groups 'name', 'guid', 'car' and 'reason'.
+type ServerLogEventRule struct {
+	Type    ServerLogEventType `yaml:"type"`
+	Pattern string             `yaml:"pattern"`
+}
+
+// defaultServerLogEventRules understand the output of the stock acServer. They are applied after any rules in
+// config.yml.
+var defaultServerLogEventRules = []*ServerLogEventRule{
+	{
+		Type:    ServerLogEventClientConnected,
+		Pattern: `^DRIVER: (?P<name>.+?)\s*\[.*\]\s*$`,
+	},
+	{
+		Type:    ServerLogEventClientDisconnected,
+		Pattern: `^Clean exit, driver disconnected:\s*(?P<name>.+?)\s*\[.*\]\s*$`,
+	},
+	{
+		Type:    ServerLogEventClientKicked,
+		Pattern: `^(?i)kick(?:ed|ing)?(?: user)?[:\s]+(?P<name>.+?)\s*(?:\[.*\])?\s*$`,
+	},
+	{
+		Type:    ServerLogEventChecksumFailed,
+		Pattern: `^(?i)(?P<reason>.*checksum.*(?:fail|mismatch).*)$`,
+	},
+	{
+		Type:    ServerLogEventLobbyError,
+		Pattern: `^(?i)(?P<reason>.*lobby.*(?:error|fail|not registered).*|error.*lobby.*)$`,
+	},
+}
+
+type compiledServerLogEventRule struct {
+	*ServerLogEventRule
+
+	regex *regexp.Regexp
+}
+
+// ServerLogEventParser parses lines of acServer output into ServerLogEvents using a list of ServerLogEventRules.
+// The first rule which matches a line is used.
+type ServerLogEventParser struct {
+	rules []*compiledServerLogEventRule
+}
+
+// NewServerLogEventParser creates a ServerLogEventParser which applies rules, followed by the default rules for the
+// stock acServer.
+func NewServerLogEventParser(rules []*ServerLogEventRule) (*ServerLogEventParser, error) {
+	p := &ServerLogEventParser{}
+
+	for _, rule := range append(append([]*ServerLogEventRule(nil), rules...), defaultServerLogEventRules...) {
+		if rule.Type == "" {
+			return nil, fmt.Errorf("servermanager: log event rule %q has no type", rule.Pattern)
+		}
+
+		regex, err := regexp.Compile(rule.Pattern)
+
+		if err != nil {
+			return nil, fmt.Errorf("servermanager: invalid log event rule pattern %q: %s", rule.Pattern, err)
+		}
+
+		p.rules = append(p.rules, &compiledServerLogEventRule{ServerLogEventRule: rule, regex: regex})
+	}
+
+	return p, nil
+}
+
+// Parse returns the event reported by line, if there is one.
+func (p *ServerLogEventParser) Parse(line string) (ServerLogEvent, bool) {
+	for _, rule := range p.rules {
+		match := rule.regex.FindStringSubmatch(line)
+
+		if match == nil {
+			continue
+		}
+
+		event := ServerLogEvent{
+			Type: rule.Type,
+			Time: time.Now(),
+			Line: line,
+		}
+
+		for i, name := range rule.regex.SubexpNames() {
+			switch name {
+			case "name":
+				event.DriverName = match[i]
+			case "guid":
+				event.DriverGUID = match[i]
+			case "car":
+				event.CarModel = match[i]
+			case "reason":
+				event.Reason = match[i]
+			}
+		}
+
+		return event, true
+	}
+
+	return ServerLogEvent{}, false
+}
+
+// configuredServerLogEventParser applies config.Server.LogEventRules, falling back to the defaults if they are
+// invalid.
+func configuredServerLogEventParser() *ServerLogEventParser {
+	var rules []*ServerLogEventRule
+
+	if config != nil {
+		rules = config.Server.LogEventRules
+	}
+
+	parser, err := NewServerLogEventParser(rules)
+
+	if err != nil {
+		logrus.WithError(err).Error("Could not use log event rules from config.yml, using defaults")
+
+		parser, _ = NewServerLogEventParser(nil)
+	}
+
+	return parser
+}
+
+// allLinesRegex matches every line, so that a log scanner rule can look at each line itself.
+var allLinesRegex = regexp.MustCompile(``)
+
+// addServerLogEventRule makes scanner pass the events it finds in acServer output to the observers and the UDP
+// callback. Unlike UDP messages, they are not forwarded to plugins.
+func (sp *AssettoServerProcess) addServerLogEventRule(scanner *logScanner) {
+	parser := configuredServerLogEventParser()
+
+	scanner.AddRule(allLinesRegex, func(line string) {
+		event, ok := parser.Parse(line)
+
+		if !ok {
+			return
+		}
+
+		logrus.Debugf("acServer reported %s: %s", event.Type, line)
+
+		sp.notifyObservers(event)
+		sp.callUDPCallback(event)
+	})
+}
@@ -0,0 +1,102 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestServerLogEventParser(t *testing.T) {
+	parser, err := NewServerLogEventParser([]*ServerLogEventRule{
+		{
+			Type:    ServerLogEventClientKicked,
+			Pattern: `^Kicked (?P<name>.+) \((?P<guid>[0-9]+)\): (?P<reason>.*)$`,
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		line     string
+		expected ServerLogEvent
+	}{
+		{
+			line:     "DRIVER: Jane Doe []",
+			expected: ServerLogEvent{Type: ServerLogEventClientConnected, DriverName: "Jane Doe"},
+		},
+		{
+			line:     "Clean exit, driver disconnected:  Jane Doe []",
+			expected: ServerLogEvent{Type: ServerLogEventClientDisconnected, DriverName: "Jane Doe"},
+		},
+		{
+			line:     "Kicked Jane Doe (76561198000000000): ping too high",
+			expected: ServerLogEvent{Type: ServerLogEventClientKicked, DriverName: "Jane Doe", DriverGUID: "76561198000000000", Reason: "ping too high"},
+		},
+		{
+			line:     "CHECKSUM FAILED for content/tracks/ks_silverstone/gp/models.ini",
+			expected: ServerLogEvent{Type: ServerLogEventChecksumFailed, Reason: "CHECKSUM FAILED for content/tracks/ks_silverstone/gp/models.ini"},
+		},
+		{
+			line:     "ERROR - RESTARTING LOBBY REGISTRATION",
+			expected: ServerLogEvent{Type: ServerLogEventLobbyError, Reason: "ERROR - RESTARTING LOBBY REGISTRATION"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		event, ok := parser.Parse(testCase.line)
+
+		if !ok {
+			t.Errorf("expected an event for %q", testCase.line)
+			continue
+		}
+
+		event.Time = time.Time{}
+		testCase.expected.Line = testCase.line
+
+		if event != testCase.expected {
+			t.Errorf("expected %+v for %q, got %+v", testCase.expected, testCase.line, event)
+		}
+	}
+
+	for _, line := range []string{"Lobby registration successful", "OK", "VERSION 202"} {
+		if event, ok := parser.Parse(line); ok {
+			t.Errorf("expected no event for %q, got %+v", line, event)
+		}
+	}
+
+	if _, err := NewServerLogEventParser([]*ServerLogEventRule{{Pattern: `^Kicked`}}); err == nil {
+		t.Error("expected a rule without a type to be rejected")
+	}
+
+	if _, err := NewServerLogEventParser([]*ServerLogEventRule{{Type: ServerLogEventClientKicked, Pattern: `(`}}); err == nil {
+		t.Error("expected a rule with an invalid pattern to be rejected")
+	}
+}
+
+func TestAssettoServerProcess_ServerLogEvents(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	events := make(chan ServerLogEvent, 10)
+
+	sp.callbackFunc = func(message udp.Message) {
+		if event, ok := message.(ServerLogEvent); ok {
+			events <- event
+		}
+	}
+
+	startTestServerProcess(t, sp, "#!/bin/sh\necho 'DRIVER: Jane Doe []'\nexec sleep 600\n")
+	defer sp.Stop() //nolint:errcheck
+
+	select {
+	case event := <-events:
+		if event.Type != ServerLogEventClientConnected || event.DriverName != "Jane Doe" {
+			t.Errorf("expected a client-connected event for Jane Doe, got %+v", event)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the UDP callback to receive an event parsed from the server log")
+	}
+}
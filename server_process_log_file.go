@@ -0,0 +1,299 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	serverLogFilePrefix          = "acServer_"
+	serverLogFileDefaultMaxSize  = 10
+	serverLogFileDefaultMaxFiles = 10
+)
+
+var logFileNameRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+var (
+	ErrServerLogFilesDisabled = errors.New("servermanager: server log files are not enabled, set a log_file directory in config.yml")
+	ErrServerLogFileNotFound  = errors.New("servermanager: server log file not found")
+)
+
+// rotatingLogFile is written to by acServer in place of the log buffer. Everything is passed on to the log buffer
+// as before, and also written to a file on disk which is rotated once it reaches maxSize. Only the newest maxFiles
+// files, and none older than maxAge, are kept in the directory.
+type rotatingLogFile struct {
+	next io.Writer
+
+	directory string
+	name      string
+	header    string
+	maxSize   int64
+	maxFiles  int
+	maxAge    time.Duration
+
+	mutex sync.Mutex
+	file  *os.File
+	size  int64
+	part  int
+}
+
+// newRotatingLogFile opens a log file for raceEvent in conf.Directory. Log files are named after the time the event
+// was started and the event's name, with each rotation numbered after that.
+func newRotatingLogFile(next io.Writer, conf LogFileConfig, raceEvent RaceEvent) (*rotatingLogFile, error) {
+	name := eventFileName(serverLogFilePrefix, raceEvent)
+
+	maxSize := conf.MaxSizeMB
+
+	if maxSize <= 0 {
+		maxSize = serverLogFileDefaultMaxSize
+	}
+
+	maxFiles := conf.MaxFiles
+
+	if maxFiles <= 0 {
+		maxFiles = serverLogFileDefaultMaxFiles
+	}
+
+	f := &rotatingLogFile{
+		next:      next,
+		directory: conf.Directory,
+		name:      name,
+		header:    fmt.Sprintf("Server Manager: %s - %s\n", raceEvent.EventName(), describeRaceEvent(raceEvent)),
+		maxSize:   int64(maxSize) * 1e6,
+		maxFiles:  maxFiles,
+		maxAge:    conf.MaxAge,
+	}
+
+	if err := os.MkdirAll(f.directory, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// eventFileName names a file for raceEvent after the time it was started and the event's name.
+func eventFileName(prefix string, raceEvent RaceEvent) string {
+	name := prefix + time.Now().Format("2006-01-02_15-04-05")
+
+	if eventName := strings.Trim(logFileNameRegex.ReplaceAllString(strings.ToLower(raceEvent.EventName()), "-"), "-"); eventName != "" {
+		name += "_" + eventName
+	}
+
+	return name
+}
+
+// Write passes p on to the log buffer, then writes it to the log file. Problems with the log file are logged rather
+// than returned, so that acServer's output is never interrupted by them.
+func (f *rotatingLogFile) Write(p []byte) (int, error) {
+	n, err := f.next.Write(p)
+
+	if err != nil {
+		return n, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return n, nil
+	}
+
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		f.part++
+
+		if err := f.file.Close(); err != nil {
+			f.fail(err)
+			return n, nil
+		}
+
+		if err := f.open(); err != nil {
+			f.fail(err)
+			return n, nil
+		}
+	}
+
+	written, err := f.file.Write(p)
+	f.size += int64(written)
+
+	if err != nil {
+		f.fail(err)
+	}
+
+	return n, nil
+}
+
+// open creates the next log file, then deletes the oldest log files in the directory. It must be called with
+// f.mutex held, or before the file is in use.
+func (f *rotatingLogFile) open() error {
+	name := f.name
+
+	if f.part > 0 {
+		name += fmt.Sprintf("_%03d", f.part)
+	}
+
+	file, err := os.Create(filepath.Join(f.directory, name+".log"))
+
+	if err != nil {
+		return err
+	}
+
+	f.file = file
+	f.size = 0
+
+	// the header isn't counted towards the size of the file, so that there is always room for acServer's output.
+	if _, err := io.WriteString(file, f.header); err != nil {
+		return err
+	}
+
+	if err := f.deleteOldFiles(); err != nil {
+		logrus.WithError(err).Warnf("Could not delete old server log files in %s", f.directory)
+	}
+
+	return nil
+}
+
+func (f *rotatingLogFile) deleteOldFiles() error {
+	logFiles, err := listServerLogFiles(f.directory)
+
+	if err != nil {
+		return err
+	}
+
+	for i, file := range logFiles {
+		// the file which has just been opened is always kept.
+		if i == 0 || (i < f.maxFiles && (f.maxAge <= 0 || time.Since(file.ModTime()) < f.maxAge)) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(f.directory, file.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listServerLogFiles returns the server log files in directory, newest first.
+func listServerLogFiles(directory string) ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(directory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var logFiles []os.FileInfo
+
+	for _, file := range files {
+		if isServerLogFile(file.Name()) && !file.IsDir() {
+			logFiles = append(logFiles, file)
+		}
+	}
+
+	sort.Slice(logFiles, func(i, j int) bool {
+		if logFiles[i].ModTime().Equal(logFiles[j].ModTime()) {
+			// names start with the time the event was started, then the rotation number.
+			return logFiles[i].Name() > logFiles[j].Name()
+		}
+
+		return logFiles[i].ModTime().After(logFiles[j].ModTime())
+	})
+
+	return logFiles, nil
+}
+
+func isServerLogFile(name string) bool {
+	return strings.HasPrefix(name, serverLogFilePrefix) && filepath.Ext(name) == ".log" && filepath.Base(name) == name
+}
+
+// fail stops writing to the log file after an error. It must be called with f.mutex held.
+func (f *rotatingLogFile) fail(err error) {
+	logrus.WithError(err).Errorf("Could not write to server log file in %s, no longer writing to it", f.directory)
+
+	if f.file != nil {
+		_ = f.file.Close()
+	}
+
+	f.file = nil
+}
+
+func (f *rotatingLogFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
+
+// ServerLogFile describes a server log file on disk.
+type ServerLogFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// ServerLogFiles lists the server log files which have been kept on disk, newest first.
+func (sp *AssettoServerProcess) ServerLogFiles() ([]ServerLogFile, error) {
+	conf := sp.logFileConfig()
+
+	if conf.Directory == "" {
+		return nil, ErrServerLogFilesDisabled
+	}
+
+	files, err := listServerLogFiles(conf.Directory)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	logFiles := make([]ServerLogFile, 0, len(files))
+
+	for _, file := range files {
+		logFiles = append(logFiles, ServerLogFile{Name: file.Name(), Size: file.Size(), ModTime: file.ModTime()})
+	}
+
+	return logFiles, nil
+}
+
+// OpenServerLogFile opens the server log file called name, as listed by ServerLogFiles.
+func (sp *AssettoServerProcess) OpenServerLogFile(name string) (*os.File, error) {
+	conf := sp.logFileConfig()
+
+	if conf.Directory == "" {
+		return nil, ErrServerLogFilesDisabled
+	}
+
+	if !isServerLogFile(name) {
+		return nil, ErrServerLogFileNotFound
+	}
+
+	f, err := os.Open(filepath.Join(conf.Directory, name))
+
+	if os.IsNotExist(err) {
+		return nil, ErrServerLogFileNotFound
+	}
+
+	return f, err
+}
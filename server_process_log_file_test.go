@@ -0,0 +1,209 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-log-file")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// files which aren't server logs are left alone
+	if err := ioutil.WriteFile(filepath.Join(dir, "other.log"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := newLogBuffer(MaxLogSizeBytes)
+
+	raceEvent := QuickRace{RaceConfig: CurrentRaceConfig{Track: "ks_silverstone", TrackLayout: "gp"}}
+
+	logFile, err := newRotatingLogFile(buffer, LogFileConfig{Directory: dir, MaxFiles: 3}, raceEvent)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logFile.maxSize = 100
+
+	line := strings.Repeat("x", 39) + "\n"
+
+	for i := 0; i < 20; i++ {
+		if _, err := logFile.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := logFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if logs := buffer.String(); logs != strings.Repeat(line, 20) {
+		t.Errorf("expected every line to be written to the log buffer, got: %q", logs)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+
+	sort.Strings(names)
+
+	if len(names) != 4 || names[3] != "other.log" {
+		t.Fatalf("expected the three newest log files to be kept, got: %v", names)
+	}
+
+	for _, name := range names[:3] {
+		if !strings.HasPrefix(name, serverLogFilePrefix) || !strings.Contains(name, "_silverstone-gp") {
+			t.Errorf("expected log file name to include the event name, got: %s", name)
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(data) > 100+len(logFile.header) {
+			t.Errorf("expected log file to be rotated once it reached its max size, %s is %d bytes", name, len(data))
+		}
+
+		if !strings.Contains(string(data), describeRaceEvent(raceEvent)) {
+			t.Errorf("expected log file to start with a description of the event, got: %q", data)
+		}
+	}
+
+	if !strings.HasSuffix(names[2], "_009.log") {
+		t.Errorf("expected the last log file to be the tenth, got: %s", names[2])
+	}
+}
+
+func TestRotatingLogFile_MaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-log-file")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldFile := filepath.Join(dir, serverLogFilePrefix+"2019-01-01_00-00-00_000.log")
+	recentFile := filepath.Join(dir, serverLogFilePrefix+"2019-01-02_00-00-00_000.log")
+
+	for _, name := range []string{oldFile, recentFile} {
+		if err := ioutil.WriteFile(name, []byte("log\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Chtimes(oldFile, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(recentFile, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	logFile, err := newRotatingLogFile(ioutil.Discard, LogFileConfig{Directory: dir, MaxFiles: 10, MaxAge: 24 * time.Hour}, QuickRace{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer logFile.Close()
+
+	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Errorf("expected the log file older than max age to be deleted, got: %v", err)
+	}
+
+	if _, err := os.Stat(recentFile); err != nil {
+		t.Errorf("expected the log file newer than max age to be kept, got: %v", err)
+	}
+}
+
+func TestAssettoServerProcess_ServerLogFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-log-file")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	if _, err := sp.ServerLogFiles(); err != ErrServerLogFilesDisabled {
+		t.Errorf("expected log files to be disabled without a directory, got: %v", err)
+	}
+
+	config.Server.LogFile.Directory = dir
+
+	names := []string{serverLogFilePrefix + "2019-01-01_00-00-00_000.log", serverLogFilePrefix + "2019-01-02_00-00-00_000.log"}
+
+	for i, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		modTime := time.Now().Add(time.Duration(i-len(names)) * time.Minute)
+
+		if err := os.Chtimes(filepath.Join(dir, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "other.log"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	logFiles, err := sp.ServerLogFiles()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logFiles) != 2 || logFiles[0].Name != names[1] || logFiles[1].Name != names[0] {
+		t.Fatalf("expected the server log files, newest first, got: %+v", logFiles)
+	}
+
+	f, err := sp.OpenServerLogFile(names[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(f)
+	_ = f.Close()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != names[0] {
+		t.Errorf("expected to read the log file, got: %q", data)
+	}
+
+	for _, name := range []string{"other.log", "../" + names[0], serverLogFilePrefix + "missing.log"} {
+		if _, err := sp.OpenServerLogFile(name); err != ErrServerLogFileNotFound {
+			t.Errorf("expected %s not to be found, got: %v", name, err)
+		}
+	}
+}
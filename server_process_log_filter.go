@@ -0,0 +1,100 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidLogLevel = errors.New("servermanager: log level must be one of debug, info, warning or error")
+
+// logLevelSeverity orders the levels used by Server Manager, from least to most severe.
+var logLevelSeverity = map[string]int{
+	LogLevelDebug:   0,
+	LogLevelInfo:    1,
+	LogLevelWarning: 2,
+	LogLevelError:   3,
+}
+
+// LogFilter selects lines of acServer output for FilteredLogs. Its zero value selects every line.
+type LogFilter struct {
+	// Match only selects lines which contain it. If Regex is set, Match is a regular expression instead.
+	Match string
+	Regex bool
+
+	// MinLevel only selects lines which are classified by the log parsing rules at this level or above. Any of the
+	// level names used by acServer forks can be used, e.g. "wrn".
+	MinLevel string
+
+	// MaxLines only selects the most recent MaxLines lines which match the rest of the filter.
+	MaxLines int
+}
+
+// matcher returns a function which reports whether a line contains the filter's Match.
+func (f LogFilter) matcher() (func(line string) bool, error) {
+	if f.Match == "" {
+		return func(string) bool { return true }, nil
+	}
+
+	if !f.Regex {
+		return func(line string) bool { return strings.Contains(line, f.Match) }, nil
+	}
+
+	regex, err := regexp.Compile(f.Match)
+
+	if err != nil {
+		return nil, fmt.Errorf("servermanager: invalid log filter pattern %q: %s", f.Match, err)
+	}
+
+	return regex.MatchString, nil
+}
+
+// FilteredLogs returns the lines of acServer output selected by filter, unchanged and in the order they were written.
+func (sp *AssettoServerProcess) FilteredLogs(filter LogFilter) (string, error) {
+	match, err := filter.matcher()
+
+	if err != nil {
+		return "", err
+	}
+
+	minSeverity := 0
+
+	if filter.MinLevel != "" {
+		level, ok := logLevelAliases[strings.ToLower(filter.MinLevel)]
+
+		if !ok {
+			return "", ErrInvalidLogLevel
+		}
+
+		minSeverity = logLevelSeverity[level]
+	}
+
+	parser := configuredLogParser()
+
+	var lines []string
+
+	for _, line := range strings.Split(sp.Logs(), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if line == "" || !match(line) {
+			continue
+		}
+
+		if minSeverity > 0 && logLevelSeverity[parser.Parse(line).Level] < minSeverity {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if filter.MaxLines > 0 && len(lines) > filter.MaxLines {
+		lines = lines[len(lines)-filter.MaxLines:]
+	}
+
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
@@ -0,0 +1,89 @@
+package servermanager
+
+import (
+	"testing"
+)
+
+func TestAssettoServerProcess_FilteredLogs(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	output := "Assetto Corsa Dedicated Server v1.16\r\n" +
+		"CHAT [Driver 1]: hello\n" +
+		"WARNING: Car 3 has no skin\n" +
+		"CHAT [Driver 2]: hi\n" +
+		"ERROR: Cannot bind TCP port 9600\n" +
+		"ERROR: Plugin disconnected\n"
+
+	_, _ = sp.logBuffer.Write([]byte(output))
+
+	for _, testCase := range []struct {
+		name     string
+		filter   LogFilter
+		expected string
+	}{
+		{
+			name:     "No filter",
+			filter:   LogFilter{},
+			expected: "Assetto Corsa Dedicated Server v1.16\nCHAT [Driver 1]: hello\nWARNING: Car 3 has no skin\nCHAT [Driver 2]: hi\nERROR: Cannot bind TCP port 9600\nERROR: Plugin disconnected\n",
+		},
+		{
+			name:     "Substring",
+			filter:   LogFilter{Match: "CHAT"},
+			expected: "CHAT [Driver 1]: hello\nCHAT [Driver 2]: hi\n",
+		},
+		{
+			name:     "Regex",
+			filter:   LogFilter{Match: `Driver \d\]: h[a-z]+$`, Regex: true},
+			expected: "CHAT [Driver 1]: hello\nCHAT [Driver 2]: hi\n",
+		},
+		{
+			name:     "Minimum level",
+			filter:   LogFilter{MinLevel: "wrn"},
+			expected: "WARNING: Car 3 has no skin\nERROR: Cannot bind TCP port 9600\nERROR: Plugin disconnected\n",
+		},
+		{
+			name:     "Most recent lines",
+			filter:   LogFilter{MinLevel: LogLevelWarning, MaxLines: 2},
+			expected: "ERROR: Cannot bind TCP port 9600\nERROR: Plugin disconnected\n",
+		},
+		{
+			name:     "Combined",
+			filter:   LogFilter{Match: "port", MinLevel: LogLevelError},
+			expected: "ERROR: Cannot bind TCP port 9600\n",
+		},
+		{
+			name:     "No matches",
+			filter:   LogFilter{Match: "nothing like this"},
+			expected: "",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			logs, err := sp.FilteredLogs(testCase.filter)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if logs != testCase.expected {
+				t.Errorf("expected %q, got %q", testCase.expected, logs)
+			}
+		})
+	}
+
+	t.Run("Invalid regex", func(t *testing.T) {
+		if _, err := sp.FilteredLogs(LogFilter{Match: "(unclosed", Regex: true}); err == nil {
+			t.Error("expected an invalid pattern to return an error")
+		}
+	})
+
+	t.Run("Invalid level", func(t *testing.T) {
+		if _, err := sp.FilteredLogs(LogFilter{MinLevel: "loud"}); err != ErrInvalidLogLevel {
+			t.Errorf("expected ErrInvalidLogLevel, got: %v", err)
+		}
+	})
+
+	if logs := sp.Logs(); logs != output {
+		t.Errorf("expected the log buffer to be left unchanged, got: %q", logs)
+	}
+}
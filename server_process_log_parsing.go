@@ -0,0 +1,189 @@
+package servermanager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LogLevelDebug   = "debug"
+	LogLevelInfo    = "info"
+	LogLevelWarning = "warning"
+	LogLevelError   = "error"
+
+	LogStreamStdout = "stdout"
+	LogStreamStderr = "stderr"
+)
+
+// LogLine is a single line of acServer output, classified by the first LogParsingRule which matched it.
+type LogLine struct {
+	Time    time.Time
+	Level   string
+	Stream  string
+	Message string
+}
+
+// LogParsingRule classifies lines of acServer output. Pattern is a regular expression which may contain the named
+// groups 'time', 'level', 'stream' and 'message'. Level and Stream are used when the pattern doesn't capture them.
+type LogParsingRule struct {
+	Pattern    string `yaml:"pattern"`
+	TimeFormat string `yaml:"time_format"`
+	Level      string `yaml:"level"`
+	Stream     string `yaml:"stream"`
+}
+
+// defaultLogParsingRules classify the output of the stock acServer, which has no timestamps and only marks
+// errors and warnings with a prefix. They are applied after any rules in config.yml.
+var defaultLogParsingRules = []*LogParsingRule{
+	{
+		Pattern: `^(?i)(?P<level>error|warning)\s*[:!-]?\s*(?P<message>.*)$`,
+	},
+	{
+		Pattern: `^(?P<message>.*)$`,
+		Level:   LogLevelInfo,
+	},
+}
+
+// logLevelAliases maps the level names used by acServer forks to the levels used by Server Manager.
+var logLevelAliases = map[string]string{
+	"dbg":     LogLevelDebug,
+	"debug":   LogLevelDebug,
+	"vrb":     LogLevelDebug,
+	"verbose": LogLevelDebug,
+	"inf":     LogLevelInfo,
+	"info":    LogLevelInfo,
+	"wrn":     LogLevelWarning,
+	"warn":    LogLevelWarning,
+	"warning": LogLevelWarning,
+	"err":     LogLevelError,
+	"error":   LogLevelError,
+	"ftl":     LogLevelError,
+	"fatal":   LogLevelError,
+}
+
+type compiledLogParsingRule struct {
+	*LogParsingRule
+
+	regex *regexp.Regexp
+}
+
+// LogParser splits acServer output into LogLines using a list of LogParsingRules, applied in order.
+type LogParser struct {
+	rules []*compiledLogParsingRule
+}
+
+// NewLogParser creates a LogParser which applies rules, followed by the default rules for the stock acServer.
+func NewLogParser(rules []*LogParsingRule) (*LogParser, error) {
+	lp := &LogParser{}
+
+	for _, rule := range append(append([]*LogParsingRule(nil), rules...), defaultLogParsingRules...) {
+		regex, err := regexp.Compile(rule.Pattern)
+
+		if err != nil {
+			return nil, fmt.Errorf("servermanager: invalid log parsing rule pattern %q: %s", rule.Pattern, err)
+		}
+
+		lp.rules = append(lp.rules, &compiledLogParsingRule{LogParsingRule: rule, regex: regex})
+	}
+
+	return lp, nil
+}
+
+// Parse classifies a single line of output.
+func (lp *LogParser) Parse(line string) LogLine {
+	for _, rule := range lp.rules {
+		match := rule.regex.FindStringSubmatch(line)
+
+		if match == nil {
+			continue
+		}
+
+		logLine := LogLine{
+			Level:   rule.Level,
+			Stream:  rule.Stream,
+			Message: line,
+		}
+
+		for i, name := range rule.regex.SubexpNames() {
+			if match[i] == "" {
+				continue
+			}
+
+			switch name {
+			case "time":
+				if rule.TimeFormat == "" {
+					continue
+				}
+
+				if t, err := time.Parse(rule.TimeFormat, match[i]); err == nil {
+					logLine.Time = t
+				}
+			case "level":
+				logLine.Level = match[i]
+			case "stream":
+				logLine.Stream = match[i]
+			case "message":
+				logLine.Message = match[i]
+			}
+		}
+
+		if level, ok := logLevelAliases[strings.ToLower(logLine.Level)]; ok {
+			logLine.Level = level
+		} else if logLine.Level == "" {
+			logLine.Level = LogLevelInfo
+		}
+
+		if logLine.Stream == "" {
+			logLine.Stream = LogStreamStdout
+		}
+
+		return logLine
+	}
+
+	return LogLine{Level: LogLevelInfo, Stream: LogStreamStdout, Message: line}
+}
+
+// ParseLines classifies each non-empty line of output.
+func (lp *LogParser) ParseLines(output string) []LogLine {
+	var lines []LogLine
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if line == "" {
+			continue
+		}
+
+		lines = append(lines, lp.Parse(line))
+	}
+
+	return lines
+}
+
+// configuredLogParser applies config.Server.LogParsingRules, falling back to the defaults if they are invalid.
+func configuredLogParser() *LogParser {
+	var rules []*LogParsingRule
+
+	if config != nil {
+		rules = config.Server.LogParsingRules
+	}
+
+	parser, err := NewLogParser(rules)
+
+	if err != nil {
+		logrus.WithError(err).Error("Could not use log parsing rules from config.yml, using defaults")
+
+		parser, _ = NewLogParser(nil)
+	}
+
+	return parser
+}
+
+// LogsJSON returns the acServer output classified by config.Server.LogParsingRules.
+func (sp *AssettoServerProcess) LogsJSON() []LogLine {
+	return configuredLogParser().ParseLines(sp.Logs())
+}
@@ -0,0 +1,117 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogParser(t *testing.T) {
+	forkRules := []*LogParsingRule{
+		{
+			Pattern:    `^\[(?P<time>[0-9-]+ [0-9:]+) (?P<level>[A-Z]{3})\] (?P<message>.*)$`,
+			TimeFormat: "2006-01-02 15:04:05",
+		},
+		{
+			Pattern: `^Unhandled exception: (?P<message>.*)$`,
+			Level:   LogLevelError,
+			Stream:  LogStreamStderr,
+		},
+	}
+
+	testCases := []struct {
+		name  string
+		rules []*LogParsingRule
+		line  string
+
+		expected LogLine
+	}{
+		{
+			name:     "Stock server, plain line",
+			line:     "Assetto Corsa Dedicated Server v1.16",
+			expected: LogLine{Level: LogLevelInfo, Stream: LogStreamStdout, Message: "Assetto Corsa Dedicated Server v1.16"},
+		},
+		{
+			name:     "Stock server, error line",
+			line:     "ERROR: Cannot bind TCP port 9600",
+			expected: LogLine{Level: LogLevelError, Stream: LogStreamStdout, Message: "Cannot bind TCP port 9600"},
+		},
+		{
+			name:     "Stock server, warning line",
+			line:     "Warning! Track checksum mismatch",
+			expected: LogLine{Level: LogLevelWarning, Stream: LogStreamStdout, Message: "Track checksum mismatch"},
+		},
+		{
+			name:  "Fork rules, timestamped line",
+			rules: forkRules,
+			line:  "[2020-01-02 15:04:05 WRN] Client timed out",
+			expected: LogLine{
+				Time:    time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC),
+				Level:   LogLevelWarning,
+				Stream:  LogStreamStdout,
+				Message: "Client timed out",
+			},
+		},
+		{
+			name:     "Fork rules, fixed level and stream",
+			rules:    forkRules,
+			line:     "Unhandled exception: NullReferenceException",
+			expected: LogLine{Level: LogLevelError, Stream: LogStreamStderr, Message: "NullReferenceException"},
+		},
+		{
+			name:     "Fork rules fall back to defaults",
+			rules:    forkRules,
+			line:     "ERROR: Plugin disconnected",
+			expected: LogLine{Level: LogLevelError, Stream: LogStreamStdout, Message: "Plugin disconnected"},
+		},
+		{
+			name: "Rules are applied in order",
+			rules: []*LogParsingRule{
+				{Pattern: `^ERROR: (?P<message>Plugin.*)$`, Level: LogLevelDebug},
+			},
+			line:     "ERROR: Plugin disconnected",
+			expected: LogLine{Level: LogLevelDebug, Stream: LogStreamStdout, Message: "Plugin disconnected"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			parser, err := NewLogParser(testCase.rules)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			line := parser.Parse(testCase.line)
+
+			if line != testCase.expected {
+				t.Errorf("expected %+v, got %+v", testCase.expected, line)
+			}
+		})
+	}
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		_, err := NewLogParser([]*LogParsingRule{{Pattern: `^(?P<message>.*`}})
+
+		if err == nil {
+			t.Error("expected invalid pattern to return an error")
+		}
+	})
+
+	t.Run("Parse lines", func(t *testing.T) {
+		parser, err := NewLogParser(nil)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := parser.ParseLines("Assetto Corsa Dedicated Server v1.16\r\n\nERROR: Cannot bind TCP port 9600\n")
+
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %d", len(lines))
+		}
+
+		if lines[1].Level != LogLevelError {
+			t.Errorf("expected second line to be an error, got: %s", lines[1].Level)
+		}
+	})
+}
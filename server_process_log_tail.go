@@ -0,0 +1,153 @@
+package servermanager
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logTailBufferSize is how many new lines a Tail subscriber can fall behind by before lines are dropped for it.
+const logTailBufferSize = 256
+
+// TailLine is a line of the server log, with its offset. Offsets count every line acServer has written since Server
+// Manager started, so they carry on across restarts of acServer and can be used to resume a tail.
+type TailLine struct {
+	Offset int64
+	Line   string
+}
+
+// logSubscriber receives lines on either ch, or on lines with their offsets.
+type logSubscriber struct {
+	ch      chan string
+	lines   chan TailLine
+	dropped int
+}
+
+// send passes line on to the subscriber without blocking, it returns false if the subscriber's buffer is full.
+func (s *logSubscriber) send(line TailLine) bool {
+	if s.lines != nil {
+		select {
+		case s.lines <- line:
+			return true
+		default:
+			return false
+		}
+	}
+
+	select {
+	case s.ch <- line.Line:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *logSubscriber) close() {
+	if s.lines != nil {
+		close(s.lines)
+	} else {
+		close(s.ch)
+	}
+}
+
+// Tail streams the server log line by line. The channel first receives the lines already in the log buffer, then
+// each new line as acServer writes it. Lines are never queued beyond the channel's buffer: a consumer which falls
+// too far behind misses lines rather than holding up acServer. The returned func unsubscribes and closes the
+// channel, it is safe to call more than once.
+func (sp *AssettoServerProcess) Tail() (<-chan string, func()) {
+	sub, cancel := sp.logBuffer.subscribe(0, func(backlog int) *logSubscriber {
+		return &logSubscriber{ch: make(chan string, backlog+logTailBufferSize)}
+	})
+
+	return sub.ch, cancel
+}
+
+// TailFrom is like Tail, but each line comes with its offset, and the lines already in the log buffer are only sent
+// from offset onwards. A consumer which has seen up to offset n can resume with TailFrom(n+1) without being sent the
+// lines it has already seen. Lines which have been dropped show up as a gap in the offsets.
+func (sp *AssettoServerProcess) TailFrom(offset int64) (<-chan TailLine, func()) {
+	sub, cancel := sp.logBuffer.subscribe(offset, func(backlog int) *logSubscriber {
+		return &logSubscriber{lines: make(chan TailLine, backlog+logTailBufferSize)}
+	})
+
+	return sub.lines, cancel
+}
+
+// LogLines returns up to max lines of the server log from offset onwards, or all of them if max is zero, and the
+// offset to pass to the next call. Clients which poll rather than hold a Tail open can use it to fetch only the lines
+// they haven't seen.
+func (sp *AssettoServerProcess) LogLines(offset int64, max int) (int64, []TailLine) {
+	return sp.logBuffer.Lines(offset, max)
+}
+
+// subscribe creates a subscriber with newSubscriber, which is given the length of the backlog so that the
+// subscriber's buffer can hold all of it. The subscriber is sent the backlog from offset onwards, then each new line.
+// The returned func unsubscribes it and closes its channel.
+func (lb *logBuffer) subscribe(offset int64, newSubscriber func(backlog int) *logSubscriber) (*logSubscriber, func()) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	_, backlog := lb.linesFrom(offset, 0)
+	sub := newSubscriber(len(backlog))
+
+	for _, line := range backlog {
+		sub.send(line)
+	}
+
+	if lb.subscribers == nil {
+		lb.subscribers = make(map[*logSubscriber]bool)
+	}
+
+	lb.subscribers[sub] = true
+
+	var once sync.Once
+
+	return sub, func() {
+		once.Do(func() {
+			lb.mutex.Lock()
+			defer lb.mutex.Unlock()
+
+			delete(lb.subscribers, sub)
+			sub.close()
+		})
+	}
+}
+
+// publishLines indexes each complete line in p and passes it on to the subscribers. It must be called with lb.mutex held.
+func (lb *logBuffer) publishLines(p []byte) {
+	lb.partial = append(lb.partial, p...)
+
+	for {
+		i := bytes.IndexByte(lb.partial, '\n')
+
+		if i < 0 {
+			break
+		}
+
+		start := lb.partialStart
+		line := strings.TrimRight(string(lb.partial[:i]), "\r")
+		lb.partial = lb.partial[i+1:]
+		lb.partialStart += int64(i + 1)
+
+		if line == "" {
+			continue
+		}
+
+		lb.addSpan(logSpan{start: start, end: start + int64(len(line))})
+
+		logLine := TailLine{Offset: lb.lines, Line: line}
+		lb.lines++
+
+		for sub := range lb.subscribers {
+			if !sub.send(logLine) {
+				sub.dropped++
+
+				if sub.dropped == 1 {
+					logrus.Warn("Server log tail is not keeping up, dropping lines")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package servermanager
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logScanner is an io.Writer which splits acServer output into lines and passes each complete line to any
+// rules whose pattern matches it. It allows the manager to react to things that acServer only reports in its logs.
+type logScanner struct {
+	mutex   sync.Mutex
+	partial []byte
+	rules   []*logScanRule
+}
+
+type logScanRule struct {
+	pattern *regexp.Regexp
+	fn      func(line string)
+}
+
+func newLogScanner() *logScanner {
+	return &logScanner{}
+}
+
+func (ls *logScanner) AddRule(pattern *regexp.Regexp, fn func(line string)) {
+	ls.mutex.Lock()
+	defer ls.mutex.Unlock()
+
+	ls.rules = append(ls.rules, &logScanRule{pattern: pattern, fn: fn})
+}
+
+func (ls *logScanner) Write(p []byte) (n int, err error) {
+	ls.mutex.Lock()
+	ls.partial = append(ls.partial, p...)
+
+	var lines []string
+
+	for {
+		i := bytes.IndexByte(ls.partial, '\n')
+
+		if i < 0 {
+			break
+		}
+
+		lines = append(lines, strings.TrimRight(string(ls.partial[:i]), "\r"))
+		ls.partial = ls.partial[i+1:]
+	}
+
+	rules := ls.rules
+	ls.mutex.Unlock()
+
+	for _, line := range lines {
+		for _, rule := range rules {
+			if rule.pattern.MatchString(line) {
+				rule.fn(line)
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// gamePortInUseRegex matches the messages acServer prints when its TCP, UDP or HTTP port is already bound, which name
+// the port that couldn't be bound.
+var gamePortInUseRegex = regexp.MustCompile(`(?i)((cannot|failed to) bind (TCP|UDP|HTTP) port \d+|(TCP|UDP|HTTP) port \d+.*(address already in use|only one usage of each socket address))`)
+
+// ErrGamePortInUse is returned when acServer reports that it could not bind one of its game ports.
+type ErrGamePortInUse struct {
+	Line string
+}
+
+func (e ErrGamePortInUse) Error() string {
+	return fmt.Sprintf("servermanager: acServer could not bind its game ports, check that no other server is using them (%s)", e.Line)
+}
+
+func (sp *AssettoServerProcess) newStartupLogScanner(readiness *startupReadiness) *logScanner {
+	scanner := newLogScanner()
+
+	scanner.AddRule(serverReadyRegex, func(string) {
+		readiness.finish(true)
+	})
+
+	scanner.AddRule(serverVersionRegex, sp.setServerVersion)
+
+	scanner.AddRule(lobbyRegistrationRegex, func(string) {
+		atomic.StoreInt32(&sp.lobbyRegistered, 1)
+	})
+
+	scanner.AddRule(gamePortInUseRegex, func(line string) {
+		select {
+		case <-readiness.done:
+			// acServer binds its game ports as it starts, so anything similar printed once it is ready (or after it
+			// has already failed to start) isn't a bind failure.
+			return
+		default:
+		}
+
+		err := ErrGamePortInUse{Line: line}
+
+		sp.mutex.Lock()
+		sp.startupErr = err
+		sp.mutex.Unlock()
+
+		if config.Server.IgnoreGamePortInUse {
+			logrus.WithError(err).Warn("acServer reported that a game port is in use. Continuing as ignore_game_port_in_use is set")
+			return
+		}
+
+		logrus.WithError(err).Error("acServer failed to start. Stopping server process")
+
+		readiness.finish(false)
+
+		// the scanner is called from the acServer output pipe, stopping must happen outside of it
+		// so that the process can be waited on.
+		go func() {
+			if err := sp.Stop(); err != nil {
+				logrus.WithError(err).Error("Could not stop server process after game port bind failure")
+			}
+		}()
+	})
+
+	sp.addServerLogEventRule(scanner)
+
+	return scanner
+}
+
+// StartupError returns the most recent error acServer reported while starting, if any.
+func (sp *AssettoServerProcess) StartupError() error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	return sp.startupErr
+}
@@ -0,0 +1,168 @@
+package servermanager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MaintenanceWindow is a recurring time at which acServer is restarted, and optionally updated with steamcmd. The
+// running event is started again once the window is over. Windows are skipped while a race session is live.
+type MaintenanceWindow struct {
+	// At is the local time of day the window starts, e.g. "04:00".
+	At string `yaml:"at"`
+
+	// Days are the days of the week the window runs on, e.g. ["monday", "thursday"]. The window runs every day if
+	// Days is empty.
+	Days []string `yaml:"days"`
+
+	// SteamUpdate updates acServer with steamcmd, using the steam login from config.yml, while it is stopped.
+	SteamUpdate bool `yaml:"steam_update"`
+}
+
+type maintenanceWindow struct {
+	MaintenanceWindow
+
+	hour, minute int
+	days         map[time.Weekday]bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func parseMaintenanceWindow(w MaintenanceWindow) (*maintenanceWindow, error) {
+	at, err := time.Parse("15:04", w.At)
+
+	if err != nil {
+		return nil, fmt.Errorf("servermanager: maintenance window time %q must be given as HH:MM", w.At)
+	}
+
+	window := &maintenanceWindow{
+		MaintenanceWindow: w,
+		hour:              at.Hour(),
+		minute:            at.Minute(),
+	}
+
+	for _, day := range w.Days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+
+		if !ok {
+			return nil, fmt.Errorf("servermanager: maintenance window day %q is not a day of the week", day)
+		}
+
+		if window.days == nil {
+			window.days = make(map[time.Weekday]bool)
+		}
+
+		window.days[weekday] = true
+	}
+
+	return window, nil
+}
+
+// next returns the first time the window starts after now.
+func (w *maintenanceWindow) next(now time.Time) time.Time {
+	for i := 0; ; i++ {
+		day := now.AddDate(0, 0, i)
+		start := time.Date(day.Year(), day.Month(), day.Day(), w.hour, w.minute, 0, 0, now.Location())
+
+		if start.After(now) && (w.days == nil || w.days[start.Weekday()]) {
+			return start
+		}
+	}
+}
+
+// WithMaintenanceWindows restarts acServer at each of windows. Steam updates always update the server in
+// ServerInstallPath, so they should only be used by the default server.
+func WithMaintenanceWindows(windows []MaintenanceWindow) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.MaintenanceWindows = windows
+	}
+}
+
+// updateAssettoServer updates acServer with steamcmd during a maintenance window.
+var updateAssettoServer = func() error {
+	return InstallAssettoCorsaServer(config.Steam.Username, config.Steam.Password, true)
+}
+
+// scheduleMaintenance runs each of windows as it comes round.
+func (sp *AssettoServerProcess) scheduleMaintenance(windows []*maintenanceWindow) {
+	for {
+		now := time.Now()
+
+		var window *maintenanceWindow
+		var start time.Time
+
+		for _, w := range windows {
+			if next := w.next(now); window == nil || next.Before(start) {
+				window, start = w, next
+			}
+		}
+
+		logrus.Debugf("Next maintenance window starts at %s", start.Format(time.RFC3339))
+
+		time.Sleep(start.Sub(now))
+
+		sp.runMaintenance(window, updateAssettoServer)
+	}
+}
+
+// runMaintenance stops acServer, updates it if the window asks for it, and starts the event which was running again.
+func (sp *AssettoServerProcess) runMaintenance(window *maintenanceWindow, update func() error) {
+	sp.mutex.Lock()
+	raceEvent := sp.raceEvent
+	raceLive := raceEvent != nil && sp.raceFinish.isRace && !sp.raceFinish.ended
+	udpPluginAddress := sp.udpPluginAddress
+	udpLocalPluginPort := sp.udpPluginLocalPort
+	forwardingAddress := sp.forwardingAddress
+	forwardListenPort := sp.forwardListenPort
+	sp.mutex.Unlock()
+
+	var eventName string
+
+	if raceEvent != nil {
+		eventName = raceEvent.EventName()
+	}
+
+	if raceLive {
+		logrus.Infof("Skipping the %s maintenance window, a race session is live", window.At)
+		sp.emit(ProcessEvent{Type: ProcessEventMaintenanceSkipped, EventName: eventName, RaceEvent: raceEvent})
+		return
+	}
+
+	logrus.Infof("Starting the %s maintenance window", window.At)
+	sp.emit(ProcessEvent{Type: ProcessEventMaintenance, EventName: eventName, RaceEvent: raceEvent})
+
+	if window.SteamUpdate {
+		if raceEvent != nil {
+			if err := sp.Stop(); err != nil {
+				logrus.WithError(err).Error("Could not stop server process for maintenance")
+				return
+			}
+		}
+
+		logrus.Info("Updating acServer with steamcmd")
+
+		if err := update(); err != nil {
+			// the event is still started again, on the version of acServer which was installed.
+			logrus.WithError(err).Error("Could not update acServer during maintenance")
+		}
+	}
+
+	if raceEvent == nil {
+		return
+	}
+
+	if err := sp.Start(raceEvent, udpPluginAddress, udpLocalPluginPort, forwardingAddress, forwardListenPort); err != nil {
+		logrus.WithError(err).Error("Could not start the event again after maintenance")
+	}
+}
@@ -0,0 +1,125 @@
+package servermanager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestMaintenanceWindow_Next(t *testing.T) {
+	// 2020-01-01 was a Wednesday.
+	wednesday := time.Date(2020, 1, 1, 3, 0, 0, 0, time.Local)
+
+	testCases := []struct {
+		window   MaintenanceWindow
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			window:   MaintenanceWindow{At: "04:00"},
+			now:      wednesday,
+			expected: time.Date(2020, 1, 1, 4, 0, 0, 0, time.Local),
+		},
+		{
+			window:   MaintenanceWindow{At: "04:00"},
+			now:      wednesday.Add(time.Hour),
+			expected: time.Date(2020, 1, 2, 4, 0, 0, 0, time.Local),
+		},
+		{
+			window:   MaintenanceWindow{At: "16:30", Days: []string{"Saturday", "sunday"}},
+			now:      wednesday,
+			expected: time.Date(2020, 1, 4, 16, 30, 0, 0, time.Local),
+		},
+		{
+			window:   MaintenanceWindow{At: "04:00", Days: []string{"wednesday"}},
+			now:      wednesday.Add(time.Hour),
+			expected: time.Date(2020, 1, 8, 4, 0, 0, 0, time.Local),
+		},
+	}
+
+	for _, testCase := range testCases {
+		window, err := parseMaintenanceWindow(testCase.window)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if next := window.next(testCase.now); !next.Equal(testCase.expected) {
+			t.Errorf("expected %+v to next start at %s after %s, got %s", testCase.window, testCase.expected, testCase.now, next)
+		}
+	}
+
+	for _, invalid := range []MaintenanceWindow{{At: "4am"}, {At: "25:00"}, {At: "04:00", Days: []string{"someday"}}} {
+		if _, err := parseMaintenanceWindow(invalid); err == nil {
+			t.Errorf("expected %+v to be invalid", invalid)
+		}
+
+		if _, err := NewAssettoServerProcess(func(udp.Message) {}, nil, nil, WithMaintenanceWindows([]MaintenanceWindow{invalid})); err == nil {
+			t.Errorf("expected a server process with maintenance window %+v not to be created", invalid)
+		}
+	}
+}
+
+func TestAssettoServerProcess_RunMaintenance(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	startTestServerProcess(t, sp, testServerScript)
+	defer sp.Stop() //nolint:errcheck
+
+	window, err := parseMaintenanceWindow(MaintenanceWindow{At: "04:00", SteamUpdate: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := 0
+	update := func() error {
+		updated++
+
+		if sp.IsRunning() {
+			t.Error("expected acServer to be stopped while it is updated")
+		}
+
+		return errors.New("steamcmd failed")
+	}
+
+	sp.mutex.Lock()
+	sp.raceFinish = raceFinish{isRace: true}
+	startedAt := sp.startedAt
+	sp.mutex.Unlock()
+
+	sp.runMaintenance(window, update)
+
+	sp.mutex.Lock()
+	skipped := sp.startedAt.Equal(startedAt)
+	sp.mutex.Unlock()
+
+	if updated != 0 || !skipped {
+		t.Fatal("expected the maintenance window to be skipped while a race session is live")
+	}
+
+	sp.mutex.Lock()
+	sp.raceFinish.ended = true
+	sp.mutex.Unlock()
+
+	sp.runMaintenance(window, update)
+
+	if updated != 1 {
+		t.Errorf("expected acServer to be updated once, got %d", updated)
+	}
+
+	if !sp.IsRunning() {
+		t.Error("expected the event to be started again after maintenance, even though the update failed")
+	}
+
+	sp.mutex.Lock()
+	restarted := !sp.startedAt.Equal(startedAt)
+	sp.mutex.Unlock()
+
+	if !restarted {
+		t.Error("expected acServer to be restarted")
+	}
+}
@@ -0,0 +1,131 @@
+package servermanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultMemoryLimitCheckInterval is how often acServer's memory use is checked if no interval is configured.
+const defaultMemoryLimitCheckInterval = time.Second * 30
+
+// readProcessRSS returns the resident memory of the process pid, in bytes.
+var readProcessRSS = func(pid int) (uint64, error) {
+	p, err := process.NewProcess(int32(pid))
+
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := p.MemoryInfo()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return info.RSS, nil
+}
+
+// startMemoryWatchdog starts watching the memory use of the acServer process which has just been launched, if a
+// memory limit is configured. It must be called with sp.mutex held. The watchdog is stopped by onStop.
+func (sp *AssettoServerProcess) startMemoryWatchdog() {
+	if config == nil || config.Server.MemoryLimit.MaxMB <= 0 {
+		return
+	}
+
+	interval := config.Server.MemoryLimit.CheckInterval
+
+	if interval <= 0 {
+		interval = defaultMemoryLimitCheckInterval
+	}
+
+	sp.memoryWatchdogDone = make(chan struct{})
+
+	go sp.enforceMemoryLimit(sp.memoryWatchdogDone, readProcessRSS, sp.cmd.Process.Pid, uint64(config.Server.MemoryLimit.MaxMB)*1024*1024, interval)
+}
+
+// stopMemoryWatchdog must be called with sp.mutex held.
+func (sp *AssettoServerProcess) stopMemoryWatchdog() {
+	if sp.memoryWatchdogDone != nil {
+		close(sp.memoryWatchdogDone)
+		sp.memoryWatchdogDone = nil
+	}
+}
+
+// enforceMemoryLimit restarts the event once acServer's resident memory goes over limit. The restart is deferred while
+// a race finish is in progress, so that a leak doesn't cost a race its result.
+func (sp *AssettoServerProcess) enforceMemoryLimit(done chan struct{}, readRSS func(pid int) (uint64, error), pid int, limit uint64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	deferred := false
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			rss, err := readRSS(pid)
+
+			if err != nil {
+				logrus.WithError(err).Debugf("Could not read the memory use of acServer [pid: %d]", pid)
+				continue
+			}
+
+			if rss <= limit {
+				continue
+			}
+
+			sp.mutex.Lock()
+			finishing := sp.raceFinish.inProgress(now)
+			sp.mutex.Unlock()
+
+			if finishing {
+				if !deferred {
+					logrus.Warnf("acServer is using %s of memory, over its limit of %s. Waiting for the race to finish before restarting it", formatMB(rss), formatMB(limit))
+					deferred = true
+				}
+
+				continue
+			}
+
+			select {
+			case <-done:
+				// acServer stopped while its memory was being read.
+				return
+			default:
+			}
+
+			logrus.Warnf("acServer is using %s of memory, over its limit of %s. Restarting it (memory limit restart, not a crash)", formatMB(rss), formatMB(limit))
+
+			sp.mutex.Lock()
+			sp.memoryLimitExceeded = true
+			raceEvent := sp.raceEvent
+			sp.mutex.Unlock()
+
+			if raceEvent == nil {
+				return
+			}
+
+			sp.emit(ProcessEvent{
+				Type:      ProcessEventMemoryLimitExceeded,
+				EventName: raceEvent.EventName(),
+				RaceEvent: raceEvent,
+				Reason:    StopReasonMemoryLimit,
+				Error:     fmt.Sprintf("acServer used %s of memory, over its limit of %s", formatMB(rss), formatMB(limit)),
+			})
+
+			if err := sp.Restart(); err != nil {
+				logrus.WithError(err).Error("Could not restart acServer after it went over its memory limit")
+			}
+
+			return
+		}
+	}
+}
+
+func formatMB(bytes uint64) string {
+	return fmt.Sprintf("%dMB", bytes/1024/1024)
+}
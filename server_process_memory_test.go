@@ -0,0 +1,92 @@
+package servermanager
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_MemoryLimit(t *testing.T) {
+	var rss uint64
+
+	oldReadProcessRSS := readProcessRSS
+	readProcessRSS = func(pid int) (uint64, error) {
+		return atomic.LoadUint64(&rss), nil
+	}
+	defer func() {
+		readProcessRSS = oldReadProcessRSS
+	}()
+
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	config.Server.MemoryLimit = MemoryLimitConfig{MaxMB: 100, CheckInterval: time.Millisecond * 20}
+
+	sink := &recordingEventSink{events: make(chan ProcessEvent, 100)}
+	sp.AddEventSink(sink)
+
+	startTestServerProcess(t, sp, testServerScript)
+
+	waitForEvent := func(eventType ProcessEventType, timeout time.Duration) (ProcessEvent, bool) {
+		deadline := time.After(timeout)
+
+		for {
+			select {
+			case event := <-sink.events:
+				if event.Type == eventType {
+					return event, true
+				}
+			case <-deadline:
+				return ProcessEvent{}, false
+			}
+		}
+	}
+
+	if _, ok := waitForEvent(ProcessEventMemoryLimitExceeded, time.Millisecond*200); ok {
+		t.Fatal("expected no memory limit event while acServer is under its limit")
+	}
+
+	// the restart waits for a race which is finishing.
+	sp.UDPCallback(udp.SessionInfo{EventType: udp.EventNewSession, Type: udp.SessionTypeRace, Laps: 10})
+	sp.UDPCallback(udp.LapCompleted{Cars: []*udp.LapCompletedCar{{CarID: 1, Laps: 9}, {CarID: 2, Laps: 8}}})
+
+	atomic.StoreUint64(&rss, 200*1024*1024)
+
+	if _, ok := waitForEvent(ProcessEventMemoryLimitExceeded, time.Millisecond*300); ok {
+		t.Fatal("expected the memory limit restart to wait for the race to finish")
+	}
+
+	sp.UDPCallback(udp.EndSession("results.json"))
+
+	event, ok := waitForEvent(ProcessEventMemoryLimitExceeded, time.Second*5)
+
+	if !ok {
+		t.Fatal("expected a memory limit event once the race finished")
+	}
+
+	if event.Reason != StopReasonMemoryLimit || event.Error == "" {
+		t.Errorf("expected the memory limit event to describe the memory use, got: %+v", event)
+	}
+
+	atomic.StoreUint64(&rss, 0)
+
+	stopped, ok := waitForEvent(ProcessEventStopped, time.Second*5)
+
+	if !ok {
+		t.Fatal("expected acServer to be stopped for the restart")
+	}
+
+	if stopped.Reason != StopReasonMemoryLimit {
+		t.Errorf("expected stop reason %s, got: %s", StopReasonMemoryLimit, stopped.Reason)
+	}
+
+	if _, ok := waitForEvent(ProcessEventStarted, time.Second*5); !ok {
+		t.Fatal("expected acServer to be started again after going over its memory limit")
+	}
+
+	if exit, ok := sp.LastExit(); !ok || exit.Reason != StopReasonMemoryLimit {
+		t.Errorf("expected last exit to be a memory limit restart, got: %+v", exit)
+	}
+}
@@ -0,0 +1,94 @@
+package servermanager
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	serverProcessStartsCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "server_process_starts_total",
+		Help: "The number of times acServer has been started, including restarts.",
+	})
+
+	serverProcessStopsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "server_process_stops_total",
+		Help: "The number of times acServer has stopped, by the reason it stopped.",
+	}, []string{"reason"})
+
+	serverProcessUDPMessagesCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "server_process_udp_messages_total",
+		Help: "The number of UDP plugin messages received from acServer.",
+	})
+)
+
+var (
+	serverProcessRunningDesc = prometheus.NewDesc("server_process_running", "Whether acServer is running.", nil, nil)
+	serverProcessUptimeDesc  = prometheus.NewDesc("server_process_uptime_seconds", "How long acServer has been running for.", nil, nil)
+	serverDriversDesc        = prometheus.NewDesc("server_connected_drivers", "The number of drivers connected to the server.", nil, nil)
+	serverSessionDesc        = prometheus.NewDesc("server_session", "The type of the session which is running, which is set to 1.", []string{"type"}, nil)
+	serverPluginRunningDesc  = prometheus.NewDesc("server_plugin_running", "Whether each plugin process is running.", []string{"plugin"}, nil)
+	serverPluginRestartsDesc = prometheus.NewDesc("server_plugin_restarts_total", "The number of times each plugin process has been restarted.", []string{"plugin"}, nil)
+)
+
+// processMetricsSink counts the starts and stops of acServer.
+type processMetricsSink struct{}
+
+func (processMetricsSink) Publish(event ProcessEvent) {
+	switch event.Type {
+	case ProcessEventStarted:
+		serverProcessStartsCounter.Inc()
+	case ProcessEventStopped, ProcessEventCrashed:
+		serverProcessStopsCounter.WithLabelValues(string(event.Reason)).Inc()
+	}
+}
+
+// serverMetricsCollector reads the state of the server process and race control each time the metrics are scraped,
+// rather than keeping gauges up to date as it changes.
+type serverMetricsCollector struct {
+	process     ServerProcess
+	raceControl *RaceControl
+}
+
+func newServerMetricsCollector(process ServerProcess, raceControl *RaceControl) *serverMetricsCollector {
+	return &serverMetricsCollector{
+		process:     process,
+		raceControl: raceControl,
+	}
+}
+
+func (c *serverMetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- serverProcessRunningDesc
+	ch <- serverProcessUptimeDesc
+	ch <- serverDriversDesc
+	ch <- serverSessionDesc
+	ch <- serverPluginRunningDesc
+	ch <- serverPluginRestartsDesc
+}
+
+func (c *serverMetricsCollector) Collect(ch chan<- prometheus.Metric) {
+	status := c.process.Status()
+
+	ch <- prometheus.MustNewConstMetric(serverProcessRunningDesc, prometheus.GaugeValue, metricBool(status.Running))
+	ch <- prometheus.MustNewConstMetric(serverProcessUptimeDesc, prometheus.GaugeValue, c.process.Uptime().Seconds())
+
+	if c.raceControl != nil {
+		ch <- prometheus.MustNewConstMetric(serverDriversDesc, prometheus.GaugeValue, float64(c.raceControl.ConnectedDrivers.Len()))
+
+		if status.Running {
+			ch <- prometheus.MustNewConstMetric(serverSessionDesc, prometheus.GaugeValue, 1, c.raceControl.CurrentSessionInfo().Type.String())
+		}
+	}
+
+	for _, plugin := range status.Plugins {
+		ch <- prometheus.MustNewConstMetric(serverPluginRunningDesc, prometheus.GaugeValue, metricBool(plugin.State == PluginStateRunning), plugin.Name)
+		ch <- prometheus.MustNewConstMetric(serverPluginRestartsDesc, prometheus.CounterValue, float64(plugin.Restarts), plugin.Name)
+	}
+}
+
+func metricBool(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,133 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestServerMetricsCollector(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(newServerMetricsCollector(sp, nil))
+
+	gauge := func(name string) float64 {
+		families, err := registry.Gather()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, family := range families {
+			if family.GetName() == name {
+				return family.GetMetric()[0].GetGauge().GetValue()
+			}
+		}
+
+		t.Fatalf("expected metric %s to be collected", name)
+
+		return 0
+	}
+
+	if running := gauge("server_process_running"); running != 0 {
+		t.Errorf("expected acServer not to be running, got: %f", running)
+	}
+
+	startsBefore := testutil.ToFloat64(serverProcessStartsCounter)
+	stopsBefore := testutil.ToFloat64(serverProcessStopsCounter.WithLabelValues(string(StopReasonRequested)))
+
+	sp.AddEventSink(processMetricsSink{})
+	startTestServerProcess(t, sp, testServerScript)
+
+	if running := gauge("server_process_running"); running != 1 {
+		t.Errorf("expected acServer to be running, got: %f", running)
+	}
+
+	if uptime := gauge("server_process_uptime_seconds"); uptime <= 0 {
+		t.Errorf("expected acServer to have an uptime, got: %f", uptime)
+	}
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if starts := testutil.ToFloat64(serverProcessStartsCounter); starts != startsBefore+1 {
+		t.Errorf("expected one more start to be counted, got: %f (was %f)", starts, startsBefore)
+	}
+
+	// the stopped event is published after Stop has returned.
+	deadline := time.Now().Add(time.Second * 5)
+
+	for testutil.ToFloat64(serverProcessStopsCounter.WithLabelValues(string(StopReasonRequested))) != stopsBefore+1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected one more requested stop to be counted")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+func TestServerMetricsCollector_Session(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, sp, testStore, NewPenaltiesManager(testStore))
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(newServerMetricsCollector(sp, raceControl))
+
+	startTestServerProcess(t, sp, testServerScript)
+	defer sp.Stop() //nolint:errcheck
+
+	done := make(chan struct{})
+
+	// session information is updated from the UDP callback while metrics are collected.
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			if err := raceControl.OnNewSession(udp.SessionInfo{Type: udp.SessionTypeQualifying, EventType: udp.EventNewSession}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 10; i++ {
+		if _, err := registry.Gather(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	<-done
+
+	if err := raceControl.OnNewSession(udp.SessionInfo{Type: udp.SessionTypeRace, EventType: udp.EventNewSession}); err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := registry.Gather()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, family := range families {
+		if family.GetName() != "server_session" {
+			continue
+		}
+
+		if label := family.GetMetric()[0].GetLabel()[0].GetValue(); label != udp.SessionTypeRace.String() {
+			t.Errorf("expected the race session to be collected, got: %s", label)
+		}
+
+		return
+	}
+
+	t.Error("expected the session to be collected")
+}
@@ -33,6 +33,24 @@ func kill(ps *os.Process) error {
 	return syscall.Kill(-ps.Pid, syscall.SIGKILL)
 }
 
+// killProcessGroup kills every process left in cmd's process group, which buildCommand makes cmd the leader of.
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+		return err
+	}
+
+	return nil
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
+
 func buildCommand(ctx context.Context, command string, args ...string) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
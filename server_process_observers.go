@@ -0,0 +1,73 @@
+package servermanager
+
+import (
+	"sync/atomic"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+type udpObserver struct {
+	ch chan<- udp.Message
+
+	// dropped is accessed atomically.
+	dropped uint64
+}
+
+// AddObserver attaches a read-only consumer of the UDP messages received from acServer. Every message is sent to ch
+// before the UDP callback sees it, whether or not forwarding is enabled. Messages are never queued for an observer:
+// if ch isn't ready to receive, the message is dropped for that observer, so a buffered channel is recommended.
+func (sp *AssettoServerProcess) AddObserver(ch chan<- udp.Message) {
+	sp.observersMutex.Lock()
+	defer sp.observersMutex.Unlock()
+
+	sp.observers = append(sp.observers, &udpObserver{ch: ch})
+}
+
+// RemoveObserver detaches an observer added with AddObserver. The channel is not closed.
+func (sp *AssettoServerProcess) RemoveObserver(ch chan<- udp.Message) {
+	sp.observersMutex.Lock()
+	defer sp.observersMutex.Unlock()
+
+	var observers []*udpObserver
+
+	for _, observer := range sp.observers {
+		if observer.ch != ch {
+			observers = append(observers, observer)
+		}
+	}
+
+	sp.observers = observers
+}
+
+func (sp *AssettoServerProcess) notifyObservers(message udp.Message) {
+	if !sp.IsFeatureEnabled(FeatureUDPObservers) {
+		return
+	}
+
+	sp.observersMutex.Lock()
+	observers := sp.observers
+	sp.observersMutex.Unlock()
+
+	for _, observer := range observers {
+		select {
+		case observer.ch <- message:
+		default:
+			if atomic.AddUint64(&observer.dropped, 1) == 1 {
+				logrus.Warn("UDP observer is not keeping up, dropping messages")
+			}
+		}
+	}
+}
+
+// observerStats returns the number of observers and the total number of messages they have dropped.
+func (sp *AssettoServerProcess) observerStats() (numObservers int, dropped uint64) {
+	sp.observersMutex.Lock()
+	defer sp.observersMutex.Unlock()
+
+	for _, observer := range sp.observers {
+		dropped += atomic.LoadUint64(&observer.dropped)
+	}
+
+	return len(sp.observers), dropped
+}
@@ -0,0 +1,65 @@
+package servermanager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func multiplexUDPPlugins() bool {
+	return config != nil && config.Server.MultiplexUDPPlugins
+}
+
+// udpPluginPorts hands out the ports which the UDP plugins Server Manager runs (sTracker, Real Penalty, KissMyRank)
+// talk to it on, when they are multiplexed rather than chained through each other.
+type udpPluginPorts struct {
+	process *AssettoServerProcess
+
+	forwardingAddressTaken bool
+}
+
+// next returns the port which the plugin called name should listen on for acServer's messages, and the port it
+// should send its own messages to. The first plugin is given the forwarding address, and each plugin after it a
+// forwarding target of its own.
+func (p *udpPluginPorts) next(name string) (listenPort, sendPort int, err error) {
+	sp := p.process
+
+	if !p.forwardingAddressTaken {
+		p.forwardingAddressTaken = true
+
+		return formValueAsInt(strings.Split(sp.forwardingAddress, ":")[1]), sp.forwardListenPort, nil
+	}
+
+	listenPort, err = FreeUDPPort()
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for sendPort == 0 || sendPort == listenPort {
+		sendPort, err = FreeUDPPort()
+
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	target := udp.ForwardTarget{
+		Name:       name,
+		Address:    fmt.Sprintf("127.0.0.1:%d", listenPort),
+		ListenPort: sendPort,
+	}
+
+	sp.pluginForwardingTargets = append(sp.pluginForwardingTargets, target)
+
+	if err := sp.applyForwardingTargets(); err != nil {
+		return 0, 0, err
+	}
+
+	logrus.Infof("Multiplexing UDP plugins: forwarding to %s", target)
+
+	return listenPort, sendPort, nil
+}
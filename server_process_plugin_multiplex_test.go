@@ -0,0 +1,105 @@
+package servermanager
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp/simulator"
+)
+
+func TestUDPPluginPorts_Next(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	var ports [3]int
+
+	for i := range ports {
+		port, err := FreeUDPPort()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ports[i] = port
+	}
+
+	serverManagerPort, forwardingPort, forwardListenPort := ports[0], ports[1], ports[2]
+
+	acServer, err := simulator.NewServer(0, fmt.Sprintf("127.0.0.1:%d", serverManagerPort))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer acServer.Close()
+
+	sp.forwardingAddress = fmt.Sprintf("127.0.0.1:%d", forwardingPort)
+	sp.forwardListenPort = forwardListenPort
+	sp.udpServerConn, err = udp.NewServerClient("127.0.0.1", serverManagerPort, acServer.LocalPort(), true, sp.forwardingAddress, sp.forwardListenPort, func(udp.Message) {})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer sp.udpServerConn.Close()
+
+	pluginPorts := &udpPluginPorts{process: sp}
+
+	var plugins []*net.UDPConn
+
+	for i, name := range []string{"stracker", "kissmyrank"} {
+		listenPort, sendPort, err := pluginPorts.next(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if i == 0 && (listenPort != forwardingPort || sendPort != forwardListenPort) {
+			t.Errorf("Expected the first plugin to be given the forwarding address, got ports %d and %d", listenPort, sendPort)
+		}
+
+		plugin, err := net.DialUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: listenPort}, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: sendPort})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer plugin.Close()
+
+		plugins = append(plugins, plugin)
+	}
+
+	if statuses := sp.forwardingTargetStatuses(); len(statuses) != 1 || statuses[0].Name != "kissmyrank" || !statuses[0].Active {
+		t.Errorf("Expected an active forwarding target for kissmyrank, got %+v", statuses)
+	}
+
+	if err := acServer.Send(udp.Version(4)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, plugin := range plugins {
+		buf := make([]byte, 1024)
+
+		_ = plugin.SetReadDeadline(time.Now().Add(time.Second))
+
+		if n, err := plugin.Read(buf); err != nil || n != 2 || udp.Event(buf[0]) != udp.EventVersion {
+			t.Fatalf("Expected plugin %d to be sent the version, got %v (%v)", i, buf[:n], err)
+		}
+
+		if _, err := plugin.Write([]byte{byte(udp.EventKickUser), byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case message := <-acServer.Received():
+			if message.Event != udp.EventKickUser || message.CarID != udp.CarID(i) {
+				t.Errorf("Expected plugin %d's kick to be passed on to acServer, got %+v", i, message)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Plugin %d's kick wasn't passed on to acServer", i)
+		}
+	}
+}
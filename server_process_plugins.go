@@ -0,0 +1,448 @@
+package servermanager
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PluginState describes what a plugin process is currently doing.
+type PluginState string
+
+const (
+	PluginStateRunning PluginState = "running"
+	PluginStateStopped PluginState = "stopped"
+
+	// PluginStateRestarting is a plugin with restart set which has exited, and is waiting to be started again.
+	PluginStateRestarting PluginState = "restarting"
+
+	// PluginStateFailed is a plugin which could not be started for the current event.
+	PluginStateFailed PluginState = "failed"
+
+	// PluginStateSuspended is a plugin which has exited more times in a row than its max_restarts allows, so it
+	// isn't restarted again until the next event.
+	PluginStateSuspended PluginState = "suspended"
+
+	// PluginStateCircuitOpen is a plugin with restart set which could not be started again after it exited. Starting
+	// it is retried every pluginCircuitOpenDelay, until it starts or has been retried more than its max_restarts allows.
+	PluginStateCircuitOpen PluginState = "circuit-open"
+)
+
+var (
+	// pluginRestartDelay is how long to wait before starting a plugin again after it first exits. The wait doubles
+	// for each restart in a row, up to pluginMaxRestartDelay.
+	pluginRestartDelay    = time.Second * 5
+	pluginMaxRestartDelay = time.Minute
+
+	// pluginRestartResetAfter is how long a plugin must run for before its exits are no longer counted as in a row.
+	pluginRestartResetAfter = time.Minute * 5
+
+	// pluginCircuitOpenDelay is how long to wait before trying to start a plugin again after restarting it failed.
+	pluginCircuitOpenDelay = time.Minute
+)
+
+var errPluginExited = errors.New("servermanager: plugin exited")
+
+// PluginHealthStatus describes the health of a single plugin process. A plugin which is Stopped with a LastError
+// exited on its own while acServer was still running.
+type PluginHealthStatus struct {
+	Name      string
+	PID       int
+	State     PluginState
+	StartedAt time.Time
+	Restarts  int
+
+	// Exited is true if the plugin has exited on its own at least once, in which case ExitCode is the code it last
+	// exited with, or -1 if it was ended by a signal.
+	Exited   bool
+	ExitCode int
+
+	LastError     string
+	LastErrorTime time.Time
+}
+
+func newPluginProcess(name string, cmd *exec.Cmd, stdin io.WriteCloser) *pluginProcess {
+	return &pluginProcess{
+		cmd:       cmd,
+		stdin:     stdin,
+		launch:    newLaunchCommand(cmd),
+		name:      name,
+		state:     PluginStateRunning,
+		exited:    make(chan struct{}),
+		startedAt: time.Now(),
+	}
+}
+
+var ErrInvalidPluginEnv = errors.New("servermanager: plugin environment variables must be given as KEY=value")
+
+// pluginEnvironment returns the environment for plugin's command: Server Manager's own environment, unless
+// plugin.ReplaceEnv is set, then eventEnv, then plugin.Env.
+func pluginEnvironment(plugin *CommandPlugin, eventEnv []string) ([]string, error) {
+	for _, variable := range plugin.Env {
+		if strings.IndexByte(variable, '=') <= 0 {
+			return nil, ErrInvalidPluginEnv
+		}
+	}
+
+	var env []string
+
+	if !plugin.ReplaceEnv {
+		env = os.Environ()
+	}
+
+	return mergeEnv(mergeEnv(env, eventEnv), plugin.Env), nil
+}
+
+// pluginEventEnv describes the event which is running to plugins, so that they can configure themselves for it. It
+// must be called with sp.mutex held.
+func (sp *AssettoServerProcess) pluginEventEnv() []string {
+	if sp.raceEvent == nil {
+		return nil
+	}
+
+	raceConfig := sp.raceEvent.GetRaceConfig()
+
+	env := []string{
+		"AC_EVENT_ID=" + raceEventID(sp.raceEvent),
+		"AC_EVENT_NAME=" + sp.raceEvent.EventName(),
+		"AC_TRACK=" + raceConfig.Track,
+		"AC_TRACK_LAYOUT=" + raceConfig.TrackLayout,
+		"AC_CARS=" + raceConfig.Cars,
+		"AC_INSTALL_PATH=" + sp.installPath(),
+		"AC_PLUGIN_ADDRESS=" + sp.forwardingAddress,
+		"AC_PLUGIN_LOCAL_PORT=" + strconv.Itoa(sp.forwardListenPort),
+	}
+
+	if ports, err := readGamePorts(sp.installPath()); err == nil {
+		env = append(env,
+			"AC_SERVER_TCP_PORT="+strconv.Itoa(ports.TCP),
+			"AC_SERVER_UDP_PORT="+strconv.Itoa(ports.UDP),
+			"AC_SERVER_HTTP_PORT="+strconv.Itoa(ports.HTTP),
+		)
+	}
+
+	return env
+}
+
+// raceEventID is the ID of the custom race, championship event or race weekend session which raceEvent runs. It is
+// empty for quick races, which aren't saved.
+func raceEventID(raceEvent RaceEvent) string {
+	switch event := raceEvent.(type) {
+	case *CustomRace:
+		return event.UUID.String()
+	case *ActiveChampionship:
+		return event.EventID.String()
+	case *ActiveRaceWeekend:
+		return event.SessionID.String()
+	default:
+		return ""
+	}
+}
+
+// mergeEnv adds overrides to env, replacing any variables in env which have the same name.
+func mergeEnv(env, overrides []string) []string {
+	merged := append([]string{}, env...)
+	index := make(map[string]int, len(merged))
+
+	for i, variable := range merged {
+		index[strings.SplitN(variable, "=", 2)[0]] = i
+	}
+
+	for _, variable := range overrides {
+		name := strings.SplitN(variable, "=", 2)[0]
+
+		if i, ok := index[name]; ok {
+			merged[i] = variable
+		} else {
+			index[name] = len(merged)
+			merged = append(merged, variable)
+		}
+	}
+
+	return merged
+}
+
+// startPluginCommand starts cmd, whose Stdout and Stderr must be the same writer. The plugin is given its own pipe,
+// rather than one from exec.Cmd, so that cmd.Wait returns as soon as the plugin exits even if processes it started are
+// still holding the pipe open. Those processes are killed with the plugin's process group when it is stopped.
+func startPluginCommand(cmd *exec.Cmd) error {
+	output := cmd.Stdout
+
+	r, w, err := os.Pipe()
+
+	if err != nil {
+		return err
+	}
+
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	err = cmd.Start()
+	_ = w.Close()
+
+	if err != nil {
+		_ = r.Close()
+		return err
+	}
+
+	go func() {
+		_, _ = io.Copy(output, r)
+		_ = r.Close()
+	}()
+
+	return nil
+}
+
+// monitorPlugin waits for a started plugin process to exit and records how it exited. It is the only caller of
+// plugin.cmd.Wait, anything else which needs to know when the plugin has exited should wait on plugin.exited.
+func (sp *AssettoServerProcess) monitorPlugin(plugin *pluginProcess) {
+	plugin.exitErr = plugin.cmd.Wait()
+	close(plugin.exited)
+
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	plugin.state = PluginStateStopped
+
+	if plugin.stopping {
+		return
+	}
+
+	if plugin.exitErr != nil {
+		plugin.lastErr = plugin.exitErr
+	} else {
+		plugin.lastErr = errPluginExited
+	}
+
+	plugin.lastErrTime = time.Now()
+	plugin.exitCode = -1
+	plugin.hasExited = true
+
+	if plugin.cmd.ProcessState != nil {
+		plugin.exitCode = plugin.cmd.ProcessState.ExitCode()
+	}
+
+	logrus.WithError(plugin.lastErr).Errorf("Plugin %s [pid: %d] exited while acServer is running", plugin.name, plugin.cmd.Process.Pid)
+
+	// acServer may have just stopped, before the plugin was marked as stopping.
+	var raceEvent RaceEvent = QuickRace{}
+
+	if sp.raceEvent != nil {
+		raceEvent = sp.raceEvent
+	}
+
+	sp.emit(ProcessEvent{Type: ProcessEventPluginExited, EventName: raceEvent.EventName(), RaceEvent: raceEvent, Plugin: plugin.name, Error: plugin.lastErr.Error()})
+
+	if plugin.plugin == nil || !plugin.plugin.Restart || sp.raceEvent == nil {
+		return
+	}
+
+	if time.Since(plugin.startedAt) >= pluginRestartResetAfter {
+		plugin.restartsInARow = 0
+	}
+
+	if maxRestarts := plugin.plugin.MaxRestarts; maxRestarts > 0 && plugin.restartsInARow >= maxRestarts {
+		logrus.Errorf("Plugin %s has exited %d times in a row, it won't be restarted again until the next event", plugin.name, plugin.restartsInARow+1)
+
+		plugin.state = PluginStateSuspended
+		return
+	}
+
+	plugin.state = PluginStateRestarting
+
+	delay := pluginRestartDelay
+
+	for i := 0; i < plugin.restartsInARow && delay < pluginMaxRestartDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > pluginMaxRestartDelay {
+		delay = pluginMaxRestartDelay
+	}
+
+	logrus.Infof("Restarting plugin %s in %s", plugin.name, delay)
+
+	time.AfterFunc(delay, func() {
+		sp.restartPlugin(plugin)
+	})
+}
+
+// restartPlugin starts a plugin again after it has exited, unless it has been stopped in the meantime.
+func (sp *AssettoServerProcess) restartPlugin(plugin *pluginProcess) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if plugin.stopping || sp.raceEvent == nil {
+		plugin.state = PluginStateStopped
+		return
+	}
+
+	cmd, stdin, err := buildPluginCommand(plugin.wd, plugin.plugin, sp.pluginEventEnv(), sp.pluginOutput(plugin.name))
+
+	if err == nil {
+		err = startPluginCommand(cmd)
+	}
+
+	if err != nil {
+		plugin.lastErr = err
+		plugin.lastErrTime = time.Now()
+
+		if maxRestarts := plugin.plugin.MaxRestarts; maxRestarts > 0 && plugin.restartsInARow >= maxRestarts {
+			logrus.WithError(err).Errorf("Could not restart plugin %s, it won't be restarted again until the next event", plugin.name)
+
+			plugin.state = PluginStateSuspended
+			return
+		}
+
+		logrus.WithError(err).Errorf("Could not restart plugin %s, trying again in %s", plugin.name, pluginCircuitOpenDelay)
+
+		plugin.state = PluginStateCircuitOpen
+		plugin.restartsInARow++
+
+		time.AfterFunc(pluginCircuitOpenDelay, func() {
+			sp.restartPlugin(plugin)
+		})
+
+		return
+	}
+
+	// the processes started by the plugin may still be running, in which case its old limits can't be released yet.
+	plugin.releaseLimits()
+
+	plugin.cmd = cmd
+	plugin.stdin = stdin
+	plugin.launch = newLaunchCommand(cmd)
+	plugin.limitResources()
+	plugin.schedule()
+	plugin.exited = make(chan struct{})
+	plugin.exitErr = nil
+	plugin.state = PluginStateRunning
+	plugin.startedAt = time.Now()
+	plugin.restarts++
+	plugin.restartsInARow++
+
+	logrus.Infof("Restarted plugin %s [pid: %d]", plugin.name, cmd.Process.Pid)
+
+	sp.emit(ProcessEvent{Type: ProcessEventPluginStarted, EventName: sp.raceEvent.EventName(), RaceEvent: sp.raceEvent, Plugin: plugin.name})
+
+	go sp.monitorPlugin(plugin)
+}
+
+// limitResources applies the plugin's resource limits to its process, which has just been started. The plugin is left
+// running if they can't be applied.
+func (plugin *pluginProcess) limitResources() {
+	limits := pluginResourceLimits(plugin.plugin)
+
+	var err error
+
+	plugin.limits, err = applyResourceLimits(plugin.cmd.Process.Pid, "plugin", limits)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not apply resource limits (%+v) to plugin %s", limits, plugin.name)
+	}
+}
+
+// schedule applies the plugin's CPU affinity and priority to its process, which has just been started.
+func (plugin *pluginProcess) schedule() {
+	if plugin.plugin == nil {
+		return
+	}
+
+	applyProcessScheduling(plugin.cmd.Process.Pid, "plugin "+plugin.name, plugin.plugin.CPUAffinity, plugin.plugin.ProcessPriority)
+}
+
+func (plugin *pluginProcess) releaseLimits() {
+	if err := plugin.limits.release(); err != nil {
+		logrus.WithError(err).Warnf("Could not release the resource limits of plugin %s", plugin.name)
+	}
+
+	plugin.limits = processLimits{}
+}
+
+// PluginHealth returns the health of each plugin process started with the current event, followed by any plugins
+// which failed to start.
+func (sp *AssettoServerProcess) PluginHealth() []PluginHealthStatus {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	return sp.pluginHealth()
+}
+
+// pluginHealth returns the health of each plugin process. sp.mutex must be held by the caller.
+func (sp *AssettoServerProcess) pluginHealth() []PluginHealthStatus {
+	var statuses []PluginHealthStatus
+
+	for _, plugin := range sp.extraProcesses {
+		status := PluginHealthStatus{
+			Name:          plugin.name,
+			State:         plugin.state,
+			StartedAt:     plugin.startedAt,
+			Restarts:      plugin.restarts,
+			Exited:        plugin.hasExited,
+			ExitCode:      plugin.exitCode,
+			LastErrorTime: plugin.lastErrTime,
+		}
+
+		if plugin.cmd.Process != nil {
+			status.PID = plugin.cmd.Process.Pid
+		}
+
+		if plugin.lastErr != nil {
+			status.LastError = plugin.lastErr.Error()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return append(statuses, sp.failedPlugins...)
+}
+
+// pluginFailed records that plugin could not be started. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) pluginFailed(plugin *CommandPlugin, err error) {
+	sp.failedPlugins = append(sp.failedPlugins, PluginHealthStatus{
+		Name:          plugin.DisplayName(),
+		State:         PluginStateFailed,
+		LastError:     err.Error(),
+		LastErrorTime: time.Now(),
+	})
+}
+
+// pluginOutput returns the writer for a plugin's stdout and stderr. Output goes to the shared plugins log, and to the
+// plugin's own log so that it can be read separately with PluginLogs. Plugins with the same name share a log.
+func (sp *AssettoServerProcess) pluginOutput(name string) io.Writer {
+	sp.pluginLogsMutex.Lock()
+	defer sp.pluginLogsMutex.Unlock()
+
+	if sp.pluginLogs == nil {
+		sp.pluginLogs = make(map[string]*logBuffer)
+	}
+
+	buf, ok := sp.pluginLogs[name]
+
+	if !ok {
+		buf = newLogBuffer(MaxLogSizeBytes)
+		sp.pluginLogs[name] = buf
+	}
+
+	return io.MultiWriter(pluginsOutput, buf)
+}
+
+// PluginLogs returns the output of the plugin with the given name, which is the name set in the plugin's config or
+// the name of its executable. It is empty if no plugin with that name has been run.
+func (sp *AssettoServerProcess) PluginLogs(name string) string {
+	sp.pluginLogsMutex.Lock()
+	buf, ok := sp.pluginLogs[name]
+	sp.pluginLogsMutex.Unlock()
+
+	if !ok {
+		return ""
+	}
+
+	return buf.String()
+}
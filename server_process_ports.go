@@ -0,0 +1,128 @@
+package servermanager
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PortPurposeUDPPlugin             = "UDP plugin"
+	PortPurposeUDPPluginLocal        = "UDP plugin local"
+	PortPurposeUDPForwarding         = "UDP forwarding"
+	PortPurposeContentManagerWrapper = "Content Manager wrapper"
+	PortPurposeACServerTCP           = "acServer TCP"
+	PortPurposeACServerUDP           = "acServer UDP"
+	PortPurposeACServerHTTP          = "acServer HTTP"
+)
+
+// ErrPortInUse is returned when a port which is needed to start an event is already bound, usually by another server
+// or a server process which hasn't exited properly.
+type ErrPortInUse struct {
+	Port    int
+	Purpose string
+
+	Err error
+}
+
+func (e ErrPortInUse) Error() string {
+	return fmt.Sprintf("servermanager: the %s port %d is already in use, check that no other server is using it (%s)", e.Purpose, e.Port, e.Err)
+}
+
+// checkPorts makes sure that each port the event is about to use can be bound, so that a conflict is reported with the
+// port that caused it rather than as a failure further into the start. A UDP plugin local port of zero is replaced
+// with a free port. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) checkPorts(serverOptions *GlobalServerConfig) error {
+	if sp.udpPluginLocalPort == 0 {
+		port, err := FreeUDPPort()
+
+		if err != nil {
+			return err
+		}
+
+		logrus.Infof("No UDP plugin local port was given, using port %d", port)
+
+		sp.udpPluginLocalPort = port
+	}
+
+	if host, portStr, err := net.SplitHostPort(sp.udpPluginAddress); err == nil {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			if err := probeUDPPort(host, port, PortPurposeUDPPlugin); err != nil {
+				return err
+			}
+		}
+	}
+
+	// a remote acServer listens on its UDP plugin local port on the remote host.
+	if _, remote := sp.remote(); !remote {
+		if err := probeUDPPort("", sp.udpPluginLocalPort, PortPurposeUDPPluginLocal); err != nil {
+			return err
+		}
+	}
+
+	if sp.forwardingAddress != "" && sp.forwardListenPort != 0 {
+		if err := probeUDPPort("", sp.forwardListenPort, PortPurposeUDPForwarding); err != nil {
+			return err
+		}
+	}
+
+	if serverOptions.EnableContentManagerWrapper == 1 && serverOptions.ContentManagerWrapperPort > 0 {
+		if err := probeTCPPort(serverOptions.ContentManagerWrapperPort, PortPurposeContentManagerWrapper); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// probeUDPPort binds port, as FreeUDPPort does, and releases it straight away.
+func probeUDPPort(host string, port int, purpose string) error {
+	l, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP(host), Port: port})
+
+	if err != nil {
+		return ErrPortInUse{Port: port, Purpose: purpose, Err: err}
+	}
+
+	return l.Close()
+}
+
+func probeTCPPort(port int, purpose string) error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+
+	if err != nil {
+		return ErrPortInUse{Port: port, Purpose: purpose, Err: err}
+	}
+
+	return l.Close()
+}
+
+// autoUDPPluginPorts picks the UDP plugin address and local port for plugins to use when AutoUDPPluginPorts is on.
+// They are different to the ports used between acServer and Server Manager, which are also picked at random.
+func autoUDPPluginPorts(serverOpts *GlobalServerConfig) (address string, localPort int, err error) {
+	used := map[int]bool{serverOpts.FreeUDPPluginLocalPort: true}
+
+	if _, port, err := net.SplitHostPort(serverOpts.FreeUDPPluginAddress); err == nil {
+		if port, err := strconv.Atoi(port); err == nil {
+			used[port] = true
+		}
+	}
+
+	var ports []int
+
+	for len(ports) < 2 {
+		port, err := FreeUDPPort()
+
+		if err != nil {
+			return "", 0, err
+		}
+
+		if !used[port] {
+			used[port] = true
+			ports = append(ports, port)
+		}
+	}
+
+	return fmt.Sprintf("127.0.0.1:%d", ports[0]), ports[1], nil
+}
@@ -0,0 +1,151 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestAssettoServerProcess_PortInUse(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	useTestServerScript(t, testServerScript)
+
+	udpPluginPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	udpPluginLocalPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwardListenPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range []struct {
+		purpose string
+		port    int
+	}{
+		{purpose: PortPurposeUDPPlugin, port: udpPluginPort},
+		{purpose: PortPurposeUDPPluginLocal, port: udpPluginLocalPort},
+		{purpose: PortPurposeUDPForwarding, port: forwardListenPort},
+	} {
+		t.Run(testCase.purpose, func(t *testing.T) {
+			// another server is still holding the port
+			conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: testCase.port})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer conn.Close()
+
+			err = sp.Start(QuickRace{}, fmt.Sprintf("127.0.0.1:%d", udpPluginPort), udpPluginLocalPort, "127.0.0.1:1", forwardListenPort)
+
+			var portInUse ErrPortInUse
+
+			if !errors.As(err, &portInUse) {
+				t.Fatalf("expected ErrPortInUse, got: %v", err)
+			}
+
+			if portInUse.Port != testCase.port || portInUse.Purpose != testCase.purpose {
+				t.Errorf("expected the %s port %d to be reported, got: %+v", testCase.purpose, testCase.port, portInUse)
+			}
+
+			if sp.IsRunning() {
+				t.Error("expected the server process not to be started")
+			}
+		})
+	}
+
+	t.Run("Content Manager wrapper", func(t *testing.T) {
+		l, err := net.Listen("tcp", ":0")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer l.Close()
+
+		opts, err := sp.store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts.EnableContentManagerWrapper = 1
+		opts.ContentManagerWrapperPort = l.Addr().(*net.TCPAddr).Port
+
+		if err := sp.store.UpsertServerOptions(opts); err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			opts.EnableContentManagerWrapper = 0
+
+			_ = sp.store.UpsertServerOptions(opts)
+		}()
+
+		err = sp.Start(QuickRace{}, fmt.Sprintf("127.0.0.1:%d", udpPluginPort), udpPluginLocalPort, "", 0)
+
+		var portInUse ErrPortInUse
+
+		if !errors.As(err, &portInUse) || portInUse.Purpose != PortPurposeContentManagerWrapper {
+			t.Fatalf("expected the Content Manager wrapper port to be reported, got: %v", err)
+		}
+	})
+
+	t.Run("Local port allocated", func(t *testing.T) {
+		if err := sp.Start(QuickRace{}, fmt.Sprintf("127.0.0.1:%d", udpPluginPort), 0, "", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		defer sp.Stop() //nolint:errcheck
+
+		if port := sp.Status().UDPPluginLocalPort; port == 0 {
+			t.Error("expected a UDP plugin local port to be allocated")
+		}
+	})
+}
+
+func TestAutoUDPPluginPorts(t *testing.T) {
+	serverOpts := &GlobalServerConfig{FreeUDPPluginAddress: "127.0.0.1:10001", FreeUDPPluginLocalPort: 10002}
+
+	address, localPort, err := autoUDPPluginPorts(serverOpts)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, portStr, err := net.SplitHostPort(address)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	port, err := strconv.Atoi(portStr)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, used := range []int{0, 10001, 10002, localPort} {
+		if port == used {
+			t.Errorf("expected the plugin address port to be free and different to the other UDP plugin ports, got %d", port)
+		}
+	}
+
+	if localPort == 0 || localPort == 10001 || localPort == 10002 {
+		t.Errorf("expected the plugin local port to be free and different to the other UDP plugin ports, got %d", localPort)
+	}
+}
@@ -0,0 +1,58 @@
+package servermanager
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProcessPriority is the scheduling priority acServer is run with.
+type ProcessPriority string
+
+const (
+	ProcessPriorityIdle        ProcessPriority = "idle"
+	ProcessPriorityBelowNormal ProcessPriority = "below_normal"
+	ProcessPriorityNormal      ProcessPriority = "normal"
+	ProcessPriorityAboveNormal ProcessPriority = "above_normal"
+	ProcessPriorityHigh        ProcessPriority = "high"
+)
+
+var (
+	ErrInvalidProcessPriority       = errors.New("servermanager: process_priority must be one of idle, below_normal, normal, above_normal or high")
+	ErrInvalidCPUAffinity           = errors.New("servermanager: cpu_affinity must only contain CPU numbers of zero or more")
+	ErrProcessSchedulingUnsupported = errors.New("servermanager: cpu affinity and process priority are not supported on this platform")
+)
+
+// niceValue is the unix nice value which is closest to the priority.
+func (p ProcessPriority) niceValue() (int, error) {
+	switch p {
+	case ProcessPriorityIdle:
+		return 19, nil
+	case ProcessPriorityBelowNormal:
+		return 10, nil
+	case ProcessPriorityNormal:
+		return 0, nil
+	case ProcessPriorityAboveNormal:
+		return -5, nil
+	case ProcessPriorityHigh:
+		return -10, nil
+	default:
+		return 0, ErrInvalidProcessPriority
+	}
+}
+
+// applyProcessScheduling pins the process pid, which is called name in the log, to the configured CPUs and sets its
+// priority. The process is left running if either can't be applied.
+func applyProcessScheduling(pid int, name string, cpus []int, priority ProcessPriority) {
+	if len(cpus) > 0 {
+		if err := setCPUAffinity(pid, cpus); err != nil {
+			logrus.WithError(err).Warnf("Could not pin %s to CPUs %v", name, cpus)
+		}
+	}
+
+	if priority != "" {
+		if err := setProcessPriority(pid, priority); err != nil {
+			logrus.WithError(err).Warnf("Could not set %s's priority to %s", name, priority)
+		}
+	}
+}
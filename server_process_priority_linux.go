@@ -0,0 +1,44 @@
+// +build linux
+
+package servermanager
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// setCPUAffinity restricts the process pid to run on cpus. Threads and processes it starts afterwards inherit this.
+func setCPUAffinity(pid int, cpus []int) error {
+	var mask []uint64
+
+	for _, cpu := range cpus {
+		if cpu < 0 {
+			return ErrInvalidCPUAffinity
+		}
+
+		for len(mask) <= cpu/64 {
+			mask = append(mask, 0)
+		}
+
+		mask[cpu/64] |= 1 << uint(cpu%64)
+	}
+
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(pid), uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask[0])))
+
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// setProcessPriority sets the nice value of the process pid. Raising the priority above normal usually requires root.
+func setProcessPriority(pid int, priority ProcessPriority) error {
+	nice, err := priority.niceValue()
+
+	if err != nil {
+		return err
+	}
+
+	return syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice)
+}
@@ -0,0 +1,113 @@
+// +build linux
+
+package servermanager
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestProcessScheduling(t *testing.T) {
+	cmd := exec.Command("sleep", "600")
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	pid := cmd.Process.Pid
+
+	t.Run("CPU affinity", func(t *testing.T) {
+		if err := setCPUAffinity(pid, []int{0}); err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/status")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var allowed string
+
+		for _, line := range strings.Split(string(status), "\n") {
+			if strings.HasPrefix(line, "Cpus_allowed_list:") {
+				allowed = strings.TrimSpace(strings.TrimPrefix(line, "Cpus_allowed_list:"))
+			}
+		}
+
+		if allowed != "0" {
+			t.Errorf("expected process to be pinned to CPU 0, got: %q", allowed)
+		}
+
+		if err := setCPUAffinity(pid, []int{-1}); err != ErrInvalidCPUAffinity {
+			t.Errorf("expected negative CPUs to be rejected, got: %v", err)
+		}
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		if err := setProcessPriority(pid, ProcessPriorityBelowNormal); err != nil {
+			t.Fatal(err)
+		}
+
+		// the raw getpriority syscall returns 20 - nice.
+		priority, err := syscall.Getpriority(syscall.PRIO_PROCESS, pid)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if nice := 20 - priority; nice != 10 {
+			t.Errorf("expected nice value of 10, got: %d", nice)
+		}
+
+		if err := setProcessPriority(pid, "realtime"); err != ErrInvalidProcessPriority {
+			t.Errorf("expected unknown priorities to be rejected, got: %v", err)
+		}
+	})
+}
+
+func TestAssettoServerProcess_PluginScheduling(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	useTestServerScript(t, testServerScript)
+
+	plugin := filepath.Join(ServerInstallPath, "plugin.sh")
+
+	if err := ioutil.WriteFile(plugin, []byte("#!/bin/sh\nexec sleep 600\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Server.Plugins = []*CommandPlugin{
+		{Executable: plugin, ProcessPriority: ProcessPriorityIdle},
+	}
+
+	startTestServerProcess(t, sp, testServerScript)
+	defer sp.Stop() //nolint:errcheck
+
+	health := sp.PluginHealth()
+
+	if len(health) != 1 || health[0].PID == 0 {
+		t.Fatalf("expected the plugin to be running, got: %+v", health)
+	}
+
+	priority, err := syscall.Getpriority(syscall.PRIO_PROCESS, health[0].PID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nice := 20 - priority; nice != 19 {
+		t.Errorf("expected the plugin's own priority to be applied, got a nice value of %d", nice)
+	}
+}
@@ -0,0 +1,11 @@
+// +build !linux,!windows
+
+package servermanager
+
+func setCPUAffinity(pid int, cpus []int) error {
+	return ErrProcessSchedulingUnsupported
+}
+
+func setProcessPriority(pid int, priority ProcessPriority) error {
+	return ErrProcessSchedulingUnsupported
+}
@@ -0,0 +1,84 @@
+// +build windows
+
+package servermanager
+
+import (
+	"syscall"
+)
+
+const (
+	processSetInformation   = 0x0200
+	processQueryInformation = 0x0400
+
+	idlePriorityClass        = 0x00000040
+	belowNormalPriorityClass = 0x00004000
+	normalPriorityClass      = 0x00000020
+	aboveNormalPriorityClass = 0x00008000
+	highPriorityClass        = 0x00000080
+)
+
+var (
+	kernel32                   = syscall.NewLazyDLL("kernel32.dll")
+	procSetPriorityClass       = kernel32.NewProc("SetPriorityClass")
+	procSetProcessAffinityMask = kernel32.NewProc("SetProcessAffinityMask")
+)
+
+// setCPUAffinity restricts the process pid to run on cpus. Only the first 64 CPUs can be used.
+func setCPUAffinity(pid int, cpus []int) error {
+	var mask uintptr
+
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= 64 {
+			return ErrInvalidCPUAffinity
+		}
+
+		mask |= 1 << uint(cpu)
+	}
+
+	return withProcessHandle(pid, func(handle syscall.Handle) error {
+		if ok, _, err := procSetProcessAffinityMask.Call(uintptr(handle), mask); ok == 0 {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func setProcessPriority(pid int, priority ProcessPriority) error {
+	var class uintptr
+
+	switch priority {
+	case ProcessPriorityIdle:
+		class = idlePriorityClass
+	case ProcessPriorityBelowNormal:
+		class = belowNormalPriorityClass
+	case ProcessPriorityNormal:
+		class = normalPriorityClass
+	case ProcessPriorityAboveNormal:
+		class = aboveNormalPriorityClass
+	case ProcessPriorityHigh:
+		class = highPriorityClass
+	default:
+		return ErrInvalidProcessPriority
+	}
+
+	return withProcessHandle(pid, func(handle syscall.Handle) error {
+		if ok, _, err := procSetPriorityClass.Call(uintptr(handle), class); ok == 0 {
+			return err
+		}
+
+		return nil
+	})
+}
+
+func withProcessHandle(pid int, fn func(handle syscall.Handle) error) error {
+	handle, err := syscall.OpenProcess(processSetInformation|processQueryInformation, false, uint32(pid))
+
+	if err != nil {
+		return err
+	}
+
+	defer syscall.CloseHandle(handle) //nolint:errcheck
+
+	return fn(handle)
+}
@@ -0,0 +1,123 @@
+package servermanager
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrServerStartupTimeout    = errors.New("servermanager: acServer did not report that it was ready before the startup timeout")
+	ErrServerExitedBeforeReady = errors.New("servermanager: acServer exited before it was ready")
+)
+
+// serverReadyRegex matches the lines acServer prints once it has loaded its configuration and is accepting
+// connections.
+var serverReadyRegex = regexp.MustCompile(`^(Lobby registration successful|OK)\s*$`)
+
+// defaultGamePortBindWait is how long Start waits without a startup timeout for acServer to either report that it is
+// ready or that it couldn't bind its game ports. acServer binds its ports soon after it is launched.
+const defaultGamePortBindWait = time.Second * 5
+
+// WithStartupTimeout makes Start wait up to timeout for acServer to report that it is ready. With a zero timeout,
+// Start returns once acServer is ready or has failed to bind its game ports, or after a few seconds without either.
+func WithStartupTimeout(timeout time.Duration) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.StartupTimeout = timeout
+	}
+}
+
+// startupReadiness is finished once, either when acServer reports that it is ready or when it stops.
+type startupReadiness struct {
+	once  sync.Once
+	done  chan struct{}
+	ready bool
+}
+
+func newStartupReadiness() *startupReadiness {
+	return &startupReadiness{done: make(chan struct{})}
+}
+
+func (r *startupReadiness) finish(ready bool) {
+	r.once.Do(func() {
+		r.ready = ready
+		close(r.done)
+	})
+}
+
+// waitUntilReady waits for the event which has just been started to report that it is ready. If it doesn't within
+// StartupTimeout, or ctx is done first, acServer is stopped. Without a StartupTimeout, it only waits up to
+// gamePortBindWait for a game port bind failure, and acServer is left running if it hasn't reported either.
+func (sp *AssettoServerProcess) waitUntilReady(ctx context.Context) error {
+	sp.mutex.Lock()
+	readiness := sp.readiness
+	sp.mutex.Unlock()
+
+	if readiness == nil {
+		return nil
+	}
+
+	if sp.StartupTimeout <= 0 {
+		return sp.waitForGamePortBind(ctx, readiness)
+	}
+
+	timeout := time.NewTimer(sp.StartupTimeout)
+	defer timeout.Stop()
+
+	select {
+	case <-readiness.done:
+		if readiness.ready {
+			return nil
+		}
+
+		if err := sp.StartupError(); err != nil {
+			return err
+		}
+
+		return ErrServerExitedBeforeReady
+	case <-timeout.C:
+		logrus.Errorf("acServer did not report that it was ready within %s. Stopping server process", sp.StartupTimeout)
+
+		if err := sp.Stop(); err != nil {
+			logrus.WithError(err).Error("Could not stop server process after startup timeout")
+		}
+
+		return ErrServerStartupTimeout
+	case <-ctx.Done():
+		logrus.Info("Start was cancelled before acServer reported that it was ready. Stopping server process")
+
+		if err := sp.Stop(); err != nil {
+			logrus.WithError(err).Error("Could not stop server process after the start was cancelled")
+		}
+
+		return ctx.Err()
+	}
+}
+
+// waitForGamePortBind returns an ErrGamePortInUse if acServer fails to bind its game ports before it is ready, within
+// gamePortBindWait.
+func (sp *AssettoServerProcess) waitForGamePortBind(ctx context.Context, readiness *startupReadiness) error {
+	if sp.gamePortBindWait <= 0 {
+		return nil
+	}
+
+	timeout := time.NewTimer(sp.gamePortBindWait)
+	defer timeout.Stop()
+
+	select {
+	case <-readiness.done:
+		if err, ok := sp.StartupError().(ErrGamePortInUse); ok && !readiness.ready {
+			return err
+		}
+
+		return nil
+	case <-timeout.C:
+		return nil
+	case <-ctx.Done():
+		return nil
+	}
+}
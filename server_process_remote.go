@@ -0,0 +1,255 @@
+package servermanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RemoteConfig runs acServer on another host over SSH. Server Manager writes the server config locally as usual, and
+// copies it to the remote install path before each event. acServer's output is streamed back over the SSH connection,
+// and results files are copied back as each session ends.
+//
+// The remote host needs its own install of acServer and the content its events use. SSH must be able to log in
+// without a password, e.g. with a key in an ssh-agent or IdentityFile.
+type RemoteConfig struct {
+	// Host is the host acServer is run on. The remote server is only used if Host is set.
+	Host string `yaml:"host"`
+	User string `yaml:"user"`
+	Port int    `yaml:"port"`
+
+	// IdentityFile is the private key SSH logs in with. The default keys or the ssh-agent are used if it is empty.
+	IdentityFile string `yaml:"identity_file"`
+
+	// InstallPath is the path to the acServer install on the remote host.
+	InstallPath string `yaml:"install_path"`
+
+	// Executable is acServer, relative to InstallPath. It is "./acServer" if empty.
+	Executable string `yaml:"executable"`
+
+	// SSHBinary and SCPBinary are the SSH commands to use, "ssh" and "scp" if empty.
+	SSHBinary string `yaml:"ssh_binary"`
+	SCPBinary string `yaml:"scp_binary"`
+
+	// Options are passed to both ssh and scp as -o options, e.g. "StrictHostKeyChecking=accept-new".
+	Options []string `yaml:"options"`
+}
+
+var (
+	ErrRemoteInstallPathRequired = errors.New("servermanager: remote server config must set an install_path")
+	ErrRemoteAndDocker           = errors.New("servermanager: acServer can't be run both remotely and in docker, set only one of remote and docker")
+)
+
+const (
+	defaultRemoteExecutable = "./acServer"
+
+	// remotePIDFile is written in the remote install path, so that acServer can be stopped if SSH is disconnected.
+	remotePIDFile = "server-manager-acserver.pid"
+)
+
+func (r RemoteConfig) withDefaults() RemoteConfig {
+	if r.Executable == "" {
+		r.Executable = defaultRemoteExecutable
+	}
+
+	if r.SSHBinary == "" {
+		r.SSHBinary = "ssh"
+	}
+
+	if r.SCPBinary == "" {
+		r.SCPBinary = "scp"
+	}
+
+	return r
+}
+
+func (r RemoteConfig) validate() error {
+	if r.Host != "" && r.InstallPath == "" {
+		return ErrRemoteInstallPathRequired
+	}
+
+	return nil
+}
+
+// remoteLauncher is a serverLauncher which runs acServer on another host.
+type remoteLauncher interface {
+	serverLauncher
+
+	// remoteHost is where UDP plugin messages are sent to acServer.
+	remoteHost() string
+
+	// fetchResultsFile copies a results file written by acServer back to the local install path.
+	fetchResultsFile(name string) error
+}
+
+// WithRemote runs acServer on another host over SSH, see RemoteConfig.
+func WithRemote(conf RemoteConfig) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.launcher = &sshLauncher{config: conf.withDefaults()}
+	}
+}
+
+type sshLauncher struct {
+	config RemoteConfig
+
+	// installPath is the local install path of the event which was last started. It is read from the UDP
+	// callback, so it is guarded by mutex.
+	installPath string
+	mutex       sync.Mutex
+}
+
+func (s *sshLauncher) localInstallPath() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.installPath
+}
+
+func (s *sshLauncher) remoteHost() string {
+	return s.config.Host
+}
+
+func (s *sshLauncher) target() string {
+	if s.config.User != "" {
+		return s.config.User + "@" + s.config.Host
+	}
+
+	return s.config.Host
+}
+
+func (s *sshLauncher) remotePath(elem ...string) string {
+	return path.Join(append([]string{s.config.InstallPath}, elem...)...)
+}
+
+// commonArgs are given to both ssh and scp. They differ only in the flag for the port.
+func (s *sshLauncher) commonArgs(portFlag string) []string {
+	args := []string{"-o", "BatchMode=yes"}
+
+	if s.config.Port > 0 {
+		args = append(args, portFlag, strconv.Itoa(s.config.Port))
+	}
+
+	if s.config.IdentityFile != "" {
+		args = append(args, "-i", s.config.IdentityFile)
+	}
+
+	for _, option := range s.config.Options {
+		args = append(args, "-o", option)
+	}
+
+	return args
+}
+
+func (s *sshLauncher) sshArgs(remoteCommand string) []string {
+	return append(s.commonArgs("-p"), s.target(), remoteCommand)
+}
+
+func (s *sshLauncher) scpArgs(src, dst string) []string {
+	return append(s.commonArgs("-P"), "-r", src, dst)
+}
+
+func (s *sshLauncher) run(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// runCommand runs acServer from the remote install path, recording its pid so that it can be stopped later.
+func (s *sshLauncher) runCommand() string {
+	return fmt.Sprintf("cd %s && echo $$ > %s && exec %s", shellQuote(s.config.InstallPath), remotePIDFile, shellQuote(s.config.Executable))
+}
+
+func (s *sshLauncher) command(ctx context.Context, sp *AssettoServerProcess, _ *GlobalServerConfig) (*exec.Cmd, error) {
+	installPath := sp.installPath()
+
+	s.mutex.Lock()
+	s.installPath = installPath
+	s.mutex.Unlock()
+
+	if err := s.run(s.config.SCPBinary, s.scpArgs(filepath.Join(installPath, ServerConfigPath), s.target()+":"+s.config.InstallPath+"/")...); err != nil {
+		return nil, fmt.Errorf("servermanager: could not copy the server config to %s: %s", s.config.Host, err)
+	}
+
+	// an acServer left running by a dropped SSH connection would stop this one from starting.
+	s.stopRemote()
+
+	logrus.Debugf("Running acServer on %s in %s", s.config.Host, s.config.InstallPath)
+
+	cmd := buildCommand(ctx, s.config.SSHBinary, s.sshArgs(s.runCommand())...)
+	cmd.Dir = installPath
+
+	return cmd, nil
+}
+
+// stopRemote stops acServer on the remote host, which is left running if only the SSH connection is closed.
+func (s *sshLauncher) stopRemote() {
+	stop := fmt.Sprintf("cd %s && if [ -f %s ]; then kill $(cat %s) 2>/dev/null; rm -f %s; fi", shellQuote(s.config.InstallPath), remotePIDFile, remotePIDFile, remotePIDFile)
+
+	if err := s.run(s.config.SSHBinary, s.sshArgs(stop)...); err != nil {
+		logrus.WithError(err).Warnf("Could not stop acServer on %s", s.config.Host)
+	}
+}
+
+// cleanup stops acServer and copies back any results which weren't copied as their session ended.
+func (s *sshLauncher) cleanup() {
+	s.stopRemote()
+
+	installPath := s.localInstallPath()
+
+	if installPath == "" {
+		return
+	}
+
+	if err := s.run(s.config.SCPBinary, s.scpArgs(s.target()+":"+s.remotePath("results"), installPath+string(filepath.Separator))...); err != nil {
+		logrus.WithError(err).Warnf("Could not copy results back from %s", s.config.Host)
+	}
+}
+
+func (s *sshLauncher) fetchResultsFile(name string) error {
+	installPath := s.localInstallPath()
+
+	if installPath == "" {
+		return nil
+	}
+
+	name = path.Base(strings.Replace(name, `\`, "/", -1))
+
+	return s.run(s.config.SCPBinary, s.scpArgs(s.target()+":"+s.remotePath("results", name), filepath.Join(installPath, "results", name))...)
+}
+
+// shellQuote quotes s for the remote shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// remote returns the remoteLauncher if acServer is run on another host.
+func (sp *AssettoServerProcess) remote() (remoteLauncher, bool) {
+	remote, ok := sp.launcher.(remoteLauncher)
+
+	return remote, ok
+}
+
+// fetchRemoteResults copies a results file back from a remote acServer before the end of its session is handled.
+func (sp *AssettoServerProcess) fetchRemoteResults(resultsFile string) {
+	remote, ok := sp.remote()
+
+	if !ok {
+		return
+	}
+
+	if err := remote.fetchResultsFile(resultsFile); err != nil {
+		logrus.WithError(err).Errorf("Could not copy results file %s from %s", resultsFile, remote.remoteHost())
+	}
+}
@@ -0,0 +1,120 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// fakeSSHScript runs the remote command on this host.
+const fakeSSHScript = `#!/bin/sh
+for last; do :; done
+exec sh -c "$last"
+`
+
+// fakeSCPScript copies the last two arguments on this host, without their host prefix.
+const fakeSCPScript = `#!/bin/sh
+for arg; do src=$dst; dst=$arg; done
+exec cp -r "${src#*:}" "${dst#*:}"
+`
+
+func TestSSHLauncher_Args(t *testing.T) {
+	s := &sshLauncher{config: RemoteConfig{
+		Host:         "203.0.113.10",
+		User:         "acserver",
+		Port:         2222,
+		IdentityFile: "/keys/id_ed25519",
+		InstallPath:  "/home/acserver/assetto server",
+		Options:      []string{"StrictHostKeyChecking=accept-new"},
+	}.withDefaults()}
+
+	ssh := strings.Join(s.sshArgs(s.runCommand()), " ")
+	expected := "-o BatchMode=yes -p 2222 -i /keys/id_ed25519 -o StrictHostKeyChecking=accept-new acserver@203.0.113.10 cd '/home/acserver/assetto server' && echo $$ > server-manager-acserver.pid && exec './acServer'"
+
+	if ssh != expected {
+		t.Errorf("expected ssh args %q, got %q", expected, ssh)
+	}
+
+	if scp := strings.Join(s.scpArgs("a", "b"), " "); !strings.HasPrefix(scp, "-o BatchMode=yes -P 2222") || !strings.HasSuffix(scp, "-r a b") {
+		t.Errorf("expected scp to be given the port with -P, got %q", scp)
+	}
+
+	if quoted := shellQuote("it's"); quoted != `'it'\''s'` {
+		t.Errorf("expected a single quote to be escaped, got %s", quoted)
+	}
+
+	if err := (RemoteConfig{Host: "203.0.113.10"}).validate(); err != ErrRemoteInstallPathRequired {
+		t.Errorf("expected a remote config without an install path to be rejected, got %v", err)
+	}
+}
+
+func TestAssettoServerProcess_Remote(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	remoteInstallPath := filepath.Join(filepath.Dir(ServerInstallPath), "remote")
+
+	for _, dir := range []string{filepath.Join(remoteInstallPath, "results"), filepath.Join(ServerInstallPath, ServerConfigPath), filepath.Join(ServerInstallPath, "results")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(ServerInstallPath, ServerConfigPath, serverConfigIniPath), []byte("[SERVER]\nTCP_PORT=9600\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, contents := range map[string]string{
+		filepath.Join(ServerInstallPath, "ssh.sh"):                             fakeSSHScript,
+		filepath.Join(ServerInstallPath, "scp.sh"):                             fakeSCPScript,
+		filepath.Join(remoteInstallPath, "acServer"):                           testServerScript,
+		filepath.Join(remoteInstallPath, "results", "2020_1_1_12_0_RACE.json"): "{}",
+	} {
+		if err := ioutil.WriteFile(name, []byte(contents), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	WithRemote(RemoteConfig{
+		Host:        "127.0.0.1",
+		InstallPath: remoteInstallPath,
+		SSHBinary:   filepath.Join(ServerInstallPath, "ssh.sh"),
+		SCPBinary:   filepath.Join(ServerInstallPath, "scp.sh"),
+	})(sp)
+
+	startTestServerProcess(t, sp, testServerScript)
+
+	if _, err := os.Stat(filepath.Join(remoteInstallPath, ServerConfigPath, serverConfigIniPath)); err != nil {
+		t.Errorf("expected the server config to be copied to the remote install path: %s", err)
+	}
+
+	if !sp.IsRunning() {
+		t.Fatal("expected acServer to be running remotely")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(remoteInstallPath, "results", "2020_1_1_12_0_QUALIFY.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sp.UDPCallback(udp.EndSession(`results\2020_1_1_12_0_QUALIFY.json`))
+
+	if _, err := os.Stat(filepath.Join(ServerInstallPath, "results", "2020_1_1_12_0_QUALIFY.json")); err != nil {
+		t.Errorf("expected the results to be copied back as the session ended: %s", err)
+	}
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(ServerInstallPath, "results", "2020_1_1_12_0_RACE.json")); err != nil {
+		t.Errorf("expected the results to be copied back once acServer stopped: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(remoteInstallPath, remotePIDFile)); !os.IsNotExist(err) {
+		t.Errorf("expected the remote pid file to be removed once acServer stopped, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package servermanager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	resultsUploadBufferSize           = 20
+	resultsUploadDefaultMaxAttempts   = 5
+	resultsUploadDefaultRetryInterval = time.Second * 10
+	resultsUploadMaxRetryInterval     = time.Minute * 5
+	resultsUploadTimeout              = time.Second * 30
+)
+
+// ResultsUploader sends the results file of each session to an external API when the session ends. It is an event
+// sink: it listens for ResultsReady events, and publishes a ResultsUploaded or ResultsUploadFailed event once it has
+// finished with each file, so that the outcome is recorded alongside the other lifecycle events.
+type ResultsUploader struct {
+	url           string
+	headers       map[string]string
+	maxAttempts   int
+	retryInterval time.Duration
+	client        *http.Client
+
+	uploads chan ProcessEvent
+	publish func(ProcessEvent)
+}
+
+// NewResultsUploader creates a ResultsUploader which reports the status of each upload to publish.
+func NewResultsUploader(conf ResultsUploadConfig, publish func(ProcessEvent)) (*ResultsUploader, error) {
+	u, err := url.Parse(conf.URL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("servermanager: unsupported results upload url scheme: %s", u.Scheme)
+	}
+
+	uploader := &ResultsUploader{
+		url:           conf.URL,
+		headers:       conf.Headers,
+		maxAttempts:   conf.MaxAttempts,
+		retryInterval: conf.RetryInterval,
+		client:        &http.Client{Timeout: resultsUploadTimeout},
+		uploads:       make(chan ProcessEvent, resultsUploadBufferSize),
+		publish:       publish,
+	}
+
+	if uploader.maxAttempts <= 0 {
+		uploader.maxAttempts = resultsUploadDefaultMaxAttempts
+	}
+
+	if uploader.retryInterval <= 0 {
+		uploader.retryInterval = resultsUploadDefaultRetryInterval
+	}
+
+	go uploader.run()
+
+	return uploader, nil
+}
+
+func (u *ResultsUploader) Publish(event ProcessEvent) {
+	if event.Type != ProcessEventResultsReady || event.ResultsFile == "" {
+		return
+	}
+
+	select {
+	case u.uploads <- event:
+	default:
+		logrus.Warnf("Results upload queue is full, not uploading %s", event.ResultsFile)
+	}
+}
+
+func (u *ResultsUploader) run() {
+	for event := range u.uploads {
+		attempts, err := u.upload(event.ResultsFile)
+
+		status := ProcessEvent{
+			Type:        ProcessEventResultsUploaded,
+			EventName:   event.EventName,
+			ResultsFile: event.ResultsFile,
+		}
+
+		if err != nil {
+			logrus.WithError(err).Errorf("Could not upload results file %s after %d attempt(s)", event.ResultsFile, attempts)
+
+			status.Type = ProcessEventResultsUploadFailed
+			status.Error = err.Error()
+		} else {
+			logrus.Infof("Uploaded results file %s", event.ResultsFile)
+		}
+
+		u.publish(status)
+	}
+}
+
+// upload sends a results file, retrying with an increasing interval between attempts until it is accepted, the
+// endpoint rejects it outright, or the maximum number of attempts is reached. It returns the number of attempts made.
+func (u *ResultsUploader) upload(resultsFile string) (int, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ServerInstallPath, "results", resultsFile))
+
+	if err != nil {
+		return 0, err
+	}
+
+	interval := u.retryInterval
+
+	for attempt := 1; ; attempt++ {
+		retry, err := u.send(resultsFile, data)
+
+		if err == nil || !retry || attempt >= u.maxAttempts {
+			return attempt, err
+		}
+
+		logrus.WithError(err).Warnf("Results upload of %s failed, retrying in %s", resultsFile, interval)
+
+		time.Sleep(interval)
+
+		interval *= 2
+
+		if interval > resultsUploadMaxRetryInterval {
+			interval = resultsUploadMaxRetryInterval
+		}
+	}
+}
+
+// send makes a single upload attempt. If it fails, retry is true unless the endpoint rejected the results.
+func (u *ResultsUploader) send(resultsFile string, data []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, u.url, bytes.NewReader(data))
+
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Results-File", resultsFile)
+
+	for key, value := range u.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := u.client.Do(req)
+
+	if err != nil {
+		return true, err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("servermanager: results upload endpoint returned %s", resp.Status)
+
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
@@ -0,0 +1,119 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResultsUploader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-results-upload")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldServerInstallPath := ServerInstallPath
+	ServerInstallPath = dir
+	defer func() {
+		ServerInstallPath = oldServerInstallPath
+	}()
+
+	if err := os.MkdirAll(filepath.Join(dir, "results"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	const resultsFile = "2020_6_1_20_0_RACE.json"
+	results := []byte(`{"Type": "RACE"}`)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "results", resultsFile), results, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testCase := range []struct {
+		name             string
+		statuses         []int
+		expectedAttempts int32
+		expectedEvent    ProcessEventType
+	}{
+		{name: "Retried until accepted", statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}, expectedAttempts: 3, expectedEvent: ProcessEventResultsUploaded},
+		{name: "Gives up after max attempts", statuses: []int{http.StatusBadGateway}, expectedAttempts: 4, expectedEvent: ProcessEventResultsUploadFailed},
+		{name: "Rejected results are not retried", statuses: []int{http.StatusBadRequest}, expectedAttempts: 1, expectedEvent: ProcessEventResultsUploadFailed},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var attempts int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := atomic.AddInt32(&attempts, 1)
+
+				if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Results-File") != resultsFile {
+					t.Errorf("unexpected upload request: %s %+v", r.Method, r.Header)
+				}
+
+				body, err := ioutil.ReadAll(r.Body)
+
+				if err != nil || string(body) != string(results) {
+					t.Errorf("expected results file to be uploaded, got: %s", body)
+				}
+
+				status := testCase.statuses[len(testCase.statuses)-1]
+
+				if int(attempt) <= len(testCase.statuses) {
+					status = testCase.statuses[attempt-1]
+				}
+
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			events := make(chan ProcessEvent, 10)
+
+			uploader, err := NewResultsUploader(ResultsUploadConfig{
+				URL:           server.URL,
+				Headers:       map[string]string{"Authorization": "Bearer secret"},
+				MaxAttempts:   4,
+				RetryInterval: time.Millisecond,
+			}, func(event ProcessEvent) {
+				events <- event
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// only results ready events cause an upload
+			uploader.Publish(ProcessEvent{Type: ProcessEventStopped})
+			uploader.Publish(ProcessEvent{Type: ProcessEventResultsReady, EventName: "Test Event", ResultsFile: resultsFile})
+
+			select {
+			case event := <-events:
+				if event.Type != testCase.expectedEvent || event.ResultsFile != resultsFile || event.EventName != "Test Event" {
+					t.Errorf("expected %s event for %s, got: %+v", testCase.expectedEvent, resultsFile, event)
+				}
+
+				if (event.Type == ProcessEventResultsUploadFailed) != (event.Error != "") {
+					t.Errorf("expected only failed uploads to have an error, got: %+v", event)
+				}
+			case <-time.After(time.Second * 5):
+				t.Fatal("expected upload status to be published")
+			}
+
+			if got := atomic.LoadInt32(&attempts); got != testCase.expectedAttempts {
+				t.Errorf("expected %d upload attempts, got %d", testCase.expectedAttempts, got)
+			}
+		})
+	}
+
+	t.Run("Invalid url", func(t *testing.T) {
+		if _, err := NewResultsUploader(ResultsUploadConfig{URL: "ftp://example.com"}, func(ProcessEvent) {}); err == nil {
+			t.Error("expected an error for an unsupported url scheme")
+		}
+	})
+}
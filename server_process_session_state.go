@@ -0,0 +1,254 @@
+package servermanager
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// SessionState is what acServer has said about the session which is running, kept up to date from its UDP messages.
+type SessionState struct {
+	Type         udp.SessionType
+	Name         string
+	SessionIndex uint8
+	SessionCount uint8
+	Track        string
+	TrackConfig  string
+
+	// StartedAt is when the session started, or will start if it is still in its wait time.
+	StartedAt time.Time
+
+	// TimeRemaining is how long is left in a timed session. LapsRemaining is how many laps the leader has left in a
+	// session with a lap count. Each is zero for sessions which don't have one.
+	TimeRemaining time.Duration
+	LapsRemaining int
+
+	// Ended is set once acServer has written the session's results, to ResultsFile.
+	Ended       bool
+	ResultsFile string
+
+	// Cars are the cars which have connected during the session, in position order. Cars without a position (i.e.
+	// which haven't completed a lap) come last, in car ID order.
+	Cars []CarState
+
+	UpdatedAt time.Time
+}
+
+// CarState is what acServer has said about a car during the session.
+type CarState struct {
+	CarID      udp.CarID
+	DriverName string
+	DriverGUID udp.DriverGUID
+	CarModel   string
+
+	Connected bool
+	Loaded    bool
+
+	// Position is zero until the car has completed a lap.
+	Position  int
+	Laps      int
+	LastLap   time.Duration
+	BestLap   time.Duration
+	LastCuts  int
+	SplinePos float32
+
+	LastSeen time.Time
+}
+
+// sessionState builds a SessionState from acServer's UDP messages.
+type sessionState struct {
+	mutex sync.Mutex
+
+	session    udp.SessionInfo
+	hasSession bool
+	startedAt  time.Time
+
+	// sessionStart is startedAt as it was when the session began. startedAt moves by a few milliseconds with each
+	// session info, so it can't be used to tell sessions apart.
+	sessionStart time.Time
+
+	ended       bool
+	resultsFile string
+	leaderLaps  int
+	cars        map[udp.CarID]*CarState
+	updatedAt   time.Time
+}
+
+func (s *sessionState) handle(message udp.Message, now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch m := message.(type) {
+	case udp.Version:
+		// acServer has (re)started.
+		s.session, s.hasSession, s.startedAt, s.sessionStart, s.ended, s.resultsFile, s.leaderLaps, s.cars = udp.SessionInfo{}, false, time.Time{}, time.Time{}, false, "", 0, nil
+	case udp.SessionInfo:
+		newSession := m.Event() == udp.EventNewSession || !s.hasSession || m.CurrentSessionIndex != s.session.CurrentSessionIndex
+
+		s.session = m
+		s.hasSession = true
+		s.startedAt = now.Add(-time.Duration(m.ElapsedMilliseconds) * time.Millisecond)
+
+		if newSession {
+			s.sessionStart = s.startedAt
+			s.ended = false
+			s.resultsFile = ""
+			s.leaderLaps = 0
+
+			for _, car := range s.cars {
+				car.Position, car.Laps, car.LastLap, car.BestLap, car.LastCuts = 0, 0, 0, 0, 0
+			}
+		}
+	case udp.EndSession:
+		s.ended = true
+		s.resultsFile = string(m)
+	case udp.SessionCarInfo:
+		car := s.seen(m.CarID, now)
+		car.DriverName, car.DriverGUID, car.CarModel = m.DriverName, m.DriverGUID, m.CarModel
+
+		if m.Event() == udp.EventNewConnection {
+			car.Connected = true
+			car.Loaded = false
+			car.Position, car.Laps, car.LastLap, car.BestLap, car.LastCuts = 0, 0, 0, 0, 0
+		} else if m.Event() == udp.EventConnectionClosed {
+			car.Connected = false
+			car.Loaded = false
+		}
+	case udp.CarInfo:
+		car := s.seen(m.CarID, now)
+		car.DriverName, car.DriverGUID, car.CarModel, car.Connected = m.DriverName, m.DriverGUID, m.CarModel, m.IsConnected
+	case udp.ClientLoaded:
+		s.seen(udp.CarID(m), now).Loaded = true
+	case udp.CarUpdate:
+		car := s.seen(m.CarID, now)
+		car.SplinePos = m.NormalisedSplinePos
+		car.Connected = true
+	case udp.LapCompleted:
+		car := s.seen(m.CarID, now)
+		car.LastLap = time.Duration(m.LapTime) * time.Millisecond
+		car.LastCuts = int(m.Cuts)
+
+		// the leaderboard which comes with each lap is in position order.
+		for i, entry := range m.Cars {
+			if entry == nil || entry.Laps == 0 {
+				continue
+			}
+
+			leaderboardCar := s.car(entry.CarID)
+			leaderboardCar.Position = i + 1
+			leaderboardCar.Laps = int(entry.Laps)
+			leaderboardCar.BestLap = time.Duration(entry.LapTime) * time.Millisecond
+
+			if leaderboardCar.Laps > s.leaderLaps {
+				s.leaderLaps = leaderboardCar.Laps
+			}
+		}
+	default:
+		return
+	}
+
+	s.updatedAt = now
+}
+
+// car must be called with s.mutex held.
+func (s *sessionState) car(carID udp.CarID) *CarState {
+	if s.cars == nil {
+		s.cars = make(map[udp.CarID]*CarState)
+	}
+
+	car, ok := s.cars[carID]
+
+	if !ok {
+		car = &CarState{CarID: carID}
+		s.cars[carID] = car
+	}
+
+	return car
+}
+
+// seen returns the car which a message is about, recording that it has just been heard from. It must be called with
+// s.mutex held.
+func (s *sessionState) seen(carID udp.CarID, now time.Time) *CarState {
+	car := s.car(carID)
+	car.LastSeen = now
+
+	return car
+}
+
+// describeCar fills in the session and driver of sample's car.
+func (s *sessionState) describeCar(carID udp.CarID, sample *TelemetrySample) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sample.SessionType = s.session.Type
+	sample.SessionStart = s.sessionStart
+	sample.Track = s.session.Track
+	sample.TrackConfig = s.session.TrackConfig
+
+	if car, ok := s.cars[carID]; ok {
+		sample.DriverGUID, sample.DriverName, sample.CarModel = car.DriverGUID, car.DriverName, car.CarModel
+	}
+}
+
+func (s *sessionState) snapshot(now time.Time) SessionState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state := SessionState{
+		Type:         s.session.Type,
+		Name:         s.session.Name,
+		SessionIndex: s.session.CurrentSessionIndex,
+		SessionCount: s.session.SessionCount,
+		Track:        s.session.Track,
+		TrackConfig:  s.session.TrackConfig,
+		StartedAt:    s.startedAt,
+		Ended:        s.ended,
+		ResultsFile:  s.resultsFile,
+		UpdatedAt:    s.updatedAt,
+		Cars:         make([]CarState, 0, len(s.cars)),
+	}
+
+	if s.hasSession && !s.ended {
+		if s.session.Laps > 0 {
+			state.LapsRemaining = int(s.session.Laps) - s.leaderLaps
+
+			if state.LapsRemaining < 0 {
+				state.LapsRemaining = 0
+			}
+		} else if s.session.Time > 0 {
+			state.TimeRemaining = s.startedAt.Add(time.Duration(s.session.Time) * time.Minute).Sub(now)
+
+			if state.TimeRemaining < 0 {
+				state.TimeRemaining = 0
+			}
+		}
+	}
+
+	for _, car := range s.cars {
+		state.Cars = append(state.Cars, *car)
+	}
+
+	sort.Slice(state.Cars, func(i, j int) bool {
+		a, b := state.Cars[i], state.Cars[j]
+
+		if (a.Position == 0) != (b.Position == 0) {
+			return a.Position != 0
+		}
+
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+
+		return a.CarID < b.CarID
+	})
+
+	return state
+}
+
+// SessionState returns a snapshot of the session which is running, built from acServer's UDP messages. Use it rather
+// than keeping track of the session from UDP messages yourself.
+func (sp *AssettoServerProcess) SessionState() SessionState {
+	return sp.sessionState.snapshot(time.Now())
+}
@@ -0,0 +1,78 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestSessionState(t *testing.T) {
+	var state sessionState
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	state.handle(udp.Version(4), now)
+	state.handle(udp.SessionInfo{EventType: udp.EventNewSession, Type: udp.SessionTypeQualifying, Name: "Qualify", Time: 10, ElapsedMilliseconds: 60000, Track: "ks_vallelunga"}, now)
+	state.handle(udp.SessionCarInfo{EventType: udp.EventNewConnection, CarID: 1, DriverName: "Driver 1", DriverGUID: "1"}, now)
+	state.handle(udp.SessionCarInfo{EventType: udp.EventNewConnection, CarID: 2, DriverName: "Driver 2", DriverGUID: "2"}, now)
+	state.handle(udp.SessionCarInfo{EventType: udp.EventNewConnection, CarID: 3, DriverName: "Driver 3", DriverGUID: "3"}, now)
+	state.handle(udp.ClientLoaded(2), now)
+	state.handle(udp.LapCompleted{CarID: 2, LapTime: 71000, Cars: []*udp.LapCompletedCar{{CarID: 2, LapTime: 71000, Laps: 1}, {CarID: 1, Laps: 0}}}, now)
+	state.handle(udp.LapCompleted{CarID: 1, LapTime: 70500, Cuts: 1, Cars: []*udp.LapCompletedCar{{CarID: 1, LapTime: 70500, Laps: 1}, {CarID: 2, LapTime: 71000, Laps: 1}}}, now)
+	state.handle(udp.SessionCarInfo{EventType: udp.EventConnectionClosed, CarID: 3}, now)
+
+	snapshot := state.snapshot(now.Add(time.Minute))
+
+	if snapshot.Type != udp.SessionTypeQualifying || snapshot.Track != "ks_vallelunga" {
+		t.Errorf("expected the session info to be kept, got %+v", snapshot)
+	}
+
+	// the session started a minute before the session info arrived, and it is a minute later now.
+	if snapshot.TimeRemaining != time.Minute*8 {
+		t.Errorf("expected 8 minutes to be left, got %s", snapshot.TimeRemaining)
+	}
+
+	if len(snapshot.Cars) != 3 {
+		t.Fatalf("expected 3 cars, got %+v", snapshot.Cars)
+	}
+
+	first, second, third := snapshot.Cars[0], snapshot.Cars[1], snapshot.Cars[2]
+
+	if first.CarID != 1 || first.Position != 1 || first.LastLap != time.Millisecond*70500 || first.LastCuts != 1 {
+		t.Errorf("expected car 1 to lead after its lap, got %+v", first)
+	}
+
+	if second.CarID != 2 || second.Position != 2 || second.BestLap != time.Millisecond*71000 || !second.Loaded {
+		t.Errorf("expected car 2 to be second, got %+v", second)
+	}
+
+	if third.CarID != 3 || third.Position != 0 || third.Connected {
+		t.Errorf("expected car 3 to have disconnected without a position, got %+v", third)
+	}
+
+	state.handle(udp.SessionInfo{EventType: udp.EventNewSession, Type: udp.SessionTypeRace, CurrentSessionIndex: 1, Laps: 5}, now)
+	state.handle(udp.LapCompleted{CarID: 2, LapTime: 72000, Cars: []*udp.LapCompletedCar{{CarID: 2, LapTime: 72000, Laps: 1}}}, now)
+
+	snapshot = state.snapshot(now)
+
+	if snapshot.LapsRemaining != 4 || snapshot.TimeRemaining != 0 {
+		t.Errorf("expected 4 laps to be left in the race, got %+v", snapshot)
+	}
+
+	if snapshot.Cars[0].CarID != 2 || snapshot.Cars[1].Position != 0 || snapshot.Cars[1].BestLap != 0 {
+		t.Errorf("expected laps from the last session to be cleared, got %+v", snapshot.Cars)
+	}
+
+	state.handle(udp.EndSession("results/2020_6_1_12_0_RACE.json"), now)
+
+	if snapshot = state.snapshot(now); !snapshot.Ended || snapshot.LapsRemaining != 0 {
+		t.Errorf("expected the session to have ended, got %+v", snapshot)
+	}
+
+	state.handle(udp.Version(4), now)
+
+	if snapshot = state.snapshot(now); len(snapshot.Cars) != 0 || snapshot.Ended {
+		t.Errorf("expected a restart to clear the session, got %+v", snapshot)
+	}
+}
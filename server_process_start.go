@@ -0,0 +1,143 @@
+package servermanager
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrServerProcessStartCancelled = errors.New("servermanager: server process start was cancelled by a call to Stop")
+
+// startRequest asks the process loop to start event, and receives the result of the start.
+type startRequest struct {
+	event  RaceEvent
+	result chan error
+}
+
+// beginStart marks a start as in progress, so that a Stop which arrives before the process loop has finished starting
+// the event can cancel it.
+func (sp *AssettoServerProcess) beginStart() {
+	sp.startDoneMutex.Lock()
+	defer sp.startDoneMutex.Unlock()
+
+	atomic.StoreInt32(&sp.startCancelled, 0)
+	sp.startDone = make(chan struct{})
+}
+
+func (sp *AssettoServerProcess) endStart() {
+	sp.startDoneMutex.Lock()
+	defer sp.startDoneMutex.Unlock()
+
+	close(sp.startDone)
+	sp.startDone = nil
+}
+
+// cancelStart asks a start which is in progress to give up at its next checkpoint, and waits for it to do so. It
+// returns straight away if nothing is starting.
+func (sp *AssettoServerProcess) cancelStart() {
+	sp.startDoneMutex.Lock()
+	startDone := sp.startDone
+
+	if startDone != nil {
+		atomic.StoreInt32(&sp.startCancelled, 1)
+	}
+
+	sp.startDoneMutex.Unlock()
+
+	if startDone == nil {
+		return
+	}
+
+	logrus.Info("Stop requested while the server process is starting, cancelling the start")
+
+	<-startDone
+}
+
+// finishCancelledStart waits for a start which StartContext gave up on to finish, stopping acServer if it started
+// anyway. The start queue is held until it has, so that the next start doesn't race the teardown.
+func (sp *AssettoServerProcess) finishCancelledStart(req startRequest) {
+	err := <-req.result
+	sp.endStart()
+
+	if err == nil {
+		logrus.Info("Server process started after the start was abandoned, stopping it")
+
+		if err := sp.Stop(); err != nil {
+			logrus.WithError(err).Error("Could not stop server process after an abandoned start")
+		}
+	}
+
+	sp.startQueue.release()
+}
+
+// checkStartCancelled is called by startRaceEvent between each of its steps.
+func (sp *AssettoServerProcess) checkStartCancelled() error {
+	if atomic.LoadInt32(&sp.startCancelled) != 0 {
+		return ErrServerProcessStartCancelled
+	}
+
+	return nil
+}
+
+// abortStart tears down whatever startRaceEvent managed to start of raceEvent before it failed or was cancelled. It
+// must be called with sp.mutex held.
+func (sp *AssettoServerProcess) abortStart(raceEvent RaceEvent, err error) {
+	if err == ErrServerProcessStartCancelled {
+		logrus.Info("Server process start cancelled, tearing down")
+	} else {
+		logrus.WithError(err).Error("Server process failed to start, tearing down")
+	}
+
+	if sp.raceEvent != nil {
+		// acServer has been launched, so the process loop will be told when it ends. kill it and leave the loop to
+		// stop the UDP listener and plugins, as it would for any other stop.
+		sp.stopRequested = true
+		sp.startAborted = true
+
+		if sp.cmd.Process != nil {
+			if err := kill(getProcess(sp.cmd)); err != nil {
+				logrus.WithError(err).Errorf("Could not kill server process: %d", sp.cmd.Process.Pid)
+			}
+		}
+
+		return
+	}
+
+	if sp.cfn != nil {
+		sp.cfn()
+	}
+
+	sp.stopUDPSendQueue()
+
+	if sp.udpServerConn != nil {
+		if err := sp.stopUDPListener(); err != nil {
+			logrus.WithError(err).Error("UDP listener close errored")
+		}
+	}
+
+	if sp.logFile != nil {
+		_ = sp.logFile.Close()
+		sp.logFile = nil
+	}
+
+	if sp.errorLogFile != nil {
+		_ = sp.errorLogFile.Close()
+		sp.errorLogFile = nil
+	}
+
+	if sp.rotatingLogFile != nil {
+		_ = sp.rotatingLogFile.Close()
+		sp.rotatingLogFile = nil
+	}
+
+	sp.emit(ProcessEvent{Type: ProcessEventStartFailed, EventName: raceEvent.EventName(), RaceEvent: raceEvent, Error: err.Error()})
+}
+
+// wasStartAborted reports whether acServer was killed by abortStart, rather than being left running for Stop.
+func (sp *AssettoServerProcess) wasStartAborted() bool {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	return sp.startAborted
+}
@@ -0,0 +1,189 @@
+package servermanager
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StartPriority orders starts which are waiting for another start to finish. Starts with a higher priority go first,
+// and starts with the same priority go in the order they were made.
+type StartPriority int
+
+const (
+	StartPriorityLooper StartPriority = iota - 1
+	StartPriorityNormal
+	StartPriorityScheduled
+)
+
+func (p StartPriority) String() string {
+	switch p {
+	case StartPriorityLooper:
+		return "looper"
+	case StartPriorityScheduled:
+		return "scheduled"
+	default:
+		return "normal"
+	}
+}
+
+type startPriorityContextKey struct{}
+
+// WithStartPriority returns a context which queues a StartContext with priority. Without it, looped events are
+// queued with StartPriorityLooper and everything else with StartPriorityNormal.
+func WithStartPriority(ctx context.Context, priority StartPriority) context.Context {
+	return context.WithValue(ctx, startPriorityContextKey{}, priority)
+}
+
+func startPriority(ctx context.Context, event RaceEvent) StartPriority {
+	if priority, ok := ctx.Value(startPriorityContextKey{}).(StartPriority); ok {
+		return priority
+	}
+
+	if event != nil && event.IsLooping() {
+		return StartPriorityLooper
+	}
+
+	return StartPriorityNormal
+}
+
+// QueuedStart is a start which is waiting for the start in progress to finish.
+type QueuedStart struct {
+	// Position is 1 for the start which will go next.
+	Position  int
+	EventName string
+	Priority  StartPriority
+	QueuedAt  time.Time
+}
+
+type queuedStart struct {
+	QueuedStart
+
+	seq   uint64
+	ready chan struct{}
+}
+
+// startQueue lets one start happen at a time. The rest wait in the queue, rather than racing each other for the
+// process loop.
+type startQueue struct {
+	mutex   sync.Mutex
+	held    bool
+	seq     uint64
+	waiting []*queuedStart
+}
+
+func newStartQueue() *startQueue {
+	return &startQueue{}
+}
+
+// acquire waits until it is the turn of a start of eventName. It returns ctx.Err() if ctx is done first, in which case
+// the start leaves the queue. Each successful acquire must be followed by a release.
+func (q *startQueue) acquire(ctx context.Context, priority StartPriority, eventName string) error {
+	q.mutex.Lock()
+
+	if !q.held && len(q.waiting) == 0 {
+		q.held = true
+		q.mutex.Unlock()
+
+		return nil
+	}
+
+	q.seq++
+
+	start := &queuedStart{
+		QueuedStart: QueuedStart{EventName: eventName, Priority: priority, QueuedAt: time.Now()},
+		seq:         q.seq,
+		ready:       make(chan struct{}),
+	}
+
+	q.waiting = append(q.waiting, start)
+
+	sort.SliceStable(q.waiting, func(i, j int) bool {
+		if q.waiting[i].Priority != q.waiting[j].Priority {
+			return q.waiting[i].Priority > q.waiting[j].Priority
+		}
+
+		return q.waiting[i].seq < q.waiting[j].seq
+	})
+
+	logrus.Infof("Another start is in progress, the start of %q is queued at position %d", eventName, q.position(start))
+
+	q.mutex.Unlock()
+
+	select {
+	case <-start.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	select {
+	case <-start.ready:
+		// the start was handed its turn as ctx finished, so pass it on.
+		q.next()
+	default:
+		q.remove(start)
+	}
+
+	return ctx.Err()
+}
+
+// release finishes the start in progress, and lets the next in the queue go.
+func (q *startQueue) release() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.next()
+}
+
+// next hands the turn to the first start in the queue. It must be called with q.mutex held.
+func (q *startQueue) next() {
+	if len(q.waiting) == 0 {
+		q.held = false
+		return
+	}
+
+	start := q.waiting[0]
+	q.waiting = q.waiting[1:]
+
+	close(start.ready)
+}
+
+func (q *startQueue) remove(start *queuedStart) {
+	for i, waiting := range q.waiting {
+		if waiting == start {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *startQueue) position(start *queuedStart) int {
+	for i, waiting := range q.waiting {
+		if waiting == start {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// Queued lists the starts which are waiting, in the order they will go.
+func (q *startQueue) Queued() []QueuedStart {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	queued := make([]QueuedStart, len(q.waiting))
+
+	for i, start := range q.waiting {
+		queued[i] = start.QueuedStart
+		queued[i].Position = i + 1
+	}
+
+	return queued
+}
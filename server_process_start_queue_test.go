@@ -0,0 +1,88 @@
+package servermanager
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartQueue(t *testing.T) {
+	q := newStartQueue()
+
+	if err := q.acquire(context.Background(), StartPriorityNormal, "running"); err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan string, 3)
+
+	queue := func(priority StartPriority, eventName string) {
+		waiting := len(q.Queued())
+
+		go func() {
+			if err := q.acquire(context.Background(), priority, eventName); err != nil {
+				t.Error(err)
+				return
+			}
+
+			started <- eventName
+		}()
+
+		for len(q.Queued()) == waiting {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	queue(StartPriorityLooper, "looped")
+	queue(StartPriorityNormal, "manual")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	abandoned := make(chan error)
+
+	go func() {
+		abandoned <- q.acquire(ctx, StartPriorityNormal, "abandoned")
+	}()
+
+	for len(q.Queued()) != 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	queue(StartPriorityScheduled, "scheduled")
+
+	cancel()
+
+	if err := <-abandoned; err != context.Canceled {
+		t.Fatalf("expected an abandoned start to give up with its context, got %v", err)
+	}
+
+	queued := q.Queued()
+	expected := []string{"scheduled", "manual", "looped"}
+
+	if len(queued) != len(expected) {
+		t.Fatalf("expected %d queued starts, got %d", len(expected), len(queued))
+	}
+
+	for i, start := range queued {
+		if start.EventName != expected[i] || start.Position != i+1 {
+			t.Errorf("expected %q at position %d, got %q at position %d", expected[i], i+1, start.EventName, start.Position)
+		}
+	}
+
+	for _, eventName := range expected {
+		q.release()
+
+		select {
+		case got := <-started:
+			if got != eventName {
+				t.Errorf("expected %q to start next, got %q", eventName, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %q to start", eventName)
+		}
+	}
+
+	q.release()
+
+	if err := q.acquire(context.Background(), StartPriorityNormal, "next"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,126 @@
+package servermanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/sirupsen/logrus"
+)
+
+// StopWarningConfig warns connected drivers in the in-game chat before acServer is stopped or restarted.
+type StopWarningConfig struct {
+	// Countdown is how long Stop waits, warning drivers as it goes, before stopping acServer. There is no warning if
+	// it is zero.
+	Countdown time.Duration `yaml:"countdown"`
+
+	// Message is broadcast at each warning. A %s in it is replaced with the time left, e.g. "30 seconds".
+	Message string `yaml:"message"`
+
+	// AnnounceAt are the times left at which the message is broadcast, as well as at the start of the countdown.
+	// Any which are longer than Countdown are ignored.
+	AnnounceAt []time.Duration `yaml:"announce_at"`
+}
+
+const defaultStopWarningMessage = "The server is stopping in %s"
+
+var defaultStopWarningAnnounceAt = []time.Duration{time.Minute, time.Second * 30, time.Second * 10, time.Second * 5}
+
+// WithStopWarning warns drivers before acServer is stopped, see StopWarningConfig.
+func WithStopWarning(conf StopWarningConfig) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		if conf.Message == "" {
+			conf.Message = defaultStopWarningMessage
+		}
+
+		if len(conf.AnnounceAt) == 0 {
+			conf.AnnounceAt = defaultStopWarningAnnounceAt
+		}
+
+		sp.StopWarning = conf
+	}
+}
+
+// announcements are the times left at which the warning is broadcast, longest first.
+func (conf StopWarningConfig) announcements() []time.Duration {
+	announcements := []time.Duration{conf.Countdown}
+
+	for _, at := range conf.AnnounceAt {
+		if at > 0 && at < conf.Countdown {
+			announcements = append(announcements, at)
+		}
+	}
+
+	sort.Slice(announcements, func(i, j int) bool {
+		return announcements[i] > announcements[j]
+	})
+
+	return announcements
+}
+
+// warnBeforeStop broadcasts the stop warning over the countdown. If acServer stops on its own in the meantime, the
+// countdown ends early and the result of stopped is returned.
+func (sp *AssettoServerProcess) warnBeforeStop(stopped <-chan error) (bool, error) {
+	conf := sp.StopWarning
+
+	if conf.Countdown <= 0 {
+		return false, nil
+	}
+
+	logrus.Infof("Warning drivers that the server is stopping in %s", conf.Countdown)
+
+	deadline := time.Now().Add(conf.Countdown)
+
+	for _, at := range conf.announcements() {
+		select {
+		case <-time.After(time.Until(deadline.Add(-at))):
+			sp.broadcastStopWarning(conf.Message, at)
+		case err := <-stopped:
+			return true, err
+		}
+	}
+
+	select {
+	case <-time.After(time.Until(deadline)):
+		return false, nil
+	case err := <-stopped:
+		return true, err
+	}
+}
+
+func (sp *AssettoServerProcess) broadcastStopWarning(message string, left time.Duration) {
+	if strings.Contains(message, "%s") {
+		message = fmt.Sprintf(message, formatTimeLeft(left))
+	}
+
+	for _, line := range strings.Split(wordwrap.WrapString(message, 60), "\n") {
+		broadcast, err := udp.NewBroadcastChat(line)
+
+		if err == nil {
+			err = sp.SendUDPMessage(broadcast)
+		}
+
+		if err != nil {
+			logrus.WithError(err).Error("Could not broadcast the stop warning")
+			return
+		}
+	}
+}
+
+// formatTimeLeft formats d for drivers to read, e.g. "2 minutes" or "30 seconds".
+func formatTimeLeft(d time.Duration) string {
+	unit, count := "second", int(d.Round(time.Second)/time.Second)
+
+	if d >= time.Minute && d%time.Minute == 0 {
+		unit, count = "minute", int(d/time.Minute)
+	}
+
+	if count != 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%d %s", count, unit)
+}
@@ -0,0 +1,84 @@
+package servermanager
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStopWarningConfig_Announcements(t *testing.T) {
+	conf := StopWarningConfig{Countdown: time.Minute, AnnounceAt: []time.Duration{time.Second * 10, time.Minute * 2, time.Second * 30}}
+
+	expected := []time.Duration{time.Minute, time.Second * 30, time.Second * 10}
+
+	if announcements := conf.announcements(); !reflect.DeepEqual(announcements, expected) {
+		t.Errorf("expected announcements at %v, got %v", expected, announcements)
+	}
+
+	for d, expected := range map[time.Duration]string{
+		time.Minute * 2:  "2 minutes",
+		time.Minute:      "1 minute",
+		time.Second * 90: "90 seconds",
+		time.Second:      "1 second",
+	} {
+		if formatted := formatTimeLeft(d); formatted != expected {
+			t.Errorf("expected %s to be formatted as %q, got %q", d, expected, formatted)
+		}
+	}
+}
+
+func TestAssettoServerProcess_StopWarning(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	WithStopWarning(StopWarningConfig{
+		Countdown:  time.Millisecond * 300,
+		Message:    "Restarting in %s",
+		AnnounceAt: []time.Duration{time.Millisecond * 100},
+	})(sp)
+
+	startTestServerProcess(t, sp, testServerScript)
+
+	sp.mutex.Lock()
+	udpPluginLocalPort := sp.udpPluginLocalPort
+	sp.mutex.Unlock()
+
+	// acServer would listen on the UDP plugin local port, the test listens there instead to see the warnings.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: udpPluginLocalPort})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	started := time.Now()
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stoppedAfter := time.Since(started); stoppedAfter < sp.StopWarning.Countdown {
+		t.Errorf("expected Stop to wait for the countdown, it stopped after %s", stoppedAfter)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	warnings := 0
+
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+
+		warnings++
+	}
+
+	if warnings != 2 {
+		t.Errorf("expected a warning at the start of the countdown and with 100ms left, got %d", warnings)
+	}
+}
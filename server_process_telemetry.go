@@ -0,0 +1,447 @@
+package servermanager
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+const (
+	TelemetrySinkFile     = "file"
+	TelemetrySinkInfluxDB = "influxdb"
+
+	telemetryDefaultSampleInterval = time.Second
+	telemetryDefaultMeasurement    = "telemetry"
+	telemetryBufferSize            = 4096
+	telemetryFlushInterval         = time.Second * 5
+	telemetryInfluxDBTimeout       = time.Second * 10
+)
+
+// TelemetryConfig saves the position, speed, gear and RPM of each car from acServer's car updates, so that stints can
+// be charted and analysed after a race. Each car is sampled at most once per SampleInterval.
+type TelemetryConfig struct {
+	// Sink is where samples are written, TelemetrySinkFile or TelemetrySinkInfluxDB. Telemetry isn't captured if it is
+	// empty.
+	Sink           string        `yaml:"sink"`
+	SampleInterval time.Duration `yaml:"sample_interval"`
+
+	// Directory is where the file sink writes a CSV file for each driver in each session.
+	Directory string `yaml:"directory"`
+
+	InfluxDB TelemetryInfluxDBConfig `yaml:"influxdb"`
+}
+
+// TelemetryInfluxDBConfig is where the InfluxDB sink writes samples. InfluxDB 2 is used if Bucket is set, otherwise
+// samples are written to Database with the InfluxDB 1 API.
+type TelemetryInfluxDBConfig struct {
+	URL         string `yaml:"url"`
+	Measurement string `yaml:"measurement"`
+
+	Database string `yaml:"database"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	Org    string `yaml:"org"`
+	Bucket string `yaml:"bucket"`
+	Token  string `yaml:"token"`
+}
+
+// TelemetrySample is a car's state at Time.
+type TelemetrySample struct {
+	Time time.Time
+
+	// Server is the name of the pooled server the sample came from, empty for the main server.
+	Server       string
+	SessionType  udp.SessionType
+	SessionStart time.Time
+	Track        string
+	TrackConfig  string
+
+	CarID      udp.CarID
+	DriverGUID udp.DriverGUID
+	DriverName string
+	CarModel   string
+
+	SpeedKMH  float64
+	Gear      uint8
+	EngineRPM uint16
+	SplinePos float32
+	Pos       udp.Vec
+}
+
+// TelemetrySink stores telemetry samples. WriteTelemetry is called with the samples from the last few seconds, from
+// a single goroutine. Samples which it fails to write are not retried.
+type TelemetrySink interface {
+	WriteTelemetry(samples []TelemetrySample) error
+}
+
+// NewTelemetrySink creates the sink set in conf.
+func NewTelemetrySink(conf TelemetryConfig) (TelemetrySink, error) {
+	switch conf.Sink {
+	case TelemetrySinkFile:
+		if conf.Directory == "" {
+			return nil, fmt.Errorf("servermanager: the telemetry file sink needs a directory")
+		}
+
+		return &telemetryFileSink{directory: conf.Directory}, nil
+	case TelemetrySinkInfluxDB:
+		return newTelemetryInfluxDBSink(conf.InfluxDB)
+	default:
+		return nil, fmt.Errorf("servermanager: unknown telemetry sink: %s", conf.Sink)
+	}
+}
+
+// WithTelemetry samples each car's updates from acServer into sink, at most once per sampleInterval.
+func WithTelemetry(sink TelemetrySink, sampleInterval time.Duration) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.telemetry = newTelemetryRecorder(sink, sampleInterval)
+	}
+}
+
+// telemetryRecorder samples car updates and writes them to its sink in batches, so that a slow sink doesn't hold up
+// the UDP callback. Samples are dropped if the sink falls too far behind.
+type telemetryRecorder struct {
+	sink     TelemetrySink
+	interval time.Duration
+
+	mutex       sync.Mutex
+	lastSampled map[udp.CarID]time.Time
+
+	samples chan TelemetrySample
+}
+
+func newTelemetryRecorder(sink TelemetrySink, interval time.Duration) *telemetryRecorder {
+	if interval <= 0 {
+		interval = telemetryDefaultSampleInterval
+	}
+
+	t := &telemetryRecorder{
+		sink:        sink,
+		interval:    interval,
+		lastSampled: make(map[udp.CarID]time.Time),
+		samples:     make(chan TelemetrySample, telemetryBufferSize),
+	}
+
+	go t.run()
+
+	return t
+}
+
+// shouldSample is whether carID is due a sample at now.
+func (t *telemetryRecorder) shouldSample(carID udp.CarID, now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if last, ok := t.lastSampled[carID]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+
+	t.lastSampled[carID] = now
+
+	return true
+}
+
+func (t *telemetryRecorder) record(sample TelemetrySample) {
+	select {
+	case t.samples <- sample:
+	default:
+		logrus.Debugf("Telemetry sink is behind, dropped sample for car %d", sample.CarID)
+	}
+}
+
+func (t *telemetryRecorder) run() {
+	ticker := time.NewTicker(telemetryFlushInterval)
+	defer ticker.Stop()
+
+	var batch []TelemetrySample
+
+	for {
+		select {
+		case sample := <-t.samples:
+			batch = append(batch, sample)
+
+			if len(batch) < telemetryBufferSize/4 {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := t.sink.WriteTelemetry(batch); err != nil {
+			logrus.WithError(err).Errorf("Could not write %d telemetry samples", len(batch))
+		}
+
+		batch = nil
+	}
+}
+
+// recordTelemetry samples a car update from acServer, if telemetry is enabled.
+func (sp *AssettoServerProcess) recordTelemetry(message udp.Message, now time.Time) {
+	update, ok := message.(udp.CarUpdate)
+
+	if !ok || sp.telemetry == nil || !sp.telemetry.shouldSample(update.CarID, now) {
+		return
+	}
+
+	sample := TelemetrySample{
+		Time:      now,
+		CarID:     update.CarID,
+		SpeedKMH:  math.Sqrt(float64(update.Velocity.X*update.Velocity.X+update.Velocity.Y*update.Velocity.Y+update.Velocity.Z*update.Velocity.Z)) * 3.6,
+		Gear:      update.Gear,
+		EngineRPM: update.EngineRPM,
+		SplinePos: update.NormalisedSplinePos,
+		Pos:       update.Pos,
+	}
+
+	if sp.instance != nil {
+		sample.Server = filepath.Base(sp.instance.InstallPath)
+	}
+
+	sp.sessionState.describeCar(update.CarID, &sample)
+
+	sp.telemetry.record(sample)
+}
+
+// telemetryFileSink writes a CSV file for each driver in each session, in a directory for the session.
+type telemetryFileSink struct {
+	directory string
+}
+
+var telemetryCSVHeader = []string{"time", "car_id", "driver_guid", "driver_name", "car_model", "speed_kmh", "gear", "engine_rpm", "spline_pos", "x", "y", "z"}
+
+func (s *telemetryFileSink) path(sample TelemetrySample) string {
+	session := fmt.Sprintf("%s_%s_%s", sample.SessionStart.Format("2006-01-02_15-04-05"), sample.Track, sample.SessionType)
+
+	if sample.TrackConfig != "" {
+		session = fmt.Sprintf("%s_%s_%s_%s", sample.SessionStart.Format("2006-01-02_15-04-05"), sample.Track, sample.TrackConfig, sample.SessionType)
+	}
+
+	driver := string(sample.DriverGUID)
+
+	if driver == "" {
+		driver = fmt.Sprintf("car_%d", sample.CarID)
+	}
+
+	return filepath.Join(s.directory, sample.Server, filepath.Base(session), filepath.Base(driver)+".csv")
+}
+
+func (s *telemetryFileSink) WriteTelemetry(samples []TelemetrySample) error {
+	files := make(map[string][]TelemetrySample)
+	var paths []string
+
+	for _, sample := range samples {
+		path := s.path(sample)
+
+		if _, ok := files[path]; !ok {
+			paths = append(paths, path)
+		}
+
+		files[path] = append(files[path], sample)
+	}
+
+	for _, path := range paths {
+		if err := s.writeFile(path, files[path]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *telemetryFileSink) writeFile(path string, samples []TelemetrySample) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(f)
+
+	if info.Size() == 0 {
+		if err := w.Write(telemetryCSVHeader); err != nil {
+			return err
+		}
+	}
+
+	for _, sample := range samples {
+		err := w.Write([]string{
+			sample.Time.Format(time.RFC3339Nano),
+			strconv.Itoa(int(sample.CarID)),
+			string(sample.DriverGUID),
+			sample.DriverName,
+			sample.CarModel,
+			strconv.FormatFloat(sample.SpeedKMH, 'f', 2, 64),
+			strconv.Itoa(int(sample.Gear)),
+			strconv.Itoa(int(sample.EngineRPM)),
+			strconv.FormatFloat(float64(sample.SplinePos), 'f', 5, 32),
+			strconv.FormatFloat(float64(sample.Pos.X), 'f', 2, 32),
+			strconv.FormatFloat(float64(sample.Pos.Y), 'f', 2, 32),
+			strconv.FormatFloat(float64(sample.Pos.Z), 'f', 2, 32),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// telemetryInfluxDBSink writes samples to InfluxDB's HTTP API in line protocol, one point per sample.
+type telemetryInfluxDBSink struct {
+	writeURL    string
+	token       string
+	measurement string
+	client      *http.Client
+}
+
+func newTelemetryInfluxDBSink(conf TelemetryInfluxDBConfig) (*telemetryInfluxDBSink, error) {
+	u, err := url.Parse(conf.URL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("servermanager: unsupported influxdb url scheme: %s", u.Scheme)
+	}
+
+	query := url.Values{"precision": {"ms"}}
+
+	if conf.Bucket != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2/write"
+		query.Set("org", conf.Org)
+		query.Set("bucket", conf.Bucket)
+	} else if conf.Database != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/write"
+		query.Set("db", conf.Database)
+
+		if conf.Username != "" {
+			query.Set("u", conf.Username)
+			query.Set("p", conf.Password)
+		}
+	} else {
+		return nil, fmt.Errorf("servermanager: the telemetry influxdb sink needs a bucket or database")
+	}
+
+	u.RawQuery = query.Encode()
+
+	sink := &telemetryInfluxDBSink{
+		writeURL:    u.String(),
+		token:       conf.Token,
+		measurement: conf.Measurement,
+		client:      &http.Client{Timeout: telemetryInfluxDBTimeout},
+	}
+
+	if sink.measurement == "" {
+		sink.measurement = telemetryDefaultMeasurement
+	}
+
+	return sink, nil
+}
+
+var (
+	influxDBTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	influxDBStringFieldEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+func (s *telemetryInfluxDBSink) writeLine(buf *bytes.Buffer, sample TelemetrySample) {
+	buf.WriteString(influxDBTagEscaper.Replace(s.measurement))
+
+	tags := [][2]string{
+		{"car_id", strconv.Itoa(int(sample.CarID))},
+		{"car_model", sample.CarModel},
+		{"driver_guid", string(sample.DriverGUID)},
+		{"server", sample.Server},
+		{"session", sample.SessionType.String()},
+		{"track", sample.Track},
+		{"track_config", sample.TrackConfig},
+	}
+
+	for _, tag := range tags {
+		if tag[1] == "" {
+			continue
+		}
+
+		fmt.Fprintf(buf, ",%s=%s", tag[0], influxDBTagEscaper.Replace(tag[1]))
+	}
+
+	fmt.Fprintf(buf, ` driver_name="%s",speed_kmh=%g,gear=%di,engine_rpm=%di,spline_pos=%g,x=%g,y=%g,z=%g %d`+"\n",
+		influxDBStringFieldEscaper.Replace(sample.DriverName),
+		sample.SpeedKMH,
+		sample.Gear,
+		sample.EngineRPM,
+		sample.SplinePos,
+		sample.Pos.X,
+		sample.Pos.Y,
+		sample.Pos.Z,
+		sample.Time.UnixNano()/int64(time.Millisecond),
+	)
+}
+
+func (s *telemetryInfluxDBSink) WriteTelemetry(samples []TelemetrySample) error {
+	buf := new(bytes.Buffer)
+
+	for _, sample := range samples {
+		s.writeLine(buf, sample)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.writeURL, buf)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+
+		return fmt.Errorf("servermanager: influxdb responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
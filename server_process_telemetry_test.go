@@ -0,0 +1,134 @@
+package servermanager
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_RecordTelemetry(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	sp.telemetry = &telemetryRecorder{
+		interval:    time.Second,
+		lastSampled: make(map[udp.CarID]time.Time),
+		samples:     make(chan TelemetrySample, 10),
+	}
+
+	now := time.Now()
+
+	sp.sessionState.handle(udp.SessionInfo{Type: udp.SessionTypeRace, Track: "ks_vallelunga", TrackConfig: "club_circuit", EventType: udp.EventNewSession}, now)
+	sp.sessionState.handle(udp.SessionCarInfo{CarID: 2, DriverName: "Driver", DriverGUID: "7656", CarModel: "ks_mazda_mx5_cup", EventType: udp.EventNewConnection}, now)
+
+	update := udp.CarUpdate{CarID: 2, Velocity: udp.Vec{X: 30, Z: 40}, Gear: 4, EngineRPM: 6500, NormalisedSplinePos: 0.25}
+
+	sp.recordTelemetry(update, now)
+	sp.recordTelemetry(update, now.Add(time.Millisecond*500))
+	sp.recordTelemetry(update, now.Add(time.Second))
+
+	if len(sp.telemetry.samples) != 2 {
+		t.Fatalf("Expected 2 samples a second apart, got %d", len(sp.telemetry.samples))
+	}
+
+	sample := <-sp.telemetry.samples
+
+	if sample.SpeedKMH != 180 || sample.Gear != 4 || sample.DriverGUID != "7656" || sample.CarModel != "ks_mazda_mx5_cup" || sample.Track != "ks_vallelunga" || sample.SessionType != udp.SessionTypeRace {
+		t.Errorf("Unexpected sample %#v", sample)
+	}
+}
+
+func TestTelemetryFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-telemetry")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	sink, err := NewTelemetrySink(TelemetryConfig{Sink: TelemetrySinkFile, Directory: dir})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2020, 5, 1, 19, 0, 0, 0, time.UTC)
+	sample := TelemetrySample{Time: start, SessionStart: start, SessionType: udp.SessionTypeRace, Track: "monza", CarID: 1, DriverGUID: "7656", SpeedKMH: 200}
+
+	for i := 0; i < 2; i++ {
+		if err := sink.WriteTelemetry([]TelemetrySample{sample, sample}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, "2020-05-01_19-00-00_monza_Race", "7656.csv"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 5 || records[0][0] != "time" || records[1][5] != "200.00" {
+		t.Errorf("Expected a header and 4 samples, got %v", records)
+	}
+}
+
+func TestTelemetryInfluxDBSink(t *testing.T) {
+	var body, query, auth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body, query, auth = string(b), r.URL.RequestURI(), r.Header.Get("Authorization")
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink, err := NewTelemetrySink(TelemetryConfig{Sink: TelemetrySinkInfluxDB, InfluxDB: TelemetryInfluxDBConfig{URL: server.URL, Org: "league", Bucket: "acsm", Token: "secret"}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sink.WriteTelemetry([]TelemetrySample{{
+		Time:        time.Unix(1588359600, 0),
+		SessionType: udp.SessionTypeRace,
+		Track:       "ks nordschleife",
+		CarID:       3,
+		DriverGUID:  "7656",
+		DriverName:  `A "Driver"`,
+		Gear:        5,
+		EngineRPM:   7000,
+		SpeedKMH:    150.5,
+	}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if query != "/api/v2/write?bucket=acsm&org=league&precision=ms" || auth != "Token secret" {
+		t.Errorf("Unexpected request to %s with authorization %q", query, auth)
+	}
+
+	expected := `telemetry,car_id=3,driver_guid=7656,session=Race,track=ks\ nordschleife driver_name="A \"Driver\"",speed_kmh=150.5,gear=5i,engine_rpm=7000i,spline_pos=0,x=0,y=0,z=0 1588359600000`
+
+	if strings.TrimSpace(body) != expected {
+		t.Errorf("Expected line %s, got %s", expected, body)
+	}
+}
@@ -0,0 +1,137 @@
+package servermanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// udpReconnectDelay is how long to wait before reopening the UDP connection after it fails. The wait doubles
+	// after each failed attempt, up to udpMaxReconnectDelay.
+	udpReconnectDelay    = time.Second
+	udpMaxReconnectDelay = time.Second * 30
+
+	// a UDP connection which acServer has sent nothing on for udpProbeAfter is asked for acServer's session info,
+	// and is reopened if acServer still hasn't answered by udpDeadAfter. They are checked every udpLivenessInterval.
+	udpLivenessInterval = time.Second * 5
+	udpProbeAfter       = time.Second * 20
+	udpDeadAfter        = time.Second * 45
+)
+
+type udpLiveness struct {
+	interval, probeAfter, deadAfter time.Duration
+}
+
+// currentUDPLiveness is read when each connection is opened, so that a connection which is being watched isn't
+// affected by changes to them.
+func currentUDPLiveness() udpLiveness {
+	return udpLiveness{interval: udpLivenessInterval, probeAfter: udpProbeAfter, deadAfter: udpDeadAfter}
+}
+
+// superviseUDPListener reopens conn with the same ports if it fails while acServer is running. It returns once conn
+// has been closed by the server process, so stopping acServer never causes a reconnect.
+func (sp *AssettoServerProcess) superviseUDPListener(conn *udp.AssettoServerUDP, liveness udpLiveness) {
+	if !sp.watchUDPListener(conn, liveness) {
+		return
+	}
+
+	logrus.WithError(conn.Err()).Error("UDP connection to acServer failed, reconnecting")
+
+	raceEvent := sp.Event()
+
+	sp.emit(ProcessEvent{Type: ProcessEventUDPFailed, EventName: raceEvent.EventName(), RaceEvent: raceEvent, Error: conn.Err().Error()})
+
+	delay := udpReconnectDelay
+
+	for attempt := 1; ; attempt++ {
+		time.Sleep(delay)
+
+		reconnected, err := sp.reconnectUDPListener(conn)
+
+		if reconnected {
+			logrus.Infof("Reconnected to acServer over UDP after %d attempt(s)", attempt)
+
+			sp.emit(ProcessEvent{Type: ProcessEventUDPReconnected, EventName: raceEvent.EventName(), RaceEvent: raceEvent})
+			return
+		}
+
+		if err == nil {
+			// acServer has stopped, or the connection has already been replaced.
+			return
+		}
+
+		logrus.WithError(err).Errorf("Could not reconnect to acServer over UDP, retrying in %s", delay)
+
+		delay *= 2
+
+		if delay > udpMaxReconnectDelay {
+			delay = udpMaxReconnectDelay
+		}
+	}
+}
+
+// reconnectUDPListener replaces the failed connection with a new one, if acServer is still running on it.
+func (sp *AssettoServerProcess) reconnectUDPListener(failed *udp.AssettoServerUDP) (bool, error) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.raceEvent == nil || sp.stopRequested || sp.udpServerConn != failed {
+		return false, nil
+	}
+
+	if err := failed.Close(); err != nil {
+		logrus.WithError(err).Debug("Could not close failed UDP connection")
+	}
+
+	// the failed connection is kept until the new one has been opened, so that it is still closed when acServer stops.
+	if err := sp.startUDPListener(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// watchUDPListener waits for conn to fail or be closed, returning true if it failed. Not every failure shows up as a
+// read error: after an acServer blip, a socket can stop receiving without ever erroring. So a connection which has
+// been quiet for udpProbeAfter is probed, and one which stays quiet for udpDeadAfter is failed here so that it is
+// reopened.
+func (sp *AssettoServerProcess) watchUDPListener(conn *udp.AssettoServerUDP, liveness udpLiveness) bool {
+	opened := time.Now()
+
+	ticker := time.NewTicker(liveness.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-conn.Failed():
+			return true
+		case <-conn.Closed():
+			select {
+			case <-conn.Failed():
+				return true
+			default:
+				return false
+			}
+		case now := <-ticker.C:
+			quietFor := sp.udpQuietFor(now)
+
+			if sinceOpened := now.Sub(opened); sinceOpened < quietFor {
+				quietFor = sinceOpened
+			}
+
+			if quietFor >= liveness.deadAfter {
+				conn.Fail(fmt.Errorf("udp: acServer has not sent a message for %s, and did not answer a request for its session info", quietFor.Round(time.Second)))
+				return true
+			}
+
+			if quietFor >= liveness.probeAfter {
+				if err := conn.SendMessage(udp.GetSessionInfo{}); err != nil {
+					logrus.WithError(err).Debug("Could not probe the UDP connection to acServer")
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package servermanager
+
+import (
+	"errors"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/sirupsen/logrus"
+)
+
+// udpSendQueueSize is how many messages can be waiting to be sent to acServer before SendUDPMessage starts failing.
+const udpSendQueueSize = 512
+
+var ErrUDPSendQueueFull = errors.New("servermanager: too many UDP messages are waiting to be sent to acServer")
+
+// WithUDPSendInterval makes SendUDPMessage queue messages and send them to acServer at most once every interval,
+// so that a burst of messages isn't dropped by acServer. A zero interval sends every message straight away.
+func WithUDPSendInterval(interval time.Duration) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.UDPSendInterval = interval
+	}
+}
+
+// WithUDPSendBatchSize lets the send queue send up to batchSize messages together each UDPSendInterval, rather than
+// one. Bursts (e.g. ballast for a whole grid) then clear sooner, while still giving acServer time between them.
+func WithUDPSendBatchSize(batchSize int) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.UDPSendBatchSize = batchSize
+	}
+}
+
+// WithUDPSendRetries makes the send queue try admin messages (kicks, admin commands and session changes) up to
+// retries more times, one UDPSendInterval apart, if they can't be sent. Chat isn't retried, as a late chat message
+// is more confusing than a missing one.
+func WithUDPSendRetries(retries int) ServerProcessOption {
+	return func(sp *AssettoServerProcess) {
+		sp.UDPSendRetries = retries
+	}
+}
+
+// isAdminUDPMessage reports whether message is one which the send queue retries.
+func isAdminUDPMessage(message udp.Message) bool {
+	switch message.(type) {
+	case *udp.KickUser, *udp.AdminCommand, *udp.NextSession, *udp.RestartSession:
+		return true
+	default:
+		return false
+	}
+}
+
+type queuedUDPMessage struct {
+	message  udp.Message
+	attempts int
+}
+
+// udpSendQueue holds the messages for one run of acServer. Its messages are discarded when acServer stops, so that
+// they aren't sent to the next event.
+type udpSendQueue struct {
+	messages chan queuedUDPMessage
+	done     chan struct{}
+}
+
+func newUDPSendQueue() *udpSendQueue {
+	return &udpSendQueue{
+		messages: make(chan queuedUDPMessage, udpSendQueueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+func (q *udpSendQueue) enqueue(message udp.Message) error {
+	select {
+	case q.messages <- queuedUDPMessage{message: message}:
+		return nil
+	default:
+		return ErrUDPSendQueueFull
+	}
+}
+
+// fill adds queued messages to batch until it holds batchSize messages or the queue is empty. If batch is empty, fill
+// waits for a message first. It returns false if the queue is discarded while it waits.
+func (q *udpSendQueue) fill(batch []queuedUDPMessage, batchSize int) ([]queuedUDPMessage, bool) {
+	if len(batch) == 0 {
+		select {
+		case <-q.done:
+			return nil, false
+		case message := <-q.messages:
+			batch = append(batch, message)
+		}
+	}
+
+	for len(batch) < batchSize {
+		select {
+		case message := <-q.messages:
+			batch = append(batch, message)
+		default:
+			return batch, true
+		}
+	}
+
+	return batch, true
+}
+
+// discard stops the queue, returning how many messages were never sent.
+func (q *udpSendQueue) discard() int {
+	close(q.done)
+
+	return len(q.messages)
+}
+
+// startUDPSendQueue sets up the queue for the event which is starting, if a send interval is configured. It must be
+// called with sp.mutex held.
+func (sp *AssettoServerProcess) startUDPSendQueue() {
+	if sp.UDPSendInterval <= 0 {
+		return
+	}
+
+	sp.udpSendQueue = newUDPSendQueue()
+
+	go sp.drainUDPSendQueue(sp.udpSendQueue, sp.UDPSendInterval, sp.UDPSendBatchSize, sp.UDPSendRetries)
+}
+
+// stopUDPSendQueue discards any messages which are still waiting to be sent. It must be called with sp.mutex held.
+func (sp *AssettoServerProcess) stopUDPSendQueue() {
+	if sp.udpSendQueue == nil {
+		return
+	}
+
+	if discarded := sp.udpSendQueue.discard(); discarded > 0 {
+		logrus.Warnf("Discarded %d UDP message(s) which were waiting to be sent to acServer", discarded)
+	}
+
+	sp.udpSendQueue = nil
+}
+
+func (sp *AssettoServerProcess) drainUDPSendQueue(queue *udpSendQueue, interval time.Duration, batchSize, retries int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	var failed []queuedUDPMessage
+
+	for {
+		// messages which are being retried go first, so that admin messages are still sent in order.
+		batch, ok := queue.fill(failed, batchSize)
+
+		if !ok {
+			return
+		}
+
+		failed = nil
+
+		for _, queued := range batch {
+			err := sp.sendQueuedUDPMessage(queue, queued.message)
+
+			if err == nil {
+				continue
+			}
+
+			if isAdminUDPMessage(queued.message) && queued.attempts < retries {
+				queued.attempts++
+				failed = append(failed, queued)
+
+				logrus.WithError(err).Warnf("Could not send queued UDP message: %T, retrying (%d of %d)", queued.message, queued.attempts, retries)
+				continue
+			}
+
+			logrus.WithError(err).Errorf("Could not send queued UDP message: %T", queued.message)
+		}
+
+		select {
+		case <-queue.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendQueuedUDPMessage sends message, unless queue has been discarded since it was taken off the queue.
+func (sp *AssettoServerProcess) sendQueuedUDPMessage(queue *udpSendQueue, message udp.Message) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.udpSendQueue != queue {
+		return nil
+	}
+
+	if sp.udpServerConn == nil {
+		return ErrNoOpenUDPConnection
+	}
+
+	return sp.udpServerConn.SendMessage(message)
+}
+
+// SendUDPMessageImmediate sends message to acServer straight away, skipping any messages which are queued ahead of it.
+func (sp *AssettoServerProcess) SendUDPMessageImmediate(message udp.Message) error {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	if sp.udpServerConn == nil {
+		return ErrNoOpenUDPConnection
+	}
+
+	return sp.udpServerConn.SendMessage(message)
+}
@@ -0,0 +1,294 @@
+package servermanager
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_UDPSendQueue(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	sp.UDPSendInterval = time.Millisecond * 50
+
+	useTestServerScript(t, testServerScript)
+
+	udpPluginPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	udpPluginLocalPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan time.Time, 100)
+
+	// acServer is stood in for by a socket which records when each message arrives. like acServer, it binds its
+	// port once the event has started.
+	var acServer *net.UDPConn
+
+	start := func() {
+		t.Helper()
+
+		if err := sp.Start(QuickRace{}, fmt.Sprintf("127.0.0.1:%d", udpPluginPort), udpPluginLocalPort, "", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		acServer, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: udpPluginLocalPort})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		go func(conn *net.UDPConn) {
+			buf := make([]byte, 1024)
+
+			for {
+				if _, _, err := conn.ReadFromUDP(buf); err != nil {
+					return
+				}
+
+				received <- time.Now()
+			}
+		}(acServer)
+	}
+
+	start()
+	defer sp.Stop() //nolint:errcheck
+
+	for i := 0; i < 5; i++ {
+		if err := sp.SendUDPMessage(udp.GetSessionInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var last time.Time
+
+	for i := 0; i < 5; i++ {
+		select {
+		case at := <-received:
+			if !last.IsZero() && at.Sub(last) < sp.UDPSendInterval/2 {
+				t.Errorf("expected queued messages to be spaced out, message %d arrived %s after the last", i, at.Sub(last))
+			}
+
+			last = at
+		case <-time.After(time.Second * 5):
+			t.Fatalf("expected message %d to be sent", i)
+		}
+	}
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// with a long interval, only the first message is sent before acServer stops.
+	sp.UDPSendInterval = time.Hour
+
+	_ = acServer.Close()
+	start()
+	defer acServer.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := sp.SendUDPMessage(udp.GetSessionInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := sp.SendUDPMessageImmediate(udp.GetSessionInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second * 5):
+			t.Fatal("expected the first queued message and the immediate message to be sent")
+		}
+	}
+
+	if err := sp.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	sp.mutex.Lock()
+	queue := sp.udpSendQueue
+	sp.mutex.Unlock()
+
+	if queue != nil {
+		t.Error("expected the send queue to be discarded when acServer stops")
+	}
+
+	select {
+	case <-received:
+		t.Error("expected queued messages not to be sent after acServer stopped")
+	case <-time.After(time.Millisecond * 200):
+	}
+
+	if err := sp.SendUDPMessage(udp.GetSessionInfo{}); err == nil {
+		t.Error("expected messages sent after acServer stopped not to be queued")
+	}
+}
+
+// startWithUDPStandIn starts an event on sp, with a socket standing in for acServer which passes on the event type of
+// each message it receives.
+func startWithUDPStandIn(t *testing.T, sp *AssettoServerProcess) (<-chan udp.Event, func()) {
+	t.Helper()
+
+	useTestServerScript(t, testServerScript)
+
+	udpPluginPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	udpPluginLocalPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sp.Start(QuickRace{}, fmt.Sprintf("127.0.0.1:%d", udpPluginPort), udpPluginLocalPort, "", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	acServer, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: udpPluginLocalPort})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan udp.Event, 100)
+
+	go func() {
+		buf := make([]byte, 1024)
+
+		for {
+			n, _, err := acServer.ReadFromUDP(buf)
+
+			if err != nil {
+				return
+			}
+
+			if n > 0 {
+				received <- udp.Event(buf[0])
+			}
+		}
+	}()
+
+	return received, func() {
+		_ = sp.Stop()
+		_ = acServer.Close()
+	}
+}
+
+func TestAssettoServerProcess_UDPSendQueueBatches(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	sp.UDPSendInterval = time.Hour
+	sp.UDPSendBatchSize = 3
+
+	received, stop := startWithUDPStandIn(t, sp)
+	defer stop()
+
+	for i := 0; i < 5; i++ {
+		if err := sp.SendUDPMessage(udp.GetSessionInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second * 5):
+			t.Fatalf("expected the first batch of 3 messages to be sent, only %d were", i)
+		}
+	}
+
+	select {
+	case <-received:
+		t.Error("expected the rest of the messages to wait for the next interval")
+	case <-time.After(time.Millisecond * 200):
+	}
+}
+
+func TestAssettoServerProcess_UDPSendQueueRetries(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	sp.UDPSendInterval = time.Millisecond * 20
+	sp.UDPSendRetries = 10
+
+	received, stop := startWithUDPStandIn(t, sp)
+	defer stop()
+
+	chat, err := udp.NewBroadcastChat("hello")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// losing the UDP connection makes every send fail until it is back.
+	sp.mutex.Lock()
+	conn := sp.udpServerConn
+	sp.udpServerConn = nil
+	_ = sp.udpSendQueue.enqueue(chat)
+	_ = sp.udpSendQueue.enqueue(udp.NewKickUser(4))
+	sp.mutex.Unlock()
+
+	time.Sleep(time.Millisecond * 50)
+
+	sp.mutex.Lock()
+	sp.udpServerConn = conn
+	sp.mutex.Unlock()
+
+	select {
+	case event := <-received:
+		if event != udp.EventKickUser {
+			t.Errorf("expected only the kick to be retried, got event %d", event)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the kick to be sent once the connection was back")
+	}
+
+	select {
+	case event := <-received:
+		t.Errorf("expected the chat message not to be retried, got event %d", event)
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestIsAdminUDPMessage(t *testing.T) {
+	adminCommand, err := udp.NewAdminCommand("/ballast 1 50")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendChat, err := udp.NewSendChat(1, "hi")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for message, expected := range map[udp.Message]bool{
+		adminCommand:         true,
+		udp.NewKickUser(1):   true,
+		&udp.NextSession{}:   true,
+		sendChat:             false,
+		udp.GetSessionInfo{}: false,
+	} {
+		if isAdminUDPMessage(message) != expected {
+			t.Errorf("expected isAdminUDPMessage(%T) to be %t", message, expected)
+		}
+	}
+}
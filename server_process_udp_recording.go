@@ -0,0 +1,246 @@
+package servermanager
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp/replay"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	udpRecordingFilePrefix      = "udp_"
+	udpRecordingFileExtension   = ".jsonl"
+	udpRecordingDefaultMaxFiles = 10
+
+	// udpReplayMaxWait is the longest a replay waits between two messages, so that a recording of a server which sat
+	// empty for hours can still be replayed.
+	udpReplayMaxWait = time.Second * 5
+)
+
+var (
+	ErrUDPRecordingDisabled   = errors.New("servermanager: UDP recording is not enabled, set a udp_recording directory in config.yml")
+	ErrUDPRecordingNotFound   = errors.New("servermanager: UDP recording not found")
+	ErrUDPReplayServerRunning = errors.New("servermanager: a UDP recording can't be replayed while acServer is running")
+	ErrUDPReplayInProgress    = errors.New("servermanager: a UDP recording is being replayed")
+	errUDPRecordingEmpty      = errors.New("servermanager: the UDP recording has no messages")
+)
+
+// UDPRecordingConfig records every UDP message acServer sends to Server Manager, so that problems with live timing or
+// championships can be reproduced by replaying them.
+type UDPRecordingConfig struct {
+	Directory string `yaml:"directory"`
+	MaxFiles  int    `yaml:"max_files"`
+}
+
+// UDPRecording describes a recording of the UDP messages from an event.
+type UDPRecording struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// udpRecordingConfig returns where UDP recordings are kept. Like log files, each instance keeps its recordings in its
+// own directory.
+func (sp *AssettoServerProcess) udpRecordingConfig() UDPRecordingConfig {
+	if config == nil || config.Server.UDPRecording.Directory == "" {
+		return UDPRecordingConfig{}
+	}
+
+	conf := config.Server.UDPRecording
+
+	if sp.instance != nil {
+		conf.Directory = filepath.Join(conf.Directory, filepath.Base(sp.instance.InstallPath))
+	}
+
+	if conf.MaxFiles <= 0 {
+		conf.MaxFiles = udpRecordingDefaultMaxFiles
+	}
+
+	return conf
+}
+
+// startUDPRecording opens a recording for the event which is starting, if UDP recording is configured. It must be
+// called with sp.mutex held. The recording is kept open if the UDP connection is reopened, and closed by
+// stopUDPRecording once acServer stops.
+func (sp *AssettoServerProcess) startUDPRecording() {
+	conf := sp.udpRecordingConfig()
+
+	if conf.Directory == "" || sp.udpRecorder != nil || sp.raceEvent == nil {
+		return
+	}
+
+	if err := os.MkdirAll(conf.Directory, 0755); err != nil {
+		logrus.WithError(err).Errorf("Could not create UDP recording directory %s", conf.Directory)
+		return
+	}
+
+	path := filepath.Join(conf.Directory, eventFileName(udpRecordingFilePrefix, sp.raceEvent)+udpRecordingFileExtension)
+
+	recorder, err := replay.CreateRecording(path)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not create UDP recording %s", path)
+		return
+	}
+
+	logrus.Infof("Recording UDP messages from acServer to %s", path)
+
+	sp.udpRecorder = recorder
+
+	if err := deleteOldUDPRecordings(conf); err != nil {
+		logrus.WithError(err).Warnf("Could not delete old UDP recordings in %s", conf.Directory)
+	}
+}
+
+// stopUDPRecording must be called with sp.mutex held.
+func (sp *AssettoServerProcess) stopUDPRecording() {
+	if sp.udpRecorder == nil {
+		return
+	}
+
+	if err := sp.udpRecorder.Close(); err != nil {
+		logrus.WithError(err).Error("Could not close UDP recording")
+	}
+
+	sp.udpRecorder = nil
+}
+
+func deleteOldUDPRecordings(conf UDPRecordingConfig) error {
+	files, err := listUDPRecordingFiles(conf.Directory)
+
+	if err != nil {
+		return err
+	}
+
+	for i, file := range files {
+		// the newest recording is the one which has just been created.
+		if i < conf.MaxFiles || i == 0 {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(conf.Directory, file.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listUDPRecordingFiles returns the UDP recordings in directory, newest first.
+func listUDPRecordingFiles(directory string) ([]os.FileInfo, error) {
+	files, err := ioutil.ReadDir(directory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var recordings []os.FileInfo
+
+	for _, file := range files {
+		if isUDPRecordingFile(file.Name()) && !file.IsDir() {
+			recordings = append(recordings, file)
+		}
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		if recordings[i].ModTime().Equal(recordings[j].ModTime()) {
+			return recordings[i].Name() > recordings[j].Name()
+		}
+
+		return recordings[i].ModTime().After(recordings[j].ModTime())
+	})
+
+	return recordings, nil
+}
+
+func isUDPRecordingFile(name string) bool {
+	return strings.HasPrefix(name, udpRecordingFilePrefix) && strings.HasSuffix(name, udpRecordingFileExtension) && filepath.Base(name) == name
+}
+
+// UDPRecordings lists the UDP recordings which have been kept on disk, newest first.
+func (sp *AssettoServerProcess) UDPRecordings() ([]UDPRecording, error) {
+	conf := sp.udpRecordingConfig()
+
+	if conf.Directory == "" {
+		return nil, ErrUDPRecordingDisabled
+	}
+
+	files, err := listUDPRecordingFiles(conf.Directory)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	recordings := make([]UDPRecording, 0, len(files))
+
+	for _, file := range files {
+		recordings = append(recordings, UDPRecording{Name: file.Name(), Size: file.Size(), ModTime: file.ModTime()})
+	}
+
+	return recordings, nil
+}
+
+// ReplayUDPRecording passes each message in the recording called name to the UDP callback as if acServer had just
+// sent it, sped up by multiplier, so that live timing and championships handle it just as they did live. The
+// recording is read before ReplayUDPRecording returns, and replayed in the background. acServer can't be started
+// while a recording is being replayed, and a recording can't be replayed while acServer is running.
+func (sp *AssettoServerProcess) ReplayUDPRecording(name string, multiplier int) error {
+	conf := sp.udpRecordingConfig()
+
+	if conf.Directory == "" {
+		return ErrUDPRecordingDisabled
+	}
+
+	if !isUDPRecordingFile(name) {
+		return ErrUDPRecordingNotFound
+	}
+
+	f, err := os.Open(filepath.Join(conf.Directory, name))
+
+	if os.IsNotExist(err) {
+		return ErrUDPRecordingNotFound
+	} else if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	messages, err := replay.ReadRecording(f)
+
+	if err != nil {
+		return err
+	}
+
+	if len(messages) == 0 {
+		return errUDPRecordingEmpty
+	}
+
+	if sp.IsRunning() {
+		return ErrUDPReplayServerRunning
+	}
+
+	if !atomic.CompareAndSwapInt32(&sp.replayingUDP, 0, 1) {
+		return ErrUDPReplayInProgress
+	}
+
+	logrus.Infof("Replaying %d UDP messages from %s at %dx speed", len(messages), name, multiplier)
+
+	go func() {
+		defer atomic.StoreInt32(&sp.replayingUDP, 0)
+
+		replay.ReplayRecording(messages, multiplier, sp.UDPCallback, udpReplayMaxWait)
+
+		logrus.Infof("Finished replaying UDP recording %s", name)
+	}()
+
+	return nil
+}
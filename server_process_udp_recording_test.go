@@ -0,0 +1,92 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestAssettoServerProcess_ReplayUDPRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-udp-recording")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	if _, err := sp.UDPRecordings(); err != ErrUDPRecordingDisabled {
+		t.Errorf("expected UDP recording to be disabled without a directory, got: %v", err)
+	}
+
+	config.Server.UDPRecording.Directory = dir
+
+	messages := make(chan udp.Message, 10)
+
+	sp.callbackFunc = func(message udp.Message) {
+		messages <- message
+	}
+
+	sp.mutex.Lock()
+	sp.raceEvent = QuickRace{RaceConfig: CurrentRaceConfig{Track: "ks_silverstone", TrackLayout: "gp"}}
+	sp.startUDPRecording()
+	sp.udpRecorder.Record(time.Now(), []byte{byte(udp.EventVersion), 4})
+	sp.udpRecorder.Record(time.Now(), []byte{byte(udp.EventClientLoaded), 3})
+	sp.stopUDPRecording()
+	sp.mutex.Unlock()
+
+	recordings, err := sp.UDPRecordings()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recordings) != 1 || !isUDPRecordingFile(recordings[0].Name) {
+		t.Fatalf("expected one recording, got: %+v", recordings)
+	}
+
+	if err := sp.ReplayUDPRecording(recordings[0].Name, 1000); err != ErrUDPReplayServerRunning {
+		t.Errorf("expected a recording not to be replayed while acServer is running, got: %v", err)
+	}
+
+	sp.mutex.Lock()
+	sp.raceEvent = nil
+	sp.mutex.Unlock()
+
+	for _, name := range []string{"other.jsonl", "../" + recordings[0].Name, udpRecordingFilePrefix + "missing.jsonl"} {
+		if err := sp.ReplayUDPRecording(name, 1000); err != ErrUDPRecordingNotFound {
+			t.Errorf("expected %s not to be found, got: %v", name, err)
+		}
+	}
+
+	if err := sp.ReplayUDPRecording(recordings[0].Name, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []udp.Message{udp.Version(4), udp.ClientLoaded(3)} {
+		select {
+		case message := <-messages:
+			if message != expected {
+				t.Errorf("expected message %#v to be replayed, got %#v", expected, message)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("expected message %#v to be replayed", expected)
+		}
+	}
+
+	// the replay reads the config, so it must finish before the test server process is cleaned up.
+	for deadline := time.Now().Add(time.Second * 5); atomic.LoadInt32(&sp.replayingUDP) != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the replay to finish")
+		}
+
+		time.Sleep(time.Millisecond * 5)
+	}
+}
@@ -0,0 +1,50 @@
+package servermanager
+
+import (
+	"os"
+)
+
+// ValidateStart checks that acServer could be started with serverConfig: that its executable exists and that the
+// ports it would use are free. The ports aren't checked while an event is running, as starting the next event stops
+// it and frees them.
+func (sp *AssettoServerProcess) ValidateStart(serverConfig ServerConfig) []ValidationProblem {
+	report := &ValidationReport{}
+
+	if sp.launcher == nil {
+		if _, err := os.Stat(sp.executablePath()); err != nil {
+			report.errorf("The acServer executable could not be found at %s. Check the steam executable_path in config.yml, or install the server again", sp.executablePath())
+		}
+	}
+
+	if _, remote := sp.remote(); remote || sp.IsRunning() {
+		return report.Problems
+	}
+
+	serverOpts := serverConfig.GlobalServerConfig
+
+	checks := []func() error{
+		func() error { return probeTCPPort(serverOpts.TCPPort, PortPurposeACServerTCP) },
+		func() error { return probeUDPPort("", serverOpts.UDPPort, PortPurposeACServerUDP) },
+		func() error { return probeTCPPort(serverOpts.HTTPPort, PortPurposeACServerHTTP) },
+	}
+
+	if serverOpts.UDPPluginAddress != "" && serverOpts.UDPPluginLocalPort != 0 && serverOpts.AutoUDPPluginPorts != 1 {
+		checks = append(checks, func() error {
+			return probeUDPPort("", serverOpts.UDPPluginLocalPort, PortPurposeUDPForwarding)
+		})
+	}
+
+	if serverOpts.EnableContentManagerWrapper == 1 && serverOpts.ContentManagerWrapperPort > 0 {
+		checks = append(checks, func() error {
+			return probeTCPPort(serverOpts.ContentManagerWrapperPort, PortPurposeContentManagerWrapper)
+		})
+	}
+
+	for _, check := range checks {
+		if err, ok := check().(ErrPortInUse); ok {
+			report.errorf("The %s port %d is already in use, check that no other server is using it", err.Purpose, err.Port)
+		}
+	}
+
+	return report.Problems
+}
@@ -0,0 +1,254 @@
+package servermanager
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+var ErrInvalidLiveWeather = errors.New("servermanager: invalid live weather")
+
+// LiveWeather is weather which is changed while a session is running. acServer can't change a session's weather once
+// it has started, so live weather is sent to Custom Shaders Patch clients, which move to it over TransitionSeconds.
+// Clients without CSP keep the session's weather.
+type LiveWeather struct {
+	WeatherType        udp.CSPWeatherType `json:"WeatherType"`
+	TemperatureAmbient float32            `json:"TemperatureAmbient"`
+	TemperatureRoad    float32            `json:"TemperatureRoad"`
+	WindSpeedKMH       float32            `json:"WindSpeedKMH"`
+	WindDirection      int                `json:"WindDirection"`
+	TransitionSeconds  int                `json:"TransitionSeconds"`
+}
+
+func (w LiveWeather) validate() error {
+	if !w.WeatherType.Valid() || w.WindSpeedKMH < 0 || w.WindDirection < 0 || w.WindDirection >= 360 || w.TransitionSeconds < 0 || w.TransitionSeconds > 0xffff {
+		return ErrInvalidLiveWeather
+	}
+
+	return nil
+}
+
+func (w LiveWeather) cspMessage() udp.CSPWeather {
+	return udp.CSPWeather{
+		WeatherType:        w.WeatherType,
+		TemperatureAmbient: w.TemperatureAmbient,
+		TemperatureRoad:    w.TemperatureRoad,
+		WindSpeedKMH:       w.WindSpeedKMH,
+		WindDirection:      uint16(w.WindDirection),
+		TransitionSeconds:  uint16(w.TransitionSeconds),
+	}
+}
+
+// WeatherTimelineStep changes the live weather After a number of minutes into a session.
+type WeatherTimelineStep struct {
+	After int
+
+	// SessionType limits the step to one type of session. It is used in every session if SessionType is empty.
+	SessionType SessionType
+
+	Weather LiveWeather
+}
+
+// liveWeatherFromForm reads the i'th set of weather fields named prefix.Field from form, e.g. LiveWeather.WeatherType.
+func liveWeatherFromForm(form url.Values, prefix string, i int) LiveWeather {
+	value := func(field string) string {
+		if values := form[prefix+"."+field]; i < len(values) {
+			return values[i]
+		}
+
+		return ""
+	}
+
+	return LiveWeather{
+		WeatherType:        udp.CSPWeatherType(formValueAsInt(value("WeatherType"))),
+		TemperatureAmbient: float32(formValueAsFloat(value("TemperatureAmbient"))),
+		TemperatureRoad:    float32(formValueAsFloat(value("TemperatureRoad"))),
+		WindSpeedKMH:       float32(formValueAsFloat(value("WindSpeedKMH"))),
+		WindDirection:      formValueAsInt(value("WindDirection")),
+		TransitionSeconds:  formValueAsInt(value("TransitionSeconds")),
+	}
+}
+
+// WeatherTimelineRows are the rows of the race setup form's weather timeline: one for each step, and an empty one for
+// adding another.
+func (c CurrentRaceConfig) WeatherTimelineRows() []WeatherTimelineStep {
+	return append(append([]WeatherTimelineStep(nil), c.WeatherTimeline...), WeatherTimelineStep{
+		After:   -1,
+		Weather: LiveWeather{WeatherType: udp.CSPWeatherClear, TemperatureAmbient: 20, TemperatureRoad: 25, TransitionSeconds: 60},
+	})
+}
+
+// weatherTimelineFromForm reads the weather timeline from the race setup form, which has one row of fields per step.
+// Rows without a time, or with weather which can't be sent, are left out.
+func weatherTimelineFromForm(form url.Values) []WeatherTimelineStep {
+	var timeline []WeatherTimelineStep
+
+	sessionTypes := form["WeatherTimeline.SessionType"]
+
+	for i, after := range form["WeatherTimeline.After"] {
+		after = strings.TrimSpace(after)
+
+		if after == "" || i >= len(sessionTypes) {
+			continue
+		}
+
+		step := WeatherTimelineStep{
+			After:       formValueAsInt(after),
+			SessionType: SessionType(sessionTypes[i]),
+			Weather:     liveWeatherFromForm(form, "WeatherTimeline", i),
+		}
+
+		if step.After < 0 || step.Weather.validate() != nil {
+			continue
+		}
+
+		timeline = append(timeline, step)
+	}
+
+	return timeline
+}
+
+// weatherTimelineUnit is the unit of WeatherTimelineStep.After.
+var weatherTimelineUnit = time.Minute
+
+// weatherTimelineFor returns the steps of timeline which are used in sessionType, in the order they happen.
+func weatherTimelineFor(timeline []WeatherTimelineStep, sessionType udp.SessionType) []WeatherTimelineStep {
+	var steps []WeatherTimelineStep
+
+	for _, step := range timeline {
+		if step.SessionType == "" || step.SessionType.String() == sessionType.String() {
+			steps = append(steps, step)
+		}
+	}
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].After < steps[j].After
+	})
+
+	return steps
+}
+
+// liveWeather is the live weather of the current session, if it has been changed.
+type liveWeather struct {
+	mutex   sync.Mutex
+	weather *LiveWeather
+
+	timeline sessionTask
+}
+
+func (l *liveWeather) get() (LiveWeather, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.weather == nil {
+		return LiveWeather{}, false
+	}
+
+	return *l.weather, true
+}
+
+func (l *liveWeather) set(weather *LiveWeather) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.weather = weather
+}
+
+// LiveWeather returns the weather which CSP clients have been sent this session, if it has been changed.
+func (sp *AssettoServerProcess) LiveWeather() (LiveWeather, bool) {
+	return sp.liveWeather.get()
+}
+
+// SetLiveWeather sends weather to every CSP client, and to CSP clients which join later in the session. It returns
+// how many clients it was sent to.
+func (sp *AssettoServerProcess) SetLiveWeather(weather LiveWeather) (int, error) {
+	if err := weather.validate(); err != nil {
+		return 0, err
+	}
+
+	if !sp.IsRunning() {
+		return 0, ErrNoOpenUDPConnection
+	}
+
+	sp.liveWeather.set(&weather)
+
+	sent := 0
+
+	for _, carID := range sp.CSPClients() {
+		if err := sp.SendCSPMessage(carID, weather.cspMessage()); err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+
+	logrus.Infof("Live weather set to %s (%.0f°C ambient, %.0f°C road, %.0fkm/h wind from %d°), sent to %d CSP client(s)", weather.WeatherType, weather.TemperatureAmbient, weather.TemperatureRoad, weather.WindSpeedKMH, weather.WindDirection, sent)
+
+	return sent, nil
+}
+
+// runWeatherTimeline sets the live weather at each step of a session's timeline, until ctx is done.
+func (sp *AssettoServerProcess) runWeatherTimeline(ctx context.Context, steps []WeatherTimelineStep) {
+	start := time.Now()
+
+	for _, step := range steps {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(start.Add(time.Duration(step.After) * weatherTimelineUnit))):
+		}
+
+		if _, err := sp.SetLiveWeather(step.Weather); err == ErrNoOpenUDPConnection {
+			return
+		} else if err != nil {
+			logrus.WithError(err).Errorf("Could not set the live weather from the weather timeline")
+		}
+	}
+}
+
+// handleLiveWeather keeps the live weather up to date with messages from acServer.
+func (sp *AssettoServerProcess) handleLiveWeather(message udp.Message) {
+	switch m := message.(type) {
+	case udp.Version:
+		sp.liveWeather.timeline.stop()
+		sp.liveWeather.set(nil)
+	case udp.SessionInfo:
+		if m.Event() != udp.EventNewSession {
+			return
+		}
+
+		// each session starts with the weather acServer picked for it.
+		sp.liveWeather.set(nil)
+
+		steps := weatherTimelineFor(sp.Event().GetRaceConfig().WeatherTimeline, m.Type)
+
+		if len(steps) == 0 {
+			sp.liveWeather.timeline.stop()
+			return
+		}
+
+		sp.liveWeather.timeline.start(func(ctx context.Context) {
+			sp.runWeatherTimeline(ctx, steps)
+		})
+	case udp.EndSession:
+		sp.liveWeather.timeline.stop()
+	case udp.CSPClientMessage:
+		if _, ok := m.Message.(udp.CSPHandshake); !ok {
+			return
+		}
+
+		if weather, ok := sp.liveWeather.get(); ok {
+			if err := sp.SendCSPMessage(m.CarID, weather.cspMessage()); err != nil {
+				logrus.WithError(err).Errorf("Could not send the live weather to car %d", m.CarID)
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package servermanager
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp/simulator"
+)
+
+func TestWeatherTimelineFromForm(t *testing.T) {
+	timeline := weatherTimelineFromForm(url.Values{
+		"WeatherTimeline.After":              {"20", "", "5", "10"},
+		"WeatherTimeline.SessionType":        {"RACE", "", "", ""},
+		"WeatherTimeline.WeatherType":        {"7", "15", "15", "15"},
+		"WeatherTimeline.TemperatureAmbient": {"14.5", "20", "22", "22"},
+		"WeatherTimeline.TemperatureRoad":    {"16", "25", "30", "30"},
+		"WeatherTimeline.WindSpeedKMH":       {"25", "0", "5", "5"},
+		"WeatherTimeline.WindDirection":      {"270", "0", "90", "400"},
+		"WeatherTimeline.TransitionSeconds":  {"120", "60", "30", "30"},
+	})
+
+	expected := []WeatherTimelineStep{
+		{
+			After:       20,
+			SessionType: SessionTypeRace,
+			Weather:     LiveWeather{WeatherType: udp.CSPWeatherRain, TemperatureAmbient: 14.5, TemperatureRoad: 16, WindSpeedKMH: 25, WindDirection: 270, TransitionSeconds: 120},
+		},
+		{
+			After:   5,
+			Weather: LiveWeather{WeatherType: udp.CSPWeatherClear, TemperatureAmbient: 22, TemperatureRoad: 30, WindSpeedKMH: 5, WindDirection: 90, TransitionSeconds: 30},
+		},
+	}
+
+	if !reflect.DeepEqual(timeline, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, timeline)
+	}
+}
+
+func TestWeatherTimelineFor(t *testing.T) {
+	timeline := []WeatherTimelineStep{
+		{After: 30, SessionType: SessionTypeRace},
+		{After: 10},
+		{After: 5, SessionType: SessionTypePractice},
+		{After: 20, SessionType: SessionTypeRace},
+	}
+
+	steps := weatherTimelineFor(timeline, udp.SessionTypeRace)
+
+	if len(steps) != 3 || steps[0].After != 10 || steps[1].After != 20 || steps[2].After != 30 {
+		t.Errorf("Expected the race's steps in order, got %+v", steps)
+	}
+}
+
+func TestAssettoServerProcess_SetLiveWeather(t *testing.T) {
+	oldUnit := weatherTimelineUnit
+	weatherTimelineUnit = time.Millisecond * 20
+
+	defer func() {
+		weatherTimelineUnit = oldUnit
+	}()
+
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	clear := LiveWeather{WeatherType: udp.CSPWeatherClear, TemperatureAmbient: 20, TemperatureRoad: 25, TransitionSeconds: 60}
+	rain := LiveWeather{WeatherType: udp.CSPWeatherRain, TemperatureAmbient: 14, TemperatureRoad: 16, WindSpeedKMH: 30, WindDirection: 180}
+
+	if _, err := sp.SetLiveWeather(clear); err != ErrNoOpenUDPConnection {
+		t.Errorf("Expected ErrNoOpenUDPConnection while acServer isn't running, got %v", err)
+	}
+
+	serverManagerPort, err := FreeUDPPort()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acServer, err := simulator.NewServer(0, fmt.Sprintf("127.0.0.1:%d", serverManagerPort))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer acServer.Close()
+
+	sp.udpServerConn, err = udp.NewServerClient("127.0.0.1", serverManagerPort, acServer.LocalPort(), false, "", 0, func(udp.Message) {})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sp.mutex.Lock()
+	sp.raceEvent = &CustomRace{RaceConfig: CurrentRaceConfig{
+		WeatherTimeline: []WeatherTimelineStep{{After: 1, SessionType: SessionTypeRace, Weather: rain}},
+	}}
+	sp.mutex.Unlock()
+
+	defer func() {
+		sp.liveWeather.timeline.stop()
+
+		sp.mutex.Lock()
+		sp.raceEvent = nil
+		sp.udpServerConn.Close()
+		sp.udpServerConn = nil
+		sp.mutex.Unlock()
+	}()
+
+	handle := func(message udp.Message) {
+		sp.cspClients.handle(message)
+		sp.handleLiveWeather(message)
+	}
+
+	expectWeatherSentTo := func(carID udp.CarID) {
+		t.Helper()
+
+		select {
+		case message := <-acServer.Received():
+			if message.Event != udp.EventSendChat || message.CarID != carID {
+				t.Errorf("Expected the weather to be sent to car %d, got %+v", carID, message)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected the weather to be sent to car %d, nothing was sent", carID)
+		}
+	}
+
+	handle(udp.CSPClientMessage{CarID: 2, Type: udp.CSPMessageHandshake, Message: udp.CSPHandshake{Version: 2100}})
+
+	if _, err := sp.SetLiveWeather(LiveWeather{WeatherType: udp.CSPWeatherClear, WindDirection: 360}); err != ErrInvalidLiveWeather {
+		t.Errorf("Expected ErrInvalidLiveWeather, got %v", err)
+	}
+
+	if sent, err := sp.SetLiveWeather(clear); err != nil || sent != 1 {
+		t.Fatalf("Expected the weather to be sent to one client, got %d (%v)", sent, err)
+	}
+
+	expectWeatherSentTo(2)
+
+	// clients which join later in the session are sent the weather once they have shaken hands.
+	handle(udp.CSPClientMessage{CarID: 4, Type: udp.CSPMessageHandshake, Message: udp.CSPHandshake{Version: 2100}})
+
+	expectWeatherSentTo(4)
+
+	handle(udp.SessionInfo{Type: udp.SessionTypeRace, EventType: udp.EventNewSession})
+
+	if _, changed := sp.LiveWeather(); changed {
+		t.Error("Expected the live weather to be cleared when a new session starts")
+	}
+
+	expectWeatherSentTo(2)
+	expectWeatherSentTo(4)
+
+	if weather, changed := sp.LiveWeather(); !changed || weather != rain {
+		t.Errorf("Expected the weather timeline to change the weather to rain, got %+v (%t)", weather, changed)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"syscall"
 )
 
 const ServerExecutablePath = "acServer.exe"
@@ -25,6 +26,36 @@ func kill(ps *os.Process) error {
 	return exec.Command("TASKKILL", "/T", "/F", "/PID", fmt.Sprintf("%d", ps.Pid)).Run()
 }
 
+// killProcessGroup does nothing on Windows, where kill already stops the whole process tree with TASKKILL. Descendants
+// whose parent exited before it was stopped are not found by TASKKILL, and are left running.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return nil
+}
+
+// processAlive reports whether a process with the given pid is still running.
+func processAlive(pid int) bool {
+	// STILL_ACTIVE is the exit code of a process which hasn't exited yet.
+	const stillActive = 259
+
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+
+	if err != nil {
+		return false
+	}
+
+	defer syscall.CloseHandle(handle)
+
+	var exitCode uint32
+
+	if err := syscall.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return false
+	}
+
+	return exitCode == stillActive
+}
+
 func buildCommand(ctx context.Context, command string, args ...string) *exec.Cmd {
-	return exec.CommandContext(ctx, command, args...)
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+	return cmd
 }
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
 	"github.com/cj123/sessions"
 	"github.com/etcd-io/bbolt"
 	"github.com/sirupsen/logrus"
@@ -69,6 +71,9 @@ type TLSConfig struct {
 
 type LuaConfig struct {
 	Enabled bool `yaml:"enabled"`
+
+	// UDPScripts is the directory which UDP scripts are loaded from, see loadLuaUDPScripts.
+	UDPScripts string `yaml:"udp_scripts"`
 }
 
 const (
@@ -118,6 +123,7 @@ type StoreConfig struct {
 	Path                    string        `yaml:"path"`
 	SharedPath              string        `yaml:"shared_data_path"`
 	ScheduledEventCheckLoop time.Duration `yaml:"scheduled_event_check_loop"`
+	CacheOptions            bool          `yaml:"cache_options"`
 }
 
 func (s *StoreConfig) BuildStore() (Store, error) {
@@ -146,25 +152,125 @@ func (s *StoreConfig) BuildStore() (Store, error) {
 		return nil, err
 	}
 
+	if s.CacheOptions {
+		rs = NewOptionsCacheStore(rs)
+	}
+
 	return rs, nil
 }
 
 type ServerExtraConfig struct {
-	Plugins                     []*CommandPlugin `yaml:"plugins"`
-	AuditLogging                bool             `yaml:"audit_logging"`
-	PerformanceMode             bool             `yaml:"performance_mode"`
-	DisableWindowsBrowserOpen   bool             `yaml:"dont_open_browser"`
-	ScanContentFolderForChanges bool             `yaml:"scan_content_folder_for_changes"`
-	UseCarNameCache             bool             `yaml:"use_car_name_cache"`
-	PersistMidSessionResults    bool             `yaml:"persist_mid_session_results"`
+	Plugins                     []*CommandPlugin      `yaml:"plugins"`
+	AuditLogging                bool                  `yaml:"audit_logging"`
+	PerformanceMode             bool                  `yaml:"performance_mode"`
+	DisableWindowsBrowserOpen   bool                  `yaml:"dont_open_browser"`
+	ScanContentFolderForChanges bool                  `yaml:"scan_content_folder_for_changes"`
+	UseCarNameCache             bool                  `yaml:"use_car_name_cache"`
+	PersistMidSessionResults    bool                  `yaml:"persist_mid_session_results"`
+	IgnoreGamePortInUse         bool                  `yaml:"ignore_game_port_in_use"`
+	MaxEventDuration            time.Duration         `yaml:"max_event_duration"`
+	RestartOnCrash              bool                  `yaml:"restart_on_crash"`
+	CrashRestart                CrashRestartPolicy    `yaml:"crash_restart"`
+	AllowCrashSimulation        bool                  `yaml:"allow_crash_simulation"`
+	LogParsingRules             []*LogParsingRule     `yaml:"log_parsing_rules"`
+	LogEventRules               []*ServerLogEventRule `yaml:"log_event_rules"`
+	LifecycleEvents             LifecycleEventsConfig `yaml:"lifecycle_events"`
+	HostMetricsInterval         time.Duration         `yaml:"host_metrics_interval"`
+	DuplicateGUIDPolicy         string                `yaml:"duplicate_guid_policy"`
+	IdleKick                    IdleKickConfig        `yaml:"idle_kick"`
+	SuccessBallast              SuccessBallastConfig  `yaml:"success_ballast"`
+	ResultsUpload               ResultsUploadConfig   `yaml:"results_upload"`
+	Features                    map[Feature]bool      `yaml:"features"`
+	StopGraceTimeout            time.Duration         `yaml:"stop_grace_timeout"`
+	StopHardTimeout             time.Duration         `yaml:"stop_hard_timeout"`
+	StopWarning                 StopWarningConfig     `yaml:"stop_warning"`
+	StartupTimeout              time.Duration         `yaml:"startup_timeout"`
+	LogFile                     LogFileConfig         `yaml:"log_file"`
+	UDPRecording                UDPRecordingConfig    `yaml:"udp_recording"`
+	Telemetry                   TelemetryConfig       `yaml:"telemetry"`
+	MaintenanceWindows          []MaintenanceWindow   `yaml:"maintenance_windows"`
+	ResourceLimits              ResourceLimits        `yaml:"resource_limits"`
+	PluginResourceLimits        ResourceLimits        `yaml:"plugin_resource_limits"`
+	UDPSendInterval             time.Duration         `yaml:"udp_send_interval"`
+	UDPSendBatchSize            int                   `yaml:"udp_send_batch_size"`
+	UDPSendRetries              int                   `yaml:"udp_send_retries"`
+	UDPForwardingTargets        []udp.ForwardTarget   `yaml:"udp_forwarding_targets"`
+	MultiplexUDPPlugins         bool                  `yaml:"multiplex_udp_plugins"`
+	UDPStream                   UDPStreamConfig       `yaml:"udp_stream"`
+	CPUAffinity                 []int                 `yaml:"cpu_affinity"`
+	ProcessPriority             ProcessPriority       `yaml:"process_priority"`
+	MemoryLimit                 MemoryLimitConfig     `yaml:"memory_limit"`
+	HangDetection               HangDetectionConfig   `yaml:"hang_detection"`
+	Docker                      DockerConfig          `yaml:"docker"`
+	Remote                      RemoteConfig          `yaml:"remote"`
+	Hooks                       HookScriptsConfig     `yaml:"hooks"`
+	Servers                     []PooledServerConfig  `yaml:"servers"`
 
 	// Deprecated: use Plugins instead
 	RunOnStart []string `yaml:"run_on_start"`
 }
 
+type LifecycleEventsConfig struct {
+	NATSURL string `yaml:"nats_url"`
+	Subject string `yaml:"subject"`
+}
+
+type ResultsUploadConfig struct {
+	URL           string            `yaml:"url"`
+	Headers       map[string]string `yaml:"headers"`
+	MaxAttempts   int               `yaml:"max_attempts"`
+	RetryInterval time.Duration     `yaml:"retry_interval"`
+}
+
+type MemoryLimitConfig struct {
+	MaxMB         int           `yaml:"max_mb"`
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+type LogFileConfig struct {
+	Directory string        `yaml:"directory"`
+	MaxSizeMB int           `yaml:"max_size_mb"`
+	MaxFiles  int           `yaml:"max_files"`
+	MaxAge    time.Duration `yaml:"max_age"`
+}
+
 type CommandPlugin struct {
 	Executable string   `yaml:"executable"`
 	Arguments  []string `yaml:"arguments"`
+
+	// Name identifies the plugin in its health and logs. It defaults to the name of the executable.
+	Name string `yaml:"name"`
+
+	// Restart starts the plugin again if it exits while acServer is running. If MaxRestarts is set, the plugin is
+	// suspended until the next event once it has been restarted that many times in a row.
+	Restart     bool `yaml:"restart"`
+	MaxRestarts int  `yaml:"max_restarts"`
+
+	// WorkingDir is the directory the plugin is run from. It defaults to the directory of the executable.
+	WorkingDir string `yaml:"working_dir"`
+
+	// Env sets environment variables for the plugin, each given as KEY=value. They are added to Server Manager's own
+	// environment, replacing any variables with the same name. If ReplaceEnv is set, the plugin only gets Env.
+	Env        []string `yaml:"env"`
+	ReplaceEnv bool     `yaml:"replace_env"`
+
+	// ResourceLimits cap the CPU and memory used by the plugin and the processes it starts. Any which aren't set
+	// default to the server's plugin_resource_limits.
+	ResourceLimits ResourceLimits `yaml:"resource_limits"`
+
+	// CPUAffinity and ProcessPriority are set for the plugin in the same way as the server's cpu_affinity and
+	// process_priority are for acServer. Plugins are left unpinned at normal priority if they are empty.
+	CPUAffinity     []int           `yaml:"cpu_affinity"`
+	ProcessPriority ProcessPriority `yaml:"process_priority"`
+}
+
+// DisplayName is the Name of the plugin if it has one, otherwise the name of its executable.
+func (c *CommandPlugin) DisplayName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return filepath.Base(c.Executable)
 }
 
 func (c *CommandPlugin) String() string {
@@ -198,6 +304,32 @@ func ReadConfig(location string) (conf *Configuration, err error) {
 		logrus.Infof("WARNING! Admin Password Override is set. Please only have this set if you are resetting your admin account password!")
 	}
 
+	if _, err := NewLogParser(config.Server.LogParsingRules); err != nil {
+		return nil, err
+	}
+
+	if _, err := NewServerLogEventParser(config.Server.LogEventRules); err != nil {
+		return nil, err
+	}
+
+	if err := config.Server.Remote.validate(); err != nil {
+		return nil, err
+	}
+
+	if config.Server.Remote.Host != "" && config.Server.Docker.Image != "" {
+		return nil, ErrRemoteAndDocker
+	}
+
+	switch config.Server.DuplicateGUIDPolicy {
+	case "", DuplicateGUIDPolicyDisambiguate, DuplicateGUIDPolicyReject:
+	default:
+		return nil, ErrInvalidDuplicateGUIDPolicy
+	}
+
+	if err := validateFeatures(config.Server.Features); err != nil {
+		return nil, err
+	}
+
 	if config.Steam.ExecutablePath == "" {
 		config.Steam.ExecutablePath = ServerExecutablePath
 	}
@@ -0,0 +1,72 @@
+package servermanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Server Manager can be run as a service, either by systemd with Type=notify or by the Windows Service Control
+// Manager. The service manager is only told that Server Manager is ready once its store is open and the HTTP server
+// is listening, and stopping the service stops the running event in the same way as interrupting Server Manager.
+
+var (
+	serviceReady     = make(chan struct{})
+	serviceReadyOnce sync.Once
+
+	shutdownFunc func()
+	shutdownOnce sync.Once
+)
+
+// NotifyServiceReady tells the service manager that Server Manager has started. If systemd has a watchdog set up,
+// it is pinged from then on for as long as store can be read.
+func NotifyServiceReady(store Store) {
+	serviceReadyOnce.Do(func() {
+		close(serviceReady)
+
+		if err := sdNotify("READY=1"); err != nil {
+			logrus.WithError(err).Error("Could not tell systemd that Server Manager is ready")
+		}
+
+		if interval := watchdogInterval(); interval > 0 {
+			logrus.Infof("Pinging the systemd watchdog every %s", interval/2)
+
+			go serviceWatchdog(store, interval)
+		}
+	})
+}
+
+// serviceWatchdog pings the systemd watchdog at half its interval. Pings are skipped while the store can't be read,
+// so that systemd restarts Server Manager if it stays that way.
+func serviceWatchdog(store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var id ServerID
+
+		if err := store.GetMeta(serverIDMetaKey, &id); err != nil {
+			logrus.WithError(err).Error("Could not read the store, not pinging the systemd watchdog")
+			continue
+		}
+
+		if err := sdNotify("WATCHDOG=1"); err != nil {
+			logrus.WithError(err).Error("Could not ping the systemd watchdog")
+		}
+	}
+}
+
+// Shutdown stops the running event, saving it to be started again if the server options say so, and persists live
+// timing data so that Server Manager can exit. Only the first call does anything.
+func Shutdown() {
+	shutdownOnce.Do(func() {
+		if err := sdNotify("STOPPING=1"); err != nil {
+			logrus.WithError(err).Error("Could not tell systemd that Server Manager is stopping")
+		}
+
+		if shutdownFunc != nil {
+			shutdownFunc()
+		}
+	})
+}
@@ -0,0 +1,57 @@
+//+build !windows
+
+package servermanager
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// RunService runs Server Manager. Under systemd, there is nothing to set up here: readiness and the watchdog are
+// handled through NOTIFY_SOCKET, and the service is stopped with SIGTERM.
+func RunService(run func()) {
+	run()
+}
+
+// sdNotify sends state to systemd. It does nothing if Server Manager wasn't started by systemd with Type=notify.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+
+	if socket == "" {
+		return nil
+	}
+
+	if socket[0] == '@' {
+		// abstract namespace socket
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+
+	return err
+}
+
+// watchdogInterval is the WatchdogSec of the systemd service, or zero if there is no watchdog for this process.
+func watchdogInterval() time.Duration {
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,76 @@
+//+build !windows
+
+package servermanager
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSdNotify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-sd-notify")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	defer os.Unsetenv("NOTIFY_SOCKET")
+	defer os.Unsetenv("WATCHDOG_USEC")
+	defer os.Unsetenv("WATCHDOG_PID")
+
+	if err := sdNotify("READY=1"); err != nil {
+		t.Errorf("expected sdNotify to do nothing when not run by systemd, got: %s", err)
+	}
+
+	if err := os.Setenv("NOTIFY_SOCKET", socket); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state := string(buf[:n]); state != "READY=1" {
+		t.Errorf("expected systemd to be sent READY=1, got %q", state)
+	}
+
+	_ = os.Setenv("WATCHDOG_USEC", "30000000")
+
+	if interval := watchdogInterval(); interval != time.Second*30 {
+		t.Errorf("expected a watchdog interval of 30s, got %s", interval)
+	}
+
+	_ = os.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+
+	if interval := watchdogInterval(); interval != 0 {
+		t.Errorf("expected no watchdog for another process, got %s", interval)
+	}
+}
@@ -0,0 +1,87 @@
+//+build windows
+
+package servermanager
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc"
+)
+
+// windowsServiceName is ignored by the Service Control Manager for services which run in their own process, which
+// Server Manager always does. The name the service is installed under is used instead.
+const windowsServiceName = "AssettoServerManager"
+
+// RunService runs Server Manager, as a Windows service if it was started by the Service Control Manager.
+func RunService(run func()) {
+	interactive, err := svc.IsAnInteractiveSession()
+
+	if err != nil {
+		logrus.WithError(err).Error("Could not tell if Server Manager is running as a Windows service, assuming it isn't")
+	}
+
+	if err != nil || interactive {
+		run()
+		return
+	}
+
+	// services are started in the system directory, but config.yml is looked for next to the executable.
+	if executable, err := os.Executable(); err == nil {
+		if err := os.Chdir(filepath.Dir(executable)); err != nil {
+			logrus.WithError(err).Error("Could not change to the directory of the Server Manager executable")
+		}
+	}
+
+	if err := svc.Run(windowsServiceName, &windowsService{run: run}); err != nil {
+		logrus.WithError(err).Fatal("Could not run Server Manager as a Windows service")
+	}
+}
+
+type windowsService struct {
+	run func()
+}
+
+func (s *windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	exited := make(chan struct{})
+
+	go func() {
+		s.run()
+		close(exited)
+	}()
+
+	ready := serviceReady
+
+	for {
+		select {
+		case <-ready:
+			changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+			ready = nil
+		case <-exited:
+			// run only returns if Server Manager could not start.
+			return false, 1
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending, WaitHint: uint32((time.Minute * 2).Milliseconds())}
+				Shutdown()
+
+				return false, 0
+			}
+		}
+	}
+}
+
+func sdNotify(string) error {
+	return nil
+}
+
+func watchdogInterval() time.Duration {
+	return 0
+}
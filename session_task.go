@@ -0,0 +1,48 @@
+package servermanager
+
+import (
+	"context"
+	"sync"
+)
+
+// sessionTask runs a goroutine for the length of a session, e.g. rotating chat broadcasts. Starting it again (at the
+// next session) stops the last one first.
+type sessionTask struct {
+	mutex sync.Mutex
+	cfn   context.CancelFunc
+	done  chan struct{}
+}
+
+// start stops the task if it is running, waiting for it to return so that nothing it does can happen afterwards,
+// then runs fn until it returns or its context is cancelled. A nil fn just stops the task.
+func (t *sessionTask) start(fn func(ctx context.Context)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.cfn != nil {
+		t.cfn()
+		<-t.done
+
+		t.cfn, t.done = nil, nil
+	}
+
+	if fn == nil {
+		return
+	}
+
+	var ctx context.Context
+
+	ctx, t.cfn = context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.done = done
+
+	go func() {
+		defer close(done)
+
+		fn(ctx)
+	}()
+}
+
+func (t *sessionTask) stop() {
+	t.start(nil)
+}
@@ -47,6 +47,24 @@ type Store interface {
 	GetAuditEntries() ([]*AuditEntry, error)
 	AddAuditEntry(entry *AuditEntry) error
 
+	// Process Events
+	ListProcessEvents() ([]*ProcessEvent, error)
+	AddProcessEvent(event *ProcessEvent) error
+
+	// Crash Reports
+	ListCrashReports() ([]*CrashReport, error)
+	AddCrashReport(report *CrashReport) error
+
+	// Incidents
+	UpsertSessionIncidents(si *SessionIncidents) error
+	ListSessionIncidents() ([]*SessionIncidents, error)
+	LoadSessionIncidents(id string) (*SessionIncidents, error)
+
+	// Ballast
+	ListDriverBallasts() ([]*DriverBallast, error)
+	UpsertDriverBallast(ballast *DriverBallast) error
+	DeleteDriverBallast(driverGUID string) error
+
 	// Race Weekend
 	ListRaceWeekends() ([]*RaceWeekend, error)
 	UpsertRaceWeekend(rw *RaceWeekend) error
@@ -3,6 +3,7 @@ package servermanager
 import (
 	"encoding/json"
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/etcd-io/bbolt"
@@ -708,6 +709,324 @@ func (rs *BoltStore) AddAuditEntry(entry *AuditEntry) error {
 	})
 }
 
+var processEventsBucketName = []byte("processEvents")
+
+func (rs *BoltStore) processEventsBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
+	if !tx.Writable() {
+		bkt := tx.Bucket(processEventsBucketName)
+
+		if bkt == nil {
+			return nil, bbolt.ErrBucketNotFound
+		}
+
+		return bkt, nil
+	}
+
+	return tx.CreateBucketIfNotExists(processEventsBucketName)
+}
+
+func (rs *BoltStore) ListProcessEvents() ([]*ProcessEvent, error) {
+	var events []*ProcessEvent
+
+	err := rs.db.View(func(tx *bbolt.Tx) error {
+		bkt, err := rs.processEventsBucket(tx)
+
+		if err == bbolt.ErrBucketNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		val := bkt.Get([]byte("events"))
+
+		if val == nil {
+			return nil
+		}
+
+		return rs.decode(val, &events)
+	})
+
+	return events, err
+}
+
+func (rs *BoltStore) AddProcessEvent(event *ProcessEvent) error {
+	events, err := rs.ListProcessEvents()
+
+	if err != nil {
+		return err
+	}
+
+	events = append(events, event)
+
+	if len(events) > maxProcessEvents {
+		events = events[len(events)-maxProcessEvents:]
+	}
+
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := rs.processEventsBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		enc, err := rs.encode(events)
+
+		if err != nil {
+			return err
+		}
+
+		return bkt.Put([]byte("events"), enc)
+	})
+}
+
+var crashReportsBucketName = []byte("crashReports")
+
+func (rs *BoltStore) crashReportsBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
+	if !tx.Writable() {
+		bkt := tx.Bucket(crashReportsBucketName)
+
+		if bkt == nil {
+			return nil, bbolt.ErrBucketNotFound
+		}
+
+		return bkt, nil
+	}
+
+	return tx.CreateBucketIfNotExists(crashReportsBucketName)
+}
+
+func (rs *BoltStore) ListCrashReports() ([]*CrashReport, error) {
+	var reports []*CrashReport
+
+	err := rs.db.View(func(tx *bbolt.Tx) error {
+		bkt, err := rs.crashReportsBucket(tx)
+
+		if err == bbolt.ErrBucketNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		val := bkt.Get([]byte("reports"))
+
+		if val == nil {
+			return nil
+		}
+
+		return rs.decode(val, &reports)
+	})
+
+	return reports, err
+}
+
+func (rs *BoltStore) AddCrashReport(report *CrashReport) error {
+	reports, err := rs.ListCrashReports()
+
+	if err != nil {
+		return err
+	}
+
+	reports = append(reports, report)
+
+	if len(reports) > maxCrashReportsStored {
+		reports = reports[len(reports)-maxCrashReportsStored:]
+	}
+
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := rs.crashReportsBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		enc, err := rs.encode(reports)
+
+		if err != nil {
+			return err
+		}
+
+		return bkt.Put([]byte("reports"), enc)
+	})
+}
+
+var incidentsBucketName = []byte("incidents")
+
+func (rs *BoltStore) incidentsBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
+	if !tx.Writable() {
+		bkt := tx.Bucket(incidentsBucketName)
+
+		if bkt == nil {
+			return nil, bbolt.ErrBucketNotFound
+		}
+
+		return bkt, nil
+	}
+
+	return tx.CreateBucketIfNotExists(incidentsBucketName)
+}
+
+func (rs *BoltStore) UpsertSessionIncidents(si *SessionIncidents) error {
+	si.Updated = time.Now()
+
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := rs.incidentsBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		data, err := rs.encode(si)
+
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(si.ID), data)
+	})
+}
+
+func (rs *BoltStore) ListSessionIncidents() ([]*SessionIncidents, error) {
+	var sessions []*SessionIncidents
+
+	err := rs.db.View(func(tx *bbolt.Tx) error {
+		b, err := rs.incidentsBucket(tx)
+
+		if err == bbolt.ErrBucketNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var si *SessionIncidents
+
+			if err := rs.decode(v, &si); err != nil {
+				return err
+			}
+
+			sessions = append(sessions, si)
+
+			return nil
+		})
+	})
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.After(sessions[j].StartTime)
+	})
+
+	return sessions, err
+}
+
+func (rs *BoltStore) LoadSessionIncidents(id string) (*SessionIncidents, error) {
+	var si *SessionIncidents
+
+	err := rs.db.View(func(tx *bbolt.Tx) error {
+		b, err := rs.incidentsBucket(tx)
+
+		if err == bbolt.ErrBucketNotFound {
+			return ErrSessionIncidentsNotFound
+		} else if err != nil {
+			return err
+		}
+
+		data := b.Get([]byte(id))
+
+		if data == nil {
+			return ErrSessionIncidentsNotFound
+		}
+
+		return rs.decode(data, &si)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return si, nil
+}
+
+var ballastBucketName = []byte("ballast")
+
+func (rs *BoltStore) ballastBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
+	if !tx.Writable() {
+		bkt := tx.Bucket(ballastBucketName)
+
+		if bkt == nil {
+			return nil, bbolt.ErrBucketNotFound
+		}
+
+		return bkt, nil
+	}
+
+	return tx.CreateBucketIfNotExists(ballastBucketName)
+}
+
+func (rs *BoltStore) ListDriverBallasts() ([]*DriverBallast, error) {
+	var ballasts []*DriverBallast
+
+	err := rs.db.View(func(tx *bbolt.Tx) error {
+		b, err := rs.ballastBucket(tx)
+
+		if err == bbolt.ErrBucketNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			var ballast *DriverBallast
+
+			if err := rs.decode(v, &ballast); err != nil {
+				return err
+			}
+
+			ballasts = append(ballasts, ballast)
+
+			return nil
+		})
+	})
+
+	sort.Slice(ballasts, func(i, j int) bool {
+		return ballasts[i].DriverName < ballasts[j].DriverName
+	})
+
+	return ballasts, err
+}
+
+func (rs *BoltStore) UpsertDriverBallast(ballast *DriverBallast) error {
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := rs.ballastBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		data, err := rs.encode(ballast)
+
+		if err != nil {
+			return err
+		}
+
+		return b.Put([]byte(ballast.DriverGUID), data)
+	})
+}
+
+func (rs *BoltStore) DeleteDriverBallast(driverGUID string) error {
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := rs.ballastBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		if b.Get([]byte(driverGUID)) == nil {
+			return ErrDriverBallastNotFound
+		}
+
+		return b.Delete([]byte(driverGUID))
+	})
+}
+
 func (rs *BoltStore) raceWeekendsBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
 	if !tx.Writable() {
 		bkt := tx.Bucket(raceWeekendsBucketName)
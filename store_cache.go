@@ -0,0 +1,136 @@
+package servermanager
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const (
+	serverOptionsCacheKey      = "server_options"
+	strackerOptionsCacheKey    = "stracker_options"
+	kissMyRankOptionsCacheKey  = "kissmyrank_options"
+	realPenaltyOptionsCacheKey = "realpenalty_options"
+)
+
+// OptionsCacheStore wraps a Store, keeping an in-memory copy of the options which are loaded every time the
+// server process starts. Options are cached in their encoded form so that every Load returns a new copy which
+// callers are free to modify. Any Upsert made through the OptionsCacheStore invalidates the cached copy.
+type OptionsCacheStore struct {
+	Store
+
+	mutex sync.Mutex
+	cache map[string][]byte
+}
+
+func NewOptionsCacheStore(store Store) *OptionsCacheStore {
+	return &OptionsCacheStore{
+		Store: store,
+		cache: make(map[string][]byte),
+	}
+}
+
+// Refresh discards all cached options, so that they are next loaded from the underlying Store.
+func (s *OptionsCacheStore) Refresh() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.cache = make(map[string][]byte)
+}
+
+func (s *OptionsCacheStore) load(key string, out interface{}, loadFn func() (interface{}, error)) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, ok := s.cache[key]
+
+	if !ok {
+		opts, err := loadFn()
+
+		if err != nil {
+			return err
+		}
+
+		data, err = json.Marshal(opts)
+
+		if err != nil {
+			return err
+		}
+
+		s.cache[key] = data
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func (s *OptionsCacheStore) upsert(key string, upsertFn func() error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.cache, key)
+
+	return upsertFn()
+}
+
+func (s *OptionsCacheStore) UpsertServerOptions(so *GlobalServerConfig) error {
+	return s.upsert(serverOptionsCacheKey, func() error {
+		return s.Store.UpsertServerOptions(so)
+	})
+}
+
+func (s *OptionsCacheStore) LoadServerOptions() (*GlobalServerConfig, error) {
+	var out *GlobalServerConfig
+
+	err := s.load(serverOptionsCacheKey, &out, func() (interface{}, error) {
+		return s.Store.LoadServerOptions()
+	})
+
+	return out, err
+}
+
+func (s *OptionsCacheStore) UpsertStrackerOptions(sto *StrackerConfiguration) error {
+	return s.upsert(strackerOptionsCacheKey, func() error {
+		return s.Store.UpsertStrackerOptions(sto)
+	})
+}
+
+func (s *OptionsCacheStore) LoadStrackerOptions() (*StrackerConfiguration, error) {
+	var out *StrackerConfiguration
+
+	err := s.load(strackerOptionsCacheKey, &out, func() (interface{}, error) {
+		return s.Store.LoadStrackerOptions()
+	})
+
+	return out, err
+}
+
+func (s *OptionsCacheStore) UpsertKissMyRankOptions(kmr *KissMyRankConfig) error {
+	return s.upsert(kissMyRankOptionsCacheKey, func() error {
+		return s.Store.UpsertKissMyRankOptions(kmr)
+	})
+}
+
+func (s *OptionsCacheStore) LoadKissMyRankOptions() (*KissMyRankConfig, error) {
+	var out *KissMyRankConfig
+
+	err := s.load(kissMyRankOptionsCacheKey, &out, func() (interface{}, error) {
+		return s.Store.LoadKissMyRankOptions()
+	})
+
+	return out, err
+}
+
+func (s *OptionsCacheStore) UpsertRealPenaltyOptions(rpc *RealPenaltyConfig) error {
+	return s.upsert(realPenaltyOptionsCacheKey, func() error {
+		return s.Store.UpsertRealPenaltyOptions(rpc)
+	})
+}
+
+func (s *OptionsCacheStore) LoadRealPenaltyOptions() (*RealPenaltyConfig, error) {
+	var out *RealPenaltyConfig
+
+	err := s.load(realPenaltyOptionsCacheKey, &out, func() (interface{}, error) {
+		return s.Store.LoadRealPenaltyOptions()
+	})
+
+	return out, err
+}
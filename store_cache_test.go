@@ -0,0 +1,186 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cj123/sessions"
+)
+
+type loadCountingStore struct {
+	Store
+
+	serverOptionsLoads int
+}
+
+func (s *loadCountingStore) LoadServerOptions() (*GlobalServerConfig, error) {
+	s.serverOptionsLoads++
+
+	return s.Store.LoadServerOptions()
+}
+
+func newTestOptionsCacheStore(t *testing.T) (*OptionsCacheStore, *loadCountingStore, func()) {
+	dir, err := ioutil.TempDir("", "asm-store-cache")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counting := &loadCountingStore{Store: NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))}
+
+	return NewOptionsCacheStore(counting), counting, func() {
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestOptionsCacheStore(t *testing.T) {
+	t.Run("Cache miss then hit", func(t *testing.T) {
+		store, counting, cleanup := newTestOptionsCacheStore(t)
+		defer cleanup()
+
+		for i := 0; i < 3; i++ {
+			if _, err := store.LoadServerOptions(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if counting.serverOptionsLoads != 1 {
+			t.Errorf("expected underlying store to be loaded from once, was loaded %d times", counting.serverOptionsLoads)
+		}
+	})
+
+	t.Run("Loads return copies", func(t *testing.T) {
+		store, _, cleanup := newTestOptionsCacheStore(t)
+		defer cleanup()
+
+		opts, err := store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts.Name = "modified without upsert"
+
+		opts, err = store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if opts.Name == "modified without upsert" {
+			t.Error("expected modifying loaded options not to modify the cache")
+		}
+	})
+
+	t.Run("Upsert invalidates", func(t *testing.T) {
+		store, counting, cleanup := newTestOptionsCacheStore(t)
+		defer cleanup()
+
+		opts, err := store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts.Name = "Updated Server Name"
+
+		if err := store.UpsertServerOptions(opts); err != nil {
+			t.Fatal(err)
+		}
+
+		opts, err = store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if opts.Name != "Updated Server Name" {
+			t.Errorf("expected updated server name, got: %s", opts.Name)
+		}
+
+		if counting.serverOptionsLoads != 2 {
+			t.Errorf("expected underlying store to be loaded from twice, was loaded %d times", counting.serverOptionsLoads)
+		}
+	})
+
+	t.Run("Refresh", func(t *testing.T) {
+		store, counting, cleanup := newTestOptionsCacheStore(t)
+		defer cleanup()
+
+		if _, err := store.LoadServerOptions(); err != nil {
+			t.Fatal(err)
+		}
+
+		// modify the underlying store directly, bypassing the cache.
+		opts, err := counting.Store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts.Name = "Edited Elsewhere"
+
+		if err := counting.Store.UpsertServerOptions(opts); err != nil {
+			t.Fatal(err)
+		}
+
+		store.Refresh()
+
+		opts, err = store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if opts.Name != "Edited Elsewhere" {
+			t.Errorf("expected refreshed server name, got: %s", opts.Name)
+		}
+	})
+}
+
+func TestServerAdministrationHandler_RefreshOptionsCache(t *testing.T) {
+	oldSessionsStore := sessionsStore
+	sessionsStore = sessions.NewCookieStore([]byte("test-session-key"))
+
+	defer func() {
+		sessionsStore = oldSessionsStore
+	}()
+
+	store, counting, cleanup := newTestOptionsCacheStore(t)
+	defer cleanup()
+
+	if _, err := store.LoadServerOptions(); err != nil {
+		t.Fatal(err)
+	}
+
+	sah := &ServerAdministrationHandler{store: store}
+
+	w := httptest.NewRecorder()
+	sah.refreshOptionsCache(w, httptest.NewRequest(http.MethodPost, "/server-options/refresh-cache", nil))
+
+	if w.Code != http.StatusFound {
+		t.Errorf("Expected a redirect back to the server options, got status %d", w.Code)
+	}
+
+	if _, err := store.LoadServerOptions(); err != nil {
+		t.Fatal(err)
+	}
+
+	if counting.serverOptionsLoads != 2 {
+		t.Errorf("Expected the server options to be loaded from the underlying store again, were loaded %d times", counting.serverOptionsLoads)
+	}
+
+	entries, err := store.GetAuditEntries()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Action != "Refreshed the options cache" {
+		t.Errorf("Expected the refresh to be audited, got %+v", entries)
+	}
+}
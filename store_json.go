@@ -12,7 +12,9 @@ import (
 )
 
 const (
-	maxAuditEntries = 1000
+	maxAuditEntries       = 1000
+	maxProcessEvents      = 5000
+	maxCrashReportsStored = 200
 
 	// private data
 	accountsDir            = "accounts"
@@ -20,11 +22,15 @@ const (
 	frameLinksFile         = "frame_links.json"
 	serverMetaDir          = "meta"
 	auditFile              = "audit.json"
+	processEventsFile      = "process_events.json"
+	crashReportsFile       = "crash_reports.json"
 	strackerOptionsFile    = "stracker_options.json"
 	kissMyRankOptionsFile  = "kissmyrank_options.json"
 	realPenaltyOptionsFile = "realpenalty_options.json"
 	liveTimingsDataFile    = "live_timings.json"
 	lastRaceEventFile      = "last_race_event.json"
+	incidentsDir           = "incidents"
+	ballastDir             = "ballast"
 
 	// shared data
 	championshipsDir = "championships"
@@ -494,6 +500,152 @@ func (rs *JSONStore) AddAuditEntry(entry *AuditEntry) error {
 	return rs.encodeFile(rs.base, auditFile, entries)
 }
 
+func (rs *JSONStore) ListProcessEvents() ([]*ProcessEvent, error) {
+	var events []*ProcessEvent
+
+	err := rs.decodeFile(rs.base, processEventsFile, &events)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (rs *JSONStore) AddProcessEvent(event *ProcessEvent) error {
+	events, err := rs.ListProcessEvents()
+
+	if err != nil {
+		return err
+	}
+
+	events = append(events, event)
+
+	if len(events) > maxProcessEvents {
+		events = events[len(events)-maxProcessEvents:]
+	}
+
+	return rs.encodeFile(rs.base, processEventsFile, events)
+}
+
+func (rs *JSONStore) ListCrashReports() ([]*CrashReport, error) {
+	var reports []*CrashReport
+
+	err := rs.decodeFile(rs.base, crashReportsFile, &reports)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (rs *JSONStore) AddCrashReport(report *CrashReport) error {
+	reports, err := rs.ListCrashReports()
+
+	if err != nil {
+		return err
+	}
+
+	reports = append(reports, report)
+
+	if len(reports) > maxCrashReportsStored {
+		reports = reports[len(reports)-maxCrashReportsStored:]
+	}
+
+	return rs.encodeFile(rs.base, crashReportsFile, reports)
+}
+
+func (rs *JSONStore) UpsertSessionIncidents(si *SessionIncidents) error {
+	si.Updated = time.Now()
+
+	return rs.encodeFile(rs.base, filepath.Join(incidentsDir, si.ID+".json"), si)
+}
+
+func (rs *JSONStore) ListSessionIncidents() ([]*SessionIncidents, error) {
+	files, err := rs.listFiles(filepath.Join(rs.base, incidentsDir))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*SessionIncidents
+
+	for _, file := range files {
+		si, err := rs.LoadSessionIncidents(file)
+
+		if err != nil {
+			continue
+		}
+
+		sessions = append(sessions, si)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.After(sessions[j].StartTime)
+	})
+
+	return sessions, nil
+}
+
+func (rs *JSONStore) LoadSessionIncidents(id string) (*SessionIncidents, error) {
+	var si *SessionIncidents
+
+	err := rs.decodeFile(rs.base, filepath.Join(incidentsDir, id+".json"), &si)
+
+	if os.IsNotExist(err) {
+		return nil, ErrSessionIncidentsNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return si, nil
+}
+
+func (rs *JSONStore) ListDriverBallasts() ([]*DriverBallast, error) {
+	files, err := rs.listFiles(filepath.Join(rs.base, ballastDir))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ballasts []*DriverBallast
+
+	for _, file := range files {
+		var ballast *DriverBallast
+
+		if err := rs.decodeFile(rs.base, filepath.Join(ballastDir, file+".json"), &ballast); err != nil {
+			return nil, err
+		}
+
+		ballasts = append(ballasts, ballast)
+	}
+
+	sort.Slice(ballasts, func(i, j int) bool {
+		return ballasts[i].DriverName < ballasts[j].DriverName
+	})
+
+	return ballasts, nil
+}
+
+func (rs *JSONStore) UpsertDriverBallast(ballast *DriverBallast) error {
+	return rs.encodeFile(rs.base, filepath.Join(ballastDir, filepath.Base(ballast.DriverGUID)+".json"), ballast)
+}
+
+func (rs *JSONStore) DeleteDriverBallast(driverGUID string) error {
+	err := os.Remove(filepath.Join(rs.base, ballastDir, filepath.Base(driverGUID)+".json"))
+
+	if os.IsNotExist(err) {
+		return ErrDriverBallastNotFound
+	}
+
+	return err
+}
+
 func (rs *JSONStore) ListRaceWeekends() ([]*RaceWeekend, error) {
 	files, err := rs.listFiles(filepath.Join(rs.shared, raceWeekendsDir))
 
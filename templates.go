@@ -23,6 +23,8 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/mattn/go-zglob"
 	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
 )
 
 // BuildVersion is the time Server Manager was built at
@@ -199,6 +201,7 @@ func (tr *Renderer) init() error {
 	funcs["ordinal"] = ordinal
 	funcs["prettify"] = prettifyName
 	funcs["weatherName"] = weatherName
+	funcs["cspWeatherTypes"] = udp.CSPWeatherTypes
 	funcs["carList"] = carList
 	funcs["jsonEncode"] = jsonEncode
 	funcs["varSplit"] = varSplit
@@ -530,6 +533,7 @@ type BaseTemplateVars struct {
 	Errors                []interface{}
 	ServerStatus          bool
 	ServerEvent           RaceEvent
+	ServerForwarding      ForwardingStatus
 	ServerName            string
 	CustomCSS             template.CSS
 	User                  *Account
@@ -581,6 +585,7 @@ func (tr *Renderer) addData(w http.ResponseWriter, r *http.Request, vars Templat
 
 	data.ServerStatus = tr.process.IsRunning()
 	data.ServerEvent = tr.process.Event()
+	data.ServerForwarding = tr.process.Forwarding()
 	data.ServerName = opts.Name
 	data.CustomCSS = template.CSS(opts.CustomCSS)
 	data.User = AccountFromRequest(r)
@@ -0,0 +1,58 @@
+package servermanager
+
+import (
+	"sync"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// UDPHandlerFunc handles a message from acServer. process is the server process which received it (the main server, or
+// one of the pool servers), and can be used to reply, e.g. with process.SendUDPMessage.
+type UDPHandlerFunc func(process ServerProcess, message udp.Message)
+
+var (
+	udpHandlers      = make(map[udp.Event][]UDPHandlerFunc)
+	udpHandlersMutex sync.RWMutex
+)
+
+// RegisterUDPHandler adds handler to the handlers which are called with each message of type event from acServer, so
+// that custom race logic can be compiled into a build of Server Manager rather than added by forking the UDP callback.
+// It is meant to be called from an init function in a file added to cmd/server-manager, e.g.:
+//
+//	func init() {
+//		servermanager.RegisterUDPHandler(udp.EventLapCompleted, func(process servermanager.ServerProcess, message udp.Message) {
+//			lap := message.(udp.LapCompleted)
+//			// ...
+//		})
+//	}
+//
+// Each message's type is the one udp.ParseMessage returns for event, e.g. udp.LapCompleted for
+// udp.EventLapCompleted and udp.SessionCarInfo for udp.EventNewConnection. Handlers are called after Server Manager's
+// own handling of the message (live timing, championships etc.), one at a time and in the order they were registered,
+// so a slow handler holds up every UDP message after it. A handler which panics is recovered from without affecting
+// the other handlers.
+func RegisterUDPHandler(event udp.Event, handler UDPHandlerFunc) {
+	if handler == nil {
+		panic("servermanager: nil UDP handler")
+	}
+
+	udpHandlersMutex.Lock()
+	defer udpHandlersMutex.Unlock()
+
+	udpHandlers[event] = append(udpHandlers[event], handler)
+}
+
+// callUDPHandlers calls each handler registered for message's event.
+func callUDPHandlers(process ServerProcess, message udp.Message) {
+	udpHandlersMutex.RLock()
+	handlers := udpHandlers[message.Event()]
+	udpHandlersMutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler := handler
+
+		panicCapture(func() {
+			handler(process, message)
+		})
+	}
+}
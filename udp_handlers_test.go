@@ -0,0 +1,54 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestRegisterUDPHandler(t *testing.T) {
+	udpHandlersMutex.Lock()
+	oldUDPHandlers, oldLogMultiWriter := udpHandlers, logMultiWriter
+	udpHandlers, logMultiWriter = make(map[udp.Event][]UDPHandlerFunc), ioutil.Discard
+	udpHandlersMutex.Unlock()
+
+	defer func() {
+		udpHandlersMutex.Lock()
+		udpHandlers, logMultiWriter = oldUDPHandlers, oldLogMultiWriter
+		udpHandlersMutex.Unlock()
+	}()
+
+	var calls []string
+
+	RegisterUDPHandler(udp.EventChat, func(process ServerProcess, message udp.Message) {
+		calls = append(calls, "first: "+message.(udp.Chat).Message)
+		panic("handler panicked")
+	})
+
+	RegisterUDPHandler(udp.EventChat, func(process ServerProcess, message udp.Message) {
+		if process == nil {
+			t.Error("expected the handler to be given the server process")
+		}
+
+		calls = append(calls, "second: "+message.(udp.Chat).Message)
+	})
+
+	RegisterUDPHandler(udp.EventLapCompleted, func(process ServerProcess, message udp.Message) {
+		calls = append(calls, "lap completed")
+	})
+
+	callUDPHandlers(dummyServerProcess{}, udp.Chat{Message: "hello"})
+
+	if len(calls) != 2 || calls[0] != "first: hello" || calls[1] != "second: hello" {
+		t.Errorf("expected the chat handlers to be called in order despite the first panicking, got %v", calls)
+	}
+
+	calls = nil
+
+	callUDPHandlers(dummyServerProcess{}, udp.Version(4))
+
+	if len(calls) != 0 {
+		t.Errorf("expected no handlers to be called for a message without any, got %v", calls)
+	}
+}
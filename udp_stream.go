@@ -0,0 +1,187 @@
+package servermanager
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// UDPStreamConfig lets dashboards and bots which can't log in to Server Manager read the UDP stream, by passing one
+// of Tokens as a bearer token or as the token query parameter.
+type UDPStreamConfig struct {
+	Tokens []string `yaml:"tokens"`
+}
+
+// udpStreamBufferSize is how many messages a UDP stream client can fall behind by before messages are dropped for it.
+const udpStreamBufferSize = 512
+
+var udpStreamEventNames = map[udp.Event]string{
+	udp.EventCollisionWithCar: "collision-with-car",
+	udp.EventCollisionWithEnv: "collision-with-env",
+	udp.EventNewSession:       "new-session",
+	udp.EventNewConnection:    "new-connection",
+	udp.EventConnectionClosed: "connection-closed",
+	udp.EventCarUpdate:        "car-update",
+	udp.EventCarInfo:          "car-info",
+	udp.EventEndSession:       "end-session",
+	udp.EventVersion:          "version",
+	udp.EventChat:             "chat",
+	udp.EventClientLoaded:     "client-loaded",
+	udp.EventSessionInfo:      "session-info",
+	udp.EventError:            "error",
+	udp.EventLapCompleted:     "lap-completed",
+	udp.EventCSPClientMessage: "csp-client-message",
+}
+
+// udpStreamMessage is a UDP message from acServer as it is sent to UDP stream clients.
+type udpStreamMessage struct {
+	EventType udp.Event
+	Type      string
+	Received  time.Time
+	Message   udp.Message `json:",omitempty"`
+	Error     string      `json:",omitempty"`
+}
+
+func newUDPStreamMessage(message udp.Message) udpStreamMessage {
+	streamMessage := udpStreamMessage{
+		EventType: message.Event(),
+		Type:      udpStreamEventNames[message.Event()],
+		Received:  time.Now(),
+		Message:   message,
+	}
+
+	if serverError, ok := message.(udp.ServerError); ok {
+		// errors don't encode to JSON by themselves.
+		streamMessage.Message = nil
+		streamMessage.Error = serverError.Error()
+	}
+
+	return streamMessage
+}
+
+// parseUDPStreamEvents reads the events query parameter, a comma separated list of event names (e.g.
+// "lap-completed,chat"), into the set of events to send. A nil set sends every event.
+func parseUDPStreamEvents(events string) (map[udp.Event]bool, error) {
+	if events == "" {
+		return nil, nil
+	}
+
+	filter := make(map[udp.Event]bool)
+
+	for _, name := range strings.Split(events, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+
+		for event, eventName := range udpStreamEventNames {
+			if eventName == name {
+				filter[event] = true
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("servermanager: unknown UDP event %q", name)
+		}
+	}
+
+	return filter, nil
+}
+
+// udpStreamTokenMiddleware lets requests with one of the configured UDP stream tokens through, and passes every other
+// request on to requireLogin.
+func udpStreamTokenMiddleware(requireLogin func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		loggedIn := requireLogin(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get("token")
+
+			if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				token = strings.TrimPrefix(auth, "Bearer ")
+			}
+
+			if token != "" && config != nil {
+				for _, allowed := range config.Server.UDPStream.Tokens {
+					if allowed != "" && subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			loggedIn.ServeHTTP(w, r)
+		})
+	}
+}
+
+// streamUDPMessages streams the UDP messages from acServer over a websocket as JSON, one udpStreamMessage per
+// websocket message. The events query parameter picks which events are sent, e.g. ?events=lap-completed,chat. Like
+// Tail, messages are dropped for a client which can't keep up, rather than holding up acServer.
+func (sah *ServerAdministrationHandler) streamUDPMessages(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseUDPStreamEvents(r.URL.Query().Get("events"))
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		logrus.WithError(err).Error("could not upgrade UDP stream to a websocket")
+		return
+	}
+
+	defer conn.Close()
+
+	messages := make(chan udp.Message, udpStreamBufferSize)
+
+	sah.process.AddObserver(messages)
+	defer sah.process.RemoveObserver(messages)
+
+	// nothing is read from the client, but reading is needed to notice that it has gone away.
+	disconnected := make(chan struct{})
+
+	go func() {
+		defer close(disconnected)
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(time.Second * 10)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message := <-messages:
+			if filter != nil && !filter[message.Event()] {
+				continue
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := conn.WriteJSON(newUDPStreamMessage(message)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-disconnected:
+			return
+		}
+	}
+}
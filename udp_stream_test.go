@@ -0,0 +1,93 @@
+package servermanager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/gorilla/websocket"
+)
+
+func TestServerAdministrationHandler_StreamUDPMessages(t *testing.T) {
+	sp, cleanup := newTestServerProcess(t)
+	defer cleanup()
+
+	config.Server.UDPStream.Tokens = []string{"dashboard-token"}
+
+	sah := &ServerAdministrationHandler{process: sp}
+
+	requireLogin := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+
+	server := httptest.NewServer(udpStreamTokenMiddleware(requireLogin)(http.HandlerFunc(sah.streamUDPMessages)))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	for _, header := range []http.Header{nil, {"Authorization": []string{"Bearer wrong-token"}}} {
+		if _, resp, err := websocket.DefaultDialer.Dial(url, header); err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected the stream to need a login or a token, got: %v", err)
+		}
+	}
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url+"?token=dashboard-token&events=unknown", nil); err == nil || resp == nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an unknown event to be rejected, got: %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?events=chat,lap-completed", http.Header{"Authorization": []string{"Bearer dashboard-token"}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	// wait for the stream to start observing.
+	for i := 0; ; i++ {
+		sp.observersMutex.Lock()
+		observing := len(sp.observers) > 0
+		sp.observersMutex.Unlock()
+
+		if observing {
+			break
+		}
+
+		if i > 100 {
+			t.Fatal("expected the stream to observe UDP messages")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	sp.UDPCallback(udp.Version(4))
+	sp.UDPCallback(udp.Chat{CarID: 3, Message: "hello"})
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+	var message struct {
+		EventType udp.Event
+		Type      string
+		Message   udp.Chat
+	}
+
+	_, data, err := conn.ReadMessage()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := json.Unmarshal(data, &message); err != nil {
+		t.Fatal(err)
+	}
+
+	if message.EventType != udp.EventChat || message.Type != "chat" || message.Message.Message != "hello" || message.Message.CarID != 3 {
+		t.Errorf("expected only the chat message to be streamed, got: %s", data)
+	}
+}